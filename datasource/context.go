@@ -213,9 +213,15 @@ func (m *ContextSimple) Delete(row map[string]value.Value) error {
 }
 
 // NewNestedContextReader provides a context reader which is a composite of ordered child readers
-// the first reader with a key will be used
+// the first reader with a key will be used, nil readers such as a missing session are skipped
 func NewNestedContextReader(readers []expr.ContextReader, ts time.Time) expr.ContextReader {
-	return &NestedContextReader{readers, nil, ts}
+	nonNil := make([]expr.ContextReader, 0, len(readers))
+	for _, r := range readers {
+		if r != nil {
+			nonNil = append(nonNil, r)
+		}
+	}
+	return &NestedContextReader{nonNil, nil, ts}
 }
 
 // NewNestedContextReader provides a context reader which is a composite of ordered child readers
@@ -12,6 +12,7 @@ import (
 
 	u "github.com/araddon/gou"
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/value"
 )
@@ -20,6 +21,7 @@ var (
 	_ schema.Source      = (*CsvDataSource)(nil)
 	_ schema.Conn        = (*CsvDataSource)(nil)
 	_ schema.ConnScanner = (*CsvDataSource)(nil)
+	_ plan.SourcePruner  = (*CsvDataSource)(nil)
 )
 
 // Csv DataSource, implements qlbridge schema DataSource, SourceConn, Scanner
@@ -41,6 +43,7 @@ type CsvDataSource struct {
 	colindex map[string]int
 	indexCol int
 	filter   expr.Node
+	keep     []bool // positions of the columns read, nil reads all
 }
 
 // NewCsvSource reader assumes we are getting first row as headers
@@ -130,6 +133,21 @@ func (m *CsvDataSource) Open(connInfo string) (schema.Conn, error) {
 	return NewCsvSource(connInfo, 0, f, exit)
 }
 
+// PruneColumns read only the values of these columns, the others are nil.
+func (m *CsvDataSource) PruneColumns(cols []string) bool {
+	keep := make([]bool, len(m.headers))
+	for _, col := range cols {
+		for i, h := range m.headers {
+			if strings.EqualFold(h, col) {
+				keep[i] = true
+			}
+		}
+	}
+	m.keep = keep
+	m.csvr.ReuseRecord = true
+	return true
+}
+
 func (m *CsvDataSource) Close() error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -167,7 +185,9 @@ func (m *CsvDataSource) Next() schema.Message {
 			}
 			vals := make([]driver.Value, len(row))
 			for i, val := range row {
-				vals[i] = val
+				if m.keep == nil || m.keep[i] {
+					vals[i] = val
+				}
 			}
 			//u.Debugf("headers: %#v \n\trows:  %#v", m.headers, row)
 			return NewSqlDriverMessageMap(m.rowct, vals, m.colindex)
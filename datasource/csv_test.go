@@ -1,6 +1,7 @@
 package datasource_test
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"strings"
 	"testing"
@@ -56,4 +57,15 @@ func TestCsvDataSource(t *testing.T) {
 	csvIn, err = datasource.NewCsvSource("user.csv", 0, sr, make(<-chan bool, 1))
 	assert.Equal(t, nil, err)
 	csvIn.Close()
+
+	// only the values of pruned columns are read
+	sr = strings.NewReader(testData["user.csv"])
+	csvSrc, err := datasource.NewCsvSource("user.csv", 0, sr, make(<-chan bool, 1))
+	assert.Equal(t, nil, err)
+	assert.True(t, csvSrc.PruneColumns([]string{"EMAIL", "item_count"}))
+	msg := csvSrc.Next()
+	assert.Equal(t, []driver.Value{nil, "aaron@email.com", nil, nil, "82"}, msg.(*datasource.SqlDriverMessageMap).Values())
+	msg = csvSrc.Next()
+	assert.Equal(t, []driver.Value{nil, "bob@email.com", nil, nil, "12"}, msg.(*datasource.SqlDriverMessageMap).Values())
+	csvSrc.Close()
 }
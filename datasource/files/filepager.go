@@ -19,6 +19,7 @@ var (
 	_ FileReaderIterator  = (*FilePager)(nil)
 	_ schema.ConnScanner  = (*FilePager)(nil)
 	_ exec.ExecutorSource = (*FilePager)(nil)
+	_ plan.SourcePruner   = (*FilePager)(nil)
 
 	// Default file queue size to buffer by pager
 	FileBufferSize = 5
@@ -42,6 +43,7 @@ type FilePager struct {
 	tbl             *schema.Table
 	p               *plan.Source
 	usePartitioning bool
+	pruneCols       []string // columns read by scanners that can prune
 
 	schema.ConnScanner
 }
@@ -101,10 +103,20 @@ func (m *FilePager) NextScanner() (schema.ConnScanner, error) {
 		u.Errorf("Could not open file scanner %v err=%v", m.fs.fileType, err)
 		return nil, err
 	}
+	if pruner, ok := scanner.(plan.SourcePruner); ok && m.pruneCols != nil {
+		pruner.PruneColumns(m.pruneCols)
+	}
 	m.ConnScanner = scanner
 	return scanner, err
 }
 
+// PruneColumns the scanner of each file reads only these columns, if
+// the scanner of its file type can.
+func (m *FilePager) PruneColumns(cols []string) bool {
+	m.pruneCols = cols
+	return true
+}
+
 // NextFile gets next file
 func (m *FilePager) NextFile() (*FileReader, error) {
 
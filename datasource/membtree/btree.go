@@ -155,7 +155,6 @@ func NewStaticData(name string) *StaticDataSource {
 
 func (m *StaticDataSource) Init()                                     {}
 func (m *StaticDataSource) Setup(*schema.Schema) error                { return nil }
-func (m *StaticDataSource) Open(connInfo string) (schema.Conn, error) { return m, nil }
 func (m *StaticDataSource) Table(table string) (*schema.Table, error) { return m.tbl, nil }
 func (m *StaticDataSource) Close() error                              { return nil }
 func (m *StaticDataSource) CreateIterator() schema.Iterator           { return m }
@@ -164,6 +163,15 @@ func (m *StaticDataSource) Columns() []string                         { return m
 func (m *StaticDataSource) Length() int                               { return m.bt.Len() }
 func (m *StaticDataSource) SetColumns(cols []string)                  { m.tbl.SetColumns(cols) }
 
+// Open a connection to this table with its own cursor, sharing the data, so
+// that concurrent scans of the same table such as a self-join or a sub-select
+// of it each read every row.
+func (m *StaticDataSource) Open(connInfo string) (schema.Conn, error) {
+	conn := *m
+	conn.cursor = nil
+	return &conn, nil
+}
+
 func (m *StaticDataSource) Next() schema.Message {
 	//u.Infof("Next()")
 	select {
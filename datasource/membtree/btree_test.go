@@ -155,3 +155,31 @@ func TestStaticDataSource(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, curSize, delCt, "Should have deleted all records")
 }
+
+func TestStaticDataSourceOpen(t *testing.T) {
+
+	static := membtree.NewStaticDataSource("nums", 0, [][]driver.Value{{1}, {2}, {3}}, []string{"n"})
+
+	// each connection scans every row with its own cursor, interleaved
+	c1, err := static.Open("nums")
+	assert.Equal(t, nil, err)
+	c2, err := static.Open("nums")
+	assert.Equal(t, nil, err)
+	s1 := c1.(schema.ConnScanner)
+	s2 := c2.(schema.ConnScanner)
+	ct1, ct2 := 0, 0
+	for {
+		m1, m2 := s1.Next(), s2.Next()
+		if m1 == nil && m2 == nil {
+			break
+		}
+		if m1 != nil {
+			ct1++
+		}
+		if m2 != nil {
+			ct2++
+		}
+	}
+	assert.Equal(t, 3, ct1)
+	assert.Equal(t, 3, ct2)
+}
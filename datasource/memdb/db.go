@@ -6,6 +6,7 @@ package memdb
 import (
 	"database/sql/driver"
 	"fmt"
+	"strings"
 
 	u "github.com/araddon/gou"
 	"github.com/hashicorp/go-memdb"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
@@ -33,6 +36,8 @@ var (
 	_ schema.ConnUpsert   = (*dbConn)(nil)
 	_ schema.ConnDeletion = (*dbConn)(nil)
 	_ schema.ConnSeeker   = (*dbConn)(nil)
+	_ plan.SourceSorter   = (*dbConn)(nil)
+	_ plan.SourcePruner   = (*dbConn)(nil)
 )
 
 // MemDb implements qlbridge `Source` to allow in-memory native go data
@@ -51,6 +56,9 @@ type dbConn struct {
 	db     *memdb.MemDB
 	txn    *memdb.Txn
 	result memdb.ResultIterator
+	limit  int
+	ct     int
+	keep   []bool // positions of the columns read, nil reads all
 }
 
 // NewMemDbData creates a MemDb with given indexes, columns, and values
@@ -169,11 +177,24 @@ func (m *dbConn) Next() schema.Message {
 				}
 				m.result = result
 			}
+			if m.limit > 0 && m.ct >= m.limit {
+				return nil
+			}
 			raw := m.result.Next()
 			if raw == nil {
 				return nil
 			}
+			m.ct++
 			if msg, ok := raw.(*datasource.SqlDriverMessage); ok {
+				if m.keep != nil {
+					vals := make([]driver.Value, len(msg.Vals))
+					for i, keep := range m.keep {
+						if keep && i < len(vals) {
+							vals[i] = msg.Vals[i]
+						}
+					}
+					return datasource.NewSqlDriverMessageMap(msg.IdVal, vals, m.md.tbl.FieldPositions)
+				}
 				return msg.ToMsgMap(m.md.tbl.FieldPositions)
 			}
 			u.Warnf("error, not correct type: %#v", raw)
@@ -182,6 +203,43 @@ func (m *dbConn) Next() schema.Message {
 	}
 }
 
+// Sort rows are scanned in string order of the primary key, so an ORDER BY
+// of a string primary key ascending is taken by this conn, along with its
+// limit.
+func (m *dbConn) Sort(orderBy rel.Columns, limit int) bool {
+	if len(orderBy) != 1 || !orderBy[0].Asc() {
+		return false
+	}
+	in, ok := orderBy[0].Expr.(*expr.IdentityNode)
+	if !ok {
+		return false
+	}
+	_, col, _ := in.LeftRight()
+	if col != m.md.tbl.Columns()[0] {
+		return false
+	}
+	if vt, ok := m.md.tbl.Column(col); !ok || vt != value.StringType {
+		return false
+	}
+	m.limit = limit
+	return true
+}
+
+// PruneColumns rows only have the values of these columns, the others are
+// nil.
+func (m *dbConn) PruneColumns(cols []string) bool {
+	keep := make([]bool, len(m.md.tbl.Columns()))
+	for _, col := range cols {
+		for i, name := range m.md.tbl.Columns() {
+			if strings.EqualFold(name, col) {
+				keep[i] = true
+			}
+		}
+	}
+	m.keep = keep
+	return true
+}
+
 // Put interface for allowing this to accept writes via ConnUpsert.Put()
 func (m *dbConn) Put(ctx context.Context, key schema.Key, row interface{}) (schema.Key, error) {
 
@@ -100,16 +100,20 @@ func (m *Source) DropTable(t string) error {
 func (m *Source) Open(tableName string) (schema.Conn, error) {
 
 	tableName = strings.ToLower(tableName)
-	if ds, ok := m.tables[tableName]; ok {
-		return &Table{StaticDataSource: ds}, nil
+	ds, ok := m.tables[tableName]
+	if !ok {
+		if err := m.loadTable(tableName); err != nil {
+			u.Errorf("could not load table %q  err=%v", tableName, err)
+			return nil, err
+		}
+		ds = m.tables[tableName]
 	}
-	err := m.loadTable(tableName)
+	// each connection scans with its own cursor
+	conn, err := ds.Open(tableName)
 	if err != nil {
-		u.Errorf("could not load table %q  err=%v", tableName, err)
 		return nil, err
 	}
-	ds := m.tables[tableName]
-	return &Table{StaticDataSource: ds}, nil
+	return &Table{StaticDataSource: conn.(*membtree.StaticDataSource)}, nil
 }
 
 // Table get table schema for given table name.  If given table is not currently
@@ -68,7 +68,7 @@ func (m *rewrite) rewrite() (string, error) {
 // eval() returns ( value, isOk, isIdentity )
 func (m *rewrite) eval(arg expr.Node) (value.Value, bool, bool) {
 	switch arg := arg.(type) {
-	case *expr.NumberNode, *expr.StringNode:
+	case *expr.NumberNode, *expr.StringNode, *expr.ParamNode:
 		val, ok := vm.Eval(nil, arg)
 		return val, ok, false
 	case *expr.IdentityNode:
@@ -123,6 +123,13 @@ func (m *rewrite) walkSelectList() error {
 			// 	return nil, value.NewStringValue(curNode.Text), nil
 			case *expr.IdentityNode:
 				//u.Debugf("likely a projection, not agg T:%T  %v", curNode, curNode)
+			case *expr.CaseNode:
+				// native to sqlite
+				newNode, err := m.walkCase(curNode)
+				if err != nil {
+					return err
+				}
+				col.Expr = newNode
 			default:
 				u.Warnf("unrecognized not agg T:%T  %v", curNode, curNode)
 				//panic("Unrecognized node type")
@@ -197,7 +204,7 @@ func (m *rewrite) selectFunc(cur expr.Node) (expr.Node, error) {
 func (m *rewrite) walkNode(cur expr.Node) (expr.Node, error) {
 	//u.Debugf("WalkNode: %#v", cur)
 	switch curNode := cur.(type) {
-	case *expr.NumberNode, *expr.StringNode:
+	case *expr.NumberNode, *expr.StringNode, *expr.ParamNode:
 		return curNode, nil
 	case *expr.BinaryNode:
 		return m.walkFilterBinary(curNode)
@@ -214,6 +221,8 @@ func (m *rewrite) walkNode(cur expr.Node) (expr.Node, error) {
 		return curNode, nil
 	case *expr.ArrayNode:
 		return m.walkArrayNode(curNode)
+	case *expr.CaseNode:
+		return m.walkCase(curNode)
 	default:
 		u.Debugf("unrecognized T:%T  %v", cur, cur)
 	}
@@ -246,6 +255,36 @@ func (m *rewrite) walkFilterTri(node *expr.TriNode) (expr.Node, error) {
 	return node, nil
 }
 
+// Case expressions are native to sqlite, so only their arg, WHEN, THEN
+// and ELSE expressions need re-writing.
+//
+//     CASE WHEN x != NULL THEN x ELSE "none" END
+//
+func (m *rewrite) walkCase(node *expr.CaseNode) (expr.Node, error) {
+	walk := func(n expr.Node) (expr.Node, error) {
+		if n == nil {
+			return nil, nil
+		}
+		return m.walkNode(n)
+	}
+	var err error
+	if node.Arg, err = walk(node.Arg); err != nil {
+		return nil, err
+	}
+	for i := range node.Whens {
+		if node.Whens[i], err = walk(node.Whens[i]); err != nil {
+			return nil, err
+		}
+		if node.Thens[i], err = walk(node.Thens[i]); err != nil {
+			return nil, err
+		}
+	}
+	if node.Else, err = walk(node.Else); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
 // Array Nodes expressions:
 //
 //    year IN (1990,1992)  =>
@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"encoding"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// The global aggregate registry
+	aggReg = NewAggregatorRegistry()
+)
+
+func init() {
+	aggReg.Add("avg", NewAvg)
+	aggReg.Add("count", func(col *rel.Column, partial bool) Aggregator { return NewCount(col) })
+	aggReg.Add("sum", NewSum)
+	aggReg.Add("min", NewMin)
+	aggReg.Add("max", NewMax)
+	aggReg.Add("stddev", NewStdDev)
+	aggReg.Add("variance", NewVariance)
+	aggReg.Add("median", NewMedian)
+	aggReg.Add("group_concat", NewGroupConcat)
+	aggReg.Add("grouping", NewGrouping)
+}
+
+type (
+	// AggregatorFactory creates the Aggregator for an aggregate func column
+	// of a group by.  If partial, Result must return an *AggPartial that the
+	// GroupByFinal task Merges into an Aggregator made with partial=false.
+	AggregatorFactory func(col *rel.Column, partial bool) Aggregator
+
+	// AggregatorMarshaler is implemented by aggregators, such as user-defined
+	// ones, whose partial state doesn't fit the fields of AggPartial.  The
+	// GroupBy task of a partial group by sends the state of each group as
+	// marshaled by MarshalBinary, in the State of an AggPartial, and the
+	// GroupByFinal task merges it into the aggregator of the group with
+	// MergeBinary, instead of Result and Merge of an *AggPartial.
+	AggregatorMarshaler interface {
+		encoding.BinaryMarshaler
+		// MergeBinary merge the partial state of a group, as marshaled by
+		// MarshalBinary of the aggregator of another task.
+		MergeBinary(data []byte) error
+	}
+
+	// AggregatorRegistry of aggregate implementations by func name.
+	AggregatorRegistry struct {
+		mu   sync.RWMutex
+		aggs map[string]AggregatorFactory
+	}
+)
+
+// NewAggregatorRegistry create a new aggregate registry. By default there is
+// a global one holding the builtin aggregates.
+func NewAggregatorRegistry() *AggregatorRegistry {
+	return &AggregatorRegistry{aggs: make(map[string]AggregatorFactory)}
+}
+
+// Add a name/aggregate to registry, replacing any of same name.
+func (m *AggregatorRegistry) Add(name string, fn AggregatorFactory) {
+	m.mu.Lock()
+	m.aggs[strings.ToLower(name)] = fn
+	m.mu.Unlock()
+}
+
+// Get an aggregate from registry if it exists.
+func (m *AggregatorRegistry) Get(name string) (AggregatorFactory, bool) {
+	m.mu.RLock()
+	fn, ok := m.aggs[strings.ToLower(name)]
+	m.mu.RUnlock()
+	return fn, ok
+}
+
+// AggregateAdd Global add a user-defined aggregate func, both the func the
+// parser and vm validate and evaluate for each row, and the Aggregator that
+// GroupBy calculates it with.  The value passed to Aggregator.Do for each
+// row is that of the one argument of the func, or with more arguments a
+// value.SliceValue of their values.
+//
+//    exec.AggregateAdd("weighted_avg", NewWeightedAvg)
+//
+//    SELECT user_id, weighted_avg(price, item_count) FROM orders GROUP BY user_id
+//
+// Aggregators whose partial state does not fit AggPartial implement
+// AggregatorMarshaler.
+func AggregateAdd(name string, fn AggregatorFactory) {
+	expr.FuncAdd(name, &aggregateFunc{})
+	aggReg.Add(name, fn)
+}
+
+// AggregatorAdd Global add an aggregate implementation used by GroupBy for
+// func columns of this name.  The func itself must also be added with
+// expr.FuncAdd as a CustomFunc whose IsAgg() is true, the value it
+// evaluates to for each row is passed to Aggregator.Do, see AggregateAdd
+// to add both.
+func AggregatorAdd(name string, fn AggregatorFactory) {
+	aggReg.Add(name, fn)
+}
+
+// AggregatorGet Global get of an aggregate implementation.
+func AggregatorGet(name string) (AggregatorFactory, bool) {
+	return aggReg.Get(name)
+}
+
+// aggregateFunc the per-row func of a user-defined aggregate, evaluating to
+// the value of its argument or the values of its arguments.
+type aggregateFunc struct{}
+
+func (m *aggregateFunc) Type() value.ValueType { return value.UnknownType }
+func (m *aggregateFunc) IsAgg() bool           { return true }
+func (m *aggregateFunc) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) == 0 {
+		return nil, fmt.Errorf("Expected 1 or more args for %s but got none", n)
+	}
+	return func(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+		if len(vals) == 1 {
+			return vals[0], true
+		}
+		return value.NewSliceValues(vals), true
+	}, nil
+}
@@ -1,15 +1,18 @@
 package exec
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"strings"
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/vm"
 )
 
@@ -20,7 +23,7 @@ var (
 	_ TaskRunner = (*Command)(nil)
 )
 
-// Command is executeable task for SET SQL commands
+// Command is executeable task for SET and ANALYZE TABLE SQL commands
 type Command struct {
 	*TaskBase
 	p *plan.Command
@@ -48,6 +51,10 @@ func (m *Command) Run() error {
 	//defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
+	if m.p.Stmt.Keyword() == lex.TokenAnalyze {
+		return m.runAnalyze()
+	}
+
 	if m.Ctx.Session == nil {
 		u.Warnf("no Context.Session?")
 		return fmt.Errorf("no Context.Session?")
@@ -65,6 +72,69 @@ func (m *Command) Run() error {
 	return ErrNotImplemented
 
 }
+
+// runAnalyze collect the statistics of each table of ANALYZE TABLE by
+// scanning all of the rows of its source.
+func (m *Command) runAnalyze() error {
+	if m.Ctx.Schema == nil {
+		return fmt.Errorf("no schema to analyze tables of")
+	}
+	for _, col := range m.p.Stmt.Columns {
+		if err := analyzeTable(m.Ctx.Schema, col.Name); err != nil {
+			u.Warnf("could not analyze table %q err=%v", col.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func analyzeTable(s *schema.Schema, name string) error {
+	tbl, err := s.Table(name)
+	if err != nil {
+		return err
+	}
+	conn, err := s.OpenConn(name)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	scanner, ok := conn.(schema.ConnScanner)
+	if !ok {
+		return fmt.Errorf("source of %q cannot scan its rows, %T", name, conn)
+	}
+
+	cols := tbl.Columns()
+	sb := schema.NewTableStatsBuilder(cols)
+	for msg := scanner.Next(); msg != nil; msg = scanner.Next() {
+		sb.Add(tableRowValues(msg, cols))
+	}
+	tbl.SetStats(sb.Stats())
+	return nil
+}
+
+// tableRowValues the values of msg in the order of the table columns cols.
+func tableRowValues(msg schema.Message, cols []string) []driver.Value {
+	if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok && len(mm.ColIndex) > 0 {
+		vals := make([]driver.Value, len(cols))
+		for i, col := range cols {
+			if idx, ok := mm.ColIndex[col]; ok && idx < len(mm.Vals) {
+				vals[i] = mm.Vals[idx]
+			}
+		}
+		return vals
+	}
+	switch mt := msg.Body().(type) {
+	case schema.MessageValues:
+		return mt.Values()
+	case []driver.Value:
+		return mt
+	}
+	if mv, ok := msg.(schema.MessageValues); ok {
+		return mv.Values()
+	}
+	return nil
+}
+
 func (m *Command) runSet() error {
 
 	writeContext, ok := m.Ctx.Session.(expr.ContextWriter)
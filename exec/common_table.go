@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	_ = u.EMPTY
+
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*CommonTableSource)(nil)
+)
+
+// CommonTableSource is the source task for a plan.Source that reads a common
+// table expression (WITH name AS (SELECT ...)).  The select of the common
+// table runs as its own dag of tasks feeding this source.
+//
+// A common table read by only one source is streamed.  One read by more than
+// one source is materialized into memory by the first source to run, and
+// all sources then read the shared rows.
+type CommonTableSource struct {
+	*TaskBase
+	p        *plan.Source
+	result   *commonTableResult
+	colIndex map[string]int
+	id       uint64
+}
+
+// result of a common table, shared by all sources reading it.
+type commonTableResult struct {
+	once        sync.Once
+	task        TaskRunner
+	materialize bool
+	rows        [][]driver.Value
+	err         error
+}
+
+// NewCommonTableSource create the source task reading given common table
+// result.
+func NewCommonTableSource(ctx *plan.Context, p *plan.Source, result *commonTableResult) *CommonTableSource {
+	cols := p.Cte.Columns()
+	colIndex := make(map[string]int, len(cols))
+	for i, col := range cols {
+		colIndex[col] = i
+	}
+	return &CommonTableSource{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+		result:   result,
+		colIndex: colIndex,
+	}
+}
+
+func (m *CommonTableSource) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	if !m.result.materialize {
+		return runSelect(m.result.task, m.SigChan(), m.send)
+	}
+
+	m.result.once.Do(func() {
+		// Must read all rows as other sources share them, so
+		// this source quitting doesn't stop the common table.
+		m.result.err = runSelect(m.result.task, nil, func(vals []driver.Value) bool {
+			m.result.rows = append(m.result.rows, vals)
+			return true
+		})
+	})
+	if m.result.err != nil {
+		return m.result.err
+	}
+	for _, vals := range m.result.rows {
+		if !m.send(vals) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *CommonTableSource) send(vals []driver.Value) bool {
+	m.id++
+	msg := datasource.NewSqlDriverMessageMap(m.id, vals, m.colIndex)
+	wait := time.Now()
+	select {
+	case <-m.SigChan():
+		return false
+	case m.msgOutCh <- msg:
+		m.stats.sent(wait)
+		return true
+	}
+}
+
+// runSelect run the task of a select (common table, sub-select), calling fn
+// with the values of each row until fn returns false or sigCh is closed.
+func runSelect(task TaskRunner, sigCh SigChan, fn func(vals []driver.Value) bool) error {
+	if err := task.Setup(1); err != nil {
+		return err
+	}
+	defer task.Close()
+
+	var runErr error
+	done := make(chan struct{})
+	go func() {
+		runErr = task.Run()
+		close(done)
+	}()
+
+	inCh := task.MessageOut()
+	for {
+		select {
+		case <-sigCh:
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				<-done
+				return runErr
+			}
+			mt, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				u.Errorf("unrecognized msg %T", msg)
+				return fmt.Errorf("To read select rows must use SqlDriverMessageMap but got %T", msg)
+			}
+			if !fn(mt.Values()) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	_ = u.EMPTY
+
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*SetOperation)(nil)
+)
+
+// SetOperation combines the results of each select of a compound select
+// using the set operators (UNION [ALL], INTERSECT, EXCEPT) left to right.
+//
+//   select1   ->
+//                \
+//   select2   ->  --  set-op  -->
+//                /
+//   select3   ->
+//
+// UNION ALL only compounds are streamed, others must read all rows into
+// memory to de-duplicate.
+type SetOperation struct {
+	*TaskBase
+	p        *plan.Compound
+	inputs   []TaskRunner
+	colIndex map[string]int
+	id       uint64
+}
+
+// NewSetOperation create the set-operation task for given input tasks, one
+// per select in the compound.
+func NewSetOperation(ctx *plan.Context, inputs []TaskRunner, p *plan.Compound) *SetOperation {
+	m := &SetOperation{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+		inputs:   inputs,
+	}
+	// Result columns are named by the first select
+	if ctx.Projection != nil && ctx.Projection.Proj != nil {
+		m.colIndex = make(map[string]int, len(ctx.Projection.Proj.Columns))
+		for i, col := range ctx.Projection.Proj.Columns {
+			m.colIndex[col.As] = i
+		}
+	} else {
+		m.colIndex = p.Result.ColIndexes()
+	}
+	return m
+}
+
+func (m *SetOperation) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	if m.p.Stmt.IsAll() {
+		return m.runUnionAll()
+	}
+
+	rows, err := m.readAll(m.inputs[0])
+	if err != nil {
+		return err
+	}
+	for i, op := range m.p.Stmt.Ops {
+		next, err := m.readAll(m.inputs[i+1])
+		if err != nil {
+			return err
+		}
+		switch op.Op {
+		case lex.TokenUnion:
+			rows = append(rows, next...)
+			if !op.All {
+				rows = distinctRows(rows)
+			}
+		case lex.TokenIntersect:
+			rows = distinctRows(filterRows(rows, next, true))
+		case lex.TokenExcept:
+			rows = distinctRows(filterRows(rows, next, false))
+		default:
+			return fmt.Errorf("unsupported set operator %v", op.Op)
+		}
+	}
+
+	outCh := m.MessageOut()
+	for _, row := range rows {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case outCh <- row:
+			m.stats.sent(wait)
+		}
+	}
+	return nil
+}
+
+// UNION ALL, no de-duplication so send rows as we read them
+func (m *SetOperation) runUnionAll() error {
+	outCh := m.MessageOut()
+	for _, in := range m.inputs {
+		inCh := in.MessageOut()
+	msgReadLoop:
+		for {
+			wait := time.Now()
+			select {
+			case <-m.SigChan():
+				return nil
+			case msg, ok := <-inCh:
+				if !ok {
+					break msgReadLoop
+				}
+				m.stats.received(wait, inCh)
+				row, err := m.message(msg)
+				if err != nil {
+					m.Quit()
+					return err
+				}
+				wait = time.Now()
+				select {
+				case <-m.SigChan():
+					return nil
+				case outCh <- row:
+					m.stats.sent(wait)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// read all rows from a select input.
+func (m *SetOperation) readAll(in TaskRunner) ([]*datasource.SqlDriverMessageMap, error) {
+	rows := make([]*datasource.SqlDriverMessageMap, 0)
+	inCh := in.MessageOut()
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return rows, nil
+		case msg, ok := <-inCh:
+			if !ok {
+				return rows, nil
+			}
+			m.stats.received(wait, inCh)
+			row, err := m.message(msg)
+			if err != nil {
+				m.Quit()
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+	}
+}
+
+// message re-writes a row from any of the selects to use column names
+// of the combined result.
+func (m *SetOperation) message(msg schema.Message) (*datasource.SqlDriverMessageMap, error) {
+	mt, ok := msg.(*datasource.SqlDriverMessageMap)
+	if !ok {
+		u.Errorf("unrecognized msg %T", msg)
+		return nil, fmt.Errorf("To use Compound Select must use SqlDriverMessageMap but got %T", msg)
+	}
+	m.id++
+	row := datasource.NewSqlDriverMessageMap(m.id, mt.Values(), m.colIndex)
+	row.SetKey(rowKey(row))
+	return row, nil
+}
+
+// rowKey is the typed value of all columns of the row, used for row
+// equality.  Numbers are equal by value whether int or float, but not to a
+// string of the number.
+func rowKey(row *datasource.SqlDriverMessageMap) string {
+	vals := row.Values()
+	keys := make([]string, len(vals))
+	for i, v := range vals {
+		if v == nil {
+			keys[i] = "\x01"
+			continue
+		}
+		keys[i] = valueKey(value.NewValue(v))
+	}
+	return strings.Join(keys, string(byte(0)))
+}
+
+// valueKey the key of a value prefixed by its kind, so values of different
+// types that print the same are not equal.
+func valueKey(v value.Value) string {
+	switch vt := v.(type) {
+	case value.NilValue:
+		return "\x01"
+	case value.IntValue:
+		return "n" + strconv.FormatInt(vt.Val(), 10)
+	case value.NumberValue:
+		f := vt.Val()
+		if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return "n" + strconv.FormatInt(int64(f), 10)
+		}
+		return "n" + strconv.FormatFloat(f, 'g', -1, 64)
+	case value.StringValue:
+		return "s" + vt.Val()
+	case value.BoolValue:
+		return "b" + strconv.FormatBool(vt.Val())
+	case value.TimeValue:
+		return "t" + vt.Val().UTC().Format(time.RFC3339Nano)
+	}
+	return "v" + v.ToString()
+}
+
+// distinctRows removes duplicate rows keeping first seen.
+func distinctRows(rows []*datasource.SqlDriverMessageMap) []*datasource.SqlDriverMessageMap {
+	seen := make(map[driver.Value]struct{}, len(rows))
+	out := rows[:0]
+	for _, row := range rows {
+		if _, exists := seen[row.Key()]; exists {
+			continue
+		}
+		seen[row.Key()] = struct{}{}
+		out = append(out, row)
+	}
+	return out
+}
+
+// filterRows keeps rows that exist in (or for !exists are missing from) other.
+func filterRows(rows, other []*datasource.SqlDriverMessageMap, exists bool) []*datasource.SqlDriverMessageMap {
+	keys := make(map[driver.Value]struct{}, len(other))
+	for _, row := range other {
+		keys[row.Key()] = struct{}{}
+	}
+	out := rows[:0]
+	for _, row := range rows {
+		if _, found := keys[row.Key()]; found == exists {
+			out = append(out, row)
+		}
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+package exec
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*Distinct)(nil)
+
+	// DistinctMaxKeys is the max number of distinct rows a Distinct task
+	// holds in memory, rows past it are spilled to temp files on disk.
+	// Zero or less never spills.
+	DistinctMaxKeys = 1000000
+)
+
+// number of temp files spilled rows are partitioned into
+const distinctSpillParts = 16
+
+func init() {
+	// value types of rows that may be spilled to disk
+	gob.Register(time.Time{})
+	gob.Register([]string{})
+	gob.Register(map[string]interface{}{})
+}
+
+// Distinct removes duplicate rows of the result
+//
+//    SELECT DISTINCT user_id FROM orders
+//
+// Rows are streamed in the order read, keeping the first of each distinct
+// row in a hash set of row values.  Once the set holds DistinctMaxKeys rows,
+// rows not in it are spilled to temp files partitioned by hash of the row,
+// then each partition is de-duplicated on its own after all rows are read.
+// As rows must be de-duplicated before counting, LIMIT is applied here.
+type Distinct struct {
+	*TaskBase
+	p        *plan.Distinct
+	colIndex map[string]int
+	spill    []*distinctSpill
+	rowCt    int
+}
+
+// distinctSpill a temp file partition of spilled rows.
+type distinctSpill struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *gob.Encoder
+}
+
+// NewDistinct create the distinct exec task.
+func NewDistinct(ctx *plan.Context, p *plan.Distinct) *Distinct {
+	m := &Distinct{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+	}
+	return m
+}
+
+func (m *Distinct) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+	defer m.removeSpill()
+
+	inCh := m.MessageIn()
+	seen := make(map[string]struct{})
+
+msgReadLoop:
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok || msg == nil {
+				break msgReadLoop
+			}
+			m.stats.received(wait, inCh)
+			sdm, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				err := fmt.Errorf("To use Distinct must use SqlDriverMessageMap but got %T", msg)
+				u.Errorf("unrecognized msg %T", msg)
+				m.Quit()
+				return err
+			}
+			if m.limited() {
+				// drain the rest of input
+				continue
+			}
+			if m.colIndex == nil {
+				m.colIndex = sdm.ColIndex
+			}
+			key := rowKey(sdm)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			if DistinctMaxKeys > 0 && len(seen) >= DistinctMaxKeys {
+				if err := m.spillRow(key, sdm.Vals); err != nil {
+					return err
+				}
+				continue
+			}
+			seen[key] = struct{}{}
+			if !m.emit(sdm) {
+				return nil
+			}
+		}
+	}
+
+	for _, part := range m.spill {
+		if part == nil {
+			continue
+		}
+		if ok, err := m.readSpill(part); !ok || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limited has the LIMIT number of rows been sent.
+func (m *Distinct) limited() bool {
+	return m.p.Stmt.Limit > 0 && m.rowCt >= m.p.Stmt.Limit
+}
+
+// emit send a distinct row, false if we were told to quit.
+func (m *Distinct) emit(msg *datasource.SqlDriverMessageMap) bool {
+	if m.limited() {
+		return true
+	}
+	m.rowCt++
+	wait := time.Now()
+	select {
+	case m.msgOutCh <- msg:
+		m.stats.sent(wait)
+		return true
+	case <-m.SigChan():
+		return false
+	}
+}
+
+// spillRow write a row not in the in-memory set to its partition on disk.
+func (m *Distinct) spillRow(key string, row []driver.Value) error {
+	if m.spill == nil {
+		m.spill = make([]*distinctSpill, distinctSpillParts)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	idx := h.Sum32() % distinctSpillParts
+	part := m.spill[idx]
+	if part == nil {
+		f, err := ioutil.TempFile("", "qlbridge-distinct")
+		if err != nil {
+			return err
+		}
+		part = &distinctSpill{f: f, w: bufio.NewWriter(f)}
+		part.enc = gob.NewEncoder(part.w)
+		m.spill[idx] = part
+	}
+	return part.enc.Encode(row)
+}
+
+// readSpill de-duplicate and send the rows of a spilled partition, rows of
+// one key are all in the same partition.
+func (m *Distinct) readSpill(part *distinctSpill) (bool, error) {
+	if err := part.w.Flush(); err != nil {
+		return false, err
+	}
+	if _, err := part.f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	seen := make(map[string]struct{})
+	dec := gob.NewDecoder(bufio.NewReader(part.f))
+	for i := uint64(0); ; i++ {
+		var row []driver.Value
+		if err := dec.Decode(&row); err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		msg := datasource.NewSqlDriverMessageMap(i, row, m.colIndex)
+		key := rowKey(msg)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		if !m.emit(msg) {
+			return false, nil
+		}
+		if m.limited() {
+			return false, nil
+		}
+	}
+}
+
+// removeSpill close and remove the spilled temp files.
+func (m *Distinct) removeSpill() {
+	for _, part := range m.spill {
+		if part == nil {
+			continue
+		}
+		part.f.Close()
+		if err := os.Remove(part.f.Name()); err != nil {
+			u.Warnf("could not remove distinct spill file %s: %v", part.f.Name(), err)
+		}
+	}
+	m.spill = nil
+}
@@ -73,6 +73,7 @@ type (
 		ErrChan() ErrChan
 		SigChan() SigChan
 		Quit()
+		Stats() *TaskStats
 	}
 	// TaskPrinter a debug printer for dag-shape.
 	TaskPrinter interface {
@@ -93,18 +94,24 @@ type (
 
 		// DML Statements
 		WalkSelect(p *plan.Select) (Task, error)
+		WalkCompound(p *plan.Compound) (Task, error)
 		WalkInsert(p *plan.Insert) (Task, error)
 		WalkUpsert(p *plan.Upsert) (Task, error)
 		WalkUpdate(p *plan.Update) (Task, error)
 		WalkDelete(p *plan.Delete) (Task, error)
+		WalkExplain(p *plan.Explain) (Task, error)
 		// DML Child Tasks
 		WalkSource(p *plan.Source) (Task, error)
 		WalkJoin(p *plan.JoinMerge) (Task, error)
 		WalkJoinKey(p *plan.JoinKey) (Task, error)
+		WalkNestedLoopJoin(p *plan.NestedLoopJoin) (Task, error)
 		WalkWhere(p *plan.Where) (Task, error)
 		WalkHaving(p *plan.Having) (Task, error)
 		WalkGroupBy(p *plan.GroupBy) (Task, error)
 		WalkOrder(p *plan.Order) (Task, error)
+		WalkWindow(p *plan.Window) (Task, error)
+		WalkDistinct(p *plan.Distinct) (Task, error)
+		WalkSemiJoin(p *plan.SemiJoin) (Task, error)
 		WalkProjection(p *plan.Projection) (Task, error)
 		// Other Statements
 		WalkCommand(p *plan.Command) (Task, error)
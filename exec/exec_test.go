@@ -1,9 +1,16 @@
 package exec_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,11 +18,15 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
 	"github.com/araddon/qlbridge/datasource/mockcsv"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
 )
 
 func TestMain(m *testing.M) {
@@ -39,6 +50,56 @@ func TestDDLStatements(t *testing.T) {
 	testutil.RunDDLTests(t)
 }
 
+func TestExecContextCancel(t *testing.T) {
+
+	ctx := td.TestContext(`SELECT user_id, email FROM users`)
+	goctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx.Context = goctx
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	defer job.Close()
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, context.Canceled, job.Run())
+}
+
+func TestExecSubSelectErr(t *testing.T) {
+
+	// correlated sub-select of more than one row for a row fails the job
+	ctx := td.TestContext(`SELECT order_id, (SELECT x.order_id FROM orders AS x WHERE x.user_id = o.user_id) AS e FROM orders AS o`)
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	defer job.Close()
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	assert.Equal(t, nil, job.Setup())
+	err = job.Run()
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, err, job.Err())
+}
+
+func TestTaskQuitClose(t *testing.T) {
+	// a task that quit on error may be quit again on cancel, then closed
+	task := exec.NewTaskBase(td.TestContext(`SELECT user_id FROM users`))
+	task.Quit()
+	task.Quit()
+	assert.Equal(t, nil, task.Close())
+	_, open := <-task.SigChan()
+	assert.Equal(t, false, open)
+
+	task = exec.NewTaskBase(td.TestContext(`SELECT user_id FROM users`))
+	assert.Equal(t, nil, task.Close())
+	task.Quit()
+}
+
 func TestExecSqlCommands(t *testing.T) {
 
 	sqlText := `
@@ -201,6 +262,256 @@ func TestExecGroupBy(t *testing.T) {
 	assert.True(t, int(row[0].(float64)) == 14, "expected avg(len(email))=14 but got %v", int(row[0].(float64)))
 }
 
+func TestExecMinMaxNumericStrings(t *testing.T) {
+
+	// csv values are strings, numbers in them compare as numbers
+	mockcsv.LoadTable(mockcsv.SchemaName, "minmax_nums", "id,grp,n\n1,a,9\n2,b,10\n3,a,\n4,b,2\n5,a,100\n6,c,9\n7,c,1a\n8,c,10")
+
+	ctx := td.TestContext("SELECT grp, min(n) AS mn, max(n) AS mx FROM minmax_nums GROUP BY grp")
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, nil, job.Run())
+	time.Sleep(time.Millisecond * 10)
+
+	found := make(map[string][]driver.Value)
+	for _, msg := range msgs {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		found[row[0].(string)] = row
+	}
+	assert.Equal(t, 3, len(found))
+	assert.Equal(t, []driver.Value{"a", "9", "100"}, found["a"])
+	assert.Equal(t, []driver.Value{"b", "2", "10"}, found["b"])
+	// numbers sort before other strings
+	assert.Equal(t, []driver.Value{"c", "9", "1a"}, found["c"])
+}
+
+func TestExecGroupByPartial(t *testing.T) {
+
+	sqlText := `
+		select
+	        user_id, count(DISTINCT item_id) AS cd, min(price) AS mn, max(price) AS mx,
+	        variance(price) AS v, median(price) AS md, group_concat(item_id) AS items
+	    FROM orders
+	    GROUP BY user_id
+	`
+	ctx := td.TestContext(sqlText)
+	stmt, err := rel.ParseSqlSelect(sqlText)
+	assert.Equal(t, nil, err)
+
+	cols := map[string]int{"user_id": 0, "item_id": 1, "price": 2}
+	nodes := [][][]driver.Value{
+		{{"u1", "1", "10"}, {"u1", "2", "30"}, {"u2", "1", "5"}},
+		{{"u1", "1", "20"}, {"u1", nil, "40"}},
+	}
+
+	// Each node calculates partials for its own rows, the final task
+	// merges them.
+	partials := make(exec.MessageChan, 10)
+	for _, rows := range nodes {
+		p := plan.NewGroupBy(stmt)
+		p.Partial = true
+		gb := exec.NewGroupBy(ctx, p)
+		in := make(exec.MessageChan, len(rows))
+		for i, row := range rows {
+			in <- datasource.NewSqlDriverMessageMap(uint64(i), row, cols)
+		}
+		close(in)
+		gb.MessageInSet(in)
+		assert.Equal(t, nil, gb.Run())
+		for msg := range gb.MessageOut() {
+			partials <- msg
+		}
+	}
+	close(partials)
+
+	final := exec.NewGroupByFinal(ctx, plan.NewGroupBy(stmt))
+	final.MessageInSet(partials)
+	assert.Equal(t, nil, final.Run())
+
+	found := make(map[string][]driver.Value)
+	for msg := range final.MessageOut() {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		found[row[0].(string)] = row
+	}
+	assert.Equal(t, 2, len(found))
+	row := found["u1"]
+	assert.Equal(t, int64(2), row[1], "count distinct %v", row)
+	assert.Equal(t, "10", row[2], "min %v", row)
+	assert.Equal(t, "40", row[3], "max %v", row)
+	assert.Equal(t, float64(125), row[4], "variance %v", row)
+	assert.Equal(t, float64(25), row[5], "median %v", row)
+	assert.Equal(t, "1,2,1", row[6], "group_concat %v", row)
+	row = found["u2"]
+	assert.Equal(t, []driver.Value{"u2", int64(1), "5", "5", float64(0), float64(5), "1"}, row)
+}
+
+func TestExecGroupByRollupPartial(t *testing.T) {
+
+	sqlText := `SELECT user_id, count(*) AS ct, grouping(user_id) AS g FROM orders GROUP BY ROLLUP (user_id)`
+	ctx := td.TestContext(sqlText)
+	stmt, err := rel.ParseSqlSelect(sqlText)
+	assert.Equal(t, nil, err)
+
+	cols := map[string]int{"user_id": 0, "item_id": 1, "price": 2}
+	nodes := [][][]driver.Value{
+		{{"u1", "1", "10"}, {"u1", "2", "30"}, {"u2", "1", "5"}},
+		{{"u1", "1", "20"}, {"u1", nil, "40"}},
+	}
+
+	partials := make(exec.MessageChan, 10)
+	for _, rows := range nodes {
+		p := plan.NewGroupBy(stmt)
+		p.Partial = true
+		gb := exec.NewGroupBy(ctx, p)
+		in := make(exec.MessageChan, len(rows))
+		for i, row := range rows {
+			in <- datasource.NewSqlDriverMessageMap(uint64(i), row, cols)
+		}
+		close(in)
+		gb.MessageInSet(in)
+		assert.Equal(t, nil, gb.Run())
+		for msg := range gb.MessageOut() {
+			partials <- msg
+		}
+	}
+	close(partials)
+
+	final := exec.NewGroupByFinal(ctx, plan.NewGroupBy(stmt))
+	final.MessageInSet(partials)
+	assert.Equal(t, nil, final.Run())
+
+	found := make(map[string][]driver.Value)
+	for msg := range final.MessageOut() {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		key, _ := row[0].(string)
+		found[key] = row
+	}
+	assert.Equal(t, 3, len(found))
+	assert.Equal(t, []driver.Value{"u1", int64(4), int64(0)}, found["u1"])
+	assert.Equal(t, []driver.Value{"u2", int64(1), int64(0)}, found["u2"])
+	// the grand total of all nodes
+	assert.Equal(t, []driver.Value{nil, int64(5), int64(1)}, found[""])
+}
+
+// weightedAvg a user-defined weighted_avg(value, weight) aggregate whose
+// partial state is marshaled
+type weightedAvg struct {
+	N, W float64
+}
+
+func (m *weightedAvg) Do(v value.Value) {
+	sv, ok := v.(value.SliceValue)
+	if !ok || len(sv.Val()) != 2 {
+		return
+	}
+	n, ok := value.ValueToFloat64(sv.Val()[0])
+	w, ok2 := value.ValueToFloat64(sv.Val()[1])
+	if ok && ok2 {
+		m.N += n * w
+		m.W += w
+	}
+}
+func (m *weightedAvg) Result() interface{} {
+	if m.W == 0 {
+		return nil
+	}
+	return m.N / m.W
+}
+func (m *weightedAvg) Reset()                   { m.N, m.W = 0, 0 }
+func (m *weightedAvg) Merge(a *exec.AggPartial) { m.MergeBinary(a.State) }
+func (m *weightedAvg) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode([]float64{m.N, m.W})
+	return buf.Bytes(), err
+}
+func (m *weightedAvg) MergeBinary(data []byte) error {
+	var p []float64
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&p); err != nil {
+		return err
+	}
+	if len(p) != 2 {
+		return fmt.Errorf("invalid weighted_avg state %v", p)
+	}
+	m.N += p[0]
+	m.W += p[1]
+	return nil
+}
+
+func TestExecAggregatorRegistry(t *testing.T) {
+
+	exec.AggregateAdd("weighted_avg", func(col *rel.Column, partial bool) exec.Aggregator {
+		return &weightedAvg{}
+	})
+
+	sqlText := "SELECT user_id, weighted_avg(price, item_id) AS wa FROM orders GROUP BY user_id ORDER BY user_id"
+	testutil.TestSelect(t, sqlText,
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", float64(32.5)}, {"abcabcabc", float64(22.5)}},
+	)
+
+	_, ok := exec.AggregatorGet("WEIGHTED_AVG")
+	assert.True(t, ok)
+	_, ok = exec.AggregatorGet("not_an_aggregate")
+	assert.True(t, !ok)
+
+	// partial group by on each node, merged by the final task
+	ctx := td.TestContext(sqlText)
+	stmt, err := rel.ParseSqlSelect(sqlText)
+	assert.Equal(t, nil, err)
+
+	cols := map[string]int{"user_id": 0, "item_id": 1, "price": 2}
+	nodes := [][][]driver.Value{
+		{{"u1", "1", "10"}, {"u1", "2", "30"}, {"u2", "1", "5"}},
+		{{"u1", "1", "20"}, {"u1", nil, "40"}},
+	}
+
+	partials := make(exec.MessageChan, 10)
+	for _, rows := range nodes {
+		p := plan.NewGroupBy(stmt)
+		p.Partial = true
+		gb := exec.NewGroupBy(ctx, p)
+		in := make(exec.MessageChan, len(rows))
+		for i, row := range rows {
+			in <- datasource.NewSqlDriverMessageMap(uint64(i), row, cols)
+		}
+		close(in)
+		gb.MessageInSet(in)
+		assert.Equal(t, nil, gb.Run())
+		for msg := range gb.MessageOut() {
+			mm := msg.(*datasource.SqlDriverMessageMap)
+			row := mm.Values()
+			partial, ok := row[1].(*exec.AggPartial)
+			assert.True(t, ok, "partial %T", row[1])
+			// partials are sent between nodes encoded
+			var buf bytes.Buffer
+			assert.Equal(t, nil, gob.NewEncoder(&buf).Encode(partial))
+			var sent exec.AggPartial
+			assert.Equal(t, nil, gob.NewDecoder(&buf).Decode(&sent))
+			row[1] = &sent
+			partials <- datasource.NewSqlDriverMessageMap(mm.Id(), row, mm.ColIndex)
+		}
+	}
+	close(partials)
+
+	final := exec.NewGroupByFinal(ctx, plan.NewGroupBy(stmt))
+	final.MessageInSet(partials)
+	assert.Equal(t, nil, final.Run())
+
+	found := make(map[string][]driver.Value)
+	for msg := range final.MessageOut() {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		found[row[0].(string)] = row
+	}
+	assert.Equal(t, 2, len(found))
+	assert.Equal(t, []driver.Value{"u1", float64(22.5)}, found["u1"])
+	assert.Equal(t, []driver.Value{"u2", float64(5)}, found["u2"])
+}
+
 func TestExecHaving(t *testing.T) {
 	sqlText := `
 		select 
@@ -232,6 +543,468 @@ func TestExecHaving(t *testing.T) {
 	assert.True(t, int(row[1].(int64)) == 2, "expected 2 orders for %v", row)
 }
 
+func TestExecWindow(t *testing.T) {
+
+	mockcsv.LoadTable(mockcsv.SchemaName, "window_scores", "id,grp,score\n1,a,10\n2,a,20\n3,a,20\n4,a,35\n5,b,15\n6,b,25")
+
+	sqlText := `
+		select
+	        id,
+	        sum(score) OVER (PARTITION BY grp ORDER BY score, id ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) AS moving,
+	        count(*) OVER (PARTITION BY grp ORDER BY score RANGE BETWEEN 10 PRECEDING AND CURRENT ROW) AS near,
+	        lead(id, 1, "none") OVER (PARTITION BY grp ORDER BY id) AS nxt,
+	        count(*) OVER (PARTITION BY grp ORDER BY score) AS running_ct,
+	        first_value(id) OVER (PARTITION BY grp ORDER BY score DESC, id) AS top,
+	        rank() OVER (ORDER BY score DESC) AS r
+	    FROM window_scores
+	`
+	ctx := td.TestContext(sqlText)
+	job, err := exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+	assert.Equal(t, 6, len(msgs))
+
+	expected := map[string][]driver.Value{
+		"1": {"1", float64(30), int64(1), "2", int64(1), "4", int64(6)},
+		"2": {"2", float64(50), int64(3), "3", int64(3), "4", int64(3)},
+		"3": {"3", float64(75), int64(3), "4", int64(3), "4", int64(3)},
+		"4": {"4", float64(55), int64(1), "none", int64(4), "4", int64(1)},
+		"5": {"5", float64(40), int64(1), "6", int64(1), "6", int64(5)},
+		"6": {"6", float64(40), int64(2), "none", int64(2), "6", int64(2)},
+	}
+	for _, msg := range msgs {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		assert.Equal(t, expected[row[0].(string)], row, "row %v", row[0])
+	}
+}
+
+func TestExecOrder(t *testing.T) {
+
+	mockcsv.LoadTable(mockcsv.SchemaName, "order_people", "id,grp,n,name\n1,a,9,bob\n2,b,10,Alice\n3,a,,carl\n4,b,2,alice\n5,a,100,Bob")
+	mockcsv.LoadTable(mockcsv.SchemaName, "order_mixed", "id,v\n1,9\n2,10\n3,1a\n4,2\n5,b\n6,")
+
+	tests := []struct {
+		sql string
+		ids []string
+	}{
+		// numbers sort by value not string
+		{"select id FROM order_people ORDER BY toint(n)", []string{"3", "4", "1", "2", "5"}},
+		{"select id FROM order_people ORDER BY toint(n) DESC", []string{"5", "2", "1", "4", "3"}},
+		{"select id FROM order_people ORDER BY toint(n) NULLS LAST", []string{"4", "1", "2", "5", "3"}},
+		{"select id FROM order_people ORDER BY toint(n) DESC NULLS FIRST", []string{"3", "5", "2", "1", "4"}},
+		// numbers in the strings of csv sort by value
+		{"select id FROM order_people ORDER BY n", []string{"3", "4", "1", "2", "5"}},
+		{"select id FROM order_people ORDER BY n DESC", []string{"5", "2", "1", "4", "3"}},
+		{"select id FROM order_people ORDER BY grp DESC, toint(n) ASC", []string{"4", "2", "3", "1", "5"}},
+		{"select id FROM order_people ORDER BY name", []string{"2", "5", "4", "1", "3"}},
+		{`select id FROM order_people ORDER BY name, id DESC WITH collation = "nocase"`, []string{"4", "2", "5", "1", "3"}},
+		// limit keeps only the top rows
+		{"select id FROM order_people ORDER BY toint(n) DESC LIMIT 2", []string{"5", "2"}},
+		{"select id FROM order_people ORDER BY name LIMIT 3", []string{"2", "5", "4"}},
+		{"select id FROM order_people WHERE grp = \"a\" ORDER BY toint(n) NULLS LAST LIMIT 2", []string{"1", "5"}},
+		// numbers in strings sort by value, before all other strings
+		{"select id FROM order_mixed ORDER BY v", []string{"6", "4", "1", "2", "3", "5"}},
+		{"select id FROM order_mixed ORDER BY v DESC", []string{"5", "3", "2", "1", "4", "6"}},
+		{"select id FROM order_mixed ORDER BY v LIMIT 4", []string{"6", "4", "1", "2"}},
+	}
+	// in memory, spill every row, and spill runs of a few rows
+	for _, limit := range []int64{0, 1, 300} {
+		for _, tt := range tests {
+			ctx := td.TestContext(tt.sql)
+			ctx.MemoryLimit = limit
+			job, err := exec.BuildSqlJob(ctx)
+			assert.True(t, err == nil, "no error %v", err)
+
+			msgs := make([]schema.Message, 0)
+			resultWriter := exec.NewResultBuffer(ctx, &msgs)
+			job.RootTask.Add(resultWriter)
+
+			err = job.Setup()
+			assert.True(t, err == nil)
+			err = job.Run()
+			time.Sleep(time.Millisecond * 10)
+			assert.True(t, err == nil, "no error %v", err)
+
+			ids := make([]string, 0, len(msgs))
+			for _, msg := range msgs {
+				ids = append(ids, msg.(*datasource.SqlDriverMessageMap).Values()[0].(string))
+			}
+			assert.Equal(t, tt.ids, ids, "limit %d: %s", limit, tt.sql)
+		}
+	}
+	spilled, _ := filepath.Glob(filepath.Join(os.TempDir(), "qlbridge-order*"))
+	assert.Equal(t, 0, len(spilled), "spill files removed %v", spilled)
+}
+
+func TestExecOrderPushdown(t *testing.T) {
+
+	rows := [][]driver.Value{{"c", 3}, {"a", 1}, {"d", 4}, {"b", 2}}
+	db, err := memdb.NewMemDbData("sorted_t", rows, []string{"id", "ct"})
+	assert.Equal(t, nil, err)
+	err = schema.RegisterSourceAsSchema("memdb_sorted", db)
+	assert.Equal(t, nil, err)
+	sch, ok := schema.DefaultRegistry().Schema("memdb_sorted")
+	assert.True(t, ok)
+
+	tests := []struct {
+		sql string
+		ids []string
+	}{
+		// the memdb conn sorts by its primary key
+		{"select id FROM sorted_t ORDER BY id", []string{"a", "b", "c", "d"}},
+		{"select id FROM sorted_t ORDER BY id LIMIT 2", []string{"a", "b"}},
+		{"select id FROM sorted_t WHERE ct > 1 ORDER BY id LIMIT 2", []string{"b", "c"}},
+		// else sorted by an order task
+		{"select id FROM sorted_t ORDER BY id DESC LIMIT 3", []string{"d", "c", "b"}},
+		{"select id FROM sorted_t ORDER BY ct DESC", []string{"d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		ctx := td.TestContext(tt.sql)
+		ctx.Schema = sch
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+
+		msgs := make([]schema.Message, 0)
+		resultWriter := exec.NewResultBuffer(ctx, &msgs)
+		job.RootTask.Add(resultWriter)
+
+		err = job.Setup()
+		assert.True(t, err == nil)
+		err = job.Run()
+		time.Sleep(time.Millisecond * 10)
+		assert.True(t, err == nil, "no error %v", err)
+
+		ids := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg == nil {
+				// end of limit
+				continue
+			}
+			ids = append(ids, msg.(*datasource.SqlDriverMessageMap).Values()[0].(string))
+		}
+		assert.Equal(t, tt.ids, ids, tt.sql)
+	}
+}
+
+func TestExecDistinctSpill(t *testing.T) {
+
+	mockcsv.LoadTable(mockcsv.SchemaName, "distinct_events", "id,grp,ct\n1,a,1\n2,b,2\n3,a,1\n4,c,\n5,b,2\n6,d,4\n7,c,\n8,a,3")
+
+	maxKeys := exec.DistinctMaxKeys
+	defer func() { exec.DistinctMaxKeys = maxKeys }()
+
+	for _, keys := range []int{0, 1, 2} {
+		// Only hold keys of first rows in memory, the rest spill to disk
+		exec.DistinctMaxKeys = keys
+
+		ctx := td.TestContext("select DISTINCT grp, ct FROM distinct_events")
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+
+		msgs := make([]schema.Message, 0)
+		resultWriter := exec.NewResultBuffer(ctx, &msgs)
+		job.RootTask.Add(resultWriter)
+
+		err = job.Setup()
+		assert.True(t, err == nil)
+		err = job.Run()
+		time.Sleep(time.Millisecond * 10)
+		assert.True(t, err == nil, "no error %v", err)
+
+		found := make(map[string]int)
+		for _, msg := range msgs {
+			row := msg.(*datasource.SqlDriverMessageMap).Values()
+			ct, _ := row[1].(string)
+			found[row[0].(string)+":"+ct]++
+		}
+		assert.Equal(t, map[string]int{"a:1": 1, "b:2": 1, "c:": 1, "d:4": 1, "a:3": 1}, found, "max keys %d", keys)
+	}
+}
+
+func TestExecHashSpill(t *testing.T) {
+
+	mockcsv.LoadTable(mockcsv.SchemaName, "spill_events", "id,grp,ct\n1,a,1\n2,b,2\n3,a,1\n4,c,\n5,b,2\n6,d,4\n7,c,\n8,a,3")
+	mockcsv.LoadTable(mockcsv.SchemaName, "spill_groups", "grp,name\na,alpha\nb,beta\nd,delta\ne,epsilon")
+
+	tests := []struct {
+		sql  string
+		rows map[string]int
+	}{
+		{"select grp, count(*) AS ct FROM spill_events GROUP BY grp",
+			map[string]int{"a:3": 1, "b:2": 1, "c:2": 1, "d:1": 1}},
+		{"select e.id, g.name FROM spill_events AS e INNER JOIN spill_groups AS g ON e.grp = g.grp WHERE e.ct != 1",
+			map[string]int{"2:beta": 1, "5:beta": 1, "6:delta": 1, "8:alpha": 1}},
+		{"select e.id, g.name FROM spill_groups AS g LEFT JOIN spill_events AS e ON g.grp = e.grp WHERE g.grp != \"a\"",
+			map[string]int{"2:beta": 1, "5:beta": 1, "6:delta": 1, ":epsilon": 1}},
+		{"select e.id, g.name FROM spill_groups AS g INNER JOIN spill_events AS e ON g.grp = e.grp WHERE e.ct != 1",
+			map[string]int{"2:beta": 1, "5:beta": 1, "6:delta": 1, "8:alpha": 1}},
+	}
+	setStats := func(analyzed bool) {
+		for _, name := range []string{"spill_events", "spill_groups"} {
+			tbl, err := td.TestContext("select 1").Schema.Table(name)
+			assert.Equal(t, nil, err)
+			tbl.SetStats(nil)
+			if !analyzed {
+				continue
+			}
+			job, err := exec.BuildSqlJob(td.TestContext("ANALYZE TABLE " + name))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, nil, job.Setup())
+			assert.Equal(t, nil, job.Run())
+		}
+	}
+	defer setStats(false)
+	// without stats, then with stats hashing the smaller side of inner joins
+	for _, analyzed := range []bool{false, true} {
+		setStats(analyzed)
+		// in memory, spill every partition, and spill a few partitions
+		for _, limit := range []int64{0, 1, 400} {
+			for _, tt := range tests {
+				ctx := td.TestContext(tt.sql)
+				ctx.MemoryLimit = limit
+				job, err := exec.BuildSqlJob(ctx)
+				assert.True(t, err == nil, "no error %v", err)
+
+				msgs := make([]schema.Message, 0)
+				resultWriter := exec.NewResultBuffer(ctx, &msgs)
+				job.RootTask.Add(resultWriter)
+
+				err = job.Setup()
+				assert.True(t, err == nil)
+				err = job.Run()
+				time.Sleep(time.Millisecond * 10)
+				assert.True(t, err == nil, "no error %v", err)
+
+				found := make(map[string]int)
+				for _, msg := range msgs {
+					row := msg.(*datasource.SqlDriverMessageMap).Values()
+					found[fmt.Sprintf("%v:%v", nilString(row[0]), nilString(row[1]))]++
+				}
+				assert.Equal(t, tt.rows, found, "analyzed %v limit %d: %s", analyzed, limit, tt.sql)
+			}
+		}
+	}
+	spilled, _ := filepath.Glob(filepath.Join(os.TempDir(), "qlbridge-groupby*"))
+	assert.Equal(t, 0, len(spilled), "spill files removed %v", spilled)
+	spilled, _ = filepath.Glob(filepath.Join(os.TempDir(), "qlbridge-join*"))
+	assert.Equal(t, 0, len(spilled), "spill files removed %v", spilled)
+}
+
+func TestExecHashSpillSkew(t *testing.T) {
+
+	// a key of most of the rows, and more rows than fit in the partitions
+	// of the memory limit
+	events := []string{"id,grp,ct"}
+	for i := 0; i < 600; i++ {
+		grp := "a"
+		if i%3 == 0 {
+			grp = fmt.Sprintf("g%d", i%97)
+		}
+		events = append(events, fmt.Sprintf("%d,%s,%d", i, grp, i%5))
+	}
+	mockcsv.LoadTable(mockcsv.SchemaName, "skew_events", strings.Join(events, "\n"))
+	groups := []string{"grp,name", "a,alpha", "zz,none"}
+	for i := 0; i < 97; i += 2 {
+		groups = append(groups, fmt.Sprintf("g%d,name%d", i, i))
+	}
+	mockcsv.LoadTable(mockcsv.SchemaName, "skew_groups", strings.Join(groups, "\n"))
+
+	tests := []string{
+		"select grp, count(*) AS ct, sum(ct) AS total FROM skew_events GROUP BY grp",
+		"select e.id, g.name FROM skew_events AS e INNER JOIN skew_groups AS g ON e.grp = g.grp",
+		"select e.id, g.name FROM skew_groups AS g LEFT JOIN skew_events AS e ON g.grp = e.grp",
+		"select e.id, g.name FROM skew_events AS e LEFT JOIN skew_groups AS g ON e.grp = g.grp",
+	}
+	for _, sql := range tests {
+		var expected map[string]int
+		// in memory, then spilled partitions split and the rows of the key
+		// of most rows read from disk
+		for _, limit := range []int64{0, 4000} {
+			ctx := td.TestContext(sql)
+			ctx.MemoryLimit = limit
+			job, err := exec.BuildSqlJob(ctx)
+			assert.True(t, err == nil, "no error %v", err)
+
+			msgs := make([]schema.Message, 0)
+			job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+			assert.Equal(t, nil, job.Setup())
+			err = job.Run()
+			time.Sleep(time.Millisecond * 10)
+			assert.True(t, err == nil, "no error %v", err)
+
+			found := make(map[string]int)
+			for _, msg := range msgs {
+				found[fmt.Sprintf("%v", msg.(*datasource.SqlDriverMessageMap).Values())]++
+			}
+			if expected == nil {
+				expected = found
+				assert.True(t, len(found) > 40, "rows %d %s", len(found), sql)
+				continue
+			}
+			assert.Equal(t, expected, found, "limit %d: %s", limit, sql)
+		}
+	}
+	spilled, _ := filepath.Glob(filepath.Join(os.TempDir(), "qlbridge-*"))
+	assert.Equal(t, 0, len(spilled), "spill files removed %v", spilled)
+}
+
+// nilString a result value, empty for nil
+func nilString(v driver.Value) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+func TestExecExplain(t *testing.T) {
+
+	ctx := td.TestContext("EXPLAIN SELECT user_id, count(*) AS ct FROM orders WHERE price > 10 GROUP BY user_id ORDER BY ct DESC LIMIT 1")
+	job, err := exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+
+	// a row per task of the plan, not the rows of the select
+	tasks := make(map[string]string)
+	for _, msg := range msgs {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		assert.Equal(t, 7, len(row))
+		tasks[row[2].(string)] = row[6].(string)
+	}
+	assert.Equal(t, "price > 10", tasks["Where"])
+	assert.Equal(t, "user_id", tasks["GroupBy"])
+	assert.Equal(t, "ct DESC top 1", tasks["Order"])
+	_, hasSource := tasks["Source"]
+	assert.True(t, hasSource)
+
+	ctx = td.TestContext("EXPLAIN FORMAT = JSON SELECT user_id FROM orders WHERE price > 10")
+	job, err = exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs = make([]schema.Message, 0)
+	resultWriter = exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+
+	assert.Equal(t, 1, len(msgs))
+	var root plan.ExplainTask
+	err = json.Unmarshal([]byte(msgs[0].(*datasource.SqlDriverMessageMap).Values()[0].(string)), &root)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Select", root.Task)
+	assert.Equal(t, "Source", root.Children[0].Task)
+	assert.Equal(t, "orders", root.Children[0].Source)
+}
+
+func TestExecExplainAnalyze(t *testing.T) {
+
+	// stats of the tasks of a job, once it has run
+	ctx := td.TestContext("SELECT user_id FROM orders WHERE price > 30")
+	job, err := exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	assert.True(t, err == nil, "no error %v", err)
+
+	tasks := make(map[string]*exec.TaskAnalysis)
+	var walk func(ta *exec.TaskAnalysis)
+	walk = func(ta *exec.TaskAnalysis) {
+		if _, exists := tasks[ta.Task]; !exists {
+			tasks[ta.Task] = ta
+		}
+		for _, c := range ta.Children {
+			walk(c)
+		}
+	}
+	root := job.Analyze()
+	walk(root)
+	assert.True(t, root.Wall > 0)
+	assert.Equal(t, int64(len(msgs)), tasks["ResultBuffer"].RowsIn)
+	assert.Equal(t, int64(len(msgs)), tasks["Projection"].RowsOut)
+	assert.True(t, tasks["Source"].RowsOut > int64(len(msgs)), "source rows %d", tasks["Source"].RowsOut)
+	assert.Equal(t, tasks["Source"].RowsOut, tasks["Where"].RowsIn)
+	assert.True(t, tasks["Where"].RowsOut < tasks["Where"].RowsIn)
+	assert.True(t, tasks["Where"].PeakBuffered > 0)
+	sourceRows := tasks["Source"].RowsOut
+
+	// EXPLAIN ANALYZE runs the select and sends the stats of its tasks
+	// instead of its rows
+	ctx = td.TestContext("EXPLAIN ANALYZE SELECT user_id FROM orders WHERE price > 30")
+	job, err = exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs = make([]schema.Message, 0)
+	resultWriter = exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+
+	rows := make(map[string][]driver.Value)
+	for _, msg := range msgs {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		assert.Equal(t, 9, len(row))
+		if _, exists := rows[row[2].(string)]; !exists {
+			rows[row[2].(string)] = row
+		}
+	}
+	assert.Equal(t, sourceRows, rows["Source"][5])
+	assert.Equal(t, sourceRows, rows["Where"][4])
+	_, err = time.ParseDuration(rows["Projection"][6].(string))
+	assert.Equal(t, nil, err)
+
+	ctx = td.TestContext("EXPLAIN ANALYZE FORMAT = JSON SELECT user_id FROM orders WHERE price > 30")
+	job, err = exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs = make([]schema.Message, 0)
+	resultWriter = exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+
+	assert.Equal(t, 1, len(msgs))
+	var ta exec.TaskAnalysis
+	err = json.Unmarshal([]byte(msgs[0].(*datasource.SqlDriverMessageMap).Values()[0].(string)), &ta)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "TaskSequential", ta.Task)
+	assert.True(t, ta.RowsOut > 0 && ta.RowsOut < sourceRows, "rows out %d", ta.RowsOut)
+}
+
 type UserEvent struct {
 	Id     string
 	UserId string
@@ -239,6 +1012,73 @@ type UserEvent struct {
 	Date   time.Time
 }
 
+func TestExecAnalyzeTable(t *testing.T) {
+
+	runSql := func(sql string) []schema.Message {
+		ctx := td.TestContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		err = job.Setup()
+		assert.True(t, err == nil)
+		err = job.Run()
+		time.Sleep(time.Millisecond * 10)
+		assert.True(t, err == nil, "no error %v", err)
+		return msgs
+	}
+	joinSql := "SELECT o.order_id, u.email FROM orders AS o INNER JOIN users AS u ON o.user_id = u.user_id WHERE o.price > 30"
+	before := runSql(joinSql)
+	assert.Equal(t, 1, len(before))
+
+	ctx := td.TestContext(joinSql)
+	orders, err := ctx.Schema.Table("orders")
+	assert.Equal(t, nil, err)
+	users, err := ctx.Schema.Table("users")
+	assert.Equal(t, nil, err)
+	defer func() {
+		orders.SetStats(nil)
+		users.SetStats(nil)
+	}()
+	assert.True(t, orders.Stats() == nil)
+
+	msgs := runSql("ANALYZE TABLE orders, users")
+	assert.Equal(t, 0, len(msgs))
+	stats := orders.Stats()
+	assert.True(t, stats != nil)
+	assert.Equal(t, int64(3), stats.RowCount)
+	price := stats.Column("price")
+	assert.Equal(t, int64(2), price.Distinct)
+	assert.Equal(t, "22.50", price.Min)
+	assert.Equal(t, "37.50", price.Max)
+	assert.Equal(t, 0.0, price.NullFraction)
+	assert.Equal(t, int64(3), users.Stats().RowCount)
+
+	// the plan follows the stats, the results are the same
+	after := runSql(joinSql)
+	assert.Equal(t, len(before), len(after))
+	assert.Equal(t, before[0].Body().(*datasource.SqlDriverMessageMap).Values(),
+		after[0].Body().(*datasource.SqlDriverMessageMap).Values())
+	explain := runSql("EXPLAIN " + joinSql)
+	tasks := make([]string, 0)
+	for _, msg := range explain {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		tasks = append(tasks, row[2].(string))
+		if row[2] == "Source" {
+			assert.True(t, strings.HasPrefix(row[6].(string), "estimated rows"), "detail %v", row[6])
+		}
+	}
+	assert.Equal(t, "JoinMerge", tasks[1])
+
+	ctx = td.TestContext("ANALYZE TABLE not_a_table")
+	job, err := exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	assert.True(t, err != nil)
+}
+
 func TestExecInsert(t *testing.T) {
 
 	// By "Loading" table we force it to exist in this non DDL mock store
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"fmt"
+	"sync"
 
 	u "github.com/araddon/gou"
 
@@ -30,6 +31,12 @@ type JobExecutor struct {
 	Ctx      *plan.Context
 	distinct bool
 	children []Task
+
+	// results of common table expressions, shared by the sources reading them
+	commonTables map[*plan.CommonTable]*commonTableResult
+
+	mu  sync.Mutex
+	err error // error failing the job while running, such as of a sub-select
 }
 
 // NewExecutor creates a new Job Executor.
@@ -57,6 +64,23 @@ func BuildSqlJob(ctx *plan.Context) (*JobExecutor, error) {
 	return job, err
 }
 
+// BuildSqlJobFromPlan given a plan context and the plan of its statement,
+// already planned, create a JobExecutor to run it, such as for a prepared
+// statement that is planned once and run many times.
+func BuildSqlJobFromPlan(ctx *plan.Context, pln plan.Task) (*JobExecutor, error) {
+	job := NewExecutor(ctx, plan.NewPlanner(ctx))
+	task, err := job.Executor.WalkPlan(pln)
+	if err != nil {
+		return nil, err
+	}
+	taskRunner, ok := task.(TaskRunner)
+	if !ok {
+		return nil, fmt.Errorf("Expected TaskRunner but was %T", task)
+	}
+	job.RootTask = taskRunner
+	return job, nil
+}
+
 // BuildSqlJobPlanned Create Job made up of sub-tasks in DAG that is the
 // plan for execution of this query/job.
 func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Context) (Task, error) {
@@ -118,6 +142,8 @@ func (m *JobExecutor) WalkPlan(p plan.Task) (Task, error) {
 			p.Stmt.SetSystemQry()
 		}
 		return m.Executor.WalkSelect(p)
+	case *plan.Compound:
+		return m.Executor.WalkCompound(p)
 	case *plan.Upsert:
 		return m.Executor.WalkUpsert(p)
 	case *plan.Insert:
@@ -128,6 +154,8 @@ func (m *JobExecutor) WalkPlan(p plan.Task) (Task, error) {
 		return m.Executor.WalkDelete(p)
 	case *plan.Command:
 		return m.Executor.WalkCommand(p)
+	case *plan.Explain:
+		return m.Executor.WalkExplain(p)
 
 	// DDL
 	case *plan.Create:
@@ -149,9 +177,72 @@ func (m *JobExecutor) WalkPreparedStatement(p *plan.PreparedStatement) (Task, er
 
 // WalkSelect create dag of plan Select.
 func (m *JobExecutor) WalkSelect(p *plan.Select) (Task, error) {
+	for _, ss := range p.SubSelects {
+		sub := newScalarSubSelect(m.Ctx, ss, m.fail)
+		if len(ss.Outer) == 0 {
+			// uncorrelated sub-selects are run once, now, so their
+			// errors fail building the job
+			if _, err := sub.value(nil); err != nil {
+				return nil, err
+			}
+		}
+		ss.Node.Eval = sub.eval
+	}
 	root := m.NewTask(p)
 	return root, m.WalkChildren(p, root)
 }
+
+// WalkCompound create dag of compound select, each select runs in parallel
+// feeding a SetOperation that combines them, then order, limit.
+func (m *JobExecutor) WalkCompound(p *plan.Compound) (Task, error) {
+	root := m.NewTask(p)
+	selects := NewTaskParallel(m.Ctx)
+	inputs := make([]TaskRunner, len(p.Selects))
+	for i, sel := range p.Selects {
+		t, err := m.Executor.WalkSelect(sel)
+		if err != nil {
+			return nil, err
+		}
+		if err = selects.Add(t); err != nil {
+			return nil, err
+		}
+		inputs[i] = t.(TaskRunner)
+	}
+	if err := selects.Add(NewSetOperation(m.Ctx, inputs, p)); err != nil {
+		return nil, err
+	}
+	if err := root.Add(selects); err != nil {
+		return nil, err
+	}
+	if err := m.WalkChildren(p, root); err != nil {
+		return nil, err
+	}
+	if p.Result.Limit > 0 || p.Result.Offset > 0 {
+		limit := NewProjectionLimit(m.Ctx, plan.NewProjectionInProcess(p.Result))
+		if err := root.Add(limit); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// WalkExplain create the task sending the rows of the explained plan, or
+// for EXPLAIN ANALYZE running it and sending the stats of its tasks.
+func (m *JobExecutor) WalkExplain(p *plan.Explain) (Task, error) {
+	root := m.NewTask(p)
+	if !p.Stmt.Analyze {
+		return root, root.Add(NewExplain(m.Ctx, p))
+	}
+	task, err := m.Executor.WalkPlan(p.Plan)
+	if err != nil {
+		return nil, err
+	}
+	tr, ok := task.(TaskRunner)
+	if !ok {
+		return nil, fmt.Errorf("Expected TaskRunner but was %T", task)
+	}
+	return root, root.Add(NewExplainAnalyze(m.Ctx, p, tr))
+}
 func (m *JobExecutor) WalkUpsert(p *plan.Upsert) (Task, error) {
 	root := m.NewTask(p)
 	return root, root.Add(NewUpsert(m.Ctx, p))
@@ -169,6 +260,9 @@ func (m *JobExecutor) WalkDelete(p *plan.Delete) (Task, error) {
 	return root, root.Add(NewDelete(m.Ctx, p))
 }
 func (m *JobExecutor) WalkSource(p *plan.Source) (Task, error) {
+	if p.Cte != nil {
+		return m.walkCommonTable(p)
+	}
 	if len(p.Static) > 0 {
 		static := membtree.NewStaticData("static")
 		static.SetColumns(p.Cols)
@@ -197,6 +291,28 @@ func (m *JobExecutor) WalkSource(p *plan.Source) (Task, error) {
 	}
 	return NewSource(m.Ctx, p)
 }
+
+// walkCommonTable create the source task for a source reading a common
+// table, the common table select dag is only created once per job.
+func (m *JobExecutor) walkCommonTable(p *plan.Source) (Task, error) {
+	result, ok := m.commonTables[p.Cte]
+	if !ok {
+		task, err := m.Executor.WalkSelect(p.Cte.Plan)
+		if err != nil {
+			return nil, err
+		}
+		taskRunner, ok := task.(TaskRunner)
+		if !ok {
+			return nil, fmt.Errorf("Expected TaskRunner but was %T", task)
+		}
+		result = &commonTableResult{task: taskRunner, materialize: p.Cte.Refs > 1}
+		if m.commonTables == nil {
+			m.commonTables = make(map[*plan.CommonTable]*commonTableResult)
+		}
+		m.commonTables[p.Cte] = result
+	}
+	return NewCommonTableSource(m.Ctx, p, result), nil
+}
 func (m *JobExecutor) WalkSourceExec(p *plan.Source) (Task, error) {
 
 	if p.Conn == nil {
@@ -230,6 +346,23 @@ func (m *JobExecutor) WalkGroupBy(p *plan.GroupBy) (Task, error) {
 func (m *JobExecutor) WalkOrder(p *plan.Order) (Task, error) {
 	return NewOrder(m.Ctx, p), nil
 }
+func (m *JobExecutor) WalkWindow(p *plan.Window) (Task, error) {
+	return NewWindow(m.Ctx, p), nil
+}
+func (m *JobExecutor) WalkDistinct(p *plan.Distinct) (Task, error) {
+	return NewDistinct(m.Ctx, p), nil
+}
+func (m *JobExecutor) WalkSemiJoin(p *plan.SemiJoin) (Task, error) {
+	task, err := m.Executor.WalkSelect(p.Sub)
+	if err != nil {
+		return nil, err
+	}
+	sub, ok := task.(TaskRunner)
+	if !ok {
+		return nil, fmt.Errorf("Expected TaskRunner but was %T", task)
+	}
+	return NewSemiJoin(m.Ctx, p, sub), nil
+}
 func (m *JobExecutor) WalkProjection(p *plan.Projection) (Task, error) {
 	return NewProjection(m.Ctx, p), nil
 }
@@ -265,6 +398,33 @@ func (m *JobExecutor) WalkJoin(p *plan.JoinMerge) (Task, error) {
 func (m *JobExecutor) WalkJoinKey(p *plan.JoinKey) (Task, error) {
 	return NewJoinKey(m.Ctx, p), nil
 }
+func (m *JobExecutor) WalkNestedLoopJoin(p *plan.NestedLoopJoin) (Task, error) {
+	execTask := NewTaskParallel(m.Ctx)
+	l, err := m.WalkPlanAll(p.Left)
+	if err != nil {
+		return nil, err
+	}
+	if _, isJoin := l.(*TaskParallel); isJoin {
+		// the join on the left writes to its own output channel, not
+		// to the one of this parallel task
+		seq := NewTaskSequential(m.Ctx)
+		if err = seq.Add(l); err != nil {
+			return nil, err
+		}
+		l = seq
+	}
+	if err = execTask.Add(l); err != nil {
+		return nil, err
+	}
+	r, err := m.WalkPlanAll(p.Right)
+	if err != nil {
+		return nil, err
+	}
+	if err = execTask.Add(r); err != nil {
+		return nil, err
+	}
+	return execTask, execTask.Add(NewNestedLoopJoin(m.Ctx, l.(TaskRunner), r.(TaskRunner), p))
+}
 func (m *JobExecutor) WalkPlanAll(p plan.Task) (Task, error) {
 	root, err := m.WalkPlanTask(p)
 	if err != nil {
@@ -296,12 +456,20 @@ func (m *JobExecutor) WalkPlanTask(p plan.Task) (Task, error) {
 		return m.Executor.WalkGroupBy(p)
 	case *plan.Order:
 		return m.Executor.WalkOrder(p)
+	case *plan.Window:
+		return m.Executor.WalkWindow(p)
+	case *plan.Distinct:
+		return m.Executor.WalkDistinct(p)
+	case *plan.SemiJoin:
+		return m.Executor.WalkSemiJoin(p)
 	case *plan.Projection:
 		return m.Executor.WalkProjection(p)
 	case *plan.JoinMerge:
 		return m.Executor.WalkJoin(p)
 	case *plan.JoinKey:
 		return m.Executor.WalkJoinKey(p)
+	case *plan.NestedLoopJoin:
+		return m.Executor.WalkNestedLoopJoin(p)
 	}
 	panic(fmt.Sprintf("Task plan-exec Not implemented for %T", p))
 }
@@ -384,9 +552,69 @@ func (m *JobExecutor) Setup() error {
 	return m.RootTask.Setup(0)
 }
 
-// Run this task
+// Run this task, if the go context of the plan context is canceled while
+// running every task of the job is told to quit and the context error is
+// returned, as is the error of a job that failed.
 func (m *JobExecutor) Run() error {
-	return m.RootTask.Run()
+	if m.Ctx == nil || m.Ctx.Context == nil || m.Ctx.Context.Done() == nil {
+		err := runTimed(m.RootTask)
+		if jobErr := m.Err(); jobErr != nil {
+			return jobErr
+		}
+		return err
+	}
+	finished := make(chan bool)
+	defer close(finished)
+	go func() {
+		select {
+		case <-m.Ctx.Context.Done():
+			quitTasks(m.RootTask)
+		case <-finished:
+		}
+	}()
+	err := runTimed(m.RootTask)
+	if ctxErr := m.Ctx.Context.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if jobErr := m.Err(); jobErr != nil {
+		return jobErr
+	}
+	return err
+}
+
+// Err the error that failed the job while running, nil if none.
+func (m *JobExecutor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// fail the running job with err, telling every task to quit.
+func (m *JobExecutor) fail(err error) {
+	m.mu.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.mu.Unlock()
+	if m.RootTask != nil {
+		quitTasks(m.RootTask)
+	}
+}
+
+// quitTasks signal task and all of its child tasks to quit.
+func quitTasks(task Task) {
+	if tr, ok := task.(TaskRunner); ok {
+		tr.Quit()
+	}
+	for _, child := range task.Children() {
+		quitTasks(child)
+	}
+}
+
+// Analyze the dag of tasks of this job and their runtime stats, once Run()
+// has returned.
+func (m *JobExecutor) Analyze() *TaskAnalysis {
+	return AnalyzeTasks(m.RootTask)
 }
 
 // Close the normal close of root task
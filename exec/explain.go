@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*Explain)(nil)
+)
+
+// Explain is executeable task for EXPLAIN of a statement, it sends rows
+// describing the tasks of the plan of the statement instead of running it.
+//
+//    EXPLAIN SELECT user_id FROM orders WHERE price > 10
+//
+// With EXPLAIN ANALYZE the statement is run, its rows discarded, and the
+// rows describe its dag of tasks along with their runtime stats.
+type Explain struct {
+	*TaskBase
+	p    *plan.Explain
+	task TaskRunner // the task of the statement run by EXPLAIN ANALYZE
+}
+
+// NewExplain creates new explain exec task
+func NewExplain(ctx *plan.Context, p *plan.Explain) *Explain {
+	m := &Explain{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+	}
+	return m
+}
+
+// NewExplainAnalyze creates new explain exec task that runs task, the
+// task of the explained statement, to describe its runtime stats.
+func NewExplainAnalyze(ctx *plan.Context, p *plan.Explain, task TaskRunner) *Explain {
+	m := NewExplain(ctx, p)
+	m.task = task
+	return m
+}
+
+// Children the analyzed task of the statement, if any.
+func (m *Explain) Children() []Task {
+	if m.task == nil {
+		return nil
+	}
+	return []Task{m.task}
+}
+
+// Run Explain
+func (m *Explain) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	var rows [][]driver.Value
+	var err error
+	if m.task != nil {
+		rows, err = m.analyze()
+	} else {
+		rows, err = m.p.Rows()
+	}
+	if err != nil {
+		return err
+	}
+	colIndex := make(map[string]int)
+	for i, col := range m.p.Columns() {
+		colIndex[col] = i
+	}
+	for i, row := range rows {
+		wait := time.Now()
+		select {
+		case m.msgOutCh <- datasource.NewSqlDriverMessageMap(uint64(i), row, colIndex):
+			m.stats.sent(wait)
+		case <-m.SigChan():
+			return nil
+		}
+	}
+	return nil
+}
+
+// analyze run the task of the statement, draining its rows, then describe
+// its tasks and their stats.
+func (m *Explain) analyze() ([][]driver.Value, error) {
+	if err := m.task.Setup(0); err != nil {
+		return nil, err
+	}
+	defer m.task.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runTimed(m.task)
+	}()
+
+	tasks := m.task.Children()
+	drain := tasks[len(tasks)-1].(TaskRunner).MessageOut()
+drainLoop:
+	for {
+		select {
+		case <-m.SigChan():
+			return nil, nil
+		case _, ok := <-drain:
+			if !ok {
+				break drainLoop
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+
+	ta := AnalyzeTasks(m.task)
+	if m.p.Stmt.Format == "json" {
+		by, err := json.Marshal(ta)
+		if err != nil {
+			return nil, err
+		}
+		return [][]driver.Value{{string(by)}}, nil
+	}
+	rows := make([][]driver.Value, 0)
+	var addRows func(t *TaskAnalysis)
+	addRows = func(t *TaskAnalysis) {
+		mode := "sequential"
+		if t.Parallel {
+			mode = "parallel"
+		}
+		rows = append(rows, []driver.Value{t.Id, t.ParentId, t.Task, mode, t.RowsIn, t.RowsOut,
+			t.Wall.String(), t.Blocked.String(), t.PeakBuffered})
+		for _, c := range t.Children {
+			addRows(c)
+		}
+	}
+	addRows(ta)
+	return rows, nil
+}
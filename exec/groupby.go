@@ -4,6 +4,9 @@ import (
 	"database/sql/driver"
 	"encoding/gob"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,9 +34,14 @@ func init() {
 // Group by a Sql Group By task which creates a hashable key from row
 // commposed of key = {each,value,of,column,in,groupby}
 //
-// A very stupid naive parallel groupby holds values in memory.  This
-// is a toy implementation that is only useful for small cardinality
-// group-bys, small number of rows.
+// GROUP BY ROLLUP, CUBE and GROUPING SETS group each row once per grouping
+// set in the same scan of the input, keyed by set, group by values not in
+// the set of a group are nil.
+//
+// Rows of each group are held in memory, hash partitioned by key, until
+// the memory limit of the query is passed, then the largest partitions are
+// spilled to temp files.  After the groups in memory are output, each
+// spilled partition is read back and grouped one at a time.
 type GroupBy struct {
 	*TaskBase
 	closed bool
@@ -73,7 +81,6 @@ func (m *GroupBy) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
-	outCh := m.MessageOut()
 	inCh := m.MessageIn()
 
 	columns := m.p.Stmt.Columns
@@ -85,13 +92,48 @@ func (m *GroupBy) Run() error {
 		return err
 	}
 
-	// are are going to hold entire row in memory while we are calculating
-	//  so obviously not scalable.
 	gb := make(map[string][]*datasource.SqlDriverMessageMap)
+	spill := newHashSpill("groupby", memoryLimit(m.Ctx, HashMemoryLimit))
+	defer spill.remove()
+	rowCt := 0
+	var rowColIndex map[string]int
+
+	// add a row to the group of key, spilling partitions past memory limit
+	add := func(key string, sdm *datasource.SqlDriverMessageMap) error {
+		part := hashPartition(key)
+		if spill.spilled(part) {
+			return spill.write(part, 0, key, sdm.Vals)
+		}
+		gb[key] = append(gb[key], sdm)
+		if !spill.add(part, sdm.Vals) {
+			return nil
+		}
+		for spill.overLimit() {
+			evict, err := spill.evictPart()
+			if err != nil || evict < 0 {
+				return err
+			}
+			for key, msgs := range gb {
+				if hashPartition(key) != evict {
+					continue
+				}
+				for _, msg := range msgs {
+					if err := spill.write(evict, 0, key, msg.Vals); err != nil {
+						return err
+					}
+				}
+				delete(gb, key)
+			}
+		}
+		return nil
+	}
+
+	// grouping sets, nil for plain group by
+	sets := m.p.Stmt.GroupingSets
 
 msgReadLoop:
 	for {
-
+		wait := time.Now()
 		select {
 		case <-m.SigChan():
 			return nil
@@ -99,6 +141,7 @@ msgReadLoop:
 			if !ok {
 				break msgReadLoop
 			} else {
+				m.stats.received(wait, inCh)
 				var sdm *datasource.SqlDriverMessageMap
 
 				switch mt := msg.(type) {
@@ -110,7 +153,7 @@ msgReadLoop:
 					if !isContextReader {
 						err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
 						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
+						m.Quit()
 						return err
 					}
 
@@ -125,55 +168,152 @@ msgReadLoop:
 						keys[i] = key.ToString()
 					}
 				}
-				key := strings.Join(keys, ",")
-				gb[key] = append(gb[key], sdm)
+				rowCt++
+				if rowColIndex == nil {
+					rowColIndex = sdm.ColIndex
+				}
+				if sets == nil {
+					if err := add(strings.Join(keys, ","), sdm); err != nil {
+						return err
+					}
+					continue
+				}
+				for si, set := range sets {
+					setKeys := make([]string, len(set))
+					for i, idx := range set {
+						setKeys[i] = keys[idx]
+					}
+					if err := add(fmt.Sprintf("%d:%s", si, strings.Join(setKeys, ",")), sdm); err != nil {
+						return err
+					}
+				}
 			}
 		}
 	}
 
-	i := uint64(0)
-	for key, v := range gb {
-		//u.Debugf("got %s:%v msgs", k, len(v))
+	if rowCt == 0 && !m.p.Partial {
+		if len(m.p.Stmt.GroupBy) == 0 {
+			// aggregates without group by are one row even of no rows,
+			// ie count(*) is 0
+			gb[""] = nil
+		}
+		for si, set := range sets {
+			// as are empty grouping sets, the grand total of ROLLUP
+			if len(set) == 0 {
+				gb[fmt.Sprintf("%d:", si)] = nil
+			}
+		}
+	}
 
-		for _, mm := range v {
-			for i, col := range columns {
-				//u.Debugf("col: idx:%v sidx: %v pidx:%v key:%v   %s", col.Index, col.SourceIndex, col.ParentIndex, col.Key(), col.Expr)
+	// the group by column of each select column, -1 if none
+	colGroups := make([]int, len(columns))
+	for i, col := range columns {
+		colGroups[i] = groupByIndex(m.p.Stmt.GroupBy, col)
+	}
 
-				if col.Expr == nil {
-					u.Warnf("wat?   nil col expr? %#v", col)
-				} else {
-					v, ok := vm.Eval(mm, col.Expr)
-					//u.Infof("mt: %T  mm %#v", mm, mm)
-					if !ok || v == nil {
-						//u.Debugf("evaled nil? key=%v  val=%v expr:%s", col.Key(), v, col.Expr.String())
-						//u.Infof("mt: %T  mm %#v", mm, mm)
-						aggs[i].Do(value.NewNilValue())
-					} else {
-						//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
-						aggs[i].Do(v)
-					}
-				}
-			}
+	i := uint64(0)
+	emit := func(gb map[string][]*datasource.SqlDriverMessageMap) {
+		for key, v := range gb {
+			m.emitGroup(key, v, aggs, colGroups, colIndex, i)
+			i++
 		}
+	}
+	emit(gb)
+	gb = nil
+
+	return spill.each(func(part int, rows []*spillRow) error {
+		pgb := make(map[string][]*datasource.SqlDriverMessageMap)
+		for ri, row := range rows {
+			msg := datasource.NewSqlDriverMessageMap(uint64(ri), row.Vals, rowColIndex)
+			pgb[row.Key] = append(pgb[row.Key], msg)
+		}
+		emit(pgb)
+		return nil
+	}, func(key string, scan spillScan) error {
+		// a group too large for memory is aggregated a row at a time
+		err := scan(func(row *spillRow) error {
+			m.aggregate(aggs, datasource.NewSqlDriverMessageMap(0, row.Vals, rowColIndex))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		m.emitGroup(key, nil, aggs, colGroups, colIndex, i)
+		i++
+		return nil
+	})
+}
 
-		row := make([]driver.Value, len(columns))
-		for i, agg := range aggs {
+// emitGroup aggregate the rows of the group of key and send the result row.
+func (m *GroupBy) emitGroup(key string, v []*datasource.SqlDriverMessageMap, aggs []Aggregator,
+	colGroups []int, colIndex map[string]int, id uint64) {
+
+	columns := m.p.Stmt.Columns
+	var rolledUp map[int]bool
+	if sets := m.p.Stmt.GroupingSets; sets != nil {
+		// keys of grouping sets are prefixed by set index
+		si, _ := strconv.Atoi(key[:strings.Index(key, ":")])
+		rolledUp = groupingRolledUp(len(m.p.Stmt.GroupBy), sets[si])
+	}
+
+	for _, mm := range v {
+		m.aggregate(aggs, mm)
+	}
+
+	row := make([]driver.Value, len(columns))
+	for i, agg := range aggs {
+		if ga, isGrouping := agg.(*grouping); isGrouping && rolledUp != nil {
+			ga.setRolledUp(rolledUp)
+		}
+		if am, isMarshaler := agg.(AggregatorMarshaler); isMarshaler && m.p.Partial {
+			state, err := am.MarshalBinary()
+			if err != nil {
+				u.Warnf("could not marshal partial state of %s err=%v", columns[i].Expr, err)
+			}
+			row[i] = &AggPartial{State: state}
+		} else {
 			row[i] = driver.Value(agg.Result())
-			agg.Reset()
-			//u.Debugf("agg result: %#v  %v", row[i], row[i])
 		}
-
-		if m.p.Partial {
-			// Partial results, append key at end?  shouldn't be able to be fit in message itself?
-			row = append(row, key)
-			//u.Debugf("GroupBy output row? key:%s %#v", key, row)
+		agg.Reset()
+		if colGroups[i] >= 0 && rolledUp[colGroups[i]] {
+			row[i] = nil
 		}
-		//u.Debugf("row: %v  cols:%v", row, colIndex)
-		outCh <- datasource.NewSqlDriverMessageMap(i, row, colIndex)
-		i++
+		//u.Debugf("agg result: %#v  %v", row[i], row[i])
 	}
 
-	return nil
+	if m.p.Partial {
+		// Partial results, append key at end?  shouldn't be able to be fit in message itself?
+		row = append(row, key)
+		//u.Debugf("GroupBy output row? key:%s %#v", key, row)
+	}
+	//u.Debugf("row: %v  cols:%v", row, colIndex)
+	wait := time.Now()
+	m.msgOutCh <- datasource.NewSqlDriverMessageMap(id, row, colIndex)
+	m.stats.sent(wait)
+}
+
+// aggregate the values of the select columns of row mm of a group.
+func (m *GroupBy) aggregate(aggs []Aggregator, mm *datasource.SqlDriverMessageMap) {
+	for i, col := range m.p.Stmt.Columns {
+		//u.Debugf("col: idx:%v sidx: %v pidx:%v key:%v   %s", col.Index, col.SourceIndex, col.ParentIndex, col.Key(), col.Expr)
+
+		if col.Expr == nil {
+			u.Warnf("wat?   nil col expr? %#v", col)
+		} else if da, isDistinct := aggs[i].(*distinctAgg); isDistinct {
+			da.DoRow(mm)
+		} else {
+			v, ok := vm.Eval(mm, col.Expr)
+			//u.Infof("mt: %T  mm %#v", mm, mm)
+			if !ok || v == nil {
+				//u.Debugf("evaled nil? key=%v  val=%v expr:%s", col.Key(), v, col.Expr.String())
+				//u.Infof("mt: %T  mm %#v", mm, mm)
+				aggs[i].Do(value.NewNilValue())
+			} else {
+				//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
+				aggs[i].Do(v)
+			}
+		}
+	}
 }
 
 // Run group-by-final Runs standard task interface.
@@ -197,7 +337,7 @@ func (m *GroupByFinal) Run() error {
 
 msgReadLoop:
 	for {
-
+		wait := time.Now()
 		select {
 		case <-m.SigChan():
 			u.Warnf("got signal quit")
@@ -207,6 +347,7 @@ msgReadLoop:
 				//u.Debugf("GroupByFinal, got closed channel shutdown")
 				break msgReadLoop
 			} else {
+				m.stats.received(wait, inCh)
 				//u.Infof("got gbfinal message %#v", msg)
 				switch mt := msg.(type) {
 				case *datasource.SqlDriverMessageMap:
@@ -223,7 +364,7 @@ msgReadLoop:
 				default:
 					err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
 					u.Errorf("unrecognized msg %T", msg)
-					close(m.TaskBase.sigCh)
+					m.Quit()
 					return err
 				}
 			}
@@ -247,13 +388,15 @@ msgReadLoop:
 					switch vt := v.(type) {
 					case *AggPartial:
 						//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
-						aggs[i].Merge(vt)
+						mergePartial(aggs[i], vt)
 					case AggPartial:
-						aggs[i].Merge(&vt)
+						mergePartial(aggs[i], &vt)
 					case int64:
 						aggs[i].Merge(&AggPartial{Ct: vt})
 					case string:
 						aggs[i] = &groupByFunc{vt}
+					case nil:
+						// group by value rolled up by a grouping set
 					default:
 						u.Warnf("unhandled type: %#v", v)
 					}
@@ -268,7 +411,9 @@ msgReadLoop:
 			//u.Debugf("agg result: %#v  %v", row[i], row[i])
 		}
 		//u.Debugf("GroupBy output row? %v", row)
+		wait := time.Now()
 		outCh <- datasource.NewSqlDriverMessageMap(i, row, colIndex)
+		m.stats.sent(wait)
 		i++
 	}
 
@@ -314,6 +459,18 @@ func (m *GroupByFinal) Close() error {
 	return m.TaskBase.Close()
 }
 
+// mergePartial merge the partial result of a group from another task into
+// the aggregator of the group.
+func mergePartial(agg Aggregator, a *AggPartial) {
+	if am, isMarshaler := agg.(AggregatorMarshaler); isMarshaler {
+		if err := am.MergeBinary(a.State); err != nil {
+			u.Warnf("could not merge partial state err=%v", err)
+		}
+		return
+	}
+	agg.Merge(a)
+}
+
 // AggPartial is a struct to represent the partial aggregation
 // that will be reduced on finalizer.  IE, for consistent-hash based
 // group-bys calculated across multiple nodes this holds info that
@@ -321,14 +478,31 @@ func (m *GroupByFinal) Close() error {
 type AggPartial struct {
 	Ct int64
 	N  float64
+	// Sq sum of squares, for variance and stddev
+	Sq float64
+	// Vals values held for aggregates that need them, such as
+	// min, max, median, group_concat, and the DISTINCT values of an aggregate
+	Vals []driver.Value
+	// Keys the distinct keys of Vals for a DISTINCT aggregate
+	Keys []string
+	// State partial state of aggregators implementing AggregatorMarshaler
+	State []byte
 }
 
 type AggFunc func(v value.Value)
 type resultFunc func() interface{}
+
+// Aggregator calculates an aggregate func over the rows of a group,
+// see AggregateAdd to register one and AggregatorMarshaler to send its
+// partial state between tasks.
 type Aggregator interface {
+	// Do aggregate the per-row value of the func
 	Do(v value.Value)
+	// Result of the group, or an *AggPartial if partial
 	Result() interface{}
+	// Reset for the next group
 	Reset()
+	// Merge a partial result of this group from another task
 	Merge(*AggPartial)
 }
 type agg struct {
@@ -347,6 +521,69 @@ func NewGroupByValue(col *rel.Column) Aggregator {
 	return &groupByFunc{}
 }
 
+// grouping of a row of GROUP BY ROLLUP, CUBE or GROUPING SETS, a bit per
+// argument set if it is rolled up in the grouping set of the row.
+//
+//    SELECT a, b, grouping(a, b) FROM t GROUP BY ROLLUP (a, b)
+type grouping struct {
+	args []int // group by column index of each arg
+	v    int64
+}
+
+func (m *grouping) Do(v value.Value)    {}
+func (m *grouping) Result() interface{} { return m.v }
+func (m *grouping) Reset()              { m.v = 0 }
+func (m *grouping) Merge(a *AggPartial) { m.v = a.Ct }
+
+// setRolledUp set the bits of the args rolled up in the row being output.
+func (m *grouping) setRolledUp(rolledUp map[int]bool) {
+	m.v = 0
+	for _, idx := range m.args {
+		m.v <<= 1
+		if rolledUp[idx] {
+			m.v |= 1
+		}
+	}
+}
+
+// resolve find the group by column of each arg.
+func (m *grouping) resolve(fn *expr.FuncNode, gbs rel.Columns) error {
+	m.args = make([]int, len(fn.Args))
+	for i, arg := range fn.Args {
+		m.args[i] = groupByIndex(gbs, &rel.Column{As: arg.String(), Expr: arg})
+		if m.args[i] < 0 {
+			return fmt.Errorf("grouping() argument %s must be a group by column", arg)
+		}
+	}
+	return nil
+}
+func NewGrouping(col *rel.Column, partial bool) Aggregator {
+	return &grouping{}
+}
+
+// groupingRolledUp the group by columns not grouped on in a grouping set.
+func groupingRolledUp(gbCt int, set []int) map[int]bool {
+	rolledUp := make(map[int]bool, gbCt)
+	for i := 0; i < gbCt; i++ {
+		rolledUp[i] = true
+	}
+	for _, idx := range set {
+		delete(rolledUp, idx)
+	}
+	return rolledUp
+}
+
+// groupByIndex the index of the group by column this select column is the
+// value of, -1 if none.
+func groupByIndex(gbs rel.Columns, col *rel.Column) int {
+	for i, gb := range gbs {
+		if gb.As == col.As || (col.Expr != nil && col.Expr.Equal(gb.Expr)) {
+			return i
+		}
+	}
+	return -1
+}
+
 type sum struct {
 	partial bool
 	ct      int64
@@ -354,12 +591,9 @@ type sum struct {
 }
 
 func (m *sum) Do(v value.Value) {
-	m.ct++
-	switch vt := v.(type) {
-	case value.IntValue:
-		m.n += vt.Float()
-	case value.NumberValue:
-		m.n += vt.Val()
+	if f, ok := aggFloat(v); ok {
+		m.ct++
+		m.n += f
 	}
 }
 func (m *sum) Result() interface{} {
@@ -367,11 +601,11 @@ func (m *sum) Result() interface{} {
 		return m.n
 	}
 	return &AggPartial{
-		m.ct,
-		m.n,
+		Ct: m.ct,
+		N:  m.n,
 	}
 }
-func (m *sum) Reset() { m.n = 0 }
+func (m *sum) Reset() { m.n = 0; m.ct = 0 }
 func (m *sum) Merge(a *AggPartial) {
 	m.ct += a.Ct
 	m.n += a.N
@@ -387,21 +621,21 @@ type avg struct {
 }
 
 func (m *avg) Do(v value.Value) {
-	m.ct++
-	switch vt := v.(type) {
-	case value.IntValue:
-		m.n += vt.Float()
-	case value.NumberValue:
-		m.n += vt.Val()
+	if f, ok := aggFloat(v); ok {
+		m.ct++
+		m.n += f
 	}
 }
 func (m *avg) Result() interface{} {
 	if !m.partial {
+		if m.ct == 0 {
+			return nil
+		}
 		return m.n / float64(m.ct)
 	}
 	return &AggPartial{
-		m.ct,
-		m.n,
+		Ct: m.ct,
+		N:  m.n,
 	}
 }
 func (m *avg) Reset() { m.n = 0; m.ct = 0 }
@@ -434,40 +668,308 @@ func NewCount(col *rel.Column) Aggregator {
 	return &count{}
 }
 
+// minMax keeps the smallest, or largest, non-null value compared by
+// value, so numbers in strings compare as numbers.
+type minMax struct {
+	partial bool
+	max     bool
+	ct      int64
+	v       value.Value
+}
+
+func (m *minMax) Do(v value.Value) {
+	if v == nil || v.Nil() || v.Err() {
+		return
+	}
+	m.ct++
+	if m.v == nil {
+		m.v = v
+		return
+	}
+	c := compareValues(v, m.v, false)
+	if (m.max && c > 0) || (!m.max && c < 0) {
+		m.v = v
+	}
+}
+func (m *minMax) Result() interface{} {
+	if !m.partial {
+		if m.v == nil {
+			return nil
+		}
+		return m.v.Value()
+	}
+	a := &AggPartial{Ct: m.ct}
+	if m.v != nil {
+		a.Vals = []driver.Value{m.v.Value()}
+	}
+	return a
+}
+func (m *minMax) Reset() { m.v = nil; m.ct = 0 }
+func (m *minMax) Merge(a *AggPartial) {
+	for _, v := range a.Vals {
+		m.Do(value.NewValue(v))
+	}
+}
+func NewMin(col *rel.Column, partial bool) Aggregator {
+	return &minMax{partial: partial}
+}
+func NewMax(col *rel.Column, partial bool) Aggregator {
+	return &minMax{partial: partial, max: true}
+}
+
+// variance population variance, or standard deviation, from the count,
+// sum and sum of squares so partials merge by adding.
+type variance struct {
+	partial bool
+	stddev  bool
+	ct      int64
+	n       float64
+	sq      float64
+}
+
+func (m *variance) Do(v value.Value) {
+	if f, ok := aggFloat(v); ok {
+		m.ct++
+		m.n += f
+		m.sq += f * f
+	}
+}
+func (m *variance) Result() interface{} {
+	if m.partial {
+		return &AggPartial{Ct: m.ct, N: m.n, Sq: m.sq}
+	}
+	if m.ct == 0 {
+		return nil
+	}
+	mean := m.n / float64(m.ct)
+	vr := m.sq/float64(m.ct) - mean*mean
+	if vr < 0 {
+		// float rounding of identical values
+		vr = 0
+	}
+	if m.stddev {
+		return math.Sqrt(vr)
+	}
+	return vr
+}
+func (m *variance) Reset() { m.ct = 0; m.n = 0; m.sq = 0 }
+func (m *variance) Merge(a *AggPartial) {
+	m.ct += a.Ct
+	m.n += a.N
+	m.sq += a.Sq
+}
+func NewVariance(col *rel.Column, partial bool) Aggregator {
+	return &variance{partial: partial}
+}
+func NewStdDev(col *rel.Column, partial bool) Aggregator {
+	return &variance{partial: partial, stddev: true}
+}
+
+// median holds all the numeric values of the group to find the middle.
+type median struct {
+	partial bool
+	vals    []float64
+}
+
+func (m *median) Do(v value.Value) {
+	if f, ok := aggFloat(v); ok {
+		m.vals = append(m.vals, f)
+	}
+}
+func (m *median) Result() interface{} {
+	if m.partial {
+		a := &AggPartial{Ct: int64(len(m.vals))}
+		for _, f := range m.vals {
+			a.Vals = append(a.Vals, f)
+		}
+		return a
+	}
+	if len(m.vals) == 0 {
+		return nil
+	}
+	sort.Float64s(m.vals)
+	mid := len(m.vals) / 2
+	if len(m.vals)%2 == 1 {
+		return m.vals[mid]
+	}
+	return (m.vals[mid-1] + m.vals[mid]) / 2
+}
+func (m *median) Reset() { m.vals = nil }
+func (m *median) Merge(a *AggPartial) {
+	for _, v := range a.Vals {
+		m.Do(value.NewValue(v))
+	}
+}
+func NewMedian(col *rel.Column, partial bool) Aggregator {
+	return &median{partial: partial}
+}
+
+// groupConcat joins the non-null string values of the group with
+// separator, which is the optional 2nd arg of group_concat, default ",".
+type groupConcat struct {
+	partial bool
+	sep     string
+	vals    []string
+}
+
+func (m *groupConcat) Do(v value.Value) {
+	if v == nil || v.Nil() || v.Err() {
+		return
+	}
+	m.vals = append(m.vals, v.ToString())
+}
+func (m *groupConcat) Result() interface{} {
+	if m.partial {
+		a := &AggPartial{Ct: int64(len(m.vals))}
+		for _, s := range m.vals {
+			a.Vals = append(a.Vals, s)
+		}
+		return a
+	}
+	if len(m.vals) == 0 {
+		return nil
+	}
+	return strings.Join(m.vals, m.sep)
+}
+func (m *groupConcat) Reset() { m.vals = nil }
+func (m *groupConcat) Merge(a *AggPartial) {
+	for _, v := range a.Vals {
+		m.Do(value.NewValue(v))
+	}
+}
+func NewGroupConcat(col *rel.Column, partial bool) Aggregator {
+	gc := &groupConcat{partial: partial, sep: ","}
+	if fn, ok := col.Expr.(*expr.FuncNode); ok && len(fn.Args) > 1 {
+		if sn, ok := fn.Args[1].(*expr.StringNode); ok {
+			gc.sep = sn.Text
+		}
+	}
+	return gc
+}
+
+// aggFloat the float value of a numeric aggregate input, false
+// for nulls and non-numeric values.
+func aggFloat(v value.Value) (float64, bool) {
+	if v == nil || v.Nil() || v.Err() {
+		return 0, false
+	}
+	f, ok := value.ValueToFloat64(v)
+	if !ok || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+// distinctAgg aggregates only the first row of each distinct value of
+// the DISTINCT argument of an aggregate func.  Partials hold the distinct
+// keys and values, which are unioned on merge.
+//
+//    count(DISTINCT user_id)
+type distinctAgg struct {
+	Aggregator
+	partial bool
+	arg     expr.Node // the DISTINCT argument
+	fn      expr.Node // the aggregate func with DISTINCT removed
+	seen    map[string]struct{}
+	keys    []string
+	vals    []driver.Value
+}
+
+func newDistinctAgg(agg Aggregator, fn *expr.FuncNode, arg expr.Node, partial bool) *distinctAgg {
+	nfn := *fn
+	nfn.Args = append([]expr.Node{arg}, fn.Args[1:]...)
+	return &distinctAgg{Aggregator: agg, partial: partial, arg: arg, fn: &nfn, seen: make(map[string]struct{})}
+}
+
+// DoRow aggregate the row if its DISTINCT argument value has not been seen,
+// nulls are not aggregated.
+func (m *distinctAgg) DoRow(ctx expr.EvalContext) {
+	v, ok := vm.Eval(ctx, m.arg)
+	if !ok || v == nil || v.Nil() {
+		return
+	}
+	key := v.ToString()
+	if _, exists := m.seen[key]; exists {
+		return
+	}
+	m.seen[key] = struct{}{}
+	if v, ok = vm.Eval(ctx, m.fn); !ok || v == nil {
+		v = value.NewNilValue()
+	}
+	if m.partial {
+		m.keys = append(m.keys, key)
+		m.vals = append(m.vals, v.Value())
+		return
+	}
+	m.Aggregator.Do(v)
+}
+func (m *distinctAgg) Result() interface{} {
+	if m.partial {
+		return &AggPartial{Ct: int64(len(m.keys)), Keys: m.keys, Vals: m.vals}
+	}
+	return m.Aggregator.Result()
+}
+func (m *distinctAgg) Reset() {
+	m.Aggregator.Reset()
+	m.seen = make(map[string]struct{})
+	m.keys = nil
+	m.vals = nil
+}
+func (m *distinctAgg) Merge(a *AggPartial) {
+	for i, key := range a.Keys {
+		if _, exists := m.seen[key]; exists || i >= len(a.Vals) {
+			continue
+		}
+		m.seen[key] = struct{}{}
+		m.Aggregator.Do(value.NewValue(a.Vals[i]))
+	}
+}
+
+// distinctArg the argument of DISTINCT if the first argument of the
+// aggregate func is DISTINCT(arg), else nil.
+func distinctArg(fn *expr.FuncNode) expr.Node {
+	if len(fn.Args) == 0 {
+		return nil
+	}
+	dn, ok := fn.Args[0].(*expr.FuncNode)
+	if !ok || strings.ToLower(dn.Name) != "distinct" || len(dn.Args) != 1 {
+		return nil
+	}
+	return dn.Args[0]
+}
+
 func buildAggs(p *plan.GroupBy) ([]Aggregator, error) {
 
 	aggs := make([]Aggregator, len(p.Stmt.Columns))
-colLoop:
 	for colIdx, col := range p.Stmt.Columns {
-		for _, gb := range p.Stmt.GroupBy {
-			if gb.As == col.As || (col.Expr != nil && col.Expr.Equal(gb.Expr)) {
-				// simple Non Aggregate Value  gb.As == col.AS
-				//   SELECT domain, count(*) FROM users GROUP BY domain;
-
-				// aliased column
-				// SELECT `users`.`name` AS usernames FROM `users` GROUP BY `users`.`name`
-				//   gb.String() == "`users`.`name`"  && col.Expr.String() == "`users`.`name`"
-				aggs[colIdx] = NewGroupByValue(col)
-				continue colLoop
-			}
+		if groupByIndex(p.Stmt.GroupBy, col) >= 0 {
+			// simple Non Aggregate Value  gb.As == col.AS
+			//   SELECT domain, count(*) FROM users GROUP BY domain;
+
+			// aliased column
+			// SELECT `users`.`name` AS usernames FROM `users` GROUP BY `users`.`name`
+			//   gb.String() == "`users`.`name`"  && col.Expr.String() == "`users`.`name`"
+			aggs[colIdx] = NewGroupByValue(col)
+			continue
 		}
 
 		// Since we made it here, it is an aggregate func
-		//  move to a registry of some kind to allow extension
 		switch n := col.Expr.(type) {
 		case *expr.FuncNode:
 
-			// TODO:  extract to a UDF Registry Similar to builtins
-			switch strings.ToLower(n.Name) {
-			case "avg":
-				aggs[colIdx] = NewAvg(col, p.Partial)
-			case "count":
-				aggs[colIdx] = NewCount(col)
-			case "sum":
-				aggs[colIdx] = NewSum(col, p.Partial)
-			default:
+			newAgg, ok := AggregatorGet(n.Name)
+			if !ok {
 				return nil, fmt.Errorf("Not implemented groupby for function: %s", col.Expr)
 			}
+			aggs[colIdx] = newAgg(col, p.Partial)
+			if arg := distinctArg(n); arg != nil {
+				aggs[colIdx] = newDistinctAgg(aggs[colIdx], n, arg, p.Partial)
+			}
+			if ga, isGrouping := aggs[colIdx].(*grouping); isGrouping {
+				if err := ga.resolve(n, p.Stmt.GroupBy); err != nil {
+					return nil, err
+				}
+			}
 		case *expr.BinaryNode:
 			// expression logic?
 			return nil, fmt.Errorf("Not implemented groupby for expression column: %s", col.Expr)
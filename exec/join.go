@@ -3,15 +3,19 @@ package exec
 import (
 	"database/sql/driver"
 	"fmt"
+	"strconv"
 	"strings"
-	"sync"
+	"time"
 
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
@@ -20,10 +24,15 @@ var (
 
 	// Ensure that we implement the Task Runner interface
 	_ TaskRunner = (*JoinMerge)(nil)
+	_ TaskRunner = (*NestedLoopJoin)(nil)
 )
 
 type KeyEvaluator func(msg schema.Message) driver.Value
 
+// nullJoinKey is the key of rows with a NULL join value, they never match
+// a row of the other side but are kept by an outer join.
+const nullJoinKey = "\x00null"
+
 // Evaluate messages to create JoinKey based message, where the
 //    Join Key (composite of each value in join expr) hashes consistently
 //
@@ -60,7 +69,7 @@ func (m *JoinKey) Run() error {
 	joinNodes := m.p.Source.Stmt.JoinNodes()
 
 	for {
-
+		wait := time.Now()
 		select {
 		case <-m.SigChan():
 			//u.Debugf("got signal quit")
@@ -70,25 +79,30 @@ func (m *JoinKey) Run() error {
 				//u.Debugf("NICE, got msg shutdown")
 				return nil
 			}
+			m.stats.received(wait, inCh)
 
 			//u.Infof("In joinkey msg %#v", msg)
-		msgTypeSwitch:
 			switch mt := msg.(type) {
 			case *datasource.SqlDriverMessageMap:
 				vals := make([]string, len(joinNodes))
+				key := ""
 				for i, node := range joinNodes {
 					joinVal, ok := vm.Eval(mt, node)
 					//u.Debugf("evaluating: ok?%v T:%T result=%v node '%v'", ok, joinVal, joinVal.ToString(), node.String())
-					if !ok {
-						u.Errorf("could not evaluate: %T %#v   %v", joinVal, joinVal, msg)
-						break msgTypeSwitch
+					if !ok || joinVal == nil || joinVal.Nil() {
+						key = nullJoinKey
+						break
 					}
 					vals[i] = joinVal.ToString()
 				}
 				//u.Infof("joinkey: %v row:%v", vals, mt)
-				key := strings.Join(vals, string(byte(0)))
+				if key == "" {
+					key = strings.Join(vals, string(byte(0)))
+				}
 				mt.SetKeyHashed(key)
+				wait = time.Now()
 				outCh <- mt
+				m.stats.sent(wait)
 			default:
 				return fmt.Errorf("To use JoinKey must use SqlDriverMessageMap but got %T", msg)
 			}
@@ -99,13 +113,30 @@ func (m *JoinKey) Run() error {
 
 // Scans 2 source tasks for rows, evaluate keys, use for join
 //
+// Rows of the left source without a match are kept for LEFT and FULL joins,
+// rows of the right source for RIGHT and FULL joins, with the columns of
+// the other side NULL.
+//
+// The rows of the build side, the right unless the plan builds the left,
+// are read first and hashed by key into hash partitions, held in memory
+// until the memory limit of the query is passed, then the largest
+// partitions are spilled to temp files.  The rows of the other side are
+// then streamed past the partitions in memory, or written to the spilled
+// partition of their key.  Last each spilled partition is read back and
+// joined one at a time, split again if still past the memory limit, those
+// of a single key joined a block at a time from disk.
+//
 type JoinMerge struct {
 	*TaskBase
-	leftStmt  *rel.SqlSource
-	rightStmt *rel.SqlSource
-	ltask     TaskRunner
-	rtask     TaskRunner
-	colIndex  map[string]int
+	leftStmt   *rel.SqlSource
+	rightStmt  *rel.SqlSource
+	ltask      TaskRunner
+	rtask      TaskRunner
+	colIndex   map[string]int
+	width      int  // number of values of joined rows
+	leftOuter  bool // keep un-matched left rows
+	rightOuter bool // keep un-matched right rows
+	buildLeft  bool // hash the left rows, stream the right
 }
 
 // A very stupid naive parallel join merge, uses Key() as value to merge
@@ -132,6 +163,7 @@ func NewJoinNaiveMerge(ctx *plan.Context, l, r TaskRunner, p *plan.JoinMerge) *J
 	m := &JoinMerge{
 		TaskBase: NewTaskBase(ctx),
 		colIndex: p.ColIndex,
+		width:    joinWidth(p.ColIndex),
 	}
 
 	m.ltask = l
@@ -139,117 +171,288 @@ func NewJoinNaiveMerge(ctx *plan.Context, l, r TaskRunner, p *plan.JoinMerge) *J
 	m.leftStmt = p.LeftFrom
 	m.rightStmt = p.RightFrom
 
+	m.leftOuter, m.rightOuter = outerJoin(p.RightFrom)
+	m.buildLeft = p.BuildLeft
 	return m
 }
 
+// outerJoin are the un-matched left and right rows of the join of from kept.
+func outerJoin(from *rel.SqlSource) (left, right bool) {
+	switch from.LeftOrRight {
+	case lex.TokenLeft:
+		return true, false
+	case lex.TokenRight:
+		return false, true
+	case lex.TokenFull:
+		return true, true
+	}
+	return false, false
+}
+
+// joinWidth the number of values of joined rows with columns colIndex.
+func joinWidth(colIndex map[string]int) int {
+	width := 0
+	for _, idx := range colIndex {
+		if idx >= width {
+			width = idx + 1
+		}
+	}
+	return width
+}
+
 func (m *JoinMerge) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
 	outCh := m.MessageOut()
 
-	leftIn := m.ltask.MessageOut()
-	rightIn := m.rtask.MessageOut()
+	// side 0 is left, 1 right
+	buildTask, probeTask, buildSide := m.rtask, m.ltask, 1
+	buildOuter, probeOuter := m.rightOuter, m.leftOuter
+	if m.buildLeft {
+		buildTask, probeTask, buildSide = m.ltask, m.rtask, 0
+		buildOuter, probeOuter = m.leftOuter, m.rightOuter
+	}
+	probeSide := 1 - buildSide
 
-	lh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
-	rh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
+	bh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
+	spill := newHashSpill("join", memoryLimit(m.Ctx, HashMemoryLimit))
+	defer spill.remove()
 
-	wg := new(sync.WaitGroup)
-	wg.Add(1)
-	var fatalErr error
-	go func() {
-		for {
-			//u.Infof("In source Scanner msg %#v", msg)
-			select {
-			case <-m.SigChan():
-				u.Debugf("got signal quit")
-				wg.Done()
-				wg.Done()
-				return
-			case msg, ok := <-leftIn:
-				if !ok {
-					//u.Debugf("NICE, got left shutdown")
-					wg.Done()
-					return
-				} else {
-					switch mt := msg.(type) {
-					case *datasource.SqlDriverMessageMap:
-						key := mt.Key()
-						if key == "" {
-							fatalErr = fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
-							u.Errorf("no key? %#v  %v", mt, fatalErr)
-							close(m.TaskBase.sigCh)
-							return
-						}
-						lh[key] = append(lh[key], mt)
-					default:
-						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
-						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
-						return
+	// hash the build rows, spilling partitions past memory limit
+	err := m.read(buildTask, func(mt *datasource.SqlDriverMessageMap) error {
+		key := mt.Key()
+		part := hashPartition(fmt.Sprint(key))
+		if spill.spilled(part) {
+			return spill.write(part, buildSide, fmt.Sprint(key), mt.Vals)
+		}
+		bh[key] = append(bh[key], mt)
+		if !spill.add(part, mt.Vals) {
+			return nil
+		}
+		for spill.overLimit() {
+			evict, err := spill.evictPart()
+			if err != nil || evict < 0 {
+				return err
+			}
+			for key, msgs := range bh {
+				if hashPartition(fmt.Sprint(key)) != evict {
+					continue
+				}
+				for _, msg := range msgs {
+					if err := spill.write(evict, buildSide, fmt.Sprint(key), msg.Vals); err != nil {
+						return err
 					}
 				}
+				delete(bh, key)
 			}
-
 		}
-	}()
-	wg.Add(1)
-	go func() {
-		for {
+		return nil
+	})
+	if err == errJoinDone {
+		return nil
+	} else if err != nil {
+		u.Errorf("could not read build side of join %v", err)
+		m.Quit()
+		return err
+	}
 
-			//u.Infof("In source Scanner iter %#v", item)
+	i := uint64(0)
+	send := func(msgs []*datasource.SqlDriverMessageMap) bool {
+		for _, msg := range msgs {
+			msg.IdVal = i
+			i++
+			wait := time.Now()
 			select {
 			case <-m.SigChan():
-				u.Debugf("got quit signal join source 1")
-				wg.Done()
-				wg.Done()
-				return
-			case msg, ok := <-rightIn:
-				if !ok {
-					//u.Debugf("NICE, got right shutdown")
-					wg.Done()
-					return
-				} else {
-					switch mt := msg.(type) {
-					case *datasource.SqlDriverMessageMap:
-						key := mt.Key()
-						if key == "" {
-							fatalErr = fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
-							u.Errorf("no key? %#v  %v", mt, fatalErr)
-							close(m.TaskBase.sigCh)
-							return
-						}
-						rh[key] = append(rh[key], mt)
-					default:
-						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
-						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
-						return
-					}
-				}
+				return false
+			case outCh <- msg:
+				m.stats.sent(wait)
 			}
+		}
+		return true
+	}
 
+	// stream the probe rows past the build rows in memory
+	matched := make(map[driver.Value]bool)
+	done := false
+	err = m.read(probeTask, func(mt *datasource.SqlDriverMessageMap) error {
+		key := mt.Key()
+		part := hashPartition(fmt.Sprint(key))
+		if spill.spilled(part) {
+			return spill.write(part, probeSide, fmt.Sprint(key), mt.Vals)
 		}
-	}()
-	wg.Wait()
-	//u.Info("leaving source scanner")
-	i := uint64(0)
+		probe := []*datasource.SqlDriverMessageMap{mt}
+		if rows, ok := bh[key]; ok && key != nullJoinKey {
+			matched[key] = true
+			if m.buildLeft {
+				done = !send(m.mergeValueMessages(rows, probe))
+			} else {
+				done = !send(m.mergeValueMessages(probe, rows))
+			}
+		} else if probeOuter {
+			done = !send(m.padValueMessages(probe, m.sideColumns(probeSide)))
+		}
+		if done {
+			return errJoinDone
+		}
+		return nil
+	})
+	if err == errJoinDone {
+		return nil
+	} else if err != nil {
+		u.Errorf("could not read probe side of join %v", err)
+		m.Quit()
+		return err
+	}
+	if buildOuter {
+		for key, rows := range bh {
+			if !matched[key] && !send(m.padValueMessages(rows, m.sideColumns(buildSide))) {
+				return nil
+			}
+		}
+	}
+	bh = nil
+
+	sendAll := func(msgs []*datasource.SqlDriverMessageMap) { send(msgs) }
+	return spill.each(func(part int, rows []*spillRow) error {
+		plh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
+		prh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
+		for _, row := range rows {
+			msg := datasource.NewSqlDriverMessageMap(0, row.Vals, nil)
+			if row.Side == 0 {
+				plh[row.Key] = append(plh[row.Key], msg)
+			} else {
+				prh[row.Key] = append(prh[row.Key], msg)
+			}
+		}
+		m.joinHashes(plh, prh, sendAll)
+		return nil
+	}, func(key string, scan spillScan) error {
+		return m.joinKey(key, scan, spill.limit, sendAll)
+	})
+}
+
+// joinKey join the spilled rows of a single key, too many to read into
+// memory.  Every left row matches every right row, so blocks of left rows
+// up to the memory limit are joined to the right rows of a scan each.
+func (m *JoinMerge) joinKey(key string, scan spillScan, limit int64, send func([]*datasource.SqlDriverMessageMap)) error {
+	var sides [2]bool
+	err := scan(func(row *spillRow) error {
+		sides[row.Side] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if key == nullJoinKey || !sides[0] || !sides[1] {
+		// no row matches, the rows of outer sides are kept
+		return scan(func(row *spillRow) error {
+			if (row.Side == 0 && m.leftOuter) || (row.Side == 1 && m.rightOuter) {
+				msg := datasource.NewSqlDriverMessageMap(0, row.Vals, nil)
+				send(m.padValueMessages([]*datasource.SqlDriverMessageMap{msg}, m.sideColumns(row.Side)))
+			}
+			return nil
+		})
+	}
+
+	block := make([]*datasource.SqlDriverMessageMap, 0)
+	size := int64(0)
+	joinBlock := func() error {
+		err := scan(func(row *spillRow) error {
+			if row.Side == 1 {
+				msg := datasource.NewSqlDriverMessageMap(0, row.Vals, nil)
+				send(m.mergeValueMessages(block, []*datasource.SqlDriverMessageMap{msg}))
+			}
+			return nil
+		})
+		block, size = block[:0], 0
+		return err
+	}
+	err = scan(func(row *spillRow) error {
+		if row.Side != 0 {
+			return nil
+		}
+		block = append(block, datasource.NewSqlDriverMessageMap(0, row.Vals, nil))
+		if size += rowSize(row.Vals); size >= limit {
+			return joinBlock()
+		}
+		return nil
+	})
+	if err != nil || len(block) == 0 {
+		return err
+	}
+	return joinBlock()
+}
+
+// errJoinDone stops reading the rows of a join whose output was closed.
+var errJoinDone = fmt.Errorf("join done")
+
+// read the keyed rows of task until done or fn returns an error.
+func (m *JoinMerge) read(task TaskRunner, fn func(mt *datasource.SqlDriverMessageMap) error) error {
+	inCh := task.MessageOut()
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return errJoinDone
+		case msg, ok := <-inCh:
+			if !ok {
+				return nil
+			}
+			m.stats.received(wait, inCh)
+			mt, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				return fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+			}
+			if mt.Key() == "" {
+				return fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
+			}
+			if err := fn(mt); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sideColumns the source columns of the left (0) or right (1) side.
+func (m *JoinMerge) sideColumns(side int) []*rel.Column {
+	if side == 0 {
+		return m.leftStmt.Source.Columns
+	}
+	return m.rightStmt.Source.Columns
+}
+
+// joinHashes join the rows of the left and right hashes of keys.
+func (m *JoinMerge) joinHashes(lh, rh map[driver.Value][]*datasource.SqlDriverMessageMap, send func([]*datasource.SqlDriverMessageMap)) {
 	for keyLeft, valLeft := range lh {
 		//u.Debugf("compare:  key:%v  left:%#v  right:%#v  rh: %#v", keyLeft, valLeft, rh[keyLeft], rh)
-		if valRight, ok := rh[keyLeft]; ok {
+		if valRight, ok := rh[keyLeft]; ok && keyLeft != nullJoinKey {
 			//u.Debugf("found match?\n\t%d left=%#v\n\t%d right=%#v", len(valLeft), valLeft, len(valRight), valRight)
-			msgs := m.mergeValueMessages(valLeft, valRight)
-			//u.Debugf("msgsct: %v   msgs:%#v", len(msgs), msgs)
-			for _, msg := range msgs {
-				//outCh <- datasource.NewUrlValuesMsg(i, msg)
-				//u.Debugf("i:%d   msg:%#v", i, msg)
-				msg.IdVal = i
-				i++
-				outCh <- msg
+			send(m.mergeValueMessages(valLeft, valRight))
+		} else if m.leftOuter {
+			send(m.padValueMessages(valLeft, m.leftStmt.Source.Columns))
+		}
+	}
+	if m.rightOuter {
+		for keyRight, valRight := range rh {
+			if _, ok := lh[keyRight]; !ok || keyRight == nullJoinKey {
+				send(m.padValueMessages(valRight, m.rightStmt.Source.Columns))
 			}
 		}
 	}
-	return nil
+}
+
+// padValueMessages the un-matched rows of one side of an outer join, with
+// the columns of the other side NULL.
+func (m *JoinMerge) padValueMessages(msgs []*datasource.SqlDriverMessageMap, cols []*rel.Column) []*datasource.SqlDriverMessageMap {
+	out := make([]*datasource.SqlDriverMessageMap, 0, len(msgs))
+	for _, msg := range msgs {
+		vals := make([]driver.Value, m.width)
+		vals = valIndexing(vals, msg.Values(), cols)
+		out = append(out, datasource.NewSqlDriverMessageMap(0, vals, m.colIndex))
+	}
+	return out
 }
 
 func (m *JoinMerge) mergeValueMessages(lmsgs, rmsgs []*datasource.SqlDriverMessageMap) []*datasource.SqlDriverMessageMap {
@@ -260,9 +463,9 @@ func (m *JoinMerge) mergeValueMessages(lmsgs, rmsgs []*datasource.SqlDriverMessa
 	for _, lm := range lmsgs {
 		//u.Warnf("nice SqlDriverMessageMap: %#v", lmt)
 		for _, rm := range rmsgs {
-			vals := make([]driver.Value, len(m.colIndex))
-			vals = m.valIndexing(vals, lm.Values(), m.leftStmt.Source.Columns)
-			vals = m.valIndexing(vals, rm.Values(), m.rightStmt.Source.Columns)
+			vals := make([]driver.Value, m.width)
+			vals = valIndexing(vals, lm.Values(), m.leftStmt.Source.Columns)
+			vals = valIndexing(vals, rm.Values(), m.rightStmt.Source.Columns)
 			newMsg := datasource.NewSqlDriverMessageMap(0, vals, m.colIndex)
 			//u.Infof("out: %+v", newMsg)
 			out = append(out, newMsg)
@@ -271,7 +474,9 @@ func (m *JoinMerge) mergeValueMessages(lmsgs, rmsgs []*datasource.SqlDriverMessa
 	return out
 }
 
-func (m *JoinMerge) valIndexing(valOut, valSource []driver.Value, cols []*rel.Column) []driver.Value {
+// valIndexing copy the values of a row of a source with columns cols into
+// the values of a joined row.
+func valIndexing(valOut, valSource []driver.Value, cols []*rel.Column) []driver.Value {
 	for _, col := range cols {
 		if col.ParentIndex < 0 {
 			continue
@@ -294,3 +499,288 @@ func (m *JoinMerge) valIndexing(valOut, valSource []driver.Value, cols []*rel.Co
 	}
 	return valOut
 }
+
+// NestedLoopJoin joins the rows of a left task, a source or a join of
+// sources, to those of a right source on any condition, evaluated by the vm
+//
+//    SELECT ... FROM orders AS o INNER JOIN orders AS x ON o.price < x.price
+//    SELECT ... FROM users AS u CROSS JOIN orders AS o
+//    SELECT ... FROM users AS u, orders AS o, orders AS x WHERE ...
+//
+// The right rows are read first, hashed by the values of their side of the
+// = conditions (into one bucket if there are none).  Each left row is then
+// compared to the right rows of its bucket.  Un-matched rows are kept with
+// the columns of the other side NULL for outer joins.
+//
+// Columns compared by order in the condition (> >= < <= BETWEEN) are
+// compared as numbers or times when their values are strings of them, as
+// those of csv and other untyped sources are.
+//
+//   left source or join  ->
+//                          \
+//                            --  join on cond  -->
+//                          /
+//   right source         ->
+//
+type NestedLoopJoin struct {
+	*TaskBase
+	p          *plan.NestedLoopJoin
+	ltask      TaskRunner
+	rtask      TaskRunner
+	width      int   // number of values of joined rows
+	rightCols  []int // indexes of joined row values of right source
+	ordered    []int // indexes of joined row values compared by order in cond
+	leftOuter  bool  // keep un-matched left rows
+	rightOuter bool  // keep un-matched right rows
+}
+
+// NewNestedLoopJoin join the rows of task l to those of task r.
+func NewNestedLoopJoin(ctx *plan.Context, l, r TaskRunner, p *plan.NestedLoopJoin) *NestedLoopJoin {
+	m := &NestedLoopJoin{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+		ltask:    l,
+		rtask:    r,
+		width:    joinWidth(p.ColIndex),
+	}
+	for _, col := range p.RightFrom.Source.Columns {
+		if col.ParentIndex >= 0 && col.ParentIndex < m.width {
+			m.rightCols = append(m.rightCols, col.ParentIndex)
+		}
+	}
+	m.leftOuter, m.rightOuter = outerJoin(p.RightFrom)
+	if p.Cond != nil {
+		m.ordered = orderedCols(p.Cond, p.ColIndex, nil)
+	}
+	return m
+}
+
+func (m *NestedLoopJoin) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+
+	rows := make([][]driver.Value, 0)
+	var typed [][]driver.Value
+	buckets := make(map[string][]int)
+	err := m.read(m.rtask, func(msg *datasource.SqlDriverMessageMap) bool {
+		row := m.row(msg, m.p.RightFrom)
+		if key, ok := m.key(row, m.p.RightKeys); ok {
+			buckets[key] = append(buckets[key], len(rows))
+		}
+		rows = append(rows, row)
+		if len(m.ordered) > 0 {
+			typed = append(typed, m.typed(row))
+		}
+		return true
+	})
+	if err != nil {
+		u.Errorf("could not read right side of join %v", err)
+		m.Quit()
+		return err
+	}
+
+	i := uint64(0)
+	send := func(vals []driver.Value) bool {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return false
+		case outCh <- datasource.NewSqlDriverMessageMap(i, vals, m.p.ColIndex):
+			m.stats.sent(wait)
+			i++
+			return true
+		}
+	}
+
+	matched := make([]bool, len(rows))
+	err = m.read(m.ltask, func(msg *datasource.SqlDriverMessageMap) bool {
+		left := m.row(msg, m.p.LeftFrom)
+		found := false
+		if key, ok := m.key(left, m.p.LeftKeys); ok {
+			var typedLeft []driver.Value
+			if len(m.ordered) > 0 {
+				typedLeft = m.typed(left)
+			}
+			for _, ri := range buckets[key] {
+				vals := m.merge(left, rows[ri])
+				cond := vals
+				if len(m.ordered) > 0 {
+					cond = m.merge(typedLeft, typed[ri])
+				}
+				if !m.matches(cond) {
+					continue
+				}
+				found, matched[ri] = true, true
+				if !send(vals) {
+					return false
+				}
+			}
+		}
+		if !found && m.leftOuter {
+			return send(left)
+		}
+		return true
+	})
+	if err != nil {
+		u.Errorf("could not read left side of join %v", err)
+		m.Quit()
+		return err
+	}
+
+	if m.rightOuter {
+		for ri, row := range rows {
+			if !matched[ri] && !send(row) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// read the rows of task until done or fn returns false.
+func (m *NestedLoopJoin) read(task TaskRunner, fn func(msg *datasource.SqlDriverMessageMap) bool) error {
+	inCh := task.MessageOut()
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				return nil
+			}
+			m.stats.received(wait, inCh)
+			mt, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				return fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+			}
+			if !fn(mt) {
+				return nil
+			}
+		}
+	}
+}
+
+// row the values of msg as a joined row.  msg is a row of source from, or
+// if from is nil of a join, whose rows already are joined rows.
+func (m *NestedLoopJoin) row(msg *datasource.SqlDriverMessageMap, from *rel.SqlSource) []driver.Value {
+	vals := make([]driver.Value, m.width)
+	if from == nil {
+		copy(vals, msg.Values())
+		return vals
+	}
+	return valIndexing(vals, msg.Values(), from.Source.Columns)
+}
+
+// merge the values of right source row into a copy of left row.
+func (m *NestedLoopJoin) merge(left, right []driver.Value) []driver.Value {
+	vals := make([]driver.Value, len(left))
+	copy(vals, left)
+	for _, idx := range m.rightCols {
+		if right[idx] != nil {
+			vals[idx] = right[idx]
+		}
+	}
+	return vals
+}
+
+// key the hash key of the values of nodes for joined row vals, false if
+// any is NULL as that never equals a value of the other side.
+func (m *NestedLoopJoin) key(vals []driver.Value, nodes []expr.Node) (string, bool) {
+	if len(nodes) == 0 {
+		return "", true
+	}
+	msg := datasource.NewSqlDriverMessageMap(0, vals, m.p.ColIndex)
+	keys := make([]string, len(nodes))
+	for i, node := range nodes {
+		v, ok := vm.Eval(msg, node)
+		if !ok || v == nil || v.Nil() {
+			return "", false
+		}
+		keys[i] = v.ToString()
+	}
+	return strings.Join(keys, string(byte(0))), true
+}
+
+// typed a copy of joined row vals whose values compared by order in the
+// join condition are numbers or times, if strings of them.
+func (m *NestedLoopJoin) typed(vals []driver.Value) []driver.Value {
+	tv := make([]driver.Value, len(vals))
+	copy(tv, vals)
+	for _, idx := range m.ordered {
+		s, isString := tv[idx].(string)
+		if !isString {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+			tv[idx] = n
+		} else if f, ok := numberOf(value.NewStringValue(s)); ok {
+			tv[idx] = f
+		} else if t, ok := value.ValueToTime(value.NewStringValue(s)); ok {
+			tv[idx] = t
+		}
+	}
+	return tv
+}
+
+// orderedCols append the indexes of the joined row values of the
+// identities compared by order in node, > >= < <= or BETWEEN.
+func orderedCols(node expr.Node, colIndex map[string]int, cols []int) []int {
+	switch n := node.(type) {
+	case *expr.BinaryNode:
+		switch n.Operator.T {
+		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+			return identityCols(n.Args, colIndex, cols)
+		}
+		for _, arg := range n.Args {
+			cols = orderedCols(arg, colIndex, cols)
+		}
+	case *expr.TriNode:
+		if n.Operator.T == lex.TokenBetween {
+			return identityCols(n.Args, colIndex, cols)
+		}
+	case *expr.BooleanNode:
+		for _, arg := range n.Args {
+			cols = orderedCols(arg, colIndex, cols)
+		}
+	case *expr.UnaryNode:
+		cols = orderedCols(n.Arg, colIndex, cols)
+	}
+	return cols
+}
+
+// identityCols append the indexes of the joined row values of the identity
+// nodes of args, resolved as SqlDriverMessageMap.Get does.
+func identityCols(args []expr.Node, colIndex map[string]int, cols []int) []int {
+	for _, arg := range args {
+		in, isIdent := arg.(*expr.IdentityNode)
+		if !isIdent {
+			continue
+		}
+		idx, ok := colIndex[in.Text]
+		if !ok {
+			if _, right, hasLeft := expr.LeftRight(in.Text); hasLeft {
+				idx, ok = colIndex[right]
+			}
+		}
+		if ok {
+			cols = append(cols, idx)
+		}
+	}
+	return cols
+}
+
+// matches is the join condition true for joined row vals.
+func (m *NestedLoopJoin) matches(vals []driver.Value) bool {
+	if m.p.Cond == nil {
+		return true
+	}
+	v, ok := vm.Eval(datasource.NewSqlDriverMessageMap(0, vals, m.p.ColIndex), m.p.Cond)
+	if !ok {
+		return false
+	}
+	bv, isBool := v.(value.BoolValue)
+	return isBool && bv.Val()
+}
@@ -85,10 +85,13 @@ func NewDelete(ctx *plan.Context, p *plan.Delete) *DeletionTask {
 }
 
 func (m *Upsert) Close() error {
+	m.Lock()
 	if m.closed {
+		m.Unlock()
 		return nil
 	}
 	m.closed = true
+	m.Unlock()
 	if closer, ok := m.db.(schema.Source); ok {
 		if err := closer.Close(); err != nil {
 			return err
@@ -1,8 +1,18 @@
 package exec
 
 import (
+	"bufio"
+	"container/heap"
+	"database/sql/driver"
+	"encoding/gob"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	u "github.com/araddon/gou"
@@ -10,16 +20,47 @@ import (
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
-// Order
+var (
+	// OrderMemoryLimit is the default bytes of rows an Order task holds in
+	// memory, past it sorted runs of rows are spilled to temp files on disk.
+	// plan.Context.MemoryLimit overrides it per query.  Zero or less never
+	// spills.
+	OrderMemoryLimit int64 = 256 * 1024 * 1024
+)
+
+// Order sorts the rows of the result by the ORDER BY columns.
+//
+// Rows are held in memory until they pass the memory limit of the query,
+// then are sorted and written as a run to a temp file.  After all rows
+// are read the runs, and the rows still in memory, are k-way merged on
+// output.  Rows of equal keys keep their input order.
+//
+// ORDER BY with LIMIT only keeps the first LIMIT + OFFSET rows of the
+// order, in a bounded heap, so memory is proportional to the limit.
+//
+//    SELECT id FROM events ORDER BY ts DESC LIMIT 10
+//
 type Order struct {
 	*TaskBase
 	p          *plan.Order
 	complete   chan bool
 	closed     bool
 	isComplete bool
+	colIndex   map[string]int
+	runs       []*orderRun
+}
+
+// orderRun a sorted run of rows spilled to a temp file.
+type orderRun struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *gob.Encoder
+	dec *gob.Decoder
 }
 
 // NewORder create new order by exec task
@@ -63,16 +104,22 @@ func (m *Order) Run() error {
 	outCh := m.MessageOut()
 	inCh := m.MessageIn()
 
+	defer m.removeRuns()
+
 	colIndex := m.p.Stmt.ColIndexes()
-	orderCt := len(m.p.Stmt.OrderBy)
 
-	// are are going to hold entire row in memory while we are calculating
-	//  so obviously not scalable.
+	limit := memoryLimit(m.Ctx, OrderMemoryLimit)
+	memSize := int64(0)
+
 	sl := NewOrderMessages(m.p)
+	var top *orderTopN
+	if m.p.TopN > 0 {
+		top = &orderTopN{sl: sl, n: m.p.TopN}
+	}
 
 msgReadLoop:
 	for {
-
+		wait := time.Now()
 		select {
 		case <-m.SigChan():
 			u.Warnf("got signal quit")
@@ -82,6 +129,7 @@ msgReadLoop:
 				//u.Debugf("NICE, got closed channel shutdown")
 				break msgReadLoop
 			} else {
+				m.stats.received(wait, inCh)
 				var sdm *datasource.SqlDriverMessageMap
 
 				switch mt := msg.(type) {
@@ -93,41 +141,51 @@ msgReadLoop:
 					if !isContextReader {
 						err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
 						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
+						m.Quit()
 						return err
 					}
 
 					sdm = datasource.NewSqlDriverMessageMapCtx(msg.Id(), msgReader, colIndex)
 				}
 
-				// We are going to use VM Engine to create a value for each statement in group by
-				//  then join each value together to create a unique key.
-				keys := make([]string, orderCt)
-				for i, col := range m.p.Stmt.OrderBy {
-					if col.Expr != nil {
-						if key, ok := vm.Eval(sdm, col.Expr); ok {
-							//u.Debugf("msgtype:%T  key:%q for-expr:%s", sdm, key, col.Expr)
-							keys[i] = key.ToString()
-						} else {
-							// Is this an error?
-							//u.Warnf("no key?  %s for %+v", col.Expr, sdm)
-						}
-					} else {
-						//u.Warnf("no col.expr? %#v", col)
-					}
+				if m.colIndex == nil {
+					m.colIndex = sdm.ColIndex
 				}
 
 				//u.Infof("found key:%s for %+v", key, sdm)
-				sl.l = append(sl.l, &msgkey{keys, sdm})
+				if top != nil {
+					top.add(&msgkey{m.orderKeys(sdm), sdm})
+					continue
+				}
+				sl.l = append(sl.l, &msgkey{m.orderKeys(sdm), sdm})
+
+				memSize += rowSize(sdm.Vals)
+				if limit > 0 && memSize > limit {
+					if err := m.spillRun(sl); err != nil {
+						return err
+					}
+					sl.l = make([]*msgkey, 0)
+					memSize = 0
+				}
 			}
 		}
 	}
 
-	sort.Sort(sl)
+	if top != nil {
+		top.sort()
+	} else {
+		sort.Stable(sl)
+	}
 
-	for _, m := range sl.l {
-		//u.Debugf("got %s:%v msgs", key, vals)
-		outCh <- m.msg
+	if len(m.runs) == 0 {
+		for _, mk := range sl.l {
+			//u.Debugf("got %s:%v msgs", key, vals)
+			wait := time.Now()
+			outCh <- mk.msg
+			m.stats.sent(wait)
+		}
+	} else if err := m.mergeRuns(sl); err != nil {
+		return err
 	}
 
 	m.isComplete = true
@@ -136,48 +194,365 @@ msgReadLoop:
 	return nil
 }
 
+// orderKeys use the VM Engine to create a value for each order by
+// column, sorted by comparing typed values.
+func (m *Order) orderKeys(sdm *datasource.SqlDriverMessageMap) []value.Value {
+	keys := make([]value.Value, len(m.p.Stmt.OrderBy))
+	for i, col := range m.p.Stmt.OrderBy {
+		if col.Expr != nil {
+			if key, ok := vm.Eval(sdm, col.Expr); ok {
+				//u.Debugf("msgtype:%T  key:%q for-expr:%s", sdm, key, col.Expr)
+				keys[i] = key
+			}
+		}
+	}
+	return keys
+}
+
+// spillRun sort the rows in memory and write them as a run to a temp file.
+func (m *Order) spillRun(sl *OrderMessages) error {
+	sort.Stable(sl)
+	f, err := ioutil.TempFile("", "qlbridge-order")
+	if err != nil {
+		return err
+	}
+	run := &orderRun{f: f, w: bufio.NewWriter(f)}
+	run.enc = gob.NewEncoder(run.w)
+	m.runs = append(m.runs, run)
+	for _, mk := range sl.l {
+		if err := run.enc.Encode(mk.msg.Vals); err != nil {
+			return err
+		}
+	}
+	if err := run.w.Flush(); err != nil {
+		return err
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	run.dec = gob.NewDecoder(bufio.NewReader(f))
+	return err
+}
+
+// next read the next row of a spilled run, nil at the end of the run.
+func (m *Order) next(run *orderRun) (*msgkey, error) {
+	var row []driver.Value
+	if err := run.dec.Decode(&row); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	sdm := datasource.NewSqlDriverMessageMap(0, row, m.colIndex)
+	return &msgkey{m.orderKeys(sdm), sdm}, nil
+}
+
+// mergeRuns k-way merge the spilled runs and sorted rows still in memory,
+// which are the last rows read, to the output.
+func (m *Order) mergeRuns(sl *OrderMessages) error {
+	h := &orderHeap{sl: sl}
+	for i, run := range m.runs {
+		mk, err := m.next(run)
+		if err != nil {
+			return err
+		}
+		if mk != nil {
+			h.heads = append(h.heads, &orderHead{mk: mk, run: i})
+		}
+	}
+	mem := sl.l
+	if len(mem) > 0 {
+		h.heads = append(h.heads, &orderHead{mk: mem[0], run: len(m.runs)})
+		mem = mem[1:]
+	}
+	heap.Init(h)
+
+	outCh := m.MessageOut()
+	for h.Len() > 0 {
+		head := h.heads[0]
+		wait := time.Now()
+		select {
+		case outCh <- head.mk.msg:
+			m.stats.sent(wait)
+		case <-m.SigChan():
+			return nil
+		}
+		var next *msgkey
+		if head.run < len(m.runs) {
+			var err error
+			if next, err = m.next(m.runs[head.run]); err != nil {
+				return err
+			}
+		} else if len(mem) > 0 {
+			next = mem[0]
+			mem = mem[1:]
+		}
+		if next == nil {
+			heap.Pop(h)
+			continue
+		}
+		head.mk = next
+		heap.Fix(h, 0)
+	}
+	return nil
+}
+
+// removeRuns close and remove the spilled temp files.
+func (m *Order) removeRuns() {
+	for _, run := range m.runs {
+		run.f.Close()
+		if err := os.Remove(run.f.Name()); err != nil {
+			u.Warnf("could not remove order spill file %s: %v", run.f.Name(), err)
+		}
+	}
+	m.runs = nil
+}
+
+// orderHead the current row of a sorted run being merged.
+type orderHead struct {
+	mk  *msgkey
+	run int
+}
+
+// orderHeap min-heap of the current row of each run, rows of equal keys
+// are taken from the earlier run first.
+type orderHeap struct {
+	sl    *OrderMessages
+	heads []*orderHead
+}
+
+func (m *orderHeap) Len() int { return len(m.heads) }
+func (m *orderHeap) Less(i, j int) bool {
+	if c := m.sl.compare(m.heads[i].mk, m.heads[j].mk); c != 0 {
+		return c < 0
+	}
+	return m.heads[i].run < m.heads[j].run
+}
+func (m *orderHeap) Swap(i, j int)      { m.heads[i], m.heads[j] = m.heads[j], m.heads[i] }
+func (m *orderHeap) Push(x interface{}) { m.heads = append(m.heads, x.(*orderHead)) }
+func (m *orderHeap) Pop() interface{} {
+	head := m.heads[len(m.heads)-1]
+	m.heads = m.heads[:len(m.heads)-1]
+	return head
+}
+
+// orderTopN max-heap of the first n rows of the order, the last of them
+// on top to be replaced by a row that sorts before it.  Rows of equal keys
+// keep their input order.
+type orderTopN struct {
+	sl  *OrderMessages
+	n   int
+	seq []int
+	ct  int
+}
+
+func (m *orderTopN) Len() int { return len(m.sl.l) }
+func (m *orderTopN) Less(i, j int) bool {
+	return m.after(i, j)
+}
+func (m *orderTopN) Swap(i, j int) {
+	m.sl.l[i], m.sl.l[j] = m.sl.l[j], m.sl.l[i]
+	m.seq[i], m.seq[j] = m.seq[j], m.seq[i]
+}
+func (m *orderTopN) Push(x interface{}) {
+	m.sl.l = append(m.sl.l, x.(*msgkey))
+	m.seq = append(m.seq, m.ct)
+}
+func (m *orderTopN) Pop() interface{} {
+	mk := m.sl.l[len(m.sl.l)-1]
+	m.sl.l = m.sl.l[:len(m.sl.l)-1]
+	m.seq = m.seq[:len(m.seq)-1]
+	return mk
+}
+
+// after does row i sort after row j.
+func (m *orderTopN) after(i, j int) bool {
+	if c := m.sl.compare(m.sl.l[i], m.sl.l[j]); c != 0 {
+		return c > 0
+	}
+	return m.seq[i] > m.seq[j]
+}
+
+// add a row, kept if it is among the first n rows of the order so far.
+func (m *orderTopN) add(mk *msgkey) {
+	m.ct++
+	if len(m.sl.l) < m.n {
+		heap.Push(m, mk)
+		return
+	}
+	// later rows of equal keys sort after the top
+	if m.sl.compare(mk, m.sl.l[0]) < 0 {
+		m.sl.l[0] = mk
+		m.seq[0] = m.ct
+		heap.Fix(m, 0)
+	}
+}
+
+// sort the kept rows into order.
+func (m *orderTopN) sort() {
+	sort.Sort(sort.Reverse(m))
+}
+
 type msgkey struct {
-	keys []string
+	keys []value.Value
 	msg  *datasource.SqlDriverMessageMap
 }
 type OrderMessages struct {
 	l      []*msgkey
-	invert []bool
+	cols   rel.Columns
+	nocase bool
 }
 
 func NewOrderMessages(p *plan.Order) *OrderMessages {
-	invert := make([]bool, len(p.Stmt.OrderBy))
-	for i, col := range p.Stmt.OrderBy {
-		//u.Debugf("invert?  %s ORDER %v", col.Expr, col.Order)
-		if col.Expr != nil {
-			if !col.Asc() {
-				invert[i] = true
-			}
-		}
-	}
 	return &OrderMessages{
 		l:      make([]*msgkey, 0),
-		invert: invert,
+		cols:   p.Stmt.OrderBy,
+		nocase: orderNoCase(p.Stmt),
 	}
 }
 func (m *OrderMessages) Len() int {
 	return len(m.l)
 }
 func (m *OrderMessages) Less(i, j int) bool {
-	for ki, key := range m.l[i].keys {
-		if key < m.l[j].keys[ki] {
-			if m.invert[ki] {
-				return false
-			}
-			return true
-		} else {
-			if m.invert[ki] {
-				return true
-			}
+	return m.compare(m.l[i], m.l[j]) < 0
+}
+func (m *OrderMessages) compare(a, b *msgkey) int {
+	for ki, key := range a.keys {
+		if c := compareOrder(key, b.keys[ki], m.cols[ki], m.nocase); c != 0 {
+			return c
 		}
 	}
-	return false
+	return 0
 }
 func (m *OrderMessages) Swap(i, j int) {
 	m.l[i], m.l[j] = m.l[j], m.l[i]
 }
+
+// orderNoCase does the query ask for case-insensitive string ordering
+//
+//    SELECT name FROM users ORDER BY name WITH collation = "nocase"
+//
+// nocase or a case-insensitive mysql collation such as utf8_general_ci.
+func orderNoCase(stmt *rel.SqlSelect) bool {
+	if len(stmt.With) == 0 {
+		return false
+	}
+	collation := strings.ToLower(stmt.With.String("collation"))
+	return collation == "nocase" || strings.HasSuffix(collation, "_ci")
+}
+
+// compareOrder compare values of an order by column, with the direction
+// and null ordering of the column applied.
+func compareOrder(a, b value.Value, col *rel.Column, nocase bool) int {
+	aNil, bNil := a == nil || a.Nil(), b == nil || b.Nil()
+	switch {
+	case aNil && bNil:
+		return 0
+	case aNil || bNil:
+		// null ordering does not flip with direction
+		if aNil == col.NullsFirst() {
+			return -1
+		}
+		return 1
+	}
+	c := compareValues(a, b, nocase)
+	if !col.Asc() {
+		return -c
+	}
+	return c
+}
+
+// compareValues compare two values in a total order, nils first, then
+// numbers by value, including numbers in strings such as those of csv
+// sources, then times, bools and last all other values by string,
+// optionally case-insensitive.
+func compareValues(a, b value.Value, nocase bool) int {
+	ak, bk := valueKind(a), valueKind(b)
+	if ak != bk {
+		return compareFloats(float64(ak), float64(bk))
+	}
+	switch ak {
+	case kindNil:
+		return 0
+	case kindNumber:
+		af, _ := numberOf(a)
+		bf, _ := numberOf(b)
+		return compareFloats(af, bf)
+	case kindTime:
+		return compareTimes(a.(value.TimeValue).Val(), b.(value.TimeValue).Val())
+	case kindBool:
+		av, bv := a.(value.BoolValue).Val(), b.(value.BoolValue).Val()
+		switch {
+		case av == bv:
+			return 0
+		case av:
+			return 1
+		}
+		return -1
+	}
+	if nocase {
+		return strings.Compare(strings.ToLower(a.ToString()), strings.ToLower(b.ToString()))
+	}
+	return strings.Compare(a.ToString(), b.ToString())
+}
+
+// kinds of values in the order they sort
+const (
+	kindNil = iota
+	kindNumber
+	kindTime
+	kindBool
+	kindString
+)
+
+func valueKind(v value.Value) int {
+	if v == nil || v.Nil() {
+		return kindNil
+	}
+	if _, ok := numberOf(v); ok {
+		return kindNumber
+	}
+	switch v.(type) {
+	case value.TimeValue:
+		return kindTime
+	case value.BoolValue:
+		return kindBool
+	}
+	return kindString
+}
+
+// numberOf the number of a numeric value or of a string holding a number.
+func numberOf(v value.Value) (float64, bool) {
+	var f float64
+	switch vt := v.(type) {
+	case value.NumericValue:
+		f = vt.Float()
+	case value.StringValue:
+		var err error
+		if f, err = strconv.ParseFloat(strings.TrimSpace(vt.Val()), 64); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
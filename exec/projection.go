@@ -3,6 +3,7 @@ package exec
 import (
 	"database/sql/driver"
 	"math"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -116,14 +117,22 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 	columns := m.p.Stmt.Columns
 	colIndex := m.p.Stmt.ColIndexes()
 	limit := m.p.Stmt.Limit
-	if limit == 0 {
+	if limit == 0 || m.p.Stmt.Distinct {
+		// distinct rows are limited by the distinct task after this
 		limit = math.MaxInt32
 	}
 	colCt := len(columns)
 	// If we have a projection, use that as col count
-	if m.p.Proj != nil {
+	if m.p.Proj != nil && len(m.p.Proj.Columns) > colCt {
 		colCt = len(m.p.Proj.Columns)
 	}
+	// window task appends a value per window column onto the row
+	windowCt := 0
+	for _, col := range columns {
+		if col.Over != nil {
+			windowCt++
+		}
+	}
 
 	rowCt := 0
 	return func(ctx *plan.Context, msg schema.Message) bool {
@@ -175,6 +184,9 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 				}
 				if col.Star {
 					starRow := mt.Values()
+					if windowCt > 0 && len(starRow) >= windowCt {
+						starRow = starRow[:len(starRow)-windowCt]
+					}
 					//u.Infof("star row: %#v", starRow)
 					if len(columns) > 1 {
 						//   select *, myvar, 1
@@ -202,6 +214,11 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 
 				} else if col.Expr == nil {
 					u.Warnf("wat?   nil col expr? %#v", col)
+				} else if col.Over != nil {
+					// window columns were calculated by window task
+					if v, ok := mt.Get(WindowKey(col)); ok && v != nil {
+						row[colIdx] = v.Value()
+					}
 				} else {
 					v, ok := vm.Eval(rdr, col.Expr)
 					if !ok {
@@ -302,8 +319,10 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 		rowCt++
 
 		//u.Debugf("row:%d  completed projection for: %p %#v", rowCt, out, outMsg)
+		wait := time.Now()
 		select {
 		case out <- outMsg:
+			m.stats.sent(wait)
 			return true
 		case <-m.SigChan():
 			return false
@@ -319,6 +338,7 @@ func (m *Projection) limitEvaluator() MessageHandler {
 	if limit == 0 {
 		limit = math.MaxInt32
 	}
+	offset := m.p.Stmt.Offset
 
 	rowCt := 0
 	return func(ctx *plan.Context, msg schema.Message) bool {
@@ -330,6 +350,11 @@ func (m *Projection) limitEvaluator() MessageHandler {
 		default:
 		}
 
+		if offset > 0 {
+			offset--
+			return true // skip it
+		}
+
 		if rowCt >= limit {
 			if rowCt == limit {
 				//u.Debugf("%p Projection reaching Limit!!! rowct:%v  limit:%v", m, rowCt, limit)
@@ -343,8 +368,10 @@ func (m *Projection) limitEvaluator() MessageHandler {
 		}
 		rowCt++
 
+		wait := time.Now()
 		select {
 		case out <- msg:
+			m.stats.sent(wait)
 			return true
 		case <-m.SigChan():
 			return false
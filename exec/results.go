@@ -3,6 +3,7 @@ package exec
 import (
 	"database/sql/driver"
 	"io"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -164,6 +165,7 @@ func (m *ResultBuffer) Close() error {
 
 // Next his is implementation of the sql/driver Rows() Next() interface
 func (m *ResultWriter) Next(dest []driver.Value) error {
+	wait := time.Now()
 	select {
 	case <-m.SigChan():
 		return ErrShuttingDown
@@ -176,6 +178,7 @@ func (m *ResultWriter) Next(dest []driver.Value) error {
 		if msg == nil {
 			return io.EOF
 		}
+		m.stats.received(wait, m.MessageIn())
 		return msgToRow(msg, m.cols, dest)
 	}
 }
@@ -211,8 +214,10 @@ func resultWrite(m *ResultWriter) MessageHandler {
 		// 	u.Errorf("could not convert to message reader: %T", msg.Body())
 		// }
 
+		wait := time.Now()
 		select {
 		case out <- msg:
+			m.stats.sent(wait)
 			return true
 		case <-m.SigChan():
 			return false
@@ -257,10 +262,10 @@ func msgToRow(msg schema.Message, cols []string, dest []driver.Value) error {
 			if ok && val != nil && !val.Nil() {
 				dest[i] = val.Value()
 				//u.Infof("key=%v   val=%v", key, val)
-			} else if val == nil {
-				u.Errorf("could not evaluate? %v  %#v", key, mt)
 			} else {
-				u.Warnf("missing value? %v %T %v", key, val.Value(), val.Value())
+				// missing and NULL values are NULL, dest is re-used
+				// between rows so must be cleared
+				dest[i] = nil
 			}
 		}
 		//u.Debugf("got msg in row result writer: %#v", dest)
@@ -0,0 +1,162 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*SemiJoin)(nil)
+)
+
+// SemiJoin filters rows by the sub-select of the where clause
+//
+//    SELECT ... WHERE user_id [NOT] IN (SELECT user_id FROM orders)
+//    SELECT ... WHERE [NOT] EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id)
+//
+// The sub-select is run once before any rows are read, its rows hashed by
+// the values of its correlated columns and IN column.  Each row is kept if
+// the values of its own expressions are found (semi-join), or if negated
+// are not found (anti-join).  IN follows sql NULL semantics, a NULL on
+// either side is neither IN nor NOT IN.
+type SemiJoin struct {
+	*TaskBase
+	p        *plan.SemiJoin
+	sub      TaskRunner
+	colIndex map[string]int
+	keys     map[string]struct{}
+	nulls    map[string]bool // correlation keys whose IN column has a NULL
+}
+
+// NewSemiJoin create semi-join task filtering rows by the rows of task sub.
+func NewSemiJoin(ctx *plan.Context, p *plan.SemiJoin, sub TaskRunner) *SemiJoin {
+	return &SemiJoin{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+		sub:      sub,
+		colIndex: p.Stmt.ColIndexes(),
+		keys:     make(map[string]struct{}),
+		nulls:    make(map[string]bool),
+	}
+}
+
+func (m *SemiJoin) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	if err := runSelect(m.sub, m.SigChan(), m.addRow); err != nil {
+		u.Errorf("could not run sub-select %s err=%v", m.p.Sub.Stmt, err)
+		m.Quit()
+		return err
+	}
+
+	outCh := m.MessageOut()
+	inCh := m.MessageIn()
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				return nil
+			}
+			m.stats.received(wait, inCh)
+			var reader expr.ContextReader
+			switch mt := msg.(type) {
+			case *datasource.SqlDriverMessage:
+				reader = mt.ToMsgMap(m.colIndex)
+			case expr.ContextReader:
+				reader = mt
+			default:
+				err := fmt.Errorf("To use SemiJoin must use SqlDriverMessageMap but got %T", msg)
+				u.Errorf("unrecognized msg %T", msg)
+				m.Quit()
+				return err
+			}
+			if !m.matches(reader) {
+				continue
+			}
+			wait = time.Now()
+			select {
+			case outCh <- msg:
+				m.stats.sent(wait)
+			case <-m.SigChan():
+				return nil
+			}
+		}
+	}
+}
+
+// addRow add the values of a row of the sub-select, the IN column
+// followed by the correlated columns.
+func (m *SemiJoin) addRow(vals []driver.Value) bool {
+	corr := vals[len(vals)-len(m.p.Outer):]
+	keys := make([]string, len(corr), len(corr)+1)
+	for i, v := range corr {
+		if v == nil {
+			// NULL never equals an outer value
+			return true
+		}
+		keys[i] = value.NewValue(v).ToString()
+	}
+	if m.p.Stmt.Where.Op == lex.TokenIN {
+		corrKey := strings.Join(keys, string(byte(0)))
+		if vals[0] == nil {
+			m.nulls[corrKey] = true
+			return true
+		}
+		keys = append(keys, value.NewValue(vals[0]).ToString())
+	}
+	m.keys[strings.Join(keys, string(byte(0)))] = struct{}{}
+	return true
+}
+
+// matches should the row be kept
+func (m *SemiJoin) matches(reader expr.ContextReader) bool {
+	w := m.p.Stmt.Where
+	keys := make([]string, len(m.p.Outer), len(m.p.Outer)+1)
+	found := true
+	for i, node := range m.p.Outer {
+		v, ok := vm.Eval(reader, node)
+		if !ok || v == nil || v.Nil() {
+			// NULL never equals an inner value, so no rows match
+			found = false
+			break
+		}
+		keys[i] = v.ToString()
+	}
+
+	if w.Op == lex.TokenIN {
+		lv, ok := vm.Eval(reader, w.Left)
+		if !ok || lv == nil || lv.Nil() {
+			return false
+		}
+		if found {
+			corrKey := strings.Join(keys, string(byte(0)))
+			_, found = m.keys[corrKey+string(byte(0))+lv.ToString()]
+			if len(keys) == 0 {
+				_, found = m.keys[lv.ToString()]
+			}
+			if !found && m.nulls[corrKey] {
+				// x IN (NULL, ...) is NULL not false
+				return false
+			}
+		}
+	} else if found {
+		_, found = m.keys[strings.Join(keys, string(byte(0)))]
+	}
+	return found != w.Negate
+}
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"fmt"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -133,11 +134,12 @@ func (m *Source) Run() error {
 
 	for item := m.Scanner.Next(); item != nil; item = m.Scanner.Next() {
 
+		wait := time.Now()
 		select {
 		case <-sigChan:
 			return nil
 		case m.msgOutCh <- item:
-			// continue
+			m.stats.sent(wait)
 		}
 
 	}
@@ -0,0 +1,273 @@
+package exec
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// HashMemoryLimit is the default bytes of rows the GroupBy and JoinMerge
+	// tasks hold in their hash tables in memory, past it hash partitions are
+	// spilled to temp files on disk.  plan.Context.MemoryLimit overrides it
+	// per query.  Zero or less never spills.
+	HashMemoryLimit int64 = 256 * 1024 * 1024
+)
+
+const (
+	// number of hash partitions rows are spilled into
+	hashSpillParts = 16
+	// times a spilled partition still past the memory limit is split again
+	hashSpillLevels = 8
+)
+
+// memoryLimit the memory limit of the query of this context, else def.
+func memoryLimit(ctx *plan.Context, def int64) int64 {
+	if ctx != nil && ctx.MemoryLimit > 0 {
+		return ctx.MemoryLimit
+	}
+	return def
+}
+
+// hashPartition the hash partition of a key.
+func hashPartition(key string) int {
+	return levelPartition(key, 0)
+}
+
+// levelPartition the hash partition of a key when partitions are split
+// level times, each level hashing with a different seed.
+func levelPartition(key string, level int) int {
+	h := fnv.New32a()
+	if level > 0 {
+		h.Write([]byte{byte(level)})
+	}
+	h.Write([]byte(key))
+	return int(h.Sum32() % hashSpillParts)
+}
+
+// hashSpill grace hash partitions of keyed rows.  Partitions are held in
+// memory until the memory limit is passed, then the largest are spilled
+// to temp files, along with all later rows of those partitions.  Spilled
+// partitions are then read back one at a time, those still past the memory
+// limit first split into partitions again by a differently seeded hash.
+// The rows of a single key can't be split, they are scanned from disk.
+type hashSpill struct {
+	name    string
+	limit   int64
+	level   int // times the partitions of the rows were split
+	memSize int64
+	sizes   [hashSpillParts]int64
+	parts   [hashSpillParts]*spillPart
+}
+
+// spillPart a hash partition spilled to a temp file.
+type spillPart struct {
+	f     *os.File
+	w     *bufio.Writer
+	enc   *gob.Encoder
+	size  int64  // bytes of the rows written
+	key   string // key of the first row written
+	mixed bool   // rows of more than one key written
+}
+
+// spillRow a keyed row as written to a spill file, of the left (0) or
+// right (1) side of a join.
+type spillRow struct {
+	Key  string
+	Side int
+	Vals []driver.Value
+}
+
+func newHashSpill(name string, limit int64) *hashSpill {
+	return &hashSpill{name: name, limit: limit}
+}
+
+// spilled is this partition on disk.
+func (m *hashSpill) spilled(part int) bool {
+	return m.parts[part] != nil
+}
+
+// add account for a row held in memory of partition part, true if the
+// memory limit has been passed and partitions should be evicted.
+func (m *hashSpill) add(part int, vals []driver.Value) bool {
+	size := rowSize(vals)
+	m.sizes[part] += size
+	m.memSize += size
+	return m.limit > 0 && m.memSize > m.limit
+}
+
+// evictPart the largest partition still in memory, which the caller must
+// then write with write(), -1 if none are in memory.
+func (m *hashSpill) evictPart() (int, error) {
+	part := -1
+	for i, size := range m.sizes {
+		if m.parts[i] == nil && size > 0 && (part < 0 || size > m.sizes[part]) {
+			part = i
+		}
+	}
+	if part < 0 {
+		return -1, nil
+	}
+	if err := m.open(part); err != nil {
+		return -1, err
+	}
+	m.memSize -= m.sizes[part]
+	m.sizes[part] = 0
+	return part, nil
+}
+
+// open the temp file partition part is spilled to.
+func (m *hashSpill) open(part int) error {
+	f, err := ioutil.TempFile("", "qlbridge-"+m.name)
+	if err != nil {
+		return err
+	}
+	sp := &spillPart{f: f, w: bufio.NewWriter(f)}
+	sp.enc = gob.NewEncoder(sp.w)
+	m.parts[part] = sp
+	return nil
+}
+
+// overLimit is memory still past the limit.
+func (m *hashSpill) overLimit() bool {
+	return m.limit > 0 && m.memSize > m.limit
+}
+
+// write a row to its spilled partition.
+func (m *hashSpill) write(part, side int, key string, vals []driver.Value) error {
+	sp := m.parts[part]
+	if sp.size == 0 {
+		sp.key = key
+	} else if key != sp.key {
+		sp.mixed = true
+	}
+	sp.size += rowSize(vals)
+	return sp.enc.Encode(&spillRow{Key: key, Side: side, Vals: vals})
+}
+
+// each read back the rows of each spilled partition, one partition at a
+// time.  Partitions past the memory limit are split into partitions again,
+// those of a single key, which can't be split, are given to fnKey to scan
+// their rows instead of being read into memory.
+func (m *hashSpill) each(fn func(part int, rows []*spillRow) error, fnKey func(key string, scan spillScan) error) error {
+	for i, sp := range m.parts {
+		if sp == nil {
+			continue
+		}
+		if m.limit > 0 && sp.size > m.limit {
+			if !sp.mixed {
+				if err := fnKey(sp.key, sp.scan); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := m.split(sp, fn, fnKey); err != nil {
+				return err
+			}
+			continue
+		}
+		rows := make([]*spillRow, 0)
+		err := sp.scan(func(row *spillRow) error {
+			rows = append(rows, row)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err = fn(i, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// split the rows of spilled partition sp into the partitions of the next
+// level, and read those back.
+func (m *hashSpill) split(sp *spillPart, fn func(part int, rows []*spillRow) error, fnKey func(key string, scan spillScan) error) error {
+	if m.level+1 >= hashSpillLevels {
+		return fmt.Errorf("%s: could not split %d bytes of rows under the memory limit of %d bytes",
+			m.name, sp.size, m.limit)
+	}
+	next := newHashSpill(m.name, m.limit)
+	next.level = m.level + 1
+	defer next.remove()
+	err := sp.scan(func(row *spillRow) error {
+		part := levelPartition(row.Key, next.level)
+		if !next.spilled(part) {
+			if err := next.open(part); err != nil {
+				return err
+			}
+		}
+		return next.write(part, row.Side, row.Key, row.Vals)
+	})
+	if err != nil {
+		return err
+	}
+	return next.each(fn, fnKey)
+}
+
+// spillScan scans the rows of a spilled partition, one at a time, until
+// done or fn returns an error.
+type spillScan func(fn func(row *spillRow) error) error
+
+// scan the rows of the spilled partition, one at a time.  Each scan reads
+// the file by its own handle, so scans may be nested.
+func (m *spillPart) scan(fn func(row *spillRow) error) error {
+	if err := m.w.Flush(); err != nil {
+		return err
+	}
+	f, err := os.Open(m.f.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		row := &spillRow{}
+		if err := dec.Decode(row); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// remove close and remove the spilled temp files.
+func (m *hashSpill) remove() {
+	for i, sp := range m.parts {
+		if sp == nil {
+			continue
+		}
+		sp.f.Close()
+		if err := os.Remove(sp.f.Name()); err != nil {
+			u.Warnf("could not remove %s spill file %s: %v", m.name, sp.f.Name(), err)
+		}
+		m.parts[i] = nil
+	}
+}
+
+// rowSize rough bytes of memory a row holds.
+func rowSize(vals []driver.Value) int64 {
+	n := int64(64 + 32*len(vals))
+	for _, v := range vals {
+		switch vt := v.(type) {
+		case string:
+			n += int64(len(vt))
+		case []byte:
+			n += int64(len(vt))
+		}
+	}
+	return n
+}
@@ -1,14 +1,12 @@
 package exec
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
-	"errors"
 	"fmt"
 	"io"
-	"strconv"
-	"strings"
+	"reflect"
 	"sync"
 	"time"
 
@@ -18,17 +16,25 @@ import (
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
 )
 
 var (
 	// Ensure our driver implements appropriate database/sql interfaces
-	_ driver.Conn    = (*qlbConn)(nil)
-	_ driver.Driver  = (*qlbdriver)(nil)
-	_ driver.Execer  = (*qlbConn)(nil)
-	_ driver.Queryer = (*qlbConn)(nil)
-	_ driver.Result  = (*qlbResult)(nil)
-	_ driver.Rows    = (*qlbRows)(nil)
-	_ driver.Stmt    = (*qlbStmt)(nil)
+	_ driver.Conn                           = (*qlbConn)(nil)
+	_ driver.ConnPrepareContext             = (*qlbConn)(nil)
+	_ driver.Driver                         = (*qlbdriver)(nil)
+	_ driver.Execer                         = (*qlbConn)(nil)
+	_ driver.ExecerContext                  = (*qlbConn)(nil)
+	_ driver.Queryer                        = (*qlbConn)(nil)
+	_ driver.QueryerContext                 = (*qlbConn)(nil)
+	_ driver.Result                         = (*qlbResult)(nil)
+	_ driver.Rows                           = (*qlbRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*qlbRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*qlbRows)(nil)
+	_ driver.Stmt                           = (*qlbStmt)(nil)
+	_ driver.StmtExecContext                = (*qlbStmt)(nil)
+	_ driver.StmtQueryContext               = (*qlbStmt)(nil)
 	//_ driver.Tx      = (*driverConn)(nil)
 
 	// Create an instance of our driver
@@ -113,21 +119,66 @@ type qlbConn struct {
 // Execer implementation. To be used for queries that do not return any rows
 // such as Create Index, Insert, Upset, Delete etc
 func (m *qlbConn) Exec(query string, args []driver.Value) (driver.Result, error) {
-	stmt := &qlbStmt{conn: m, query: query}
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
 	return stmt.Exec(args)
 }
 
+// ExecContext ExecerContext implementation, Exec that stops the statement
+// if ctx is canceled.
+func (m *qlbConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args)
+}
+
 // Queryer implementation
 // Query may return ErrSkip
 //
 func (m *qlbConn) Query(query string, args []driver.Value) (driver.Rows, error) {
-	stmt := &qlbStmt{conn: m, query: query}
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
 	return stmt.Query(args)
 }
 
-// Prepare returns a prepared statement, bound to this connection.
+// QueryContext QueryerContext implementation, Query that stops the query
+// if ctx is canceled, before or while its rows are read.
+func (m *qlbConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args)
+}
+
+// Prepare returns a prepared statement, bound to this connection.  The
+// query is parsed once, its ? or $N bind parameters bound to the args of
+// each execution.
 func (m *qlbConn) Prepare(query string) (driver.Stmt, error) {
-	return nil, expr.ErrNotImplemented
+	return m.prepare(query)
+}
+
+// PrepareContext ConnPrepareContext implementation, the statement isn't
+// bound to ctx, only each of its executions.
+func (m *qlbConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.prepare(query)
+}
+
+func (m *qlbConn) prepare(query string) (*qlbStmt, error) {
+	stmt := &qlbStmt{conn: m, query: query}
+	if err := stmt.parse(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
 }
 
 // Close invalidates and potentially stops any current
@@ -161,10 +212,19 @@ func (conn *qlbTx) Rollback() error { return expr.ErrNotImplemented }
 // Stmt is a prepared statement. It is bound to a Conn and not
 // used by multiple goroutines concurrently.
 //
+// The statement is parsed and planned once, each execution binds its args
+// to the bind parameters of the statement and runs the same plan, unless
+// the plan can't be re-opened, see plan.Reopen.
 type qlbStmt struct {
-	job   *JobExecutor
-	query string
-	conn  *qlbConn
+	job    *JobExecutor
+	query  string
+	conn   *qlbConn
+	stmt   rel.SqlStatement  // parsed statement
+	params []*expr.ParamNode // bind parameters of stmt
+	ctx    *plan.Context     // plan context of pln, its go context that of the current execution
+	pln    plan.Task         // plan of stmt, nil until first executed
+	stats  uint64            // schema.StatsVersion pln was planned with
+	done   chan bool         // closed when the job of the last Query finishes
 }
 
 // Close closes the statement.
@@ -187,29 +247,116 @@ func (m *qlbStmt) Close() error {
 // NumInput may also return -1, if the driver doesn't know
 // its number of placeholders. In that case, the sql package
 // will not sanity check Exec or Query argument counts.
-func (m *qlbStmt) NumInput() int { return 0 }
+func (m *qlbStmt) NumInput() int { return expr.NumParams(m.params) }
 
-// Exec executes a query that doesn't return rows, such
-// as an INSERT, UPDATE, DELETE
-func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
-	var err error
-	if len(args) > 0 {
-		m.query, err = queryArgsConvert(m.query, args)
-		if err != nil {
+// parse the query of the statement and find its bind parameters.
+func (m *qlbStmt) parse() error {
+	stmt, err := rel.ParseSql(m.query)
+	if err != nil {
+		u.Debugf("could not parse sql : %v", err)
+		return err
+	}
+	m.stmt = stmt
+	m.params = rel.Params(stmt)
+	m.pln = nil
+	return nil
+}
+
+// replan drop the plan of the statement so it is planned again, re-parsing
+// selects as they are re-written when planned.
+func (m *qlbStmt) replan() error {
+	m.pln = nil
+	switch m.stmt.(type) {
+	case *rel.SqlSelect, *rel.SqlCompound:
+		return m.parse()
+	}
+	return nil
+}
+
+// newJob bind args to the bind parameters of the statement and create a
+// Job, which is Dag of Tasks that Run() until done or ctx is canceled, from
+// its plan.  The plan of the last execution is re-used if it can be
+// re-opened.
+func (m *qlbStmt) newJob(ctx context.Context, args []driver.Value) (*JobExecutor, error) {
+	if m.done != nil {
+		select {
+		case <-m.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if m.pln != nil && m.stats != schema.StatsVersion() {
+		// the join order and pushdown of the plan follow the old stats
+		if err := m.replan(); err != nil {
 			return nil, err
 		}
 	}
+	if m.pln != nil {
+		if err := plan.Reopen(m.pln); err != nil {
+			if err := m.replan(); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	// Create a Job, which is Dag of Tasks that Run()
-	ctx := plan.NewContext(m.query)
-	ctx.Schema = m.conn.schema
-	job, err := BuildSqlJob(ctx)
+	vals := make([]value.Value, len(args))
+	for i, arg := range args {
+		if bv, ok := arg.([]byte); ok {
+			vals[i] = value.NewStringValue(string(bv))
+			continue
+		}
+		vals[i] = value.NewValue(arg)
+	}
+	if err := rel.BindParams(m.params, vals); err != nil {
+		return nil, err
+	}
+
+	if m.pln == nil {
+		planCtx := plan.NewContext(m.query)
+		planCtx.Context = ctx
+		planCtx.Schema = m.conn.schema
+		planCtx.Stmt = m.stmt
+		stats := schema.StatsVersion()
+		pln, err := plan.WalkStmt(planCtx, m.stmt, plan.NewPlanner(planCtx))
+		if err != nil {
+			m.replan()
+			return nil, err
+		}
+		m.ctx, m.pln, m.stats = planCtx, pln, stats
+	}
+	m.ctx.Context = ctx
+	job, err := BuildSqlJobFromPlan(m.ctx, m.pln)
 	if err != nil {
+		m.replan()
 		return nil, err
 	}
 	m.job = job
+	return job, nil
+}
+
+// Exec executes a query that doesn't return rows, such
+// as an INSERT, UPDATE, DELETE
+func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return m.runExec(context.Background(), args)
+}
+
+// ExecContext StmtExecContext implementation, Exec that stops the statement
+// if ctx is canceled.
+func (m *qlbStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	vals, err := driverValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return m.runExec(ctx, vals)
+}
 
-	resultWriter := NewResultExecWriter(ctx)
+func (m *qlbStmt) runExec(ctx context.Context, args []driver.Value) (driver.Result, error) {
+	job, err := m.newJob(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	resultWriter := NewResultExecWriter(job.Ctx)
 	job.RootTask.Add(resultWriter)
 
 	job.Setup()
@@ -219,43 +366,57 @@ func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
 	if err != nil {
 		u.Errorf("error on Query.Run(): %v", err)
 		//resultWriter.ErrChan() <- err
-		//job.Close()
+	}
+	job.Close()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 	return resultWriter.Result(), nil
 }
 
 // Query executes a query that may return rows, such as a SELECT
 func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
-	var err error
-	if len(args) > 0 {
-		m.query, err = queryArgsConvert(m.query, args)
-		if err != nil {
-			return nil, err
-		}
+	return m.runQuery(context.Background(), args)
+}
+
+// QueryContext StmtQueryContext implementation, Query that stops the query
+// if ctx is canceled, before or while its rows are read.
+func (m *qlbStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	vals, err := driverValues(args)
+	if err != nil {
+		return nil, err
 	}
+	return m.runQuery(ctx, vals)
+}
+
+func (m *qlbStmt) runQuery(ctx context.Context, args []driver.Value) (driver.Rows, error) {
 	u.Debugf("query: %v", m.query)
 
-	// Create a Job, which is Dag of Tasks that Run()
-	ctx := plan.NewContext(m.query)
-	ctx.Schema = m.conn.schema
-	job, err := BuildSqlJob(ctx)
+	job, err := m.newJob(ctx, args)
 	if err != nil {
 		u.Warnf("return error? %v", err)
 		return nil, err
 	}
-	m.job = job
 
 	// The only type of stmt that makes sense for Query is SELECT
 	//  and we need list of columns that requires casing
-	sqlSelect, ok := job.Ctx.Stmt.(*rel.SqlSelect)
-	if !ok {
+	var cols []string
+	switch stmt := job.Ctx.Stmt.(type) {
+	case *rel.SqlSelect:
+		cols = stmt.Columns.AliasedFieldNames()
+	case *rel.SqlCompound:
+		selCols := stmt.Columns()
+		cols = selCols.AliasedFieldNames()
+	case *rel.SqlDescribe:
+		cols = plan.ExplainColumns(stmt)
+	default:
 		u.Warnf("ctx? %v", job.Ctx)
 		return nil, fmt.Errorf("We could not recognize that as a select query: %T", job.Ctx.Stmt)
 	}
 
 	// Prepare a result writer, we manually append this task to end
 	// of job?
-	resultWriter := NewResultRows(ctx, sqlSelect.Columns.AliasedFieldNames())
+	resultWriter := NewResultRows(job.Ctx, cols)
 
 	job.RootTask.Add(resultWriter)
 
@@ -263,7 +424,10 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 
 	// TODO:   this can't run in parallel-buffered mode?
 	// how to open in go-routine and still be able to send error to rows?
+	done := make(chan bool)
+	m.done = done
 	go func() {
+		defer close(done)
 		//u.Debugf("Start Job.Run")
 		err = job.Run()
 		//u.Debugf("After job.Run()")
@@ -276,7 +440,20 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 		//u.Debugf("exiting Background Query")
 	}()
 
-	return resultWriter, nil
+	return &qlbRows{ResultWriter: resultWriter, ctx: ctx, job: job, types: columnTypes(job.Ctx, m.pln, cols)}, nil
+}
+
+// driverValues the values of args, bind parameters are by position so
+// named args are not supported.
+func driverValues(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("named arg %q not supported, use ? or $N bind parameters", arg.Name)
+		}
+		vals[i] = arg.Value
+	}
+	return vals, nil
 }
 
 // driver.ColumnConverter Interface implementation.
@@ -289,33 +466,130 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 // column index.  If the type of a specific column isn't known
 // or shouldn't be handled specially, DefaultValueConverter
 // can be returned.
-func (conn *qlbStmt) ColumnConverter(idx int) driver.ValueConverter { return nil }
+func (conn *qlbStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return driver.DefaultParameterConverter
+}
 
 // driver.Rows Interface implementation.
 //
-// Rows is an iterator over an executed query's results.
+// Rows is an iterator over an executed query's results, the rows written
+// to the ResultWriter at the end of the query's job.
 //
-type qlbRows struct{}
-
-// Columns returns the names of the columns. The number of
-// columns of the result is inferred from the length of the
-// slice.  If a particular column name isn't known, an empty
-// string should be returned for that entry.
-func (conn *qlbRows) Columns() []string { return nil }
-
-// Close closes the rows iterator.
-func (conn *qlbRows) Close() error { return expr.ErrNotImplemented }
+type qlbRows struct {
+	*ResultWriter
+	ctx   context.Context   // go context of the query
+	job   *JobExecutor      // job of the query writing the rows
+	types []value.ValueType // type of each column, from the projection
+}
 
 // Next is called to populate the next row of data into
 // the provided slice. The provided slice will be the same
 // size as the Columns() are wide.
 //
-// The dest slice may be populated only with
-// a driver Value type, but excluding string.
-// All string values must be converted to []byte.
-//
-// Next should return io.EOF when there are no more rows.
-func (conn *qlbRows) Next(dest []driver.Value) error { return expr.ErrNotImplemented }
+// Next should return io.EOF when there are no more rows, the
+// error of the context if the query was canceled, or the error
+// that failed its job.
+func (m *qlbRows) Next(dest []driver.Value) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+	err := m.ResultWriter.Next(dest)
+	if err == ErrShuttingDown || err == io.EOF {
+		if ctxErr := m.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if jobErr := m.job.Err(); jobErr != nil {
+			return jobErr
+		}
+	}
+	return err
+}
+
+// ColumnTypeScanType the go type of the values of column index, suitable
+// for scanning into.
+func (m *qlbRows) ColumnTypeScanType(index int) reflect.Type {
+	switch m.types[index] {
+	case value.IntType:
+		return reflect.TypeOf(int64(0))
+	case value.NumberType:
+		return reflect.TypeOf(float64(0))
+	case value.BoolType:
+		return reflect.TypeOf(false)
+	case value.TimeType:
+		return reflect.TypeOf(time.Time{})
+	case value.StringType:
+		return reflect.TypeOf("")
+	case value.ByteSliceType, value.JsonType:
+		return reflect.TypeOf([]byte(nil))
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
+
+// ColumnTypeDatabaseTypeName the database type of column index, without a
+// length, empty if not known.
+func (m *qlbRows) ColumnTypeDatabaseTypeName(index int) string {
+	switch m.types[index] {
+	case value.IntType:
+		return "BIGINT"
+	case value.NumberType:
+		return "DOUBLE"
+	case value.BoolType:
+		return "BOOLEAN"
+	case value.TimeType:
+		return "DATETIME"
+	case value.StringType:
+		return "VARCHAR"
+	case value.ByteSliceType:
+		return "BLOB"
+	case value.JsonType:
+		return "JSON"
+	}
+	return ""
+}
+
+// columnTypes the type of each of the result columns cols of the statement
+// planned as pln with planCtx, found by name in its final projection, else
+// unknown.
+func columnTypes(planCtx *plan.Context, pln plan.Task, cols []string) []value.ValueType {
+	types := make([]value.ValueType, len(cols))
+	for i := range types {
+		types[i] = value.UnknownType
+	}
+	proj := finalProjection(pln)
+	if proj == nil || proj.Proj == nil {
+		proj = planCtx.Projection
+	}
+	if proj == nil || proj.Proj == nil {
+		return types
+	}
+	byName := make(map[string]value.ValueType)
+	for _, col := range proj.Proj.Columns {
+		byName[col.As] = col.Type
+	}
+	for i, name := range cols {
+		if vt, ok := byName[name]; ok {
+			types[i] = vt
+		}
+	}
+	return types
+}
+
+// finalProjection the projection of the result columns of plan pln, nil if
+// it has none.
+func finalProjection(pln plan.Task) *plan.Projection {
+	if pln == nil {
+		return nil
+	}
+	if proj, ok := pln.(*plan.Projection); ok && proj.Final {
+		return proj
+	}
+	for _, child := range pln.Children() {
+		if proj := finalProjection(child); proj != nil {
+			return proj
+		}
+	}
+	return nil
+}
 
 // driver.Result Interface implementation.
 //
@@ -335,110 +609,3 @@ func (r *qlbResult) LastInsertId() (int64, error) { return r.lastId, r.err }
 // RowsAffected returns the number of rows affected by the
 // query.
 func (r *qlbResult) RowsAffected() (int64, error) { return r.affected, r.err }
-
-func join(a []string) string {
-	n := 0
-	for _, s := range a {
-		n += len(s)
-	}
-	b := make([]byte, n)
-	n = 0
-	for _, s := range a {
-		n += copy(b[n:], s)
-	}
-	return string(b)
-}
-
-func queryArgsConvert(query string, args []driver.Value) (string, error) {
-	if len(args) == 0 {
-		return query, nil
-	}
-	// a tiny, tiny, tiny bit of string sanitization
-	if strings.ContainsAny(query, `'"`) {
-		return "", nil
-	}
-	q := make([]string, 2*len(args)+1)
-	n := 0
-	for _, a := range args {
-		i := strings.IndexRune(query, '?')
-		if i == -1 {
-			return "", errors.New("number of parameters doesn't match number of placeholders")
-		}
-		var s string
-		switch v := a.(type) {
-		case nil:
-			s = "NULL"
-		case string:
-			s = "'" + escapeString(v) + "'"
-		case []byte:
-			s = "'" + escapeString(string(v)) + "'"
-		case int64:
-			s = strconv.FormatInt(v, 10)
-		case time.Time:
-			s = "'" + v.Format(MysqlTimeFormat) + "'"
-		case bool:
-			if v {
-				s = "1"
-			} else {
-				s = "0"
-			}
-		case float64:
-			s = strconv.FormatFloat(v, 'e', 12, 64)
-		default:
-			panic(fmt.Sprintf("%v (%T) can't be handled by godrv", v, v))
-		}
-		q[n] = query[:i]
-		q[n+1] = s
-		query = query[i+1:]
-		n += 2
-	}
-	q[n] = query
-	return join(q), nil
-}
-
-func escapeString(txt string) string {
-	var (
-		esc string
-		buf bytes.Buffer
-	)
-	last := 0
-	for ii, bb := range txt {
-		switch bb {
-		case 0:
-			esc = `\0`
-		case '\n':
-			esc = `\n`
-		case '\r':
-			esc = `\r`
-		case '\\':
-			esc = `\\`
-		case '\'':
-			esc = `\'`
-		case '"':
-			esc = `\"`
-		case '\032':
-			esc = `\Z`
-		default:
-			continue
-		}
-		io.WriteString(&buf, txt[last:ii])
-		io.WriteString(&buf, esc)
-		last = ii + 1
-	}
-	io.WriteString(&buf, txt[last:])
-	return buf.String()
-}
-
-func escapeQuotes(txt string) string {
-	var buf bytes.Buffer
-	last := 0
-	for ii, bb := range txt {
-		if bb == '\'' {
-			io.WriteString(&buf, txt[last:ii])
-			io.WriteString(&buf, `''`)
-			last = ii + 1
-		}
-	}
-	io.WriteString(&buf, txt[last:])
-	return buf.String()
-}
@@ -1,7 +1,12 @@
 package exec_test
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +14,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
 )
 
 type user struct {
@@ -366,3 +373,252 @@ func TestSqlDbConnFailure(t *testing.T) {
 	assert.True(t, uo1.Price == 22.5, "? %#v", uo1)
 	rows2.Close()
 }
+
+func TestSqlDriverGroupByErr(t *testing.T) {
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	// price is neither grouped by nor aggregated
+	_, err = db.Query(`SELECT user_id, count(*) AS c, price FROM orders GROUP BY user_id`)
+	assert.NotEqual(t, nil, err)
+
+	rows, err := db.Query(`SELECT user_id, count(*) AS c, max(price) AS p FROM orders GROUP BY user_id`)
+	assert.Equal(t, nil, err)
+	ct := 0
+	for rows.Next() {
+		ct++
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.Equal(t, 2, ct)
+	rows.Close()
+}
+
+func TestSqlDriverPrepared(t *testing.T) {
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	// Args are bound as values, not spliced into the sql
+	stmt, err := db.Prepare(`SELECT user_id, email FROM users WHERE email = ?`)
+	assert.Equal(t, nil, err)
+	defer stmt.Close()
+	for email, want := range map[string]string{
+		"aaron@email.com":         "9Ip1aKbeZe2njCDM",
+		"bob@email.com":           "hT2impsOPUREcVPc",
+		`x" OR email != "`:        "",
+		`aaron@email.com' OR '1'`: "",
+	} {
+		rows, err := stmt.Query(email)
+		assert.Equal(t, nil, err)
+		ids := make([]string, 0)
+		for rows.Next() {
+			var id, em string
+			assert.Equal(t, nil, rows.Scan(&id, &em))
+			assert.Equal(t, email, em)
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if want == "" {
+			assert.Equal(t, 0, len(ids), "%q", email)
+		} else {
+			assert.Equal(t, []string{want}, ids, "%q", email)
+		}
+	}
+	_, err = stmt.Query("aaron@email.com", 2)
+	assert.NotEqual(t, nil, err, "wrong number of args")
+
+	// Joined sources are re-opened for each execution of the same plan
+	stmt2, err := db.Prepare(`
+		SELECT u.email, o.price
+		FROM users AS u
+		INNER JOIN orders AS o ON u.user_id = o.user_id
+		WHERE o.price > $1`)
+	assert.Equal(t, nil, err)
+	defer stmt2.Close()
+	for price, want := range map[float64]int{10: 2, 30: 1, 50: 0} {
+		rows, err := stmt2.Query(price)
+		assert.Equal(t, nil, err)
+		ct := 0
+		for rows.Next() {
+			var email string
+			var p float64
+			assert.Equal(t, nil, rows.Scan(&email, &p))
+			assert.True(t, p > price, "price %v > %v", p, price)
+			ct++
+		}
+		rows.Close()
+		assert.Equal(t, want, ct, "price > %v", price)
+	}
+
+	// By "Loading" table we force it to exist in this non DDL mock store
+	mockcsv.LoadTable(mockcsv.SchemaName, "user_event3", "id,user_id,event,date\n1,abcabcabc,signup,\"2012-12-24T17:29:39.738Z\"")
+
+	ins, err := db.Prepare(`INSERT into user_event3 (id, user_id, event, date) VALUES (?, ?, ?, now())`)
+	assert.Equal(t, nil, err)
+	defer ins.Close()
+	for i, event := range []string{"logon", "click"} {
+		_, err = ins.Exec(fmt.Sprintf("id%d", i), "9Ip1aKbeZe2njCDM", event)
+		assert.Equal(t, nil, err)
+	}
+
+	rows, err := db.Query(`SELECT event FROM user_event3 WHERE user_id = ?`, "9Ip1aKbeZe2njCDM")
+	assert.Equal(t, nil, err)
+	events := make([]string, 0)
+	for rows.Next() {
+		var event string
+		assert.Equal(t, nil, rows.Scan(&event))
+		events = append(events, event)
+	}
+	rows.Close()
+	assert.Equal(t, []string{"logon", "click"}, events)
+}
+
+func TestSqlDriverAnalyzeResults(t *testing.T) {
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	// each row of the results as a string, sorted
+	results := func(q interface {
+		Query(args ...interface{}) (*sql.Rows, error)
+	}) []string {
+		rows, err := q.Query()
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return nil
+		}
+		defer rows.Close()
+		cols, _ := rows.Columns()
+		out := make([]string, 0)
+		for rows.Next() {
+			vals := make([]interface{}, len(cols))
+			ptrs := make([]interface{}, len(cols))
+			for i := range vals {
+				ptrs[i] = &vals[i]
+			}
+			assert.Equal(t, nil, rows.Scan(ptrs...))
+			out = append(out, fmt.Sprintf("%v", vals))
+		}
+		assert.Equal(t, nil, rows.Err())
+		sort.Strings(out)
+		return out
+	}
+	queries := []string{
+		// the where of orders filters no rows, with stats it is evaluated
+		// after the join instead of by the source
+		"SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 1",
+		"SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 30",
+		"SELECT o.order_id, u.email FROM orders AS o INNER JOIN users AS u ON o.user_id = u.user_id WHERE o.price > 1 AND u.email != \"x\"",
+		"SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id WHERE u.email != \"x\"",
+		"SELECT u.email, o.order_id, x.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id INNER JOIN orders AS x ON o.user_id = x.user_id",
+		"SELECT u.email, count(*) AS ct FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 1 GROUP BY u.email",
+	}
+	before := make([][]string, len(queries))
+	for i, sql := range queries {
+		before[i] = results(&queryer{db, sql})
+		assert.NotEqual(t, 0, len(before[i]), sql)
+	}
+	// prepared before the stats exist
+	stmt, err := db.Prepare(queries[0])
+	assert.Equal(t, nil, err)
+	defer stmt.Close()
+	assert.Equal(t, before[0], results(stmt))
+
+	ctx := td.TestContext("SELECT 1")
+	defer func() {
+		for _, name := range []string{"users", "orders"} {
+			tbl, _ := ctx.Schema.Table(name)
+			tbl.SetStats(nil)
+		}
+	}()
+	_, err = db.Exec("ANALYZE TABLE orders, users")
+	assert.Equal(t, nil, err)
+	tbl, _ := ctx.Schema.Table("orders")
+	assert.True(t, tbl.Stats() != nil)
+
+	// the where not pushed to orders is evaluated on the joined rows
+	explain := results(&queryer{db, "EXPLAIN " + queries[0]})
+	assert.Contains(t, strings.Join(explain, "\n"), " 1 Where ")
+
+	for i, sql := range queries {
+		assert.Equal(t, before[i], results(&queryer{db, sql}), sql)
+	}
+	// the prepared statement is planned again with the stats
+	assert.Equal(t, before[0], results(stmt))
+}
+
+// queryer a query of db, with no args.
+type queryer struct {
+	db  *sql.DB
+	sql string
+}
+
+func (m *queryer) Query(args ...interface{}) (*sql.Rows, error) {
+	return m.db.Query(m.sql, args...)
+}
+
+func TestSqlDriverColumnTypes(t *testing.T) {
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), `SELECT user_id, referral_count, reg_date FROM users WHERE user_id = ?`, "9Ip1aKbeZe2njCDM")
+	assert.Equal(t, nil, err)
+	defer rows.Close()
+	cts, err := rows.ColumnTypes()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(cts))
+	names := make([]string, len(cts))
+	kinds := make([]reflect.Kind, len(cts))
+	for i, ct := range cts {
+		names[i] = ct.DatabaseTypeName()
+		kinds[i] = ct.ScanType().Kind()
+	}
+	assert.Equal(t, []string{"VARCHAR", "BIGINT", "DATETIME"}, names)
+	assert.Equal(t, []reflect.Kind{reflect.String, reflect.Int64, reflect.Struct}, kinds)
+
+	// qualified columns of joined sources, NULL of un-matched rows
+	rows, err = db.Query(`SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id ORDER BY u.email ASC`)
+	assert.Equal(t, nil, err)
+	defer rows.Close()
+	cts, err = rows.ColumnTypes()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "VARCHAR", cts[0].DatabaseTypeName())
+	assert.Equal(t, "BIGINT", cts[1].DatabaseTypeName())
+	got := make([][]interface{}, 0)
+	for rows.Next() {
+		var email, orderId interface{}
+		assert.Equal(t, nil, rows.Scan(&email, &orderId))
+		got = append(got, []interface{}{email, orderId})
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.Equal(t, 4, len(got))
+	assert.Equal(t, []interface{}{"bob@email.com", nil}, got[2])
+	assert.Equal(t, []interface{}{"not_an_email_2", nil}, got[3])
+}
+
+func TestSqlDriverContext(t *testing.T) {
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, err := db.QueryContext(ctx, `SELECT user_id FROM users`)
+	assert.Equal(t, nil, err)
+	assert.True(t, rows.Next())
+	cancel()
+	for rows.Next() {
+	}
+	assert.Equal(t, context.Canceled, rows.Err())
+	rows.Close()
+
+	_, err = db.QueryContext(ctx, `SELECT user_id FROM users`)
+	assert.Equal(t, context.Canceled, err)
+
+}
@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TaskStats runtime stats of a task, recorded as it runs:  rows received
+// on its input channel and sent on its output, wall time of its Run(), time
+// it was blocked waiting on its channels, and the peak rows buffered in its
+// input channel.  Read them once the task has finished.
+type TaskStats struct {
+	RowsIn       int64         `json:"rows_in"`
+	RowsOut      int64         `json:"rows_out"`
+	Wall         time.Duration `json:"wall_ns"`
+	Blocked      time.Duration `json:"blocked_ns"`
+	PeakBuffered int64         `json:"peak_buffered"`
+}
+
+// received count a row received from ch, blocked since wait.
+func (m *TaskStats) received(wait time.Time, ch MessageChan) {
+	atomic.AddInt64(&m.RowsIn, 1)
+	atomic.AddInt64((*int64)(&m.Blocked), int64(time.Since(wait)))
+	buffered := int64(len(ch)) + 1
+	for {
+		peak := atomic.LoadInt64(&m.PeakBuffered)
+		if buffered <= peak || atomic.CompareAndSwapInt64(&m.PeakBuffered, peak, buffered) {
+			return
+		}
+	}
+}
+
+// sent count a row sent, blocked since wait.
+func (m *TaskStats) sent(wait time.Time) {
+	atomic.AddInt64(&m.RowsOut, 1)
+	atomic.AddInt64((*int64)(&m.Blocked), int64(time.Since(wait)))
+}
+
+// run the task recording its wall time.
+func runTimed(task TaskRunner) error {
+	start := time.Now()
+	defer func() {
+		atomic.StoreInt64((*int64)(&task.Stats().Wall), int64(time.Since(start)))
+	}()
+	return task.Run()
+}
+
+// TaskAnalysis a task of an executed dag of tasks along with its runtime
+// stats, as returned by EXPLAIN ANALYZE.
+type TaskAnalysis struct {
+	Id       int    `json:"id"`
+	ParentId int    `json:"parent_id"`
+	Task     string `json:"task"`
+	Parallel bool   `json:"parallel"`
+	TaskStats
+	Children []*TaskAnalysis `json:"children,omitempty"`
+}
+
+// AnalyzeTasks describe the dag of tasks of root and their stats, numbered
+// depth first.  Sequential and parallel tasks have the rows out of their
+// last task, sequential also the rows in of their first.
+func AnalyzeTasks(root Task) *TaskAnalysis {
+	id := 0
+	return analyzeTask(root, 0, &id)
+}
+
+func analyzeTask(t Task, parentId int, id *int) *TaskAnalysis {
+	*id++
+	ta := &TaskAnalysis{Id: *id, ParentId: parentId}
+	name := fmt.Sprintf("%T", t)
+	ta.Task = name[strings.LastIndex(name, ".")+1:]
+	_, ta.Parallel = t.(*TaskParallel)
+	if tr, ok := t.(TaskRunner); ok && tr.Stats() != nil {
+		ta.TaskStats = *tr.Stats()
+	}
+	for _, c := range t.Children() {
+		ta.Children = append(ta.Children, analyzeTask(c, ta.Id, id))
+	}
+	if len(ta.Children) > 0 {
+		switch t.(type) {
+		case *TaskSequential:
+			ta.RowsIn = ta.Children[0].RowsIn
+			ta.RowsOut = ta.Children[len(ta.Children)-1].RowsOut
+		case *TaskParallel:
+			ta.RowsOut = ta.Children[len(ta.Children)-1].RowsOut
+		}
+	}
+	return ta
+}
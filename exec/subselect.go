@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// scalarSubSelect evaluates a sub-select used as a value in an expression
+//
+//    SELECT email, (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) AS ct FROM users AS u
+//    SELECT order_id FROM orders WHERE price > (SELECT avg(price) FROM orders)
+//
+// An uncorrelated sub-select is run once.  A correlated one is run once per
+// distinct values of the outer expressions of rows, with its correlated
+// conditions comparing to those values.  Results are cached by those values.
+// No rows is NULL, more than one row is an error failing the query.
+type scalarSubSelect struct {
+	ctx     *plan.Context
+	p       *plan.SubSelect
+	fail    func(error) // fail the job evaluating it
+	mu      sync.Mutex
+	results map[string]value.Value
+}
+
+func newScalarSubSelect(ctx *plan.Context, p *plan.SubSelect, fail func(error)) *scalarSubSelect {
+	return &scalarSubSelect{ctx: ctx, p: p, fail: fail, results: make(map[string]value.Value)}
+}
+
+// eval the sub-select for the row of ctx, an error fails the job.
+func (m *scalarSubSelect) eval(ctx expr.EvalContext) (value.Value, bool) {
+	v, err := m.value(ctx)
+	if err != nil {
+		u.Warnf("could not evaluate sub-select %s err=%v", m.p.Stmt, err)
+		m.fail(err)
+		return nil, false
+	}
+	return v, true
+}
+
+// value of the sub-select for the row of ctx, nil for uncorrelated.
+func (m *scalarSubSelect) value(ctx expr.EvalContext) (value.Value, error) {
+	vals := make([]value.Value, len(m.p.Outer))
+	keys := make([]string, len(m.p.Outer))
+	for i, node := range m.p.Outer {
+		v, ok := vm.Eval(ctx, node)
+		if !ok || v == nil || v.Nil() {
+			// NULL never equals an inner value, so no rows
+			return value.NewNilValue(), nil
+		}
+		vals[i] = v
+		keys[i] = v.ToString()
+	}
+	key := strings.Join(keys, string(byte(0)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.results[key]; ok {
+		return v, nil
+	}
+	v, err := m.run(vals)
+	if err != nil {
+		return nil, err
+	}
+	m.results[key] = v
+	return v, nil
+}
+
+// run the sub-select with its correlated conditions equal to vals.
+func (m *scalarSubSelect) run(vals []value.Value) (value.Value, error) {
+	stmt := m.p.Stmt
+	if len(vals) > 0 {
+		conds := make([]expr.Node, 0, len(vals)+1)
+		for i, inner := range m.p.Inner {
+			conds = append(conds, expr.NewBinaryNode(lex.Token{T: lex.TokenEqual, V: "="},
+				inner, expr.NewValueNode(vals[i])))
+		}
+		stmt = stmt.Copy()
+		if stmt.Where == nil {
+			stmt.Where = &rel.SqlWhere{}
+		}
+		if stmt.Where.Expr != nil {
+			conds = append(conds, stmt.Where.Expr)
+		}
+		stmt.Where.Expr = conds[0]
+		for _, cond := range conds[1:] {
+			stmt.Where.Expr = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, stmt.Where.Expr, cond)
+		}
+	}
+
+	ctx := plan.NewContext(stmt.String())
+	ctx.Context = m.ctx.Context
+	ctx.Schema = m.ctx.Schema
+	ctx.Session = m.ctx.Session
+	ctx.Funcs = m.ctx.Funcs
+	ctx.DisableRecover = m.ctx.DisableRecover
+	job, err := BuildSqlJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result value.Value = value.NewNilValue()
+	rows := 0
+	err = runSelect(job.RootTask, nil, func(row []driver.Value) bool {
+		rows++
+		if rows > 1 {
+			return false
+		}
+		if len(row) > 0 && row[0] != nil {
+			result = value.NewValue(row[0])
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rows > 1 {
+		return nil, fmt.Errorf("sub-select returned more than one row: %s", stmt)
+	}
+	return result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -32,6 +33,7 @@ type TaskBase struct {
 	errCh    ErrChan
 	sigCh    SigChan // notify of quit/stop
 	errors   []error
+	stats    *TaskStats
 }
 
 func NewTaskBase(ctx *plan.Context) *TaskBase {
@@ -44,6 +46,7 @@ func NewTaskBase(ctx *plan.Context) *TaskBase {
 		sigCh:    make(SigChan, 1),
 		errCh:    make(ErrChan, 10),
 		errors:   make([]error, 0),
+		stats:    &TaskStats{},
 		Ctx:      ctx,
 	}
 }
@@ -65,15 +68,13 @@ func (m *TaskBase) MessageInSet(ch MessageChan)  { m.msgInCh = ch }
 func (m *TaskBase) MessageOutSet(ch MessageChan) { m.msgOutCh = ch }
 func (m *TaskBase) ErrChan() ErrChan             { return m.errCh }
 func (m *TaskBase) SigChan() SigChan             { return m.sigCh }
+func (m *TaskBase) Stats() *TaskStats            { return m.stats }
 func (m *TaskBase) Quit() {
+	m.Lock()
+	defer m.Unlock()
 	if m.hasquit {
 		return
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			u.Errorf("Error on closing sigchannel %v", r)
-		}
-	}()
 	m.hasquit = true
 	close(m.sigCh)
 }
@@ -90,9 +91,13 @@ func (m *TaskBase) Close() error {
 		return nil
 	}
 	m.closed = true
+	hasquit := m.hasquit
+	m.hasquit = true
 	m.Unlock()
 	//u.Debugf("%p finished Close()", m)
-	close(m.sigCh)
+	if !hasquit {
+		close(m.sigCh)
+	}
 	return nil
 }
 func (m *TaskBase) CloseFinal() error { return nil }
@@ -100,8 +105,10 @@ func (m *TaskBase) CloseFinal() error { return nil }
 func MakeHandler(task TaskRunner) MessageHandler {
 	out := task.MessageOut()
 	return func(ctx *plan.Context, msg schema.Message) bool {
+		wait := time.Now()
 		select {
 		case out <- msg:
+			task.Stats().sent(wait)
 			return true
 		case <-task.SigChan():
 			return false
@@ -140,9 +147,11 @@ msgLoop:
 		}
 
 		//
+		wait := time.Now()
 		select {
 		case msg, ok = <-m.msgInCh:
 			if ok {
+				m.stats.received(wait, m.msgInCh)
 				//u.Debugf("sending to handler: %T  %+v", msg, msg)
 				m.Handler(m.Ctx, msg)
 			} else {
@@ -148,7 +148,7 @@ func (m *TaskSequential) Run() (err error) {
 		go func(taskId int) {
 			task := m.runners[taskId]
 			//u.Infof("starting task %d-%d %T in:%p  out:%p", m.depth, taskId, task, task.MessageIn(), task.MessageOut())
-			if taskErr := task.Run(); taskErr != nil {
+			if taskErr := runTimed(task); taskErr != nil {
 				u.Errorf("%T.Run() errored %v", task, taskErr)
 				// TODO:  what do we do with this error?   send to error channel?
 				err = taskErr
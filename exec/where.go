@@ -1,6 +1,8 @@
 package exec
 
 import (
+	"time"
+
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource"
@@ -137,8 +139,10 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 		}
 
 		//u.Debugf("about to send from where to forward: %#v", msg)
+		wait := time.Now()
 		select {
 		case out <- msg:
+			task.Stats().sent(wait)
 			return true
 		case <-task.SigChan():
 			return false
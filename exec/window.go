@@ -0,0 +1,412 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*Window)(nil)
+)
+
+// Window evaluates the window function columns of a select, ie
+//
+//    SELECT user_id, row_number() OVER (PARTITION BY user_id ORDER BY created) FROM orders
+//
+// All rows are read into memory, split into partitions, sorted and then
+// each window column is calculated per row.  Rows are emitted in the order
+// received with the window values added to the message so final projection
+// can read them.
+type Window struct {
+	*TaskBase
+	p        *plan.Window
+	colIndex map[string]int
+}
+
+// window partition, row positions are index into all rows.
+type windowPartition struct {
+	rows  []int
+	order [][]value.Value // order by values of each row in partition
+}
+
+// NewWindow create new window function exec task
+func NewWindow(ctx *plan.Context, p *plan.Window) *Window {
+	m := &Window{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+		colIndex: p.Stmt.ColIndexes(),
+	}
+	return m
+}
+
+// WindowKey the key window column values are stored in the message
+// under, the full expression so it does not collide with source columns.
+func WindowKey(col *rel.Column) string {
+	return fmt.Sprintf("%s OVER %s", col.Expr, col.Over)
+}
+
+func (m *Window) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+	inCh := m.MessageIn()
+
+	rows := make([]*datasource.SqlDriverMessageMap, 0)
+
+msgReadLoop:
+	for {
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				break msgReadLoop
+			}
+			m.stats.received(wait, inCh)
+			switch mt := msg.(type) {
+			case *datasource.SqlDriverMessageMap:
+				rows = append(rows, mt)
+			default:
+				msgReader, isContextReader := msg.(expr.ContextReader)
+				if !isContextReader {
+					err := fmt.Errorf("To use Window must use SqlDriverMessageMap but got %T", msg)
+					u.Errorf("unrecognized msg %T", msg)
+					m.Quit()
+					return err
+				}
+				rows = append(rows, datasource.NewSqlDriverMessageMapCtx(msg.Id(), msgReader, m.colIndex))
+			}
+		}
+	}
+
+	cols := make(rel.Columns, 0)
+	for _, col := range m.p.Stmt.Columns {
+		if col.Over != nil {
+			cols = append(cols, col)
+		}
+	}
+
+	results := make([][]driver.Value, len(cols))
+	for i, col := range cols {
+		vals, err := windowColumn(col, rows)
+		if err != nil {
+			u.Errorf("could not evaluate window column %s err=%v", col, err)
+			m.Quit()
+			return err
+		}
+		results[i] = vals
+	}
+
+	for ri, row := range rows {
+		srcVals := row.Values()
+		vals := make([]driver.Value, len(srcVals), len(srcVals)+len(cols))
+		copy(vals, srcVals)
+		colIndex := make(map[string]int, len(row.ColIndex)+2*len(cols))
+		for k, idx := range row.ColIndex {
+			colIndex[k] = idx
+		}
+		for i, col := range cols {
+			colIndex[WindowKey(col)] = len(vals)
+			// also allow referencing by alias, ie ORDER BY rn
+			if _, exists := colIndex[col.As]; !exists {
+				colIndex[col.As] = len(vals)
+			}
+			vals = append(vals, results[i][ri])
+		}
+		wait := time.Now()
+		select {
+		case <-m.SigChan():
+			return nil
+		case outCh <- datasource.NewSqlDriverMessageMap(row.Id(), vals, colIndex):
+			m.stats.sent(wait)
+		}
+	}
+	return nil
+}
+
+// windowColumn calculate the value of a window column for each row.
+func windowColumn(col *rel.Column, rows []*datasource.SqlDriverMessageMap) ([]driver.Value, error) {
+
+	fn, ok := col.Expr.(*expr.FuncNode)
+	if !ok {
+		return nil, fmt.Errorf("window column must be a function but got %T", col.Expr)
+	}
+	fnName := strings.ToLower(fn.Name)
+	over := col.Over
+
+	// split into partitions, keeping order of first appearance
+	partitions := make([]*windowPartition, 0)
+	byKey := make(map[string]*windowPartition)
+	for ri, row := range rows {
+		keys := make([]string, len(over.PartitionBy))
+		for i, n := range over.PartitionBy {
+			if v, ok := vm.Eval(row, n); ok && v != nil {
+				keys[i] = v.ToString()
+			}
+		}
+		key := strings.Join(keys, string(byte(0)))
+		part, exists := byKey[key]
+		if !exists {
+			part = &windowPartition{}
+			byKey[key] = part
+			partitions = append(partitions, part)
+		}
+		order := make([]value.Value, len(over.OrderBy))
+		for i, oc := range over.OrderBy {
+			if oc.Expr == nil {
+				continue
+			}
+			if v, ok := vm.Eval(row, oc.Expr); ok {
+				order[i] = v
+			}
+		}
+		part.rows = append(part.rows, ri)
+		part.order = append(part.order, order)
+	}
+
+	out := make([]driver.Value, len(rows))
+	for _, part := range partitions {
+		sort.Stable(&windowSorter{part: part, cols: over.OrderBy})
+		if err := part.eval(fnName, fn, over, rows, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// eval calculate the window function for each row of this (sorted) partition
+func (m *windowPartition) eval(fnName string, fn *expr.FuncNode, over *rel.WindowSpec,
+	rows []*datasource.SqlDriverMessageMap, out []driver.Value) error {
+
+	n := len(m.rows)
+	rank, denseRank := 0, 0
+	for pos, ri := range m.rows {
+		newPeer := pos == 0 || !m.peers(pos-1, pos, over.OrderBy)
+		if newPeer {
+			rank = pos + 1
+			denseRank++
+		}
+		switch fnName {
+		case "row_number":
+			out[ri] = int64(pos + 1)
+		case "rank":
+			out[ri] = int64(rank)
+		case "dense_rank":
+			out[ri] = int64(denseRank)
+		case "lag", "lead":
+			offset := int64(1)
+			if len(fn.Args) > 1 {
+				v, ok := vm.Eval(rows[ri], fn.Args[1])
+				if !ok {
+					return fmt.Errorf("could not evaluate offset of %s", fn)
+				}
+				if offset, ok = value.ValueToInt64(v); !ok || offset < 0 {
+					return fmt.Errorf("offset of %s must be non-negative integer", fn)
+				}
+			}
+			target := pos - int(offset)
+			if fnName == "lead" {
+				target = pos + int(offset)
+			}
+			if target >= 0 && target < n {
+				out[ri] = evalValue(rows[m.rows[target]], fn.Args[0])
+			} else if len(fn.Args) > 2 {
+				out[ri] = evalValue(rows[ri], fn.Args[2])
+			}
+		case "first_value", "last_value":
+			start, end := m.frame(pos, over)
+			if start > end {
+				continue
+			}
+			target := start
+			if fnName == "last_value" {
+				target = end
+			}
+			out[ri] = evalValue(rows[m.rows[target]], fn.Args[0])
+		case "count", "sum", "avg":
+			start, end := m.frame(pos, over)
+			var agg Aggregator
+			switch fnName {
+			case "count":
+				agg = NewCount(nil)
+			case "sum":
+				agg = NewSum(nil, false)
+			case "avg":
+				agg = NewAvg(nil, false)
+			}
+			for i := start; i <= end; i++ {
+				row := rows[m.rows[i]]
+				if fnName == "count" && (len(fn.Args) == 0 || fn.Args[0].String() == "*") {
+					agg.Do(value.NewIntValue(1))
+					continue
+				}
+				if v, ok := vm.Eval(row, fn); ok && v != nil {
+					agg.Do(v)
+				} else {
+					agg.Do(value.NewNilValue())
+				}
+			}
+			if start > end && fnName != "count" {
+				continue
+			}
+			out[ri] = agg.Result()
+		default:
+			return fmt.Errorf("Not implemented window function: %s", fn)
+		}
+	}
+	return nil
+}
+
+// frame find the start, end (inclusive) positions of the window frame
+// for row at pos.  If start > end the frame is empty.
+func (m *windowPartition) frame(pos int, over *rel.WindowSpec) (int, int) {
+	n := len(m.rows)
+	f := over.Frame
+	if f == nil {
+		if len(over.OrderBy) == 0 {
+			// no order, the whole partition is the frame
+			return 0, n - 1
+		}
+		// RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+		return 0, m.lastPeer(pos, over.OrderBy)
+	}
+	start := m.bound(pos, f.Unit, f.Start, true, over.OrderBy)
+	end := m.lastPeer(pos, over.OrderBy)
+	if f.Unit == lex.TokenRows {
+		end = pos
+	}
+	if f.End != nil {
+		end = m.bound(pos, f.Unit, f.End, false, over.OrderBy)
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > n-1 {
+		end = n - 1
+	}
+	return start, end
+}
+
+// bound find the position of a frame start (or end) bound.
+func (m *windowPartition) bound(pos int, unit lex.TokenType, b *rel.WindowFrameBound, isStart bool, cols rel.Columns) int {
+	n := len(m.rows)
+	switch {
+	case b.Unbounded && b.Type == lex.TokenPreceding:
+		return 0
+	case b.Unbounded && b.Type == lex.TokenFollowing:
+		return n - 1
+	case b.Type == lex.TokenCurrentRow:
+		if unit == lex.TokenRows {
+			return pos
+		}
+		if isStart {
+			return m.firstPeer(pos, cols)
+		}
+		return m.lastPeer(pos, cols)
+	}
+
+	offset := b.Offset
+	if b.Type == lex.TokenPreceding {
+		offset = -offset
+	}
+	if unit == lex.TokenRows {
+		return pos + int(offset)
+	}
+
+	// RANGE with offset, distance on the single order by value
+	cur, ok := value.ValueToFloat64(m.order[pos][0])
+	if !ok {
+		if isStart {
+			return m.firstPeer(pos, cols)
+		}
+		return m.lastPeer(pos, cols)
+	}
+	dir := float64(1)
+	if !cols[0].Asc() {
+		dir = -1
+	}
+	distance := func(i int) (float64, bool) {
+		v, ok := value.ValueToFloat64(m.order[i][0])
+		return (v - cur) * dir, ok
+	}
+	if isStart {
+		for i := 0; i < n; i++ {
+			if d, ok := distance(i); ok && d >= float64(offset) {
+				return i
+			}
+		}
+		return n
+	}
+	for i := n - 1; i >= 0; i-- {
+		if d, ok := distance(i); ok && d <= float64(offset) {
+			return i
+		}
+	}
+	return -1
+}
+
+// peers are rows with equal order by values
+func (m *windowPartition) peers(i, j int, cols rel.Columns) bool {
+	for k := range cols {
+		if compareValues(m.order[i][k], m.order[j][k], false) != 0 {
+			return false
+		}
+	}
+	return true
+}
+func (m *windowPartition) firstPeer(pos int, cols rel.Columns) int {
+	for pos > 0 && m.peers(pos-1, pos, cols) {
+		pos--
+	}
+	return pos
+}
+func (m *windowPartition) lastPeer(pos int, cols rel.Columns) int {
+	for pos < len(m.rows)-1 && m.peers(pos, pos+1, cols) {
+		pos++
+	}
+	return pos
+}
+
+// windowSorter sorts a partition by its order by values.
+type windowSorter struct {
+	part *windowPartition
+	cols rel.Columns
+}
+
+func (m *windowSorter) Len() int { return len(m.part.rows) }
+func (m *windowSorter) Less(i, j int) bool {
+	for k, col := range m.cols {
+		if c := compareOrder(m.part.order[i][k], m.part.order[j][k], col, false); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+func (m *windowSorter) Swap(i, j int) {
+	m.part.rows[i], m.part.rows[j] = m.part.rows[j], m.part.rows[i]
+	m.part.order[i], m.part.order[j] = m.part.order[j], m.part.order[i]
+}
+
+func evalValue(row *datasource.SqlDriverMessageMap, arg expr.Node) driver.Value {
+	v, ok := vm.Eval(row, arg)
+	if !ok || v == nil {
+		return nil
+	}
+	return v.Value()
+}
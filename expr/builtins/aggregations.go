@@ -155,3 +155,158 @@ func incrementEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool)
 	}
 	return value.NewIntValue(1), true
 }
+
+// Min smallest of values of a group, this function DOES NOT persist state,
+// per row it evaluates to its arg, the exec GroupBy aggregates across rows.
+//
+//    min(price)  => "22.50", true
+//
+type Min struct{}
+
+// Type is Unknown, same as its arg
+func (m *Min) Type() value.ValueType { return value.UnknownType }
+func (m *Min) IsAgg() bool           { return true }
+func (m *Min) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for min(arg) but got %s", n)
+	}
+	return aggValueEval, nil
+}
+
+// Max largest of values of a group, this function DOES NOT persist state,
+// per row it evaluates to its arg, the exec GroupBy aggregates across rows.
+//
+//    max(price)  => "37.50", true
+//
+type Max struct{}
+
+// Type is Unknown, same as its arg
+func (m *Max) Type() value.ValueType { return value.UnknownType }
+func (m *Max) IsAgg() bool           { return true }
+func (m *Max) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for max(arg) but got %s", n)
+	}
+	return aggValueEval, nil
+}
+
+// StdDev population standard deviation of values of a group, this function
+// DOES NOT persist state, per row it evaluates to its arg as a number.
+//
+//    stddev(price)  => 22.5, true
+//
+type StdDev struct{}
+
+// Type is number
+func (m *StdDev) Type() value.ValueType { return value.NumberType }
+func (m *StdDev) IsAgg() bool           { return true }
+func (m *StdDev) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for stddev(arg) but got %s", n)
+	}
+	return aggNumberEval, nil
+}
+
+// Variance population variance of values of a group, this function
+// DOES NOT persist state, per row it evaluates to its arg as a number.
+//
+//    variance(price)  => 22.5, true
+//
+type Variance struct{}
+
+// Type is number
+func (m *Variance) Type() value.ValueType { return value.NumberType }
+func (m *Variance) IsAgg() bool           { return true }
+func (m *Variance) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for variance(arg) but got %s", n)
+	}
+	return aggNumberEval, nil
+}
+
+// Median middle value of values of a group, or average of the two middle
+// values for an even count.  This function DOES NOT persist state, per row
+// it evaluates to its arg as a number.
+//
+//    median(price)  => 22.5, true
+//
+type Median struct{}
+
+// Type is number
+func (m *Median) Type() value.ValueType { return value.NumberType }
+func (m *Median) IsAgg() bool           { return true }
+func (m *Median) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for median(arg) but got %s", n)
+	}
+	return aggNumberEval, nil
+}
+
+// GroupConcat concatenate non-null values of a group separated by optional
+// separator, default ",".  This function DOES NOT persist state, per row it
+// evaluates to its arg as a string.
+//
+//    group_concat(item_id)        => "1,2", true
+//    group_concat(item_id, " | ") => "1 | 2", true
+//
+type GroupConcat struct{}
+
+// Type is string
+func (m *GroupConcat) Type() value.ValueType { return value.StringType }
+func (m *GroupConcat) IsAgg() bool           { return true }
+func (m *GroupConcat) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 2 {
+		return nil, fmt.Errorf("Expected 1 or 2 args for group_concat(arg, [separator]) but got %s", n)
+	}
+	return groupConcatEval, nil
+}
+
+// Grouping of a GROUP BY ROLLUP, CUBE or GROUPING SETS result row, a bit per
+// arg that is set if the arg is rolled up in the row, ie is not grouped on
+// in the grouping set of the row, first arg highest bit.  This function DOES
+// NOT persist state, it is calculated by the group by, per row it is 0.
+//
+//    SELECT a, b, grouping(a, b) FROM t GROUP BY ROLLUP (a, b)
+//
+//    grouping(a, b) => 0 for (a, b), 1 for (a), 3 for ()
+//
+type Grouping struct{}
+
+// Type is integer
+func (m *Grouping) Type() value.ValueType { return value.IntType }
+func (m *Grouping) IsAgg() bool           { return true }
+func (m *Grouping) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 {
+		return nil, fmt.Errorf("Expected 1 or more args for grouping(arg, arg, ...) but got %s", n)
+	}
+	return groupingEval, nil
+}
+
+func groupingEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	return value.NewIntValue(0), true
+}
+
+func aggValueEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return nil, false
+	}
+	return vals[0], true
+}
+
+func aggNumberEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return value.NumberNaNValue, false
+	}
+	fv, ok := value.ValueToFloat64(vals[0])
+	if !ok || math.IsNaN(fv) {
+		return value.NumberNaNValue, false
+	}
+	return value.NewNumberValue(fv), true
+}
+
+func groupConcatEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return nil, false
+	}
+	return value.NewStringValue(vals[0].ToString()), true
+}
@@ -24,11 +24,28 @@ func LoadAllBuiltins() {
 		// math
 		expr.FuncAdd("sqrt", &Sqrt{})
 		expr.FuncAdd("pow", &Pow{})
+		expr.FuncAdd("round", &Round{})
 
 		// aggregate ops
 		expr.FuncAdd("count", &Count{})
 		expr.FuncAdd("avg", &Avg{})
 		expr.FuncAdd("sum", &Sum{})
+		expr.FuncAdd("min", &Min{})
+		expr.FuncAdd("max", &Max{})
+		expr.FuncAdd("stddev", &StdDev{})
+		expr.FuncAdd("variance", &Variance{})
+		expr.FuncAdd("median", &Median{})
+		expr.FuncAdd("group_concat", &GroupConcat{})
+		expr.FuncAdd("grouping", &Grouping{})
+
+		// window functions, only valid with OVER (...)
+		expr.FuncAdd("row_number", &RowNumber{})
+		expr.FuncAdd("rank", &Rank{})
+		expr.FuncAdd("dense_rank", &DenseRank{})
+		expr.FuncAdd("lag", &Lag{})
+		expr.FuncAdd("lead", &Lead{})
+		expr.FuncAdd("first_value", &FirstValue{})
+		expr.FuncAdd("last_value", &LastValue{})
 
 		// logical
 		expr.FuncAdd("gt", &Gt{})
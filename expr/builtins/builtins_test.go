@@ -768,6 +768,11 @@ var builtinTests = []testBuiltins{
 	{`sqrt(NotAField)`, value.ErrValue},
 	{`sqrt("hello")`, value.ErrValue},
 
+	{`round(2.5)`, value.NewNumberValue(3)},
+	{`round(3.14159, 2)`, value.NewNumberValue(3.14)},
+	{`round("22.56", 1)`, value.NewNumberValue(22.6)},
+	{`round(NotAField)`, value.ErrValue},
+
 	// Aggregation functions
 	{`sum(1,2)`, value.NewNumberValue(3)},
 	{`sum(1,[2,3])`, value.NewNumberValue(6)},
@@ -243,6 +243,9 @@ type MapTime struct{}
 
 // Type MapTime
 func (m *MapTime) Type() value.ValueType { return value.MapTimeType }
+
+// ReadsContext yes, the timestamp of context if no time arg.
+func (m *MapTime) ReadsContext() bool { return true }
 func (m *MapTime) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 	if len(n.Args) == 0 || len(n.Args) > 2 {
 		return nil, fmt.Errorf("Expected 1 or 2 args for MapTime() but got %s", n)
@@ -294,6 +297,9 @@ type Match struct{}
 // Type is MapValueType
 func (m *Match) Type() value.ValueType { return value.MapValueType }
 
+// ReadsContext yes, matches the keys of context.
+func (m *Match) ReadsContext() bool { return true }
+
 func (m *Match) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 	if len(n.Args) < 1 {
 		return nil, fmt.Errorf("Expected 1 or more arg for Match(arg) but got %s", n)
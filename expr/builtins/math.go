@@ -77,3 +77,45 @@ func powerEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 	fv = math.Pow(fv, pow)
 	return value.NewNumberValue(fv), true
 }
+
+// Round round x to the nearest integer, or to n decimal places
+//
+//    round(2.5)            =>  3, true
+//    round(3.14159, 2)     =>  3.14, true
+//    round(not_number)     =>  NilNumber, false
+//
+type Round struct{}
+
+// Type is Number
+func (m *Round) Type() value.ValueType { return value.NumberType }
+
+// Must have 1 or 2 arguments, both must be able to be coerced to Number
+func (m *Round) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 2 {
+		return nil, fmt.Errorf("Expected 1 or 2 args for Round(number, [places]) but got %s", n)
+	}
+	return roundEval, nil
+}
+
+func roundEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return value.NewNumberNil(), false
+	}
+	fv, _ := value.ValueToFloat64(args[0])
+	if math.IsNaN(fv) {
+		return value.NewNumberNil(), false
+	}
+	places := float64(0)
+	if len(args) > 1 {
+		if args[1] == nil || args[1].Err() || args[1].Nil() {
+			return value.NewNumberNil(), false
+		}
+		places, _ = value.ValueToFloat64(args[1])
+		if math.IsNaN(places) {
+			return value.NewNumberNil(), false
+		}
+	}
+	shift := math.Pow(10, math.Trunc(places))
+	return value.NewNumberValue(math.Round(fv*shift) / shift), true
+}
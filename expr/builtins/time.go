@@ -308,6 +308,9 @@ type ToDate struct{}
 
 // Type time
 func (m *ToDate) Type() value.ValueType { return value.TimeType }
+
+// ReadsContext yes, "now-1d" date math is relative to current time.
+func (m *ToDate) ReadsContext() bool { return true }
 func (m *ToDate) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 	if len(n.Args) == 0 || len(n.Args) > 2 {
 		return nil, fmt.Errorf(`Expected 1 or 2 args for ToDate([format] , field) but got %s`, n)
@@ -366,6 +369,9 @@ type ToDateIn struct{}
 
 // Type time
 func (m *ToDateIn) Type() value.ValueType { return value.TimeType }
+
+// ReadsContext yes, "now-1d" date math is relative to current time.
+func (m *ToDateIn) ReadsContext() bool { return true }
 func (m *ToDateIn) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 	if len(n.Args) != 2 {
 		return nil, fmt.Errorf(`Expected args for todatein( (field | "now-3h" ), location) but got %s`, n)
@@ -0,0 +1,121 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Window functions are evaluated across the rows of a window partition
+// by the exec Window task, they have no per-row evaluation so outside
+// of a window (no OVER clause) they evaluate to nil, false.
+
+// RowNumber sequential number of row within its partition starting at 1.
+//
+//    row_number() OVER (PARTITION BY category ORDER BY price)  =>  1, 2, 3 ...
+//
+type RowNumber struct{}
+
+// Type is Integer
+func (m *RowNumber) Type() value.ValueType { return value.IntType }
+func (m *RowNumber) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 0 {
+		return nil, fmt.Errorf("Expected 0 args for row_number() but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// Rank of row within its partition, rows with equal ORDER BY values
+// share the same rank leaving gaps.
+//
+//    rank() OVER (ORDER BY score DESC)  =>  1, 2, 2, 4
+//
+type Rank struct{}
+
+// Type is Integer
+func (m *Rank) Type() value.ValueType { return value.IntType }
+func (m *Rank) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 0 {
+		return nil, fmt.Errorf("Expected 0 args for rank() but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// DenseRank rank of row within its partition without gaps.
+//
+//    dense_rank() OVER (ORDER BY score DESC)  =>  1, 2, 2, 3
+//
+type DenseRank struct{}
+
+// Type is Integer
+func (m *DenseRank) Type() value.ValueType { return value.IntType }
+func (m *DenseRank) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 0 {
+		return nil, fmt.Errorf("Expected 0 args for dense_rank() but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// Lag value of expression from the row offset (default 1) rows before
+// current row in partition, or default (nil) if no such row.
+//
+//    lag(price) OVER (ORDER BY ts)
+//    lag(price, 2, 0) OVER (ORDER BY ts)
+//
+type Lag struct{}
+
+// Type is unknown, type of expression
+func (m *Lag) Type() value.ValueType { return value.UnknownType }
+func (m *Lag) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 3 {
+		return nil, fmt.Errorf("Expected 1 to 3 args for lag(expr [, offset [, default]]) but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// Lead value of expression from the row offset (default 1) rows after
+// current row in partition, or default (nil) if no such row.
+//
+//    lead(price) OVER (ORDER BY ts)
+//
+type Lead struct{}
+
+// Type is unknown, type of expression
+func (m *Lead) Type() value.ValueType { return value.UnknownType }
+func (m *Lead) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 3 {
+		return nil, fmt.Errorf("Expected 1 to 3 args for lead(expr [, offset [, default]]) but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// FirstValue value of expression for first row of the window frame.
+//
+//    first_value(name) OVER (PARTITION BY category ORDER BY price)
+//
+type FirstValue struct{}
+
+// Type is unknown, type of expression
+func (m *FirstValue) Type() value.ValueType { return value.UnknownType }
+func (m *FirstValue) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for first_value(expr) but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
+
+// LastValue value of expression for last row of the window frame.
+//
+//    last_value(name) OVER (PARTITION BY category ORDER BY price)
+//
+type LastValue struct{}
+
+// Type is unknown, type of expression
+func (m *LastValue) Type() value.ValueType { return value.UnknownType }
+func (m *LastValue) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for last_value(expr) but got %s", n)
+	}
+	return expr.EmptyEvalFunc, nil
+}
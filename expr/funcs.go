@@ -28,6 +28,13 @@ type (
 	AggFunc interface {
 		IsAgg() bool
 	}
+	// ContextFunc allows custom functions to specify if they read the
+	// evaluation context (row, timestamp) or current time, so they don't
+	// return the same value for the same literal args and aren't folded
+	// by Optimize.
+	ContextFunc interface {
+		ReadsContext() bool
+	}
 	// FuncResolver is a function resolution interface that allows
 	// local/namespaced function resolution.
 	FuncResolver interface {
@@ -34,6 +34,11 @@ var (
 	// ErrIncludeNotFound Include Not Found
 	ErrIncludeNotFound = fmt.Errorf("Include Not Found")
 
+	// SubSelectParser parses the sql of a sub-select used as a value in an
+	// expression.  Sql statements are not known to expr, so it is registered
+	// by the rel package, without it sub-selects cannot be parsed.
+	SubSelectParser func(sql string, fr FuncResolver) (SubSelect, error)
+
 	// a static nil includer whose job is to return errors
 	// for vm's that don't have an includer
 	noIncluder = &IncludeContext{}
@@ -57,6 +62,7 @@ var (
 	_ NodeArgs = (*FuncNode)(nil)
 	_ NodeArgs = (*UnaryNode)(nil)
 	_ NodeArgs = (*ArrayNode)(nil)
+	_ NodeArgs = (*CaseNode)(nil)
 )
 
 type (
@@ -145,6 +151,12 @@ type (
 		Ts() time.Time
 	}
 
+	// SubSelect is the sql select statement of a SubSelectNode.
+	SubSelect interface {
+		String() string
+		WriteDialect(w DialectWriter)
+	}
+
 	// ContextWriter For evaluation storage
 	// vm writes results to this after evaluation
 	ContextWriter interface {
@@ -309,6 +321,41 @@ type (
 		wraptype string //  (   or [
 		Args     []Node
 	}
+
+	// CaseNode is a CASE expression, with an optional Arg it is a simple
+	// case comparing Arg to each WHEN, else each WHEN is a boolean expression.
+	//
+	//    CASE WHEN x > 5 THEN "big" WHEN x > 2 THEN "medium" ELSE "small" END
+	//    CASE x WHEN 1 THEN "one" WHEN 2 THEN "two" END
+	CaseNode struct {
+		Arg   Node   // Arg of simple case, nil for searched case
+		Whens []Node // WHEN expressions, one per Then
+		Thens []Node // THEN result expressions
+		Else  Node   // optional ELSE result
+	}
+
+	// SubSelectNode is a select statement in parens used as a value, the
+	// single column of its single row, or NULL if it has no rows.
+	//
+	//    price > (SELECT avg(price) FROM items)
+	//    (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id)
+	SubSelectNode struct {
+		Stmt SubSelect // The select statement
+		// Eval evaluates the sub-select for the row of ctx, set by the
+		// executor running the statement this node belongs to.
+		Eval func(ctx EvalContext) (value.Value, bool)
+	}
+
+	// ParamNode is a bind parameter placeholder, its value bound by the
+	// caller before the statement is evaluated, written as ? numbered in
+	// order of the statement, or as $N.
+	//
+	//    SELECT name FROM users WHERE user_id = ? AND age > $2
+	ParamNode struct {
+		Index int         // zero based position of the param in the args bound
+		Text  string      // ? or $N as written
+		Value value.Value // bound value, nil until bound
+	}
 )
 
 // Includer defines an interface used for resolving INCLUDE clauses into a
@@ -395,6 +442,88 @@ func findIdentities(node Node, l IdentityNodes) IdentityNodes {
 		for _, arg := range n.Args {
 			l = findIdentities(arg, l)
 		}
+	case *CaseNode:
+		for _, arg := range n.ChildrenArgs() {
+			l = findIdentities(arg, l)
+		}
+	}
+	return l
+}
+
+// FindAllSubSelects find all sub-select nodes in an expression, not
+// including those nested inside of the sub-selects themselves.
+func FindAllSubSelects(node Node) []*SubSelectNode {
+	return findSubSelects(node, nil)
+}
+func findSubSelects(node Node, l []*SubSelectNode) []*SubSelectNode {
+	switch n := node.(type) {
+	case *SubSelectNode:
+		l = append(l, n)
+	case *UnaryNode:
+		l = findSubSelects(n.Arg, l)
+	case NodeArgs:
+		for _, arg := range n.ChildrenArgs() {
+			l = findSubSelects(arg, l)
+		}
+	}
+	return l
+}
+
+// FindAllParams find all bind parameter nodes in an expression, not
+// including those of sub-selects.
+func FindAllParams(node Node) []*ParamNode {
+	return findParams(node, nil)
+}
+func findParams(node Node, l []*ParamNode) []*ParamNode {
+	switch n := node.(type) {
+	case *ParamNode:
+		l = append(l, n)
+	case *UnaryNode:
+		l = findParams(n.Arg, l)
+	case NodeArgs:
+		for _, arg := range n.ChildrenArgs() {
+			l = findParams(arg, l)
+		}
+	}
+	return l
+}
+
+// NumParams the number of args to bind to bind parameters params, one for
+// each position up to the highest referenced.
+func NumParams(params []*ParamNode) int {
+	n := 0
+	for _, p := range params {
+		if p.Index >= n {
+			n = p.Index + 1
+		}
+	}
+	return n
+}
+
+// FindAllAggregates find all aggregate funcs in an expression, not
+// including those nested inside of the aggregates args or sub-selects.
+//
+//     round(avg(x), 2)           == {avg(x)}
+//     sum(price) / count(*)      == {sum(price), count(*)}
+//
+func FindAllAggregates(node Node) []*FuncNode {
+	return findAggregates(node, nil)
+}
+func findAggregates(node Node, l []*FuncNode) []*FuncNode {
+	switch n := node.(type) {
+	case *FuncNode:
+		if n.F.Aggregate {
+			return append(l, n)
+		}
+		for _, arg := range n.Args {
+			l = findAggregates(arg, l)
+		}
+	case *UnaryNode:
+		l = findAggregates(n.Arg, l)
+	case NodeArgs:
+		for _, arg := range n.ChildrenArgs() {
+			l = findAggregates(arg, l)
+		}
 	}
 	return l
 }
@@ -2018,6 +2147,370 @@ func (m *ArrayNode) Equal(n Node) bool {
 	return false
 }
 
+// NewCaseNode create a CASE expression, arg is nil for searched case
+//
+//    CASE [@arg] WHEN @when THEN @then [WHEN ...] [ELSE @else] END
+//
+func NewCaseNode(arg Node) *CaseNode {
+	return &CaseNode{Arg: arg}
+}
+func (m *CaseNode) NodeType() string { return "Case" }
+func (m *CaseNode) String() string {
+	w := NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *CaseNode) WriteDialect(w DialectWriter) {
+	io.WriteString(w, "CASE ")
+	if m.Arg != nil {
+		m.Arg.WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	for i, when := range m.Whens {
+		io.WriteString(w, "WHEN ")
+		when.WriteDialect(w)
+		io.WriteString(w, " THEN ")
+		m.Thens[i].WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	if m.Else != nil {
+		io.WriteString(w, "ELSE ")
+		m.Else.WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	io.WriteString(w, "END")
+}
+
+// Append a WHEN <when> THEN <then> pair
+func (m *CaseNode) Append(when, then Node) {
+	m.Whens = append(m.Whens, when)
+	m.Thens = append(m.Thens, then)
+}
+func (m *CaseNode) Validate() error {
+	if len(m.Whens) == 0 {
+		return fmt.Errorf("CASE requires at least one WHEN")
+	}
+	if len(m.Whens) != len(m.Thens) {
+		return fmt.Errorf("CASE requires a THEN for each WHEN")
+	}
+	for _, n := range m.ChildrenArgs() {
+		if err := n.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChildrenArgs all child nodes, [arg] when, then, ... [else]
+func (m *CaseNode) ChildrenArgs() []Node {
+	args := make([]Node, 0, len(m.Whens)*2+2)
+	if m.Arg != nil {
+		args = append(args, m.Arg)
+	}
+	for i, when := range m.Whens {
+		args = append(args, when, m.Thens[i])
+	}
+	if m.Else != nil {
+		args = append(args, m.Else)
+	}
+	return args
+}
+func (m *CaseNode) NodePb() *NodePb {
+	n := &CaseNodePb{
+		Whens: make([]NodePb, len(m.Whens)),
+		Thens: make([]NodePb, len(m.Thens)),
+	}
+	if m.Arg != nil {
+		n.Arg = m.Arg.NodePb()
+	}
+	for i, arg := range m.Whens {
+		n.Whens[i] = *arg.NodePb()
+	}
+	for i, arg := range m.Thens {
+		n.Thens[i] = *arg.NodePb()
+	}
+	if m.Else != nil {
+		n.Else = m.Else.NodePb()
+	}
+	return &NodePb{Cn: n}
+}
+func (m *CaseNode) FromPB(n *NodePb) Node {
+	return &CaseNode{
+		Arg:   NodeFromNodePb(n.Cn.Arg),
+		Whens: NodesFromNodesPb(n.Cn.Whens),
+		Thens: NodesFromNodesPb(n.Cn.Thens),
+		Else:  NodeFromNodePb(n.Cn.Else),
+	}
+}
+
+// Expr of case is
+//
+//    {"op":"case","args":[<arg>,{"op":"when","args":[<when>,<then>]},{"op":"else","args":[<else>]}]}
+func (m *CaseNode) Expr() *Expr {
+	fe := &Expr{Op: "case"}
+	if m.Arg != nil {
+		fe.Args = append(fe.Args, m.Arg.Expr())
+	}
+	for i, when := range m.Whens {
+		fe.Args = append(fe.Args, &Expr{Op: "when", Args: []*Expr{when.Expr(), m.Thens[i].Expr()}})
+	}
+	if m.Else != nil {
+		fe.Args = append(fe.Args, &Expr{Op: "else", Args: []*Expr{m.Else.Expr()}})
+	}
+	return fe
+}
+func (m *CaseNode) FromExpr(e *Expr) error {
+	if strings.ToLower(e.Op) != "case" {
+		return fmt.Errorf("unrecognized CaseNode op %q", e.Op)
+	}
+	for i, arg := range e.Args {
+		switch strings.ToLower(arg.Op) {
+		case "when":
+			if len(arg.Args) != 2 {
+				return fmt.Errorf("Invalid CaseNode, WHEN expected 2 args %+v", arg)
+			}
+			args, err := NodesFromExprs(arg.Args)
+			if err != nil {
+				return err
+			}
+			m.Append(args[0], args[1])
+		case "else":
+			if len(arg.Args) != 1 {
+				return fmt.Errorf("Invalid CaseNode, ELSE expected 1 arg %+v", arg)
+			}
+			n, err := NodeFromExpr(arg.Args[0])
+			if err != nil {
+				return err
+			}
+			m.Else = n
+		default:
+			if i != 0 {
+				return fmt.Errorf("Invalid CaseNode, unexpected arg %+v", arg)
+			}
+			n, err := NodeFromExpr(arg)
+			if err != nil {
+				return err
+			}
+			m.Arg = n
+		}
+	}
+	if len(m.Whens) == 0 {
+		return fmt.Errorf("Invalid CaseNode, expected WHEN args %+v", e)
+	}
+	return nil
+}
+func (m *CaseNode) Equal(n Node) bool {
+	if m == nil && n == nil {
+		return true
+	}
+	if m == nil && n != nil {
+		return false
+	}
+	if m != nil && n == nil {
+		return false
+	}
+	nt, ok := n.(*CaseNode)
+	if !ok {
+		return false
+	}
+	if (m.Arg == nil) != (nt.Arg == nil) || (m.Else == nil) != (nt.Else == nil) {
+		return false
+	}
+	if m.Arg != nil && !m.Arg.Equal(nt.Arg) {
+		return false
+	}
+	if m.Else != nil && !m.Else.Equal(nt.Else) {
+		return false
+	}
+	if len(m.Whens) != len(nt.Whens) || len(m.Thens) != len(nt.Thens) {
+		return false
+	}
+	for i, arg := range nt.Whens {
+		if !arg.Equal(m.Whens[i]) {
+			return false
+		}
+	}
+	for i, arg := range nt.Thens {
+		if !arg.Equal(m.Thens[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewSubSelectNode create a sub-select node of the given select statement
+//
+//    (SELECT ...)
+//
+func NewSubSelectNode(stmt SubSelect) *SubSelectNode {
+	return &SubSelectNode{Stmt: stmt}
+}
+
+// parseSubSelect the sql of a sub-select using the registered SubSelectParser
+func parseSubSelect(sql string, fr FuncResolver) (*SubSelectNode, error) {
+	if SubSelectParser == nil {
+		return nil, fmt.Errorf("sub-select not supported, no SubSelectParser registered: %s", sql)
+	}
+	stmt, err := SubSelectParser(sql, fr)
+	if err != nil {
+		return nil, err
+	}
+	return NewSubSelectNode(stmt), nil
+}
+func (m *SubSelectNode) NodeType() string { return "SubSelect" }
+func (m *SubSelectNode) String() string {
+	w := NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SubSelectNode) WriteDialect(w DialectWriter) {
+	io.WriteString(w, "(")
+	if m.Stmt != nil {
+		m.Stmt.WriteDialect(w)
+	}
+	io.WriteString(w, ")")
+}
+func (m *SubSelectNode) Validate() error {
+	if m.Stmt == nil {
+		return fmt.Errorf("sub-select requires a select statement")
+	}
+	return nil
+}
+func (m *SubSelectNode) NodePb() *NodePb {
+	return &NodePb{Ssn: &SubSelectNodePb{Sql: m.Stmt.String()}}
+}
+func (m *SubSelectNode) FromPB(n *NodePb) Node {
+	ss, err := parseSubSelect(n.Ssn.Sql, nil)
+	if err != nil {
+		u.Errorf("could not parse sub-select %q err=%v", n.Ssn.Sql, err)
+		return nil
+	}
+	return ss
+}
+
+// Expr of sub-select is
+//
+//    {"op":"select","val":"SELECT ..."}
+func (m *SubSelectNode) Expr() *Expr {
+	return &Expr{Op: "select", Value: m.Stmt.String()}
+}
+func (m *SubSelectNode) FromExpr(e *Expr) error {
+	if strings.ToLower(e.Op) != "select" {
+		return fmt.Errorf("unrecognized SubSelectNode op %q", e.Op)
+	}
+	ss, err := parseSubSelect(e.Value, nil)
+	if err != nil {
+		return err
+	}
+	m.Stmt = ss.Stmt
+	return nil
+}
+func (m *SubSelectNode) Equal(n Node) bool {
+	if m == nil && n == nil {
+		return true
+	}
+	if m == nil && n != nil {
+		return false
+	}
+	if m != nil && n == nil {
+		return false
+	}
+	nt, ok := n.(*SubSelectNode)
+	if !ok {
+		return false
+	}
+	if (m.Stmt == nil) != (nt.Stmt == nil) {
+		return false
+	}
+	return m.Stmt == nil || m.Stmt.String() == nt.Stmt.String()
+}
+
+// NewParamNode create a bind parameter node of a ? or $N token, pos the
+// position of a ? among the ? params of its statement.
+func NewParamNode(tok lex.Token, pos int) (*ParamNode, error) {
+	if tok.V == "?" {
+		return &ParamNode{Index: pos, Text: tok.V}, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(tok.V, "$"))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid bind parameter %q", tok.V)
+	}
+	return &ParamNode{Index: n - 1, Text: tok.V}, nil
+}
+func (m *ParamNode) NodeType() string { return "Param" }
+func (m *ParamNode) String() string {
+	w := NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+
+// WriteDialect writes the bound value, so statements written to re-parse
+// or to send to a source keep it, else the placeholder.
+func (m *ParamNode) WriteDialect(w DialectWriter) {
+	if m.Value != nil {
+		w.WriteValue(m.Value)
+		return
+	}
+	io.WriteString(w, m.Text)
+}
+func (m *ParamNode) Validate() error {
+	if m.Index < 0 {
+		return fmt.Errorf("invalid bind parameter %q", m.Text)
+	}
+	return nil
+}
+
+// NodePb of a bound param is the pb of its value as a literal.
+func (m *ParamNode) NodePb() *NodePb {
+	if m.Value != nil {
+		if _, isNil := m.Value.(value.NilValue); isNil {
+			return &NodePb{Niln: &NullNodePb{}}
+		}
+		if lit := literalNode(m.Value); lit != nil {
+			return lit.NodePb()
+		}
+		return NewStringNode(m.Value.ToString()).NodePb()
+	}
+	return &NodePb{Pn: &ParamNodePb{Index: int32(m.Index), Text: m.Text}}
+}
+func (m *ParamNode) FromPB(n *NodePb) Node {
+	return &ParamNode{Index: int(n.Pn.Index), Text: n.Pn.Text}
+}
+
+// Expr of param is
+//
+//    {"op":"param","val":"$1"}
+func (m *ParamNode) Expr() *Expr {
+	return &Expr{Op: "param", Value: fmt.Sprintf("$%d", m.Index+1)}
+}
+func (m *ParamNode) FromExpr(e *Expr) error {
+	if strings.ToLower(e.Op) != "param" {
+		return fmt.Errorf("unrecognized ParamNode op %q", e.Op)
+	}
+	pn, err := NewParamNode(lex.Token{T: lex.TokenParam, V: e.Value}, 0)
+	if err != nil {
+		return err
+	}
+	*m = *pn
+	return nil
+}
+func (m *ParamNode) Equal(n Node) bool {
+	if m == nil && n == nil {
+		return true
+	}
+	if m == nil && n != nil {
+		return false
+	}
+	if m != nil && n == nil {
+		return false
+	}
+	nt, ok := n.(*ParamNode)
+	if !ok {
+		return false
+	}
+	return m.Index == nt.Index && m.Text == nt.Text
+}
+
 // Node serialization helpers
 func tokenFromInt(iv int32) lex.Token {
 	t, ok := lex.TokenNameMap[lex.TokenType(iv)]
@@ -2077,6 +2570,15 @@ func NodeFromNodePb(n *NodePb) Node {
 		return in.FromPB(n)
 	case n.Niln != nil:
 		return &NullNode{}
+	case n.Cn != nil:
+		var cn *CaseNode
+		return cn.FromPB(n)
+	case n.Ssn != nil:
+		var ssn *SubSelectNode
+		return ssn.FromPB(n)
+	case n.Pn != nil:
+		var pn *ParamNode
+		return pn.FromPB(n)
 	}
 	return nil
 }
@@ -2201,6 +2703,12 @@ func NodeFromExpr(e *Expr) (Node, error) {
 			n = &UnaryNode{}
 		case "BETWEEN":
 			n = &TriNode{}
+		case "CASE":
+			n = &CaseNode{}
+		case "SELECT":
+			n = &SubSelectNode{}
+		case "PARAM":
+			n = &ParamNode{}
 		case "=", "-", "+", "++", "+=", "/", "%", "==", "<=", "!=", ">=", ">", "<", "*",
 			"LIKE", "CONTAINS", "INTERSECTS", "IN":
 
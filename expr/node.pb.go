@@ -23,6 +23,9 @@
 		NumberNodePb
 		ValueNodePb
 		NullNodePb
+		CaseNodePb
+		SubSelectNodePb
+		ParamNodePb
 */
 package expr
 
@@ -65,19 +68,22 @@ func (*ExprPb) Descriptor() ([]byte, []int) { return fileDescriptorNode, []int{0
 
 // The generic Node, must be exactly one of these types
 type NodePb struct {
-	Bn               *BinaryNodePb   `protobuf:"bytes,1,opt,name=bn" json:"bn,omitempty"`
-	Booln            *BooleanNodePb  `protobuf:"bytes,2,opt,name=booln" json:"booln,omitempty"`
-	Un               *UnaryNodePb    `protobuf:"bytes,3,opt,name=un" json:"un,omitempty"`
-	Fn               *FuncNodePb     `protobuf:"bytes,4,opt,name=fn" json:"fn,omitempty"`
-	Tn               *TriNodePb      `protobuf:"bytes,5,opt,name=tn" json:"tn,omitempty"`
-	An               *ArrayNodePb    `protobuf:"bytes,6,opt,name=an" json:"an,omitempty"`
-	Nn               *NumberNodePb   `protobuf:"bytes,10,opt,name=nn" json:"nn,omitempty"`
-	Vn               *ValueNodePb    `protobuf:"bytes,11,opt,name=vn" json:"vn,omitempty"`
-	In               *IdentityNodePb `protobuf:"bytes,12,opt,name=in" json:"in,omitempty"`
-	Sn               *StringNodePb   `protobuf:"bytes,13,opt,name=sn" json:"sn,omitempty"`
-	Incn             *IncludeNodePb  `protobuf:"bytes,14,opt,name=incn" json:"incn,omitempty"`
-	Niln             *NullNodePb     `protobuf:"bytes,15,opt,name=niln" json:"niln,omitempty"`
-	XXX_unrecognized []byte          `json:"-"`
+	Bn               *BinaryNodePb    `protobuf:"bytes,1,opt,name=bn" json:"bn,omitempty"`
+	Booln            *BooleanNodePb   `protobuf:"bytes,2,opt,name=booln" json:"booln,omitempty"`
+	Un               *UnaryNodePb     `protobuf:"bytes,3,opt,name=un" json:"un,omitempty"`
+	Fn               *FuncNodePb      `protobuf:"bytes,4,opt,name=fn" json:"fn,omitempty"`
+	Tn               *TriNodePb       `protobuf:"bytes,5,opt,name=tn" json:"tn,omitempty"`
+	An               *ArrayNodePb     `protobuf:"bytes,6,opt,name=an" json:"an,omitempty"`
+	Nn               *NumberNodePb    `protobuf:"bytes,10,opt,name=nn" json:"nn,omitempty"`
+	Vn               *ValueNodePb     `protobuf:"bytes,11,opt,name=vn" json:"vn,omitempty"`
+	In               *IdentityNodePb  `protobuf:"bytes,12,opt,name=in" json:"in,omitempty"`
+	Sn               *StringNodePb    `protobuf:"bytes,13,opt,name=sn" json:"sn,omitempty"`
+	Incn             *IncludeNodePb   `protobuf:"bytes,14,opt,name=incn" json:"incn,omitempty"`
+	Niln             *NullNodePb      `protobuf:"bytes,15,opt,name=niln" json:"niln,omitempty"`
+	Cn               *CaseNodePb      `protobuf:"bytes,16,opt,name=cn" json:"cn,omitempty"`
+	Ssn              *SubSelectNodePb `protobuf:"bytes,17,opt,name=ssn" json:"ssn,omitempty"`
+	Pn               *ParamNodePb     `protobuf:"bytes,18,opt,name=pn" json:"pn,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
 }
 
 func (m *NodePb) Reset()                    { *m = NodePb{} }
@@ -235,6 +241,43 @@ func (m *NullNodePb) String() string            { return proto.CompactTextString
 func (*NullNodePb) ProtoMessage()               {}
 func (*NullNodePb) Descriptor() ([]byte, []int) { return fileDescriptorNode, []int{13} }
 
+// Case Node, optional arg (simple case) and else
+type CaseNodePb struct {
+	Arg              *NodePb  `protobuf:"bytes,1,opt,name=arg" json:"arg,omitempty"`
+	Whens            []NodePb `protobuf:"bytes,2,rep,name=whens" json:"whens"`
+	Thens            []NodePb `protobuf:"bytes,3,rep,name=thens" json:"thens"`
+	Else             *NodePb  `protobuf:"bytes,4,opt,name=else" json:"else,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *CaseNodePb) Reset()                    { *m = CaseNodePb{} }
+func (m *CaseNodePb) String() string            { return proto.CompactTextString(m) }
+func (*CaseNodePb) ProtoMessage()               {}
+func (*CaseNodePb) Descriptor() ([]byte, []int) { return fileDescriptorNode, []int{14} }
+
+// Sub-Select Node, the sql of the select
+type SubSelectNodePb struct {
+	Sql              string `protobuf:"bytes,1,opt,name=sql" json:"sql"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SubSelectNodePb) Reset()                    { *m = SubSelectNodePb{} }
+func (m *SubSelectNodePb) String() string            { return proto.CompactTextString(m) }
+func (*SubSelectNodePb) ProtoMessage()               {}
+func (*SubSelectNodePb) Descriptor() ([]byte, []int) { return fileDescriptorNode, []int{15} }
+
+// Param Node, bind parameter placeholder
+type ParamNodePb struct {
+	Index            int32  `protobuf:"varint,1,opt,name=index" json:"index"`
+	Text             string `protobuf:"bytes,2,opt,name=text" json:"text"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *ParamNodePb) Reset()                    { *m = ParamNodePb{} }
+func (m *ParamNodePb) String() string            { return proto.CompactTextString(m) }
+func (*ParamNodePb) ProtoMessage()               {}
+func (*ParamNodePb) Descriptor() ([]byte, []int) { return fileDescriptorNode, []int{16} }
+
 func init() {
 	proto.RegisterType((*ExprPb)(nil), "expr.ExprPb")
 	proto.RegisterType((*NodePb)(nil), "expr.NodePb")
@@ -250,6 +293,9 @@ func init() {
 	proto.RegisterType((*NumberNodePb)(nil), "expr.NumberNodePb")
 	proto.RegisterType((*ValueNodePb)(nil), "expr.ValueNodePb")
 	proto.RegisterType((*NullNodePb)(nil), "expr.NullNodePb")
+	proto.RegisterType((*CaseNodePb)(nil), "expr.CaseNodePb")
+	proto.RegisterType((*SubSelectNodePb)(nil), "expr.SubSelectNodePb")
+	proto.RegisterType((*ParamNodePb)(nil), "expr.ParamNodePb")
 }
 func (m *ExprPb) Marshal() (data []byte, err error) {
 	size := m.Size()
@@ -456,6 +502,42 @@ func (m *NodePb) MarshalTo(data []byte) (int, error) {
 		}
 		i += n12
 	}
+	if m.Cn != nil {
+		data[i] = 0x82
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintNode(data, i, uint64(m.Cn.Size()))
+		n13, err := m.Cn.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n13
+	}
+	if m.Ssn != nil {
+		data[i] = 0x8a
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintNode(data, i, uint64(m.Ssn.Size()))
+		n14, err := m.Ssn.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n14
+	}
+	if m.Pn != nil {
+		data[i] = 0x92
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintNode(data, i, uint64(m.Pn.Size()))
+		n15, err := m.Pn.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n15
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -908,6 +990,124 @@ func (m *NullNodePb) MarshalTo(data []byte) (int, error) {
 	return i, nil
 }
 
+func (m *CaseNodePb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *CaseNodePb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Arg != nil {
+		data[i] = 0xa
+		i++
+		i = encodeVarintNode(data, i, uint64(m.Arg.Size()))
+		n14, err := m.Arg.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n14
+	}
+	if len(m.Whens) > 0 {
+		for _, msg := range m.Whens {
+			data[i] = 0x12
+			i++
+			i = encodeVarintNode(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.Thens) > 0 {
+		for _, msg := range m.Thens {
+			data[i] = 0x1a
+			i++
+			i = encodeVarintNode(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.Else != nil {
+		data[i] = 0x22
+		i++
+		i = encodeVarintNode(data, i, uint64(m.Else.Size()))
+		n15, err := m.Else.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n15
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *SubSelectNodePb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *SubSelectNodePb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	data[i] = 0xa
+	i++
+	i = encodeVarintNode(data, i, uint64(len(m.Sql)))
+	i += copy(data[i:], m.Sql)
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *ParamNodePb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *ParamNodePb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	data[i] = 0x8
+	i++
+	i = encodeVarintNode(data, i, uint64(m.Index))
+	data[i] = 0x12
+	i++
+	i = encodeVarintNode(data, i, uint64(len(m.Text)))
+	i += copy(data[i:], m.Text)
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
 func encodeFixed64Node(data []byte, offset int, v uint64) int {
 	data[offset] = uint8(v)
 	data[offset+1] = uint8(v >> 8)
@@ -1021,6 +1221,18 @@ func (m *NodePb) Size() (n int) {
 		l = m.Niln.Size()
 		n += 1 + l + sovNode(uint64(l))
 	}
+	if m.Cn != nil {
+		l = m.Cn.Size()
+		n += 2 + l + sovNode(uint64(l))
+	}
+	if m.Ssn != nil {
+		l = m.Ssn.Size()
+		n += 2 + l + sovNode(uint64(l))
+	}
+	if m.Pn != nil {
+		l = m.Pn.Size()
+		n += 2 + l + sovNode(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1207,6 +1419,58 @@ func (m *NullNodePb) Size() (n int) {
 	return n
 }
 
+func (m *CaseNodePb) Size() (n int) {
+	var l int
+	_ = l
+	if m.Arg != nil {
+		l = m.Arg.Size()
+		n += 1 + l + sovNode(uint64(l))
+	}
+	if len(m.Whens) > 0 {
+		for _, e := range m.Whens {
+			l = e.Size()
+			n += 1 + l + sovNode(uint64(l))
+		}
+	}
+	if len(m.Thens) > 0 {
+		for _, e := range m.Thens {
+			l = e.Size()
+			n += 1 + l + sovNode(uint64(l))
+		}
+	}
+	if m.Else != nil {
+		l = m.Else.Size()
+		n += 1 + l + sovNode(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SubSelectNodePb) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Sql)
+	n += 1 + l + sovNode(uint64(l))
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ParamNodePb) Size() (n int) {
+	var l int
+	_ = l
+	n += 1 + sovNode(uint64(m.Index))
+	l = len(m.Text)
+	n += 1 + l + sovNode(uint64(l))
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovNode(x uint64) (n int) {
 	for {
 		n++
@@ -1867,6 +2131,105 @@ func (m *NodePb) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cn", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cn == nil {
+				m.Cn = &CaseNodePb{}
+			}
+			if err := m.Cn.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ssn", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Ssn == nil {
+				m.Ssn = &SubSelectNodePb{}
+			}
+			if err := m.Ssn.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Pn", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Pn == nil {
+				m.Pn = &ParamNodePb{}
+			}
+			if err := m.Pn.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNode(data[iNdEx:])
@@ -3283,6 +3646,364 @@ func (m *NullNodePb) Unmarshal(data []byte) error {
 	}
 	return nil
 }
+func (m *CaseNodePb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNode
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CaseNodePb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CaseNodePb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Arg", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Arg == nil {
+				m.Arg = &NodePb{}
+			}
+			if err := m.Arg.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Whens", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Whens = append(m.Whens, NodePb{})
+			if err := m.Whens[len(m.Whens)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Thens", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Thens = append(m.Thens, NodePb{})
+			if err := m.Thens[len(m.Thens)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Else", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Else == nil {
+				m.Else = &NodePb{}
+			}
+			if err := m.Else.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNode(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNode
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SubSelectNodePb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNode
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SubSelectNodePb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SubSelectNodePb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sql", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sql = string(data[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNode(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNode
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParamNodePb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNode
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParamNodePb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParamNodePb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+			}
+			m.Index = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Index |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Text", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNode
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNode
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Text = string(data[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNode(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNode
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipNode(data []byte) (n int, err error) {
 	l := len(data)
 	iNdEx := 0
@@ -3391,52 +4112,56 @@ var (
 func init() { proto.RegisterFile("node.proto", fileDescriptorNode) }
 
 var fileDescriptorNode = []byte{
-	// 741 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x54, 0xcf, 0x6f, 0xd3, 0x4a,
-	0x10, 0x8e, 0x1d, 0xa7, 0x4d, 0xc6, 0x69, 0xfb, 0xde, 0xbe, 0xea, 0xc9, 0xea, 0x21, 0xaf, 0xb2,
-	0x1e, 0x50, 0x21, 0x9a, 0x4a, 0x39, 0x70, 0xa7, 0x12, 0x45, 0x3d, 0x10, 0x50, 0xa1, 0xdc, 0xed,
-	0x64, 0x9d, 0xae, 0xe4, 0x8e, 0x83, 0x63, 0x9b, 0xf6, 0xc0, 0x9d, 0x23, 0x47, 0xfe, 0xa4, 0x1c,
-	0xf9, 0x0b, 0x10, 0x3f, 0xfe, 0x11, 0x76, 0x67, 0x6d, 0x67, 0x5d, 0x5a, 0x54, 0xd4, 0x43, 0xa4,
-	0xec, 0xf7, 0x7d, 0x9e, 0xd9, 0x99, 0x6f, 0x76, 0x00, 0x30, 0x99, 0xf2, 0xe1, 0x3c, 0x4d, 0xb2,
-	0x84, 0x39, 0xfc, 0x62, 0x9e, 0xee, 0xec, 0xcf, 0x44, 0x76, 0x96, 0x87, 0xc3, 0x49, 0x72, 0x7e,
-	0x30, 0x4b, 0x66, 0xc9, 0x01, 0x91, 0x61, 0x1e, 0xd1, 0x89, 0x0e, 0xf4, 0x4f, 0x7f, 0xe4, 0x2f,
-	0x2d, 0x58, 0x7b, 0x2a, 0xbf, 0x7b, 0x19, 0xb2, 0x6d, 0xb0, 0x93, 0xb9, 0x67, 0xed, 0x5a, 0x7b,
-	0x9d, 0x43, 0x67, 0xf9, 0xe5, 0x3f, 0xeb, 0x44, 0x9e, 0xd9, 0x7d, 0x70, 0x82, 0x74, 0xb6, 0xf0,
-	0xec, 0xdd, 0xf6, 0x9e, 0x3b, 0xea, 0x0f, 0x55, 0x92, 0xa1, 0xfe, 0xa2, 0x54, 0x11, 0xcf, 0x76,
-	0xa0, 0x23, 0xa6, 0x1c, 0x33, 0xcf, 0x91, 0x01, 0x7a, 0x25, 0xa5, 0x21, 0xf6, 0x2f, 0xb4, 0x8b,
-	0x20, 0xf6, 0x3a, 0x06, 0xa3, 0x00, 0xe6, 0x81, 0x23, 0x14, 0xb1, 0x26, 0x89, 0x76, 0x15, 0x4d,
-	0x94, 0x4c, 0xa8, 0x98, 0x75, 0xc9, 0x74, 0x2b, 0x26, 0x2c, 0x99, 0x48, 0x31, 0x5d, 0xc9, 0x58,
-	0x15, 0xa3, 0x10, 0xff, 0x83, 0x03, 0x6b, 0x63, 0xd9, 0x0e, 0x59, 0xca, 0x1e, 0xd8, 0x21, 0x52,
-	0x29, 0xee, 0x88, 0xe9, 0x2b, 0x1f, 0x0a, 0x0c, 0xd2, 0x4b, 0xcd, 0x57, 0xe5, 0x85, 0xc8, 0x0e,
-	0xa0, 0x13, 0x26, 0x49, 0x8c, 0xb2, 0x3e, 0x25, 0xfe, 0xa7, 0x14, 0x4b, 0x88, 0x07, 0xd8, 0x50,
-	0x6b, 0x1d, 0x7b, 0x00, 0x76, 0x8e, 0x5e, 0x9b, 0xd4, 0x7f, 0x6b, 0xf5, 0xe9, 0xaf, 0x91, 0x73,
-	0x94, 0x8d, 0xb3, 0x23, 0xa4, 0x6e, 0xb8, 0xa3, 0xbf, 0xb4, 0xf0, 0x28, 0xc7, 0x49, 0x53, 0x17,
-	0x21, 0xbb, 0x07, 0x76, 0x86, 0xd4, 0x1b, 0x77, 0xb4, 0xa5, 0x75, 0xaf, 0x53, 0xd1, 0x94, 0x65,
-	0x94, 0x37, 0x40, 0xea, 0x54, 0x9d, 0xf7, 0x49, 0x9a, 0x06, 0x57, 0xf2, 0x06, 0xa8, 0x6a, 0x47,
-	0xf4, 0xc0, 0xac, 0x7d, 0x9c, 0x9f, 0x87, 0x3c, 0x6d, 0x2a, 0x91, 0x42, 0x16, 0xe8, 0xb9, 0x66,
-	0xc8, 0x37, 0x41, 0x9c, 0xf3, 0xa6, 0xb0, 0x40, 0xf6, 0x10, 0x6c, 0x81, 0x5e, 0x9f, 0x84, 0xdb,
-	0x5a, 0x78, 0xac, 0x8c, 0x15, 0xd9, 0x95, 0xf4, 0x82, 0xd2, 0x2f, 0xd0, 0xdb, 0x30, 0xd3, 0xbf,
-	0xca, 0x52, 0x81, 0xb3, 0xa6, 0x72, 0x81, 0x6c, 0x5f, 0xba, 0x8f, 0x13, 0xf4, 0x36, 0xcd, 0xce,
-	0x1f, 0xe3, 0x24, 0xce, 0xa7, 0xcd, 0x2b, 0x90, 0x4c, 0x5e, 0xc2, 0x41, 0x21, 0x8d, 0xda, 0x32,
-	0x3b, 0x3a, 0xce, 0xe3, 0xb8, 0xa9, 0x55, 0x1a, 0xff, 0x0c, 0xfa, 0xa6, 0xdf, 0xf5, 0x68, 0xdb,
-	0xe5, 0x68, 0xb7, 0x68, 0xb4, 0xe5, 0xc8, 0xce, 0x83, 0x94, 0x6b, 0xef, 0xbb, 0x25, 0xa1, 0xa1,
-	0x7a, 0xec, 0xdb, 0xe6, 0xd8, 0x1b, 0x99, 0x5a, 0x7a, 0xec, 0xfd, 0xe7, 0xb0, 0xd1, 0x18, 0x96,
-	0x1b, 0x52, 0x5d, 0xfb, 0x8a, 0xae, 0x09, 0xf7, 0x1e, 0x36, 0x1a, 0x1d, 0xb8, 0x21, 0xdc, 0x00,
-	0xd6, 0x91, 0xcf, 0x82, 0x8c, 0x4f, 0x65, 0x44, 0xbb, 0xbe, 0x7b, 0x05, 0xb2, 0xc7, 0xd0, 0x15,
-	0xa5, 0x41, 0xb2, 0x02, 0xfb, 0xb7, 0xb6, 0xb5, 0x4e, 0x6a, 0xad, 0xcf, 0xc1, 0x3d, 0xbd, 0x53,
-	0xdb, 0xfe, 0x87, 0xb6, 0xac, 0xa3, 0xcc, 0x79, 0x5d, 0x99, 0x8a, 0xf6, 0xc7, 0x00, 0xab, 0xa7,
-	0xa0, 0x5e, 0x34, 0x06, 0xe7, 0x9c, 0xf2, 0xf4, 0xaa, 0x6e, 0x28, 0xe4, 0xd6, 0x5d, 0x3b, 0x86,
-	0x5e, 0xfd, 0x64, 0xee, 0x68, 0xc0, 0x0b, 0x70, 0x8d, 0x67, 0xa5, 0xee, 0xf6, 0x2e, 0x0d, 0xcc,
-	0x70, 0x72, 0xc4, 0x14, 0x72, 0xeb, 0x01, 0x99, 0x42, 0xdf, 0x9c, 0x7f, 0xb2, 0x2e, 0x79, 0x9b,
-	0x27, 0x19, 0xa7, 0xfd, 0x54, 0x2d, 0xb7, 0x0a, 0x54, 0xdd, 0xd5, 0xac, 0x6d, 0x2c, 0x62, 0x0d,
-	0xa9, 0xdb, 0x64, 0xfc, 0x22, 0xa3, 0xed, 0x53, 0x77, 0x4a, 0x21, 0xfe, 0x11, 0x6c, 0x36, 0xad,
-	0x5d, 0xc5, 0xb1, 0xfe, 0x24, 0xce, 0x47, 0x0b, 0xfa, 0xe6, 0xb6, 0xa0, 0xb5, 0xbe, 0x10, 0x72,
-	0xad, 0x5b, 0xa6, 0xd9, 0x04, 0xa9, 0x52, 0xc4, 0x22, 0x8a, 0x93, 0x20, 0x6b, 0x8c, 0x42, 0x05,
-	0x2a, 0x27, 0x44, 0x41, 0xb3, 0xd0, 0xae, 0x9c, 0x10, 0x85, 0x42, 0xa3, 0x42, 0xee, 0x45, 0xbb,
-	0x5c, 0xdf, 0x12, 0x8d, 0x8a, 0xfa, 0x4a, 0x1d, 0x73, 0x08, 0xe8, 0x4a, 0xcf, 0xc0, 0x35, 0xb6,
-	0x12, 0xf3, 0xa1, 0x57, 0xa8, 0x63, 0x76, 0x39, 0xe7, 0x0d, 0x97, 0x57, 0xb0, 0x4c, 0xd1, 0xa1,
-	0x03, 0x3d, 0x8e, 0xfe, 0x89, 0x3e, 0xf8, 0x8f, 0x00, 0x56, 0xeb, 0x82, 0x7c, 0x10, 0x71, 0x19,
-	0xc5, 0xaa, 0xa3, 0x54, 0xe0, 0xe1, 0xf6, 0xf2, 0xdb, 0xa0, 0xb5, 0xfc, 0x3e, 0xb0, 0x3e, 0xcb,
-	0xdf, 0x57, 0xf9, 0xfb, 0xf4, 0x63, 0xd0, 0xfa, 0x19, 0x00, 0x00, 0xff, 0xff, 0xe8, 0xbc, 0x7b,
-	0x5f, 0x70, 0x07, 0x00, 0x00,
+	// 807 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xcf, 0x8e, 0xdb, 0x44,
+	0x18, 0xcf, 0x8c, 0xed, 0xed, 0xee, 0xe7, 0x6c, 0x5b, 0x86, 0x08, 0x8d, 0xf6, 0x10, 0x2c, 0x0b,
+	0x8a, 0x55, 0xd1, 0xac, 0x94, 0x03, 0x77, 0x16, 0x51, 0xb4, 0x07, 0x42, 0x15, 0x28, 0x77, 0x3b,
+	0x99, 0x64, 0x47, 0xf2, 0x7e, 0x13, 0x1c, 0xdb, 0xdd, 0x1e, 0x78, 0x07, 0x8e, 0x3c, 0x05, 0xe2,
+	0x31, 0x72, 0xe4, 0x09, 0x10, 0x2c, 0x2f, 0x82, 0x66, 0xc6, 0x76, 0xc6, 0xdb, 0x6c, 0xd5, 0xaa,
+	0xb7, 0xcc, 0xef, 0xf7, 0xcb, 0xf7, 0xff, 0xfb, 0x0c, 0x80, 0x6a, 0x29, 0x26, 0x9b, 0x42, 0x95,
+	0x8a, 0xf9, 0xe2, 0x66, 0x53, 0x9c, 0x3d, 0x5b, 0xcb, 0xf2, 0xaa, 0xca, 0x26, 0x0b, 0x75, 0x7d,
+	0xbe, 0x56, 0x6b, 0x75, 0x6e, 0xc8, 0xac, 0x5a, 0x99, 0x97, 0x79, 0x98, 0x5f, 0xf6, 0x4f, 0xf1,
+	0x8e, 0xc0, 0xd1, 0xb7, 0x37, 0x9b, 0xe2, 0x45, 0xc6, 0x46, 0x40, 0xd5, 0x86, 0x93, 0x88, 0x24,
+	0xc1, 0x85, 0xbf, 0xfb, 0xfb, 0x53, 0x32, 0xa7, 0x6a, 0xc3, 0x9e, 0x80, 0x9f, 0x16, 0xeb, 0x2d,
+	0xa7, 0x91, 0x97, 0x84, 0xd3, 0xe1, 0x44, 0x3b, 0x99, 0xd8, 0x7f, 0x34, 0x2a, 0xc3, 0xb3, 0x33,
+	0x08, 0xe4, 0x52, 0x60, 0xc9, 0xfd, 0x88, 0x24, 0x27, 0x0d, 0x65, 0x21, 0xf6, 0x09, 0x78, 0x75,
+	0x9a, 0xf3, 0xc0, 0x61, 0x34, 0xc0, 0x38, 0xf8, 0x52, 0x13, 0x47, 0x11, 0x49, 0xbc, 0xd6, 0x9a,
+	0x6c, 0x98, 0x4c, 0x33, 0x0f, 0x22, 0x92, 0x1c, 0xb7, 0x4c, 0xd6, 0x30, 0x2b, 0xcd, 0x1c, 0x47,
+	0x24, 0x21, 0x2d, 0xa3, 0x91, 0xf8, 0x4f, 0x1f, 0x8e, 0x66, 0x6a, 0x29, 0x5e, 0x64, 0x2c, 0x01,
+	0x9a, 0xa1, 0x49, 0x25, 0x9c, 0x32, 0x1b, 0xf2, 0x85, 0xc4, 0xb4, 0x78, 0x6d, 0xf9, 0x36, 0xbd,
+	0x0c, 0xd9, 0x39, 0x04, 0x99, 0x52, 0x39, 0x72, 0x6a, 0xc4, 0x1f, 0x37, 0x62, 0xa5, 0x72, 0x91,
+	0x62, 0x4f, 0x6d, 0x75, 0xec, 0x0b, 0xa0, 0x15, 0x72, 0xcf, 0xa8, 0x3f, 0xb2, 0xea, 0x97, 0x6f,
+	0x5a, 0xae, 0x90, 0x3d, 0x01, 0xba, 0x42, 0x53, 0x8d, 0x70, 0xfa, 0xd8, 0x0a, 0x9f, 0x57, 0xb8,
+	0xe8, 0xeb, 0x56, 0xc8, 0x3e, 0x07, 0x5a, 0xa2, 0xa9, 0x4d, 0x38, 0x7d, 0x64, 0x75, 0x3f, 0x15,
+	0xb2, 0x2f, 0x2b, 0x8d, 0xdf, 0x14, 0xf9, 0x91, 0xeb, 0xf7, 0xeb, 0xa2, 0x48, 0xef, 0xf8, 0x4d,
+	0x51, 0xe7, 0x8e, 0xc8, 0xc1, 0xcd, 0x7d, 0x56, 0x5d, 0x67, 0xa2, 0xe8, 0x2b, 0xd1, 0x98, 0xac,
+	0x91, 0x87, 0xae, 0xc9, 0x9f, 0xd3, 0xbc, 0x12, 0x7d, 0x61, 0x8d, 0xec, 0x29, 0x50, 0x89, 0x7c,
+	0x68, 0x84, 0x23, 0x2b, 0xbc, 0xd4, 0x8d, 0x95, 0xe5, 0x1d, 0xf7, 0xd2, 0xb8, 0xdf, 0x22, 0x3f,
+	0x75, 0xdd, 0xff, 0x58, 0x16, 0x12, 0xd7, 0x7d, 0xe5, 0x16, 0xd9, 0x33, 0xf0, 0x25, 0x2e, 0x90,
+	0x3f, 0x74, 0x2b, 0x7f, 0x89, 0x8b, 0xbc, 0x5a, 0xf6, 0x43, 0x30, 0x32, 0xf6, 0x14, 0x7c, 0x94,
+	0x39, 0xf2, 0x47, 0x6e, 0x45, 0x67, 0x55, 0x9e, 0xf7, 0xb5, 0x5a, 0xa3, 0x6b, 0xbf, 0x40, 0xfe,
+	0xd8, 0x55, 0x7e, 0x93, 0x6e, 0xef, 0x24, 0xb6, 0xc0, 0xf8, 0x0a, 0x86, 0xee, 0x5c, 0x74, 0x2b,
+	0x40, 0x9b, 0x15, 0x18, 0x98, 0x15, 0x38, 0x83, 0x60, 0x93, 0x16, 0xc2, 0xce, 0xc8, 0x71, 0x43,
+	0x58, 0xa8, 0x5b, 0x0f, 0xcf, 0x5d, 0x0f, 0xc7, 0xcf, 0xc0, 0xae, 0x47, 0xfc, 0x3d, 0x9c, 0xf6,
+	0x86, 0xea, 0x1e, 0x57, 0x07, 0xb7, 0xed, 0x80, 0xb9, 0x5f, 0xe1, 0xb4, 0x57, 0xa9, 0x7b, 0xcc,
+	0x8d, 0xe1, 0x01, 0x8a, 0x75, 0x5a, 0x8a, 0x25, 0xa7, 0x11, 0xed, 0x62, 0x6f, 0x41, 0xf6, 0x15,
+	0x1c, 0xcb, 0xa6, 0x91, 0xdc, 0x8b, 0xe8, 0x5b, 0xdb, 0x3b, 0x98, 0x77, 0xda, 0x58, 0x40, 0xf8,
+	0xf2, 0x83, 0xca, 0xf6, 0x19, 0x78, 0x69, 0xb1, 0x6e, 0x7c, 0x1e, 0x4a, 0x53, 0xd3, 0xf1, 0x0c,
+	0x60, 0xbf, 0x32, 0x7a, 0xf3, 0x31, 0xbd, 0x16, 0xc6, 0xcf, 0x49, 0x5b, 0x0d, 0x8d, 0xbc, 0x73,
+	0xd5, 0x2e, 0xe1, 0xa4, 0x5b, 0xad, 0x0f, 0x6c, 0xc0, 0x0f, 0x10, 0x3a, 0xeb, 0xa7, 0x63, 0x7b,
+	0x55, 0xa4, 0xae, 0x39, 0x32, 0x37, 0xc8, 0x3b, 0x0f, 0xc8, 0x12, 0x86, 0xee, 0x9e, 0x98, 0xd6,
+	0xa9, 0x5f, 0x2a, 0x55, 0x0a, 0x4e, 0xba, 0xfa, 0x91, 0x79, 0x0b, 0xea, 0xea, 0x5a, 0x96, 0x3a,
+	0x07, 0xdb, 0x42, 0x3a, 0x9a, 0x52, 0xdc, 0x94, 0xe6, 0x4a, 0x75, 0x95, 0xd2, 0x48, 0xfc, 0x1c,
+	0x1e, 0xf6, 0x5b, 0xbb, 0xb7, 0x43, 0xde, 0xc7, 0xce, 0x6f, 0x04, 0x86, 0xee, 0x55, 0x31, 0xe7,
+	0x7f, 0x2b, 0xb1, 0x74, 0x82, 0x1d, 0xcc, 0x2d, 0xa4, 0x53, 0x91, 0xdb, 0x55, 0xae, 0xd2, 0xb2,
+	0x37, 0x0a, 0x2d, 0xa8, 0x3b, 0x21, 0x6b, 0x33, 0x0b, 0x5e, 0xdb, 0x09, 0x59, 0x6b, 0x74, 0x55,
+	0x73, 0x3f, 0xa2, 0xcd, 0x99, 0x1f, 0xcc, 0xe9, 0xaa, 0xee, 0x42, 0x0a, 0xdc, 0x21, 0x30, 0x21,
+	0x7d, 0x07, 0xa1, 0x73, 0xbd, 0x58, 0x0c, 0x27, 0xb5, 0x7e, 0x96, 0xaf, 0x37, 0xa2, 0xd7, 0xe5,
+	0x3d, 0xcc, 0x46, 0x10, 0x98, 0x87, 0x59, 0x8e, 0xe1, 0xdc, 0x3e, 0xe2, 0x2f, 0x01, 0xf6, 0x67,
+	0xc5, 0xf4, 0x41, 0xe6, 0x8d, 0x15, 0xd2, 0x59, 0x69, 0xc1, 0xf8, 0x0f, 0x02, 0xb0, 0xbf, 0x2d,
+	0xed, 0x60, 0xdb, 0x4f, 0xcf, 0x9b, 0xdd, 0x26, 0x66, 0xb0, 0x59, 0x02, 0xc1, 0xab, 0x2b, 0x81,
+	0x6f, 0x1b, 0x33, 0x2b, 0xd0, 0xca, 0xd2, 0x28, 0xef, 0x9f, 0x1f, 0x2b, 0xd0, 0x83, 0x26, 0xf2,
+	0xad, 0x68, 0xbe, 0x38, 0x87, 0x5c, 0x1b, 0xfe, 0x62, 0xb4, 0xfb, 0x77, 0x3c, 0xd8, 0xdd, 0x8e,
+	0xc9, 0x5f, 0xb7, 0x63, 0xf2, 0xcf, 0xed, 0x98, 0xfc, 0xfe, 0xdf, 0x78, 0xf0, 0xff, 0x00, 0x70,
+	0x87, 0xcb, 0x3b, 0x49, 0x08, 0x00, 0x00,
 }
@@ -22,6 +22,9 @@ var pbTests = []string{
 	`AND ( EXISTS x, INCLUDE ref_name )`,
 	`company = "Toys R"" Us"`,
 	`providers.id != NULL`,
+	`CASE WHEN x > 5 THEN "big" ELSE "small" END`,
+	`CASE x WHEN 1 THEN "one" WHEN 2 THEN "two" END`,
+	`user_id = ? AND price > $3`,
 }
 
 func TestNodePb(t *testing.T) {
@@ -69,6 +72,32 @@ func TestExprRoundTrip(t *testing.T) {
 	}
 }
 
+func TestParamNode(t *testing.T) {
+	t.Parallel()
+	exp, err := expr.ParseExpression(`a = ? AND b IN (?, $1)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `a = ? AND b IN (?, $1)`, exp.String())
+	params := expr.FindAllParams(exp)
+	assert.Equal(t, 3, len(params))
+	assert.Equal(t, []int{0, 1, 0}, []int{params[0].Index, params[1].Index, params[2].Index})
+	assert.Equal(t, 2, expr.NumParams(params))
+
+	_, err = expr.ParseExpression(`a = $0`)
+	assert.NotEqual(t, nil, err)
+
+	// bound params write, and serialize, their value
+	params[0].Value = value.NewIntValue(5)
+	params[1].Value = value.NewStringValue("x")
+	params[2].Value = params[0].Value
+	assert.Equal(t, `a = 5 AND b IN ("x", 5)`, exp.String())
+	pbBytes, err := proto.Marshal(exp.NodePb())
+	assert.Equal(t, nil, err)
+	n2, err := expr.NodeFromPb(pbBytes)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, exp.String(), n2.String())
+	assert.Equal(t, 0, len(expr.FindAllParams(n2)))
+}
+
 func TestNodeJson(t *testing.T) {
 	t.Parallel()
 	for _, exprText := range pbTests {
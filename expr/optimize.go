@@ -0,0 +1,285 @@
+package expr
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/value"
+)
+
+// LiteralEvaluator evaluates an expression of only literals, ie the vm
+// without a context, used by Optimize to fold them into a single literal.
+type LiteralEvaluator func(n Node) (value.Value, bool)
+
+// Optimize returns a simplified copy of node that evaluates the same for
+// every context, node itself is not modified.
+//
+//  - expressions of only literals are evaluated once by eval and replaced
+//    by their value, ie  toint("5") + 2  =>  7,  1 = 1  =>  true
+//  - AND/OR with literal true/false args are simplified, ie
+//    x > 5 AND true  =>  x > 5,  x > 5 OR true  =>  true
+//  - double negation is removed, ie  NOT NOT x  =>  x
+//  - nested boolean nodes of the same operator are flattened, ie
+//    AND ( AND ( a, b ), c )  =>  AND ( a, b, c )
+//  - comparisons of a literal to an identity are turned around, ie
+//    5 < x  =>  x > 5
+//
+// Functions are only folded if they have args, aren't aggregates and
+// don't read the context (see ContextFunc).  eval may be nil to simplify
+// without folding.
+func Optimize(node Node, eval LiteralEvaluator) Node {
+	if node == nil {
+		return nil
+	}
+	return optimizer{eval}.optimize(node)
+}
+
+type optimizer struct {
+	eval LiteralEvaluator
+}
+
+func (m optimizer) optimize(node Node) Node {
+	switch n := node.(type) {
+	case *BinaryNode:
+		bn := *n
+		bn.Args = m.optimizeArgs(n.Args)
+		return m.fold(m.simplifyBinary(&bn))
+	case *BooleanNode:
+		return m.fold(m.simplifyBoolean(n))
+	case *UnaryNode:
+		arg := m.optimize(n.Arg)
+		if n.Operator.T == lex.TokenNegate {
+			if inner, ok := arg.(*UnaryNode); ok && inner.Operator.T == lex.TokenNegate {
+				return inner.Arg
+			}
+		}
+		return m.fold(&UnaryNode{Arg: arg, Operator: n.Operator})
+	case *TriNode:
+		tn := *n
+		tn.Args = m.optimizeArgs(n.Args)
+		return m.fold(&tn)
+	case *FuncNode:
+		fn := *n
+		fn.Args = m.optimizeArgs(n.Args)
+		return m.fold(&fn)
+	case *ArrayNode:
+		an := *n
+		an.Args = m.optimizeArgs(n.Args)
+		return &an
+	case *CaseNode:
+		cn := &CaseNode{Whens: m.optimizeArgs(n.Whens), Thens: m.optimizeArgs(n.Thens)}
+		if n.Arg != nil {
+			cn.Arg = m.optimize(n.Arg)
+		}
+		if n.Else != nil {
+			cn.Else = m.optimize(n.Else)
+		}
+		return m.fold(cn)
+	}
+	return node
+}
+
+func (m optimizer) optimizeArgs(args []Node) []Node {
+	out := make([]Node, len(args))
+	for i, arg := range args {
+		out[i] = m.optimize(arg)
+	}
+	return out
+}
+
+// simplifyBinary AND/OR with a literal boolean arg, and literal compared
+// to identity.
+func (m optimizer) simplifyBinary(n *BinaryNode) Node {
+	if len(n.Args) != 2 {
+		return n
+	}
+	switch n.Operator.T {
+	case lex.TokenAnd, lex.TokenLogicAnd, lex.TokenOr, lex.TokenLogicOr:
+		isAnd := n.Operator.T == lex.TokenAnd || n.Operator.T == lex.TokenLogicAnd
+		for i, arg := range n.Args {
+			b, ok := boolLiteral(arg)
+			if !ok {
+				continue
+			}
+			if b != isAnd {
+				// false AND x, true OR x
+				return arg
+			}
+			// true AND x, false OR x
+			return keepParen(n, n.Args[1-i])
+		}
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE,
+		lex.TokenLT, lex.TokenLE, lex.TokenGT, lex.TokenGE:
+		if !isLiteral(n.Args[0]) {
+			break
+		}
+		if in, ok := n.Args[1].(*IdentityNode); !ok || in.IsBooleanIdentity() {
+			break
+		}
+		op := n.Operator
+		switch op.T {
+		case lex.TokenLT:
+			op = lex.Token{T: lex.TokenGT, V: lex.TokenGT.String()}
+		case lex.TokenLE:
+			op = lex.Token{T: lex.TokenGE, V: lex.TokenGE.String()}
+		case lex.TokenGT:
+			op = lex.Token{T: lex.TokenLT, V: lex.TokenLT.String()}
+		case lex.TokenGE:
+			op = lex.Token{T: lex.TokenLE, V: lex.TokenLE.String()}
+		}
+		bn := *n
+		bn.Args = []Node{n.Args[1], n.Args[0]}
+		bn.Operator = op
+		return &bn
+	}
+	return n
+}
+
+// simplifyBoolean flatten nested nodes of the same operator, removing
+// literal args that don't change the result.
+func (m optimizer) simplifyBoolean(n *BooleanNode) Node {
+	isAnd := n.Operator.T == lex.TokenAnd || n.Operator.T == lex.TokenLogicAnd
+	args := make([]Node, 0, len(n.Args))
+	// args already optimized, as are the args of nested nodes
+	var addArgs func(nargs []Node) bool
+	addArgs = func(nargs []Node) bool {
+		for _, arg := range nargs {
+			if inner, ok := arg.(*BooleanNode); ok && !inner.negated && inner.Operator.T == n.Operator.T {
+				if !addArgs(inner.Args) {
+					return false
+				}
+				continue
+			}
+			if b, ok := boolLiteral(arg); ok {
+				if b != isAnd {
+					// false in AND, true in OR decides the result
+					return false
+				}
+				continue
+			}
+			args = append(args, arg)
+		}
+		return true
+	}
+	if !addArgs(m.optimizeArgs(n.Args)) {
+		return NewIdentityNodeVal(strconv.FormatBool(!isAnd != n.negated))
+	}
+	if len(args) == 0 {
+		return NewIdentityNodeVal(strconv.FormatBool(isAnd != n.negated))
+	}
+	if len(args) == 1 && !n.negated {
+		return args[0]
+	}
+	return &BooleanNode{negated: n.negated, Args: args, Operator: n.Operator}
+}
+
+// fold evaluate node if all of its args are literals, replacing it by the
+// literal of its value.
+func (m optimizer) fold(node Node) Node {
+	if m.eval == nil || !foldable(node) {
+		return node
+	}
+	val, ok := m.eval(node)
+	if !ok || val == nil {
+		return node
+	}
+	if lit := literalNode(val); lit != nil {
+		return lit
+	}
+	return node
+}
+
+// foldable are all args of node literals, and node not reading the context.
+func foldable(node Node) bool {
+	var args []Node
+	switch n := node.(type) {
+	case *UnaryNode:
+		if n.Operator.T == lex.TokenExists {
+			return false
+		}
+		args = []Node{n.Arg}
+	case *FuncNode:
+		if n.Missing || n.F.Aggregate || n.F.CustomFunc == nil || len(n.Args) == 0 {
+			return false
+		}
+		if cf, ok := n.F.CustomFunc.(ContextFunc); ok && cf.ReadsContext() {
+			return false
+		}
+		args = n.Args
+	case *CaseNode:
+		args = append(append([]Node{n.Arg, n.Else}, n.Whens...), n.Thens...)
+	case NodeArgs:
+		args = n.ChildrenArgs()
+	default:
+		return false
+	}
+	for _, arg := range args {
+		if arg != nil && !isLiteral(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLiteral is node a literal value, or array of them.
+func isLiteral(node Node) bool {
+	switch n := node.(type) {
+	case *NumberNode, *StringNode, *ValueNode, *NullNode:
+		return true
+	case *IdentityNode:
+		return n.IsBooleanIdentity()
+	case *ArrayNode:
+		for _, arg := range n.Args {
+			if !isLiteral(arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// boolLiteral the value of a literal true or false.
+func boolLiteral(node Node) (bool, bool) {
+	if in, ok := node.(*IdentityNode); ok && in.IsBooleanIdentity() {
+		return in.Bool(), true
+	}
+	return false, false
+}
+
+// literalNode the literal node of a number, string or bool value, nil for
+// other types.
+func literalNode(val value.Value) Node {
+	switch v := val.(type) {
+	case value.IntValue:
+		n, err := NewNumberStr(strconv.FormatInt(v.Val(), 10))
+		if err == nil {
+			return n
+		}
+	case value.NumberValue:
+		if math.IsNaN(v.Val()) || math.IsInf(v.Val(), 0) {
+			return nil
+		}
+		n, err := NewNumberStr(strconv.FormatFloat(v.Val(), 'f', -1, 64))
+		if err == nil {
+			return n
+		}
+	case value.StringValue:
+		return NewStringNode(v.Val())
+	case value.BoolValue:
+		return NewIdentityNodeVal(strconv.FormatBool(v.Val()))
+	}
+	return nil
+}
+
+// keepParen arg replacing binary node n, parenthesized if n was so it is
+// still written with the same precedence.
+func keepParen(n *BinaryNode, arg Node) Node {
+	if bn, ok := arg.(*BinaryNode); ok && n.Paren && !bn.Paren {
+		cp := *bn
+		cp.Paren = true
+		return &cp
+	}
+	return arg
+}
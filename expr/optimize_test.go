@@ -0,0 +1,36 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/expr"
+)
+
+func TestOptimizeNoEval(t *testing.T) {
+	// without an evaluator only simplified, nothing folded
+	tests := []struct {
+		exp string
+		out string
+	}{
+		{`x > 5 AND true`, `x > 5`},
+		{`false OR x > 5`, `x > 5`},
+		{`1 = 1 AND x > 5`, `1 = 1 AND x > 5`},
+		{`5 <= x`, `x >= 5`},
+		{`"a" = x`, `x = "a"`},
+		{`(a > 1 OR b > 1 OR false) AND c > 1`, `(a > 1 OR b > 1) AND c > 1`},
+		{`OR ( x > 5, OR ( y > 5, z > 5 ), NOT OR ( a, b ) )`, `OR ( x > 5, y > 5, z > 5, NOT OR ( a, b ) )`},
+		{`AND ( false, x > 5 )`, `false`},
+		{`NOT AND ( false, x > 5 )`, `true`},
+	}
+	for _, tc := range tests {
+		n, err := expr.ParseExpression(tc.exp)
+		assert.Equal(t, nil, err, tc.exp)
+		on := expr.Optimize(n, nil)
+		assert.Equal(t, tc.out, on.String(), tc.exp)
+		_, err = expr.ParseExpression(on.String())
+		assert.Equal(t, nil, err, tc.exp)
+	}
+	assert.Equal(t, nil, expr.Optimize(nil, nil))
+}
@@ -1,6 +1,7 @@
 package expr
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"runtime"
@@ -286,7 +287,7 @@ https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Operators/Oper
 http://www.postgresql.org/docs/9.4/static/sql-syntax-lexical.html#SQL-PRECEDENCE
 
 TODO:
- - if/else, case, for
+ - if/else, for
  - call stack & vars
 --------------------------------------
 O -> A {( "||" | OR  ) A}
@@ -295,8 +296,10 @@ C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=" | "LIKE" | "IN" | "CONTAINS" | "
 P -> M {( "+" | "-" ) M}
 M -> F {( "*" | "/" ) F}
 F -> v | "(" O ")" | "!" v | "-" O | "NOT" C | "EXISTS" v | "IS" O | "AND (" O ")" | "OR (" O ")"
-v -> value | Func | "INCLUDE" <identity>
+v -> value | Func | Case | SubSelect | "INCLUDE" <identity>
 Func -> <identity> "(" value {"," value} ")"
+Case -> "CASE" [O] "WHEN" O "THEN" O {"WHEN" O "THEN" O} ["ELSE" O] "END"
+SubSelect -> "(" "SELECT" ... ")"
 value -> number | "string" | O | <identity>
 
 
@@ -616,6 +619,9 @@ func (t *tree) v(depth int) Node {
 	case lex.TokenNull:
 		t.Next()
 		return NewNull(cur)
+	case lex.TokenParam:
+		t.Next()
+		return t.Param(cur)
 	case lex.TokenStar:
 		n := NewStringNoQuoteNode(cur.V)
 		t.Next()
@@ -633,7 +639,12 @@ func (t *tree) v(depth int) Node {
 	case lex.TokenUdfExpr:
 		t.Next() // consume Function Name
 		return t.Func(depth, cur)
+	case lex.TokenCase:
+		return t.Case(depth)
 	case lex.TokenLeftParenthesis:
+		if t.Peek().T == lex.TokenSelect {
+			return t.SubSelect(depth)
+		}
 		t.Next() // Consume  (
 		n := t.O(depth + 1)
 		debugf(depth, "v: paren  T:%T  %v   cur:%v", n, n, t.Cur())
@@ -654,6 +665,98 @@ func (t *tree) v(depth int) Node {
 	return nil
 }
 
+// Param creates the node of a bind parameter token, numbering ? params in
+// the order they are parsed from the statement.
+//
+//    ?   $1
+//
+func (t *tree) Param(tok lex.Token) Node {
+	pos := 0
+	if tok.V == "?" {
+		pos = t.Lexer().NextParam()
+	}
+	n, err := NewParamNode(tok, pos)
+	if err != nil {
+		t.error(err)
+	}
+	return n
+}
+
+// Case parses a CASE expression
+//
+//    CASE [<expr>] WHEN <expr> THEN <expr> [WHEN <expr> THEN <expr>] [ELSE <expr>] END
+//
+func (t *tree) Case(depth int) Node {
+	debugf(depth, "Case: cur:%v peek:%v", t.Cur(), t.Peek())
+	t.Next() // consume CASE
+	var arg Node
+	if t.Cur().T != lex.TokenWhen {
+		// simple case   CASE x WHEN 1 THEN ...
+		arg = t.O(depth + 1)
+	}
+	n := NewCaseNode(arg)
+	for t.Cur().T == lex.TokenWhen {
+		t.Next() // consume WHEN
+		when := t.O(depth + 1)
+		t.expect(lex.TokenThen, "CASE expected THEN")
+		t.Next() // consume THEN
+		n.Append(when, t.O(depth+1))
+	}
+	if len(n.Whens) == 0 {
+		t.unexpected(t.Cur(), "CASE expected WHEN")
+	}
+	if t.Cur().T == lex.TokenElse {
+		t.Next() // consume ELSE
+		n.Else = t.O(depth + 1)
+	}
+	t.expect(lex.TokenEnd, "CASE expected END")
+	t.Next() // consume END
+	return n
+}
+
+// SubSelect parses a select statement in parens used as a value, the
+// select itself is parsed by the registered SubSelectParser.  Its ? bind
+// parameters are numbered as $N so they keep their position in the
+// statement.
+//
+//    (SELECT <columns> FROM ...)
+//
+func (t *tree) SubSelect(depth int) Node {
+	debugf(depth, "SubSelect: cur:%v peek:%v", t.Cur(), t.Peek())
+	start := t.Next().Pos // consume (, token positions are at their end
+	raw := t.Lexer().RawInput()
+	var sql bytes.Buffer
+	parens := 0
+	for {
+		tok := t.Cur()
+		if tok.T == lex.TokenRightParenthesis && parens == 0 {
+			break
+		}
+		switch tok.T {
+		case lex.TokenLeftParenthesis:
+			parens++
+		case lex.TokenRightParenthesis:
+			parens--
+		case lex.TokenParam:
+			if tok.V == "?" {
+				sql.WriteString(raw[start : tok.Pos-1])
+				fmt.Fprintf(&sql, "$%d", t.Lexer().NextParam()+1)
+				start = tok.Pos
+			}
+		case lex.TokenEOF, lex.TokenEOS, lex.TokenError:
+			t.unexpected(tok, "Expected Right Paren to end sub-select")
+		}
+		t.Next()
+	}
+	sql.WriteString(raw[start : t.Cur().Pos-len(t.Cur().V)])
+	t.Next() // consume )
+	n, err := parseSubSelect(sql.String(), t.fr)
+	if err != nil {
+		t.error(err)
+	}
+	return n
+}
+
 func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 	debugf(depth, "Func: tok: %v cur:%v peek:%v", funcTok.V, t.Cur(), t.Peek())
 	if t.Cur().T != lex.TokenLeftParenthesis {
@@ -730,6 +833,14 @@ func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 				lastComma = true
 				t.Next()
 				continue
+			case lex.TokenIdentity:
+				if len(fn.Args) == 0 && strings.ToLower(firstToken.V) == "distinct" &&
+					t.Peek().T != lex.TokenRightParenthesis && t.Peek().T != lex.TokenComma {
+					// count(DISTINCT user_id)
+					node = t.Distinct(depth+1, t.Next())
+				} else {
+					node = t.O(depth + 1)
+				}
 			default:
 				node = t.O(depth + 1)
 			}
@@ -772,6 +883,23 @@ func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 	}
 }
 
+// Distinct parses the argument following the DISTINCT keyword of an
+// aggregate func into the same node as the DISTINCT(arg) func form.
+//
+//    count(DISTINCT user_id)  =>  count(DISTINCT(user_id))
+func (t *tree) Distinct(depth int, distinctTok lex.Token) Node {
+	funcImpl, ok := t.getFunction(distinctTok.V)
+	if !ok {
+		funcImpl = Func{Name: distinctTok.V, Eval: EmptyEvalFunc}
+	}
+	fn := NewFuncNode(distinctTok.V, funcImpl)
+	fn.Missing = !ok
+	if node := t.O(depth); node != nil {
+		fn.append(node)
+	}
+	return fn
+}
+
 // get Function from Global function registry.
 func (t *tree) getFunction(name string) (fn Func, ok bool) {
 	if t.fr != nil {
@@ -293,6 +293,11 @@ var exprTests = []exprTest{
 		`version == 4 AND (NOT(exists(@@content_whitelist_domains)) OR len(@@content_whitelist_domains) == 0 OR host(url) IN hosts(@@content_whitelist_domains))`,
 		true,
 	},
+	{
+		`count(DISTINCT user_id) > 2`,
+		`count(DISTINCT(user_id)) > 2`,
+		true,
+	},
 	// Invalid Statements
 	{
 		"`fieldname` INTERSECTS \"hello\"", // Right Side only allows (identity|array|func)
@@ -325,6 +330,32 @@ var exprTests = []exprTest{
 		`AND ( x == "y", stuff == x )`,
 		true,
 	},
+	// Case expressions
+	{
+		`CASE WHEN x > 5 THEN "big" WHEN x > 2 THEN "medium" ELSE "small" END`,
+		`CASE WHEN x > 5 THEN "big" WHEN x > 2 THEN "medium" ELSE "small" END`,
+		true,
+	},
+	{
+		`case x when 1 then "one" when 2 then "two" end`,
+		`CASE x WHEN 1 THEN "one" WHEN 2 THEN "two" END`,
+		true,
+	},
+	{
+		`toint(CASE WHEN exists(x) THEN x ELSE 0 END) + 1`,
+		`toint(CASE WHEN exists(x) THEN x ELSE 0 END) + 1`,
+		true,
+	},
+	{
+		`CASE WHEN x > 5 THEN "big"`, // missing END
+		"",
+		false,
+	},
+	{
+		`CASE x ELSE 1 END`, // requires WHEN
+		"",
+		false,
+	},
 }
 
 func TestParseExpressions(t *testing.T) {
@@ -93,6 +93,10 @@ func (fg *FilterGenerator) walkExpr(node expr.Node, depth int) (interface{}, err
 		filter, err = fg.walkExpr(n.ExprNode, depth+1)
 	case *expr.FuncNode:
 		filter, err = fg.funcExpr(n, depth+1)
+	case *expr.CaseNode:
+		// Elasticsearch filters have no conditional value expression, so CASE
+		// can't be pushed down and must be evaluated in process.
+		return nil, fmt.Errorf("qlindex: CASE expressions can't be pushed down to elasticsearch: %s", n)
 	default:
 		gou.Warnf("not handled %v", node)
 		return nil, fmt.Errorf("qlindex: unsupported node in expression: %T (%s)", node, node)
@@ -1 +1,27 @@
 package esgen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/esgen"
+	"github.com/araddon/qlbridge/rel"
+)
+
+func TestCaseNotPushable(t *testing.T) {
+	fg := esgen.NewGenerator(time.Now(), nil, nil)
+	for _, exprText := range []string{
+		`CASE WHEN x > 5 THEN true ELSE false END`,
+		`NOT CASE x WHEN 1 THEN true END`,
+	} {
+		_, err := fg.Walk(&rel.FilterStatement{Filter: expr.MustParse(exprText)})
+		assert.Error(t, err, exprText)
+		if err != nil {
+			assert.True(t, strings.Contains(err.Error(), "CASE"), err.Error())
+		}
+	}
+}
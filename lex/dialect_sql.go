@@ -19,6 +19,7 @@ var (
 	//    SHOW idenity;
 	//    DESCRIBE identity;
 	//    PREPARE
+	//    ANALYZE TABLE identity[, identity]
 	//
 	// ddl
 	//    ALTER
@@ -31,6 +32,7 @@ var (
 		Statements: []*Clause{
 			{Token: TokenPrepare, Clauses: SqlPrepare},
 			{Token: TokenSelect, Clauses: SqlSelect},
+			{Token: TokenWith, Clauses: SqlWith},
 			{Token: TokenUpdate, Clauses: SqlUpdate},
 			{Token: TokenUpsert, Clauses: SqlUpsert},
 			{Token: TokenInsert, Clauses: SqlInsert},
@@ -46,6 +48,7 @@ var (
 			{Token: TokenUse, Clauses: SqlUse},
 			{Token: TokenRollback, Clauses: SqlRollback},
 			{Token: TokenCommit, Clauses: SqlCommit},
+			{Token: TokenAnalyze, Clauses: SqlAnalyze},
 		},
 	}
 	// SqlSelect Select statement.
@@ -55,22 +58,27 @@ var (
 		{Token: TokenFrom, Lexer: LexTableReferenceFirst, Optional: true, Repeat: false, Clauses: fromSource, Name: "sqlSelect.From"},
 		{KeywordMatcher: sourceMatch, Optional: true, Repeat: true, Clauses: moreSources, Name: "sqlSelect.sources"},
 		{Token: TokenWhere, Lexer: LexConditionalClause, Optional: true, Clauses: whereQuery, Name: "sqlSelect.where"},
-		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "sqlSelect.groupby"},
+		{Token: TokenGroupBy, Lexer: LexGroupBy, Optional: true, Name: "sqlSelect.groupby"},
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true, Name: "sqlSelect.having"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "sqlSelect.orderby"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "sqlSelect.limit"},
 		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "sqlSelect.offset"},
+		{KeywordMatcher: compoundMatch, Lexer: LexCompound, Optional: true, Name: "sqlSelect.compound"},
 		{Token: TokenWith, Lexer: LexJsonOrKeyValue, Optional: true, Name: "sqlSelect.with"},
 		{Token: TokenAlias, Lexer: LexIdentifier, Optional: true, Name: "sqlSelect.alias"},
 		{Token: TokenEOF, Lexer: LexEndOfStatement, Optional: false, Name: "sqlSelect.eos"},
 	}
+	// SqlWith select statement preceded by common table expressions.
+	SqlWith = []*Clause{
+		{Token: TokenWith, Lexer: LexCommonTableExpr, Name: "sqlWith.with"},
+	}
 	fromSource = []*Clause{
 		{KeywordMatcher: sourceMatch, Lexer: LexTableReferenceFirst, Name: "fromSource.matcher"},
 		{Token: TokenSelect, Lexer: LexSelectClause, Name: "fromSource.Select"},
 		{Token: TokenFrom, Lexer: LexTableReferenceFirst, Optional: true, Repeat: true, Name: "fromSource.From"},
 		{Token: TokenWhere, Lexer: LexConditionalClause, Optional: true, Name: "fromSource.Where"},
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true, Name: "fromSource.having"},
-		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "fromSource.GroupBy"},
+		{Token: TokenGroupBy, Lexer: LexGroupBy, Optional: true, Name: "fromSource.GroupBy"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "fromSource.OrderBy"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "fromSource.Limit"},
 		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "fromSource.Offset"},
@@ -84,7 +92,7 @@ var (
 		{Token: TokenFrom, Lexer: LexTableReferenceFirst, Optional: true, Repeat: true, Name: "moreSources.From"},
 		{Token: TokenWhere, Lexer: LexConditionalClause, Optional: true, Name: "moreSources.Where"},
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true, Name: "moreSources.Having"},
-		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "moreSources.GroupBy"},
+		{Token: TokenGroupBy, Lexer: LexGroupBy, Optional: true, Name: "moreSources.GroupBy"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "moreSources.OrderBy"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "moreSources.Limit"},
 		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "moreSources.Offset"},
@@ -97,7 +105,7 @@ var (
 		{Token: TokenFrom, Lexer: LexTableReferences, Optional: true, Repeat: true, Name: "whereQuery.From"},
 		{Token: TokenWhere, Lexer: LexConditionalClause, Optional: true, Name: "whereQuery.Where"},
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true, Name: "whereQuery.Having"},
-		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "whereQuery.GroupBy"},
+		{Token: TokenGroupBy, Lexer: LexGroupBy, Optional: true, Name: "whereQuery.GroupBy"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "whereQuery.OrderBy"},
 		{Token: TokenLimit, Lexer: LexNumber, Optional: true, Name: "whereQuery.Limit"},
 		{Token: TokenRightParenthesis, Lexer: LexEndOfSubStatement, Optional: false, Name: "whereQuery.EOS"},
@@ -131,7 +139,7 @@ var (
 		{Token: TokenFrom, Lexer: LexTableReferences, Optional: true, Repeat: true},
 		{Token: TokenWhere, Lexer: LexConditionalClause, Optional: true},
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true},
-		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true},
+		{Token: TokenGroupBy, Lexer: LexGroupBy, Optional: true},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true},
 		{Token: TokenLimit, Lexer: LexNumber, Optional: true},
 	}
@@ -208,6 +216,10 @@ var (
 	SqlCommit = []*Clause{
 		{Token: TokenCommit, Lexer: LexEmpty},
 	}
+	// SqlAnalyze ANALYZE TABLE
+	SqlAnalyze = []*Clause{
+		{Token: TokenAnalyze, Lexer: LexColumns},
+	}
 )
 
 // NewSqlLexer creates a new lexer for the input string using SqlDialect
@@ -219,7 +231,7 @@ func NewSqlLexer(input string) *Lexer {
 // find any keyword that starts a source
 //    FROM <name>
 //    FROM (select ...)
-//         [(INNER | LEFT)] JOIN
+//         [(INNER | CROSS | LEFT | RIGHT | FULL)] JOIN
 func sourceMatch(c *Clause, peekWord string, l *Lexer) bool {
 	//u.Debugf("%p sourceMatch?   peekWord: %s", c, peekWord)
 	switch peekWord {
@@ -227,12 +239,177 @@ func sourceMatch(c *Clause, peekWord string, l *Lexer) bool {
 		return true
 	case "select":
 		return true
-	case "left", "right", "inner", "outer", "join":
+	case "left", "right", "full", "inner", "outer", "cross", "join":
+		return true
+	}
+	return false
+}
+
+// compoundMatch matches the set operators that join two select statements
+// into a compound select (UNION, INTERSECT, EXCEPT).
+func compoundMatch(c *Clause, peekWord string, l *Lexer) bool {
+	switch peekWord {
+	case "union", "intersect", "except":
 		return true
 	}
 	return false
 }
 
+// LexCompound lexes the set operator between two selects of a compound
+// select and then re-starts the select clauses for the next select.
+//
+//     <compound_select> := <select_stmt> [ (UNION [ALL|DISTINCT] | INTERSECT | EXCEPT) <select_stmt> ]*
+//
+func LexCompound(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	word := strings.ToLower(l.PeekWord())
+	switch word {
+	case "union":
+		l.ConsumeWord(word)
+		l.Emit(TokenUnion)
+	case "intersect":
+		l.ConsumeWord(word)
+		l.Emit(TokenIntersect)
+	case "except":
+		l.ConsumeWord(word)
+		l.Emit(TokenExcept)
+	default:
+		return l.errorToken("expected UNION, INTERSECT or EXCEPT but got: " + word)
+	}
+	l.SkipWhiteSpaces()
+	switch word = strings.ToLower(l.PeekWord()); word {
+	case "all":
+		l.ConsumeWord(word)
+		l.Emit(TokenAll)
+	case "distinct":
+		l.ConsumeWord(word)
+		l.Emit(TokenDistinct)
+	}
+	// Back to the top of the select statement clauses for next select.
+	if len(l.statement.Clauses) > 0 {
+		l.curClause = l.statement.Clauses[0]
+	}
+	return LexEmpty
+}
+
+// LexCommonTableExpr lexes the common table expressions of a WITH
+// statement, then hands off to the select statement clauses for the
+// main select.
+//
+//     WITH <cte> [, <cte>]* <select_stmt>
+//
+//     <cte> := <identity> [ '(' <identity> [, <identity>]* ')' ] AS '(' <select_stmt> ')'
+//
+func LexCommonTableExpr(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	l.Push("lexCommonTableAs", lexCommonTableAs)
+	return LexIdentifier
+}
+
+// optional column name list, then AS (select)
+func lexCommonTableAs(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if l.Peek() == '(' {
+		l.Push("lexCommonTableAs", lexCommonTableAs)
+		return LexColumnNames
+	}
+	if strings.ToLower(l.PeekWord()) != "as" {
+		return l.errorToken("expected AS for common table expression but got: " + l.PeekWord())
+	}
+	l.ConsumeWord("as")
+	l.Emit(TokenAs)
+	l.SkipWhiteSpaces()
+	if l.Peek() != '(' {
+		return l.errorToken("expected ( for common table expression")
+	}
+	l.Next()
+	l.Emit(TokenLeftParenthesis)
+
+	if matchingParen(l.input, l.pos) < 0 {
+		return l.errorToken("expected ) closing common table expression")
+	}
+	return lexSubSelect(l, lexCommonTableEnd)
+}
+
+// closing paren of a common table expression, then either another
+// expression or the main select.
+func lexCommonTableEnd(l *Lexer) StateFn {
+	l.Next()
+	l.Emit(TokenRightParenthesis)
+	l.SkipWhiteSpaces()
+	if l.Peek() == ',' {
+		l.Next()
+		l.Emit(TokenComma)
+		return LexCommonTableExpr
+	}
+	for _, stmt := range l.dialect.Statements {
+		if stmt.Token == TokenSelect {
+			l.statement = stmt
+			l.curClause = stmt.Clauses[0]
+			return nil
+		}
+	}
+	return l.errorToken("no select statement for common table expression")
+}
+
+// lexSubSelect lexes a select statement inside parens, whose ( has already
+// been emitted, with its own lexer, forwarding its tokens adjusted to
+// positions in our input.  Leaves the closing ) to be lexed by next.
+func lexSubSelect(l *Lexer, next StateFn) StateFn {
+	start := l.pos
+	end := matchingParen(l.input, start)
+	if end < 0 {
+		return l.errorToken("expected ) closing sub-select")
+	}
+	sub := NewLexer(l.input[start:end], l.dialect)
+	line := l.line
+	var forward StateFn
+	forward = func(l *Lexer) StateFn {
+		tok := sub.NextToken()
+		switch tok.T {
+		case TokenEOF, TokenEOS:
+			l.pos = end
+			l.start = end
+			return next
+		}
+		tok.Pos += start
+		tok.Line += line
+		l.lastToken = tok
+		l.tokens <- tok
+		if tok.T == TokenError {
+			return nil
+		}
+		return forward
+	}
+	return forward
+}
+
+// matchingParen finds the position of the ) closing the paren opened just
+// before start, ignoring parens inside quotes.  Returns -1 if not found.
+func matchingParen(input string, start int) int {
+	depth := 1
+	var quote byte
+	for i := start; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // LexEndOfSubStatement Look for end of statement defined by either
 // a semicolon or end of file.
 func LexEndOfSubStatement(l *Lexer) StateFn {
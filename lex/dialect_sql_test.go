@@ -358,3 +358,233 @@ func TestLexSqlSelect(t *testing.T) {
 			tv(TokenInteger, "100"),
 		})
 }
+
+func TestLexSqlSelectCompound(t *testing.T) {
+	verifyTokens(t, `SELECT a FROM t1 WHERE b = 1 UNION ALL SELECT a FROM t2 ORDER BY a LIMIT 5`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "t1"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "b"),
+			tv(TokenEqual, "="),
+			tv(TokenInteger, "1"),
+			tv(TokenUnion, "UNION"),
+			tv(TokenAll, "ALL"),
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "t2"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "a"),
+			tv(TokenLimit, "LIMIT"),
+			tv(TokenInteger, "5"),
+		})
+	verifyTokenTypes(t, `SELECT 1 INTERSECT SELECT 2 EXCEPT SELECT 3`,
+		[]TokenType{TokenSelect, TokenInteger,
+			TokenIntersect, TokenSelect, TokenInteger,
+			TokenExcept, TokenSelect, TokenInteger,
+		})
+}
+
+func TestLexSqlSelectWindow(t *testing.T) {
+	verifyTokens(t, `SELECT a, rank() OVER (PARTITION BY b ORDER BY c DESC) AS r FROM tbl`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenComma, ","),
+			tv(TokenUdfExpr, "rank"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenOver, "OVER"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenPartitionBy, "PARTITION BY"),
+			tv(TokenIdentity, "b"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "c"),
+			tv(TokenDesc, "DESC"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAs, "AS"),
+			tv(TokenIdentity, "r"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+		})
+	verifyTokenTypes(t, `SELECT sum(x) OVER (ORDER BY y ROWS BETWEEN 2 PRECEDING AND CURRENT ROW), lag(x, 1) OVER () FROM tbl`,
+		[]TokenType{TokenSelect,
+			TokenUdfExpr, TokenLeftParenthesis, TokenIdentity, TokenRightParenthesis,
+			TokenOver, TokenLeftParenthesis, TokenOrderBy, TokenIdentity,
+			TokenRows, TokenBetween, TokenInteger, TokenPreceding, TokenLogicAnd, TokenCurrentRow,
+			TokenRightParenthesis, TokenComma,
+			TokenUdfExpr, TokenLeftParenthesis, TokenIdentity, TokenComma, TokenInteger, TokenRightParenthesis,
+			TokenOver, TokenLeftParenthesis, TokenRightParenthesis,
+			TokenFrom, TokenIdentity,
+		})
+	verifyTokenTypes(t, `SELECT rank() OVER (ORDER BY y DESC NULLS LAST) FROM tbl`,
+		[]TokenType{TokenSelect,
+			TokenUdfExpr, TokenLeftParenthesis, TokenRightParenthesis,
+			TokenOver, TokenLeftParenthesis, TokenOrderBy, TokenIdentity, TokenDesc, TokenNullsLast,
+			TokenRightParenthesis,
+			TokenFrom, TokenIdentity,
+		})
+}
+
+func TestLexSqlOrderByNulls(t *testing.T) {
+	verifyTokens(t, `SELECT a FROM tbl ORDER BY a ASC NULLS LAST, toint(b) nulls first LIMIT 10`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "a"),
+			tv(TokenAsc, "ASC"),
+			tv(TokenNullsLast, "NULLS LAST"),
+			tv(TokenComma, ","),
+			tv(TokenUdfExpr, "toint"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "b"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenNullsFirst, "nulls first"),
+			tv(TokenLimit, "LIMIT"),
+			tv(TokenInteger, "10"),
+		})
+}
+
+func TestLexSqlGroupingSets(t *testing.T) {
+	verifyTokens(t, `SELECT a, b, grouping(a) FROM tbl GROUP BY ROLLUP (a, b) HAVING x > 1`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "b"),
+			tv(TokenComma, ","),
+			tv(TokenUdfExpr, "grouping"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "a"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+			tv(TokenGroupBy, "GROUP BY"),
+			tv(TokenRollup, "ROLLUP"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "a"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "b"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenHaving, "HAVING"),
+			tv(TokenIdentity, "x"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "1"),
+		})
+	verifyTokenTypes(t, `SELECT a FROM tbl GROUP BY a, CUBE(b, toint(c))`,
+		[]TokenType{TokenSelect, TokenIdentity, TokenFrom, TokenIdentity,
+			TokenGroupBy, TokenIdentity, TokenComma,
+			TokenCube, TokenLeftParenthesis, TokenIdentity, TokenComma,
+			TokenUdfExpr, TokenLeftParenthesis, TokenIdentity, TokenRightParenthesis,
+			TokenRightParenthesis,
+		})
+	verifyTokens(t, `SELECT a FROM tbl GROUP BY GROUPING SETS ((a, b), ()) ORDER BY a`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+			tv(TokenGroupBy, "GROUP BY"),
+			tv(TokenGroupingSets, "GROUPING SETS"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "a"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "b"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenComma, ","),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "a"),
+		})
+	// grouping is still a column name
+	verifyTokenTypes(t, `SELECT a FROM tbl GROUP BY a, grouping`,
+		[]TokenType{TokenSelect, TokenIdentity, TokenFrom, TokenIdentity,
+			TokenGroupBy, TokenIdentity, TokenComma, TokenIdentity,
+		})
+}
+
+func TestLexSqlWith(t *testing.T) {
+	verifyTokens(t, `WITH big AS (SELECT a FROM tbl WHERE b > 10) SELECT a FROM big`,
+		[]Token{
+			tv(TokenWith, "WITH"),
+			tv(TokenIdentity, "big"),
+			tv(TokenAs, "AS"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "b"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "10"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "big"),
+		})
+	verifyTokenTypes(t, `WITH x (a, b) AS (SELECT a, count(*) FROM t GROUP BY a), y AS (SELECT a FROM x WHERE c = "(")
+		SELECT a FROM y UNION SELECT a FROM x`,
+		[]TokenType{TokenWith,
+			TokenIdentity, TokenLeftParenthesis, TokenIdentity, TokenComma, TokenIdentity, TokenRightParenthesis,
+			TokenAs, TokenLeftParenthesis,
+			TokenSelect, TokenIdentity, TokenComma, TokenUdfExpr, TokenLeftParenthesis, TokenStar, TokenRightParenthesis,
+			TokenFrom, TokenIdentity, TokenGroupBy, TokenIdentity,
+			TokenRightParenthesis, TokenComma,
+			TokenIdentity, TokenAs, TokenLeftParenthesis,
+			TokenSelect, TokenIdentity, TokenFrom, TokenIdentity, TokenWhere, TokenIdentity, TokenEqual, TokenValue,
+			TokenRightParenthesis,
+			TokenSelect, TokenIdentity, TokenFrom, TokenIdentity,
+			TokenUnion,
+			TokenSelect, TokenIdentity, TokenFrom, TokenIdentity,
+		})
+}
+
+func TestLexSqlParams(t *testing.T) {
+	verifyTokens(t, `SELECT a FROM tbl WHERE b = ? AND c IN (?, $2) AND toint(d) > $10`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "a"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "b"),
+			tv(TokenEqual, "="),
+			tv(TokenParam, "?"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenIdentity, "c"),
+			tv(TokenIN, "IN"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenParam, "?"),
+			tv(TokenComma, ","),
+			tv(TokenParam, "$2"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenUdfExpr, "toint"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "d"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenGT, ">"),
+			tv(TokenParam, "$10"),
+		})
+	verifyTokenTypes(t, `INSERT INTO tbl (a, b) VALUES (?, ?)`,
+		[]TokenType{TokenInsert, TokenInto, TokenTable,
+			TokenLeftParenthesis, TokenIdentity, TokenComma, TokenIdentity, TokenRightParenthesis,
+			TokenValues, TokenLeftParenthesis, TokenParam, TokenComma, TokenParam, TokenRightParenthesis,
+		})
+	verifyTokenTypes(t, `UPDATE tbl SET a = ? WHERE b = $1`,
+		[]TokenType{TokenUpdate, TokenTable, TokenSet,
+			TokenIdentity, TokenEqual, TokenParam,
+			TokenWhere, TokenIdentity, TokenEqual, TokenParam,
+		})
+}
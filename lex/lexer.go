@@ -105,6 +105,7 @@ type Lexer struct {
 	peekedWordPos int
 	peekedWord    string
 	lastQuoteMark byte
+	params        int // ? bind parameters parsed so far, see NextParam
 
 	// Due to nested Expressions and evaluation this allows us to descend/ascend
 	// during lex, using push/pop to add and remove states needing evaluation
@@ -263,6 +264,19 @@ func (l *Lexer) peekRunePast(skip int) rune {
 	return rune(0)
 }
 
+// non-consuming check for a parenthesized sub-select ie  (SELECT ...)
+func (l *Lexer) isSubSelectNext() bool {
+	rest := strings.TrimLeftFunc(l.input[l.pos:], unicode.IsSpace)
+	if len(rest) == 0 || rest[0] != '(' {
+		return false
+	}
+	rest = strings.TrimLeftFunc(rest[1:], unicode.IsSpace)
+	if len(rest) < 6 || !strings.EqualFold(rest[:6], "select") {
+		return false
+	}
+	return len(rest) == 6 || !IsIdentifierRune(rune(rest[6]))
+}
+
 // PeekWord grab the next word (till whitespace, without consuming)
 func (l *Lexer) PeekWord() string {
 
@@ -412,6 +426,13 @@ func (l *Lexer) Emit(t TokenType) {
 	l.start = l.pos
 }
 
+// NextParam the zero based position of the next ? bind parameter parsed
+// from this lexer's statement, called by the parser for each ? token.
+func (l *Lexer) NextParam() int {
+	l.params++
+	return l.params - 1
+}
+
 // ignore skips over the pending input before this point.
 func (l *Lexer) ignore() {
 	l.start = l.pos
@@ -650,6 +671,8 @@ func (l *Lexer) isNextKeyword(peekWord string) bool {
 	}
 	kwMaybe := strings.ToLower(peekWord)
 	//u.Debugf("isNextKeyword?  '%s'   len:%v", kwMaybe, len(l.statement.Clauses))
+	// ie RIGHT(email, 10) is a function not a keyword
+	isFunc := strings.HasSuffix(l.PeekX(len(peekWord)+1), "(")
 
 	clause := l.curClause.next
 	if clause == nil {
@@ -669,9 +692,14 @@ func (l *Lexer) isNextKeyword(peekWord string) bool {
 			//u.Infof("return true:  %v", strings.ToLower(l.PeekX(len(clause.fullWord))))
 			return true
 		}
+		if clause.KeywordMatcher != nil && isAlpha(rune(kwMaybe[0])) && !isFunc && clause.KeywordMatcher(clause, kwMaybe, l) {
+			// ie the LEFT JOIN of another source after an ON
+			return true
+		}
 		// TODO:  allow clauses to reserve keywords, or sub-clause
 		switch kwMaybe {
-		case "select", "insert", "delete", "update", "from", "inner", "outer":
+		case "select", "insert", "delete", "update", "from", "inner", "outer",
+			"union", "intersect", "except":
 			//u.Warnf("doing true: %v", kwMaybe)
 			return true
 		}
@@ -995,6 +1023,8 @@ func LexEmpty(l *Lexer) StateFn { return nil }
 //  1.23  -> [float] = 1.23
 //  100   -> [integer] = 100
 //  ["hello","world"]  -> [array] {"hello","world"}
+//  ?     -> [param] = ?
+//  $2    -> [param] = $2
 //
 func LexValue(l *Lexer) StateFn {
 
@@ -1008,6 +1038,10 @@ func LexValue(l *Lexer) StateFn {
 	//u.Debugf("LexValue: rune=%v  peek:%v", string(rune), l.PeekX(10))
 
 	switch rune {
+	case '?':
+		// bind parameter placeholder
+		l.Emit(TokenParam)
+		return nil
 	case ')':
 		// this is a mistake and should not happen
 		u.Warnf("why did we get paren? going to panic")
@@ -1076,6 +1110,14 @@ func LexValue(l *Lexer) StateFn {
 			}
 		}
 	default:
+		if rune == '$' && isDigit(l.Peek()) {
+			//  $1  numbered bind parameter placeholder
+			for isDigit(l.Peek()) {
+				l.Next()
+			}
+			l.Emit(TokenParam)
+			return nil
+		}
 		if rune == '*' {
 			u.LogTracef(u.WARN, "why are we having a star here? %v", l.PeekX(10))
 		}
@@ -1163,6 +1205,9 @@ func LexExpressionOrIdentity(l *Lexer) StateFn {
 		l.Push("LexParenRight", LexParenRight)
 		return LexExpressionOrIdentity
 	}
+	if _, isCase := caseTokens[strings.ToLower(l.PeekWord())]; isCase {
+		return LexExpression
+	}
 	// u.Debugf("LexExpressionOrIdentity identity?%v expr?%v %v peek5='%v'", l.isIdentity(), l.isExpr(), string(l.Peek()), string(l.PeekX(5)))
 	// Expressions end in Parens:     LOWER(item)
 	if l.isExpr() {
@@ -1177,6 +1222,15 @@ func LexExpressionOrIdentity(l *Lexer) StateFn {
 	}
 }
 
+// keywords of the CASE expression
+var caseTokens = map[string]TokenType{
+	"case": TokenCase,
+	"when": TokenWhen,
+	"then": TokenThen,
+	"else": TokenElse,
+	"end":  TokenEnd,
+}
+
 // look for either an Identity or Value
 //
 func LexIdentityOrValue(l *Lexer) StateFn {
@@ -1804,10 +1858,10 @@ func LexSelectList(l *Lexer) StateFn {
 //
 //    SELECT ...  FROM <sources>
 //
-//    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <sources>      := <source> [(, | <join_clause>) <source>]*
+//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>] [ON <conditional_clause>]
 //    <table_source> := <identifier>
-//    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
+//    <join_clause>  := (INNER | CROSS | (LEFT | RIGHT | FULL) [OUTER])? JOIN
 //    <subselect>    := '(' <select_stmt> ')'
 //
 func LexTableReferenceFirst(l *Lexer) StateFn {
@@ -1857,6 +1911,9 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
 		l.Push("LexIdentifier", LexIdentifier)
 		return nil
+	case "left", "right", "full", "inner", "outer", "cross", "join":
+		// start of a joined source
+		return nil
 	case "in": // are there other functions besides in?
 		l.ConsumeWord(word)
 		l.Emit(TokenIN)
@@ -1868,6 +1925,7 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 	default:
 		r = l.Peek()
 		if r == ',' {
+			l.Next()
 			l.Emit(TokenComma)
 			l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
 			return LexExpressionOrIdentity
@@ -1894,10 +1952,10 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 //
 //    SELECT ...  FROM <sources>
 //
-//    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <sources>      := <source> [(, | <join_clause>) <source>]*
+//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>] [ON <conditional_clause>]
 //    <table_source> := <identifier>
-//    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
+//    <join_clause>  := (INNER | CROSS | (LEFT | RIGHT | FULL) [OUTER])? JOIN
 //    <subselect>    := '(' <select_stmt> ')'
 //
 func LexTableReferences(l *Lexer) StateFn {
@@ -1968,6 +2026,14 @@ func LexTableReferences(l *Lexer) StateFn {
 		l.ConsumeWord(word)
 		l.Emit(TokenRight)
 		return LexTableReferences
+	case "full":
+		l.ConsumeWord(word)
+		l.Emit(TokenFull)
+		return LexTableReferences
+	case "cross":
+		l.ConsumeWord(word)
+		l.Emit(TokenCross)
+		return LexTableReferences
 	case "join":
 		l.ConsumeWord(word)
 		l.Emit(TokenJoin)
@@ -1990,6 +2056,7 @@ func LexTableReferences(l *Lexer) StateFn {
 	default:
 		r = l.Peek()
 		if r == ',' {
+			l.Next()
 			l.Emit(TokenComma)
 			l.Push("LexTableReferences", LexTableReferences)
 			return LexExpressionOrIdentity
@@ -2019,10 +2086,10 @@ func LexTableReferences(l *Lexer) StateFn {
 //
 //    SELECT ...  FROM <sources>
 //
-//    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <sources>      := <source> [(, | <join_clause>) <source>]*
+//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>] [ON <conditional_clause>]
 //    <table_source> := <identifier>
-//    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
+//    <join_clause>  := (INNER | CROSS | (LEFT | RIGHT | FULL) [OUTER])? JOIN
 //    <subselect>    := '(' <select_stmt> ')'
 //
 func LexJoinEntry(l *Lexer) StateFn {
@@ -2078,6 +2145,14 @@ func LexJoinEntry(l *Lexer) StateFn {
 		l.ConsumeWord(word)
 		l.Emit(TokenRight)
 		return LexJoinEntry
+	case "full":
+		l.ConsumeWord(word)
+		l.Emit(TokenFull)
+		return LexJoinEntry
+	case "cross":
+		l.ConsumeWord(word)
+		l.Emit(TokenCross)
+		return LexJoinEntry
 	case "join":
 		l.ConsumeWord(word)
 		l.Emit(TokenJoin)
@@ -2252,6 +2327,14 @@ func LexConditionalClause(l *Lexer) StateFn {
 		//l.Push("LexParenRight", LexParenRight)
 		return nil
 	case '(':
+		if l.isSubSelectNext() {
+			// (SELECT ...) sub-select value, ie  (SELECT count(*) ...) > 1
+			l.Next()
+			l.Emit(TokenLeftParenthesis)
+			l.Push("LexConditionalClause", LexConditionalClause)
+			l.Push("LexExpression", LexExpression)
+			return lexSubSelect(l, LexParenRight)
+		}
 		l.Next()
 		l.Emit(TokenLeftParenthesis)
 		l.Push("LexConditionalClause", LexConditionalClause)
@@ -2284,6 +2367,82 @@ func LexColumns(l *Lexer) StateFn {
 	return LexExpression(l)
 }
 
+// LexGroupBy the columns of a group by, which may be grouping sets
+//
+//     GROUP BY <expr> [, <expr>]*
+//     GROUP BY [<expr>,]* (ROLLUP | CUBE) ( <expr> [, <expr>]* )
+//     GROUP BY GROUPING SETS ( ( [<expr> [, <expr>]*] ) [, ...] )
+//
+func LexGroupBy(l *Lexer) StateFn {
+
+	l.SkipWhiteSpaces()
+	if l.IsEnd() {
+		return nil
+	}
+
+	word := strings.ToLower(l.PeekWord())
+	switch word {
+	case "rollup", "cube":
+		l.ConsumeWord(word)
+		if word == "rollup" {
+			l.Emit(TokenRollup)
+		} else {
+			l.Emit(TokenCube)
+		}
+		return lexGroupingList(0)
+	case "grouping":
+		words := strings.Fields(strings.ToLower(l.PeekX(len(word) + 20)))
+		if len(words) > 1 && (words[1] == "sets" || strings.HasPrefix(words[1], "sets(")) {
+			l.ConsumeWord(word)
+			for isWhiteSpace(l.Peek()) {
+				l.Next()
+			}
+			l.ConsumeWord("sets")
+			l.Emit(TokenGroupingSets)
+			return lexGroupingList(0)
+		}
+	}
+	return LexExpression(l)
+}
+
+// lexGroupingList the parenthesized list of ROLLUP, CUBE or GROUPING SETS,
+// the sets of GROUPING SETS are nested lists which may be empty.
+func lexGroupingList(depth int) StateFn {
+	return func(l *Lexer) StateFn {
+
+		l.SkipWhiteSpaces()
+		if l.IsEnd() {
+			return l.errorToken("expected ) to close grouping list")
+		}
+
+		switch l.Peek() {
+		case '(':
+			l.Next()
+			l.Emit(TokenLeftParenthesis)
+			return lexGroupingList(depth + 1)
+		case ')':
+			if depth == 0 {
+				return l.errorToken("expected ( to open grouping list")
+			}
+			l.Next()
+			l.Emit(TokenRightParenthesis)
+			if depth == 1 {
+				return l.clauseState()
+			}
+			return lexGroupingList(depth - 1)
+		case ',':
+			l.Next()
+			l.Emit(TokenComma)
+			return lexGroupingList(depth)
+		}
+		if depth == 0 {
+			return l.errorToken("expected ( to open grouping list")
+		}
+		l.Push("lexGroupingList", lexGroupingList(depth))
+		return LexExpressionOrIdentity
+	}
+}
+
 // LexLogical is a lex entry function for logical expression language (+-/> etc)
 //   ie, the full logical boolean logic
 //
@@ -2363,6 +2522,12 @@ func LexExpression(l *Lexer) StateFn {
 		u.Warnf("un-handled? ")
 	case '(': // this is a logical Grouping/Ordering and must be a single
 		// logically valid expression
+		if strings.ToLower(l.PeekWord()) == "select" {
+			// (SELECT ...) sub-select
+			l.Emit(TokenLeftParenthesis)
+			l.Push("LexExpression", l.clauseState())
+			return lexSubSelect(l, LexParenRight)
+		}
 		l.Push("LexParenRight", LexParenRight)
 		l.Emit(TokenLeftParenthesis)
 		l.Push("LexExpression", l.clauseState())
@@ -2491,7 +2656,7 @@ func LexExpression(l *Lexer) StateFn {
 				l.SkipWhiteSpaces()
 				word = strings.ToLower(l.PeekWord())
 				if word == "select" {
-					return nil
+					return lexSubSelect(l, LexParenRight)
 				}
 				l.Push("LexParenRight", LexParenRight)
 				return LexListOfArgs
@@ -2509,6 +2674,7 @@ func LexExpression(l *Lexer) StateFn {
 		case "like":
 			l.ConsumeWord(word)
 			l.Emit(TokenLike)
+			l.Push("LexExpression", l.clauseState())
 			return LexExpressionOrIdentity
 		case "contains":
 			l.ConsumeWord(word)
@@ -2526,6 +2692,21 @@ func LexExpression(l *Lexer) StateFn {
 			l.Push("LexExpressionOrIdentity", LexExpressionOrIdentity)
 			return nil
 		}
+	case "case", "when", "then", "else":
+		//  CASE [<expr>] WHEN <expr> THEN <expr> [ELSE <expr>] END
+		l.ConsumeWord(word)
+		l.Emit(caseTokens[word])
+		return LexExpression
+	case "end":
+		l.ConsumeWord(word)
+		l.Emit(TokenEnd)
+		return l.clauseState()
+	case "over":
+		//  <window_func> OVER ( <window_spec> )
+		l.ConsumeWord(word)
+		l.Emit(TokenOver)
+		l.Push("LexExpression", l.clauseState())
+		return LexWindowSpec
 	case "include":
 		l.ConsumeWord(word)
 		l.Emit(TokenInclude)
@@ -2533,6 +2714,14 @@ func LexExpression(l *Lexer) StateFn {
 	case "exists":
 		l.ConsumeWord(word)
 		r = l.Peek()
+		if l.isSubSelectNext() {
+			//  EXISTS (SELECT ...)
+			l.Emit(TokenExists)
+			l.SkipWhiteSpaces()
+			l.Next()
+			l.Emit(TokenLeftParenthesis)
+			return lexSubSelect(l, LexParenRight)
+		}
 		if r == '(' {
 			l.Emit(TokenUdfExpr)
 			l.ConsumeWord("(")
@@ -2643,7 +2832,7 @@ func LexExpression(l *Lexer) StateFn {
 
 // Handle columnar identies with keyword appendate (ASC, DESC)
 //
-//     [ORDER BY] ( <identity> | <expr> ) [(ASC | DESC)]
+//     [ORDER BY] ( <identity> | <expr> ) [(ASC | DESC)] [NULLS (FIRST | LAST)]
 //
 func LexOrderByColumn(l *Lexer) StateFn {
 
@@ -2666,6 +2855,9 @@ func LexOrderByColumn(l *Lexer) StateFn {
 		l.Emit(TokenComma)
 		l.Push("LexOrderByColumn", LexOrderByColumn)
 		return LexExpressionOrIdentity
+	case '!', '=', '>', '<', '+', '-', '*', '/', '%':
+		// operators of an expression such as CASE WHEN x > 1 ...
+		return LexExpression
 	}
 
 	word := strings.ToLower(l.PeekWord())
@@ -2683,8 +2875,10 @@ func LexOrderByColumn(l *Lexer) StateFn {
 		l.ConsumeWord(word)
 		l.Emit(TokenDesc)
 		return LexOrderByColumn
+	case "nulls":
+		return lexNullsOrder(l, LexOrderByColumn)
 	default:
-		if len(l.stack) < 2 {
+		if len(l.stack) < 100 {
 			l.Push("LexOrderByColumn", LexOrderByColumn)
 			return LexExpressionOrIdentity
 		} else {
@@ -2696,6 +2890,115 @@ func LexOrderByColumn(l *Lexer) StateFn {
 	return nil
 }
 
+// lexNullsOrder lex the null ordering of an order by column then
+// continue with next
+//
+//     NULLS (FIRST | LAST)
+//
+func lexNullsOrder(l *Lexer, next StateFn) StateFn {
+	l.ConsumeWord("nulls")
+	for isWhiteSpace(l.Peek()) {
+		l.Next()
+	}
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "first":
+		l.ConsumeWord(word)
+		l.Emit(TokenNullsFirst)
+	case "last":
+		l.ConsumeWord(word)
+		l.Emit(TokenNullsLast)
+	default:
+		return l.errorToken("expected FIRST or LAST after NULLS")
+	}
+	return next
+}
+
+// LexWindowSpec the parenthesized window specification of a window function
+//
+//     OVER ( [PARTITION BY <expr> [, <expr>]*] [ORDER BY <expr> [(ASC | DESC)] [NULLS (FIRST | LAST)] [, ...]] [<frame>] )
+//
+//     <frame>       := (ROWS | RANGE) ( <frame_bound> | BETWEEN <frame_bound> AND <frame_bound> )
+//     <frame_bound> := UNBOUNDED (PRECEDING | FOLLOWING) | CURRENT ROW | <integer> (PRECEDING | FOLLOWING)
+//
+func LexWindowSpec(l *Lexer) StateFn {
+
+	l.SkipWhiteSpaces()
+	if l.IsEnd() {
+		return nil
+	}
+
+	r := l.Peek()
+	switch r {
+	case '(':
+		l.Next()
+		l.Emit(TokenLeftParenthesis)
+		return LexWindowSpec
+	case ')':
+		l.Next()
+		l.Emit(TokenRightParenthesis)
+		return nil
+	case ',':
+		l.Next()
+		l.Emit(TokenComma)
+		return LexWindowSpec
+	}
+	if isDigit(r) {
+		l.Push("LexWindowSpec", LexWindowSpec)
+		return LexNumber
+	}
+
+	word := strings.ToLower(l.PeekWord())
+	switch word {
+	case "partition", "order":
+		l.ConsumeWord(word)
+		for isWhiteSpace(l.Peek()) {
+			l.Next()
+		}
+		if strings.ToLower(l.PeekWord()) != "by" {
+			return l.errorToken("expected BY after " + word)
+		}
+		l.ConsumeWord("by")
+		if word == "partition" {
+			l.Emit(TokenPartitionBy)
+		} else {
+			l.Emit(TokenOrderBy)
+		}
+		return LexWindowSpec
+	case "current":
+		l.ConsumeWord(word)
+		for isWhiteSpace(l.Peek()) {
+			l.Next()
+		}
+		if strings.ToLower(l.PeekWord()) != "row" {
+			return l.errorToken("expected ROW after CURRENT")
+		}
+		l.ConsumeWord("row")
+		l.Emit(TokenCurrentRow)
+		return LexWindowSpec
+	case "nulls":
+		return lexNullsOrder(l, LexWindowSpec)
+	case "asc", "desc", "rows", "range", "between", "and", "unbounded", "preceding", "following":
+		l.ConsumeWord(word)
+		l.Emit(windowTokens[word])
+		return LexWindowSpec
+	}
+	l.Push("LexWindowSpec", LexWindowSpec)
+	return LexExpressionOrIdentity
+}
+
+// keywords of the window specification
+var windowTokens = map[string]TokenType{
+	"asc":       TokenAsc,
+	"desc":      TokenDesc,
+	"rows":      TokenRows,
+	"range":     TokenRange,
+	"between":   TokenBetween,
+	"and":       TokenLogicAnd,
+	"unbounded": TokenUnbounded,
+	"preceding": TokenPreceding,
+	"following": TokenFollowing,
+}
+
 // Lex either Json or Key/Value pairs
 //
 //    Must start with { or [ for json
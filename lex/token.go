@@ -123,6 +123,11 @@ const (
 	TokenNull             TokenType = 88 // NULL
 	TokenContains         TokenType = 89 // CONTAINS
 	TokenIntersects       TokenType = 90 // INTERSECTS
+	TokenCase             TokenType = 91 // CASE
+	TokenWhen             TokenType = 92 // WHEN
+	TokenThen             TokenType = 93 // THEN
+	TokenElse             TokenType = 94 // ELSE
+	TokenEnd              TokenType = 95 // END
 
 	// ql top-level keywords, these first keywords determine parser
 	TokenPrepare   TokenType = 200
@@ -142,6 +147,7 @@ const (
 	TokenReplace   TokenType = 214 // Insert/Replace are interchangeable on insert statements
 	TokenRollback  TokenType = 215
 	TokenCommit    TokenType = 216
+	TokenAnalyze   TokenType = 217 // ANALYZE TABLE
 
 	// Other QL Keywords, These are clause-level keywords that mark separation between clauses
 	TokenFrom     TokenType = 300 // from
@@ -172,6 +178,30 @@ const (
 	TokenSession  TokenType = 325 // SESSION
 	TokenTables   TokenType = 326 // TABLES
 
+	// Compound select set operators
+	TokenUnion     TokenType = 327 // UNION
+	TokenIntersect TokenType = 328 // INTERSECT
+	TokenExcept    TokenType = 329 // EXCEPT
+
+	// Window function keywords
+	TokenOver        TokenType = 330 // OVER
+	TokenPartitionBy TokenType = 331 // partition by
+	TokenRows        TokenType = 332 // ROWS
+	TokenRange       TokenType = 333 // RANGE
+	TokenUnbounded   TokenType = 334 // UNBOUNDED
+	TokenPreceding   TokenType = 335 // PRECEDING
+	TokenFollowing   TokenType = 336 // FOLLOWING
+	TokenCurrentRow  TokenType = 337 // current row
+
+	// Order by null ordering
+	TokenNullsFirst TokenType = 338 // nulls first
+	TokenNullsLast  TokenType = 339 // nulls last
+
+	// Group by grouping sets
+	TokenRollup       TokenType = 340 // ROLLUP
+	TokenCube         TokenType = 341 // CUBE
+	TokenGroupingSets TokenType = 342 // grouping sets
+
 	// ddl major words
 	TokenSchema         TokenType = 400 // SCHEMA
 	TokenDatabase       TokenType = 401 // DATABASE
@@ -212,6 +242,7 @@ const (
 	TokenValueEscaped TokenType = 602 // '' becomes ' inside the string, parser will need to replace the string
 	TokenRegex        TokenType = 603 // regex
 	TokenDuration     TokenType = 604 // 14d , 22w, 3y, 45ms, 45us, 24hr, 2h, 45m, 30s
+	TokenParam        TokenType = 605 // ? or $1 bind parameter placeholder
 
 	// Data Type Definitions
 	TokenTypeDef     TokenType = 999
@@ -304,6 +335,11 @@ var (
 		TokenNull:       {Kw: "null", Description: "NULL"},
 		TokenContains:   {Kw: "contains", Description: "contains"},
 		TokenIntersects: {Kw: "intersects", Description: "intersects"},
+		TokenCase:       {Kw: "case", Description: "CASE"},
+		TokenWhen:       {Kw: "when", Description: "WHEN"},
+		TokenThen:       {Kw: "then", Description: "THEN"},
+		TokenElse:       {Kw: "else", Description: "ELSE"},
+		TokenEnd:        {Kw: "end", Description: "END"},
 
 		// Identity ish bools
 		TokenTrue:  {Kw: "true", Description: "True"},
@@ -334,6 +370,7 @@ var (
 		TokenReplace:   {Description: "replace"},
 		TokenRollback:  {Description: "rollback"},
 		TokenCommit:    {Description: "commit"},
+		TokenAnalyze:   {Description: "analyze"},
 
 		// Top Level dml ql clause keywords
 		TokenInto:    {Description: "into"},
@@ -365,6 +402,30 @@ var (
 		TokenSession:  {Description: "session"},
 		TokenTables:   {Description: "tables"},
 
+		// compound select set operators
+		TokenUnion:     {Description: "union"},
+		TokenIntersect: {Description: "intersect"},
+		TokenExcept:    {Description: "except"},
+
+		// window function keywords
+		TokenOver:        {Description: "over"},
+		TokenPartitionBy: {Description: "partition by"},
+		TokenRows:        {Description: "rows"},
+		TokenRange:       {Description: "range"},
+		TokenUnbounded:   {Description: "unbounded"},
+		TokenPreceding:   {Description: "preceding"},
+		TokenFollowing:   {Description: "following"},
+		TokenCurrentRow:  {Description: "current row"},
+
+		// order by null ordering
+		TokenNullsFirst: {Description: "nulls first"},
+		TokenNullsLast:  {Description: "nulls last"},
+
+		// group by grouping sets
+		TokenRollup:       {Description: "rollup"},
+		TokenCube:         {Description: "cube"},
+		TokenGroupingSets: {Description: "grouping sets"},
+
 		// ddl keywords
 		TokenSchema:         {Description: "schema"},
 		TokenDatabase:       {Description: "database"},
@@ -401,6 +462,7 @@ var (
 		TokenValueEscaped: {Description: "value-escaped"},
 		TokenRegex:        {Description: "regex"},
 		TokenDuration:     {Description: "duration"},
+		TokenParam:        {Description: "param"},
 
 		// Data TYPES:  ie type system
 		TokenTypeDef:     {Description: "TypeDef"}, // Generic DataType
@@ -0,0 +1,245 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+)
+
+// splitAggregates split an aggregate select whose aggregates are used inside
+// of expressions, or in HAVING/ORDER BY, into the aggregation statement the
+// GroupBy task calculates, with a column per group by expression and
+// aggregate func, and the post-aggregation statement whose columns, having
+// and order by reference those columns by name.  Returns nil if the GroupBy
+// task can calculate the select columns as they are, an error if a column
+// is neither grouped by nor aggregated.
+//
+//    SELECT user_id, sum(price) / count(*) AS a FROM orders GROUP BY user_id HAVING count(*) > 1
+//
+//    aggregation:       SELECT user_id AS _gb0, sum(price) AS _agg0, count(*) AS _agg1
+//    post-aggregation:  SELECT _gb0 AS user_id, _agg0 / _agg1 AS a HAVING _agg1 > 1
+//
+func splitAggregates(stmt *rel.SqlSelect) (*rel.SqlSelect, *rel.SqlSelect, error) {
+	if !needsAggSplit(stmt) {
+		return nil, nil, nil
+	}
+
+	agg := *stmt
+	agg.Columns = make(rel.Columns, 0, len(stmt.GroupBy))
+	agg.Having = nil
+	agg.OrderBy = nil
+	agg.Distinct = false
+	agg.Limit = 0
+	agg.Offset = 0
+	addCol := func(as string, n expr.Node) {
+		agg.Columns = append(agg.Columns, &rel.Column{As: as, Expr: n, Index: len(agg.Columns)})
+	}
+	for i, gb := range stmt.GroupBy {
+		addCol(fmt.Sprintf("_gb%d", i), gb.Expr)
+	}
+
+	aggKeys := make(map[string]string)
+	ungrouped := ""
+	var rewrite func(n expr.Node, aliases bool) expr.Node
+	rewrite = func(n expr.Node, aliases bool) expr.Node {
+		return replaceNodes(n, func(n expr.Node) expr.Node {
+			for i, gb := range stmt.GroupBy {
+				if gb.Expr != nil && n.Equal(gb.Expr) {
+					return expr.NewIdentityNodeVal(fmt.Sprintf("_gb%d", i))
+				}
+			}
+			switch nt := n.(type) {
+			case *expr.FuncNode:
+				if !nt.F.Aggregate {
+					return nil
+				}
+				key, exists := aggKeys[nt.String()]
+				if !exists {
+					key = fmt.Sprintf("_agg%d", len(aggKeys))
+					aggKeys[nt.String()] = key
+					addCol(key, nt)
+				}
+				return expr.NewIdentityNodeVal(key)
+			case *expr.IdentityNode:
+				// having and order by may use the alias of a column
+				if col := aliasedColumn(stmt, nt.Text); aliases && col != nil {
+					return rewrite(col.Expr, false)
+				}
+				if aliases && windowAlias(stmt, nt.Text) {
+					// window values are read by alias after the window task
+					return nil
+				}
+				if ungrouped == "" && len(expr.FilterSpecialIdentities([]string{nt.Text})) > 0 {
+					ungrouped = nt.Text
+				}
+			}
+			return nil
+		})
+	}
+
+	post := *stmt
+	post.Columns = make(rel.Columns, len(stmt.Columns))
+	for i, col := range stmt.Columns {
+		nc := *col
+		switch {
+		case col.Over != nil:
+			// window funcs are evaluated on the aggregated rows, only their
+			// arguments and partition/order expressions are rewritten.
+			if fn, ok := col.Expr.(*expr.FuncNode); ok {
+				nfn := *fn
+				nfn.Args = make([]expr.Node, len(fn.Args))
+				for ai, arg := range fn.Args {
+					nfn.Args[ai] = rewrite(arg, false)
+				}
+				nc.Expr = &nfn
+			}
+			over := *col.Over
+			over.PartitionBy = make([]expr.Node, len(col.Over.PartitionBy))
+			for pi, n := range col.Over.PartitionBy {
+				over.PartitionBy[pi] = rewrite(n, false)
+			}
+			over.OrderBy = make(rel.Columns, len(col.Over.OrderBy))
+			for oi, oc := range col.Over.OrderBy {
+				noc := *oc
+				if oc.Expr != nil {
+					noc.Expr = rewrite(oc.Expr, false)
+				}
+				over.OrderBy[oi] = &noc
+			}
+			nc.Over = &over
+		case !col.Star && col.Expr != nil:
+			nc.Expr = rewrite(col.Expr, false)
+		}
+		post.Columns[i] = &nc
+	}
+	if stmt.Having != nil {
+		post.Having = rewrite(stmt.Having, true)
+	}
+	post.OrderBy = make(rel.Columns, len(stmt.OrderBy))
+	for i, col := range stmt.OrderBy {
+		nc := *col
+		if col.Expr != nil {
+			nc.Expr = rewrite(col.Expr, true)
+		}
+		post.OrderBy[i] = &nc
+	}
+	if ungrouped != "" {
+		return nil, nil, fmt.Errorf("column %q must be in the GROUP BY or used in an aggregate function", ungrouped)
+	}
+	return &agg, &post, nil
+}
+
+// needsAggSplit are there select columns that are not a group by value or
+// an aggregate func, or aggregates in having or order by.
+func needsAggSplit(stmt *rel.SqlSelect) bool {
+	if stmt.Star {
+		return false
+	}
+	if stmt.IsWindowQuery() {
+		// window columns are calculated over the aggregated rows
+		return true
+	}
+	if stmt.Having != nil && len(expr.FindAllAggregates(stmt.Having)) > 0 {
+		return true
+	}
+	for _, col := range stmt.OrderBy {
+		if col.Expr != nil && len(expr.FindAllAggregates(col.Expr)) > 0 {
+			return true
+		}
+	}
+colLoop:
+	for _, col := range stmt.Columns {
+		if col.Expr == nil {
+			continue
+		}
+		for _, gb := range stmt.GroupBy {
+			if gb.As == col.As || col.Expr.Equal(gb.Expr) {
+				continue colLoop
+			}
+		}
+		fn, ok := col.Expr.(*expr.FuncNode)
+		if !ok || !fn.F.Aggregate {
+			return true
+		}
+		for _, arg := range fn.Args {
+			if len(expr.FindAllAggregates(arg)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aliasedColumn the select column of this alias, nil if none.
+func aliasedColumn(stmt *rel.SqlSelect, alias string) *rel.Column {
+	for _, col := range stmt.Columns {
+		if col.Expr != nil && !col.Star && col.Over == nil && strings.EqualFold(col.As, alias) {
+			return col
+		}
+	}
+	return nil
+}
+
+// windowAlias is this the alias of a window column of the select.
+func windowAlias(stmt *rel.SqlSelect, alias string) bool {
+	for _, col := range stmt.Columns {
+		if col.Over != nil && strings.EqualFold(col.As, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceNodes copy of the expression with each node that fn returns a
+// replacement for replaced, descending into the args of the others.
+func replaceNodes(n expr.Node, fn func(expr.Node) expr.Node) expr.Node {
+	if n == nil {
+		return nil
+	}
+	if rn := fn(n); rn != nil {
+		return rn
+	}
+	replaceArgs := func(args []expr.Node) []expr.Node {
+		nargs := make([]expr.Node, len(args))
+		for i, arg := range args {
+			nargs[i] = replaceNodes(arg, fn)
+		}
+		return nargs
+	}
+	switch nt := n.(type) {
+	case *expr.BinaryNode:
+		nn := *nt
+		nn.Args = replaceArgs(nt.Args)
+		return &nn
+	case *expr.BooleanNode:
+		nn := *nt
+		nn.Args = replaceArgs(nt.Args)
+		return &nn
+	case *expr.TriNode:
+		nn := *nt
+		nn.Args = replaceArgs(nt.Args)
+		return &nn
+	case *expr.ArrayNode:
+		nn := *nt
+		nn.Args = replaceArgs(nt.Args)
+		return &nn
+	case *expr.FuncNode:
+		nn := *nt
+		nn.Args = replaceArgs(nt.Args)
+		return &nn
+	case *expr.UnaryNode:
+		nn := *nt
+		nn.Arg = replaceNodes(nt.Arg, fn)
+		return &nn
+	case *expr.CaseNode:
+		nn := *nt
+		nn.Arg = replaceNodes(nt.Arg, fn)
+		nn.Whens = replaceArgs(nt.Whens)
+		nn.Thens = replaceArgs(nt.Thens)
+		nn.Else = replaceNodes(nt.Else, fn)
+		return &nn
+	}
+	return n
+}
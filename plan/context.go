@@ -2,6 +2,7 @@ package plan
 
 import (
 	"math/rand"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
@@ -28,6 +29,25 @@ func mathRandId() uint64 {
 	return uint64(rs.Int63())
 }
 
+// CommonTable is a common table expression (WITH name AS (SELECT ...)) of
+// the statement being planned.  Its select is planned once, and every
+// Source that references it by name reads its result, so if it is
+// referenced more than once the result is materialized once and shared.
+//
+// It is the schema.Conn of the Sources reading it.
+type CommonTable struct {
+	Stmt *rel.CommonTableExpr
+	Plan *Select       // plan of the select of the common table
+	Tbl  *schema.Table // table schema of the common table result
+	Refs int           // number of Sources reading this common table
+}
+
+// Columns of the common table result.
+func (m *CommonTable) Columns() []string { return m.Tbl.Columns() }
+
+// Close nothing to close, the result belongs to the executing job.
+func (m *CommonTable) Close() error { return nil }
+
 // Context for plan of a Relational task has info about the query
 // projection, schema, function resolvers necessary to plan this statement.
 // - may be transported across network boundaries to particpate in dag of tasks
@@ -51,8 +71,12 @@ type Context struct {
 	Schema  *schema.Schema         // this schema for this connection
 	Funcs   expr.FuncResolver      // Local/Dialect specific functions
 
+	// Common table expressions (WITH name AS (SELECT ...)) of statement, by lower-case name
+	CommonTables map[string]*CommonTable
+
 	// From configuration
 	DisableRecover bool
+	MemoryLimit    int64 // bytes of rows a task such as order by may hold in memory before spilling to disk, 0 for the exec default
 
 	// Local State
 	Errors     []error
@@ -85,6 +109,24 @@ func (m *Context) init() {
 	}
 }
 
+// CommonTable find the common table expression of given name, nil if
+// the statement has none of that name.
+func (m *Context) CommonTable(name string) *CommonTable {
+	if m == nil || len(m.CommonTables) == 0 {
+		return nil
+	}
+	return m.CommonTables[strings.ToLower(name)]
+}
+
+// AddCommonTable registers a planned common table so sources planned after
+// it may reference it by name.
+func (m *Context) AddCommonTable(ct *CommonTable) {
+	if m.CommonTables == nil {
+		m.CommonTables = make(map[string]*CommonTable)
+	}
+	m.CommonTables[strings.ToLower(ct.Stmt.Name)] = ct
+}
+
 // called by go routines/tasks to ensure any recovery panics are captured
 func (m *Context) ToPB() *ContextPb {
 	m.init()
@@ -0,0 +1,205 @@
+package plan
+
+import (
+	"strconv"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// PushPredicateSelectivity the estimated fraction of the rows of a
+	// joined source passing its where, at or above which the where is not
+	// pushed to the source, as it would filter few rows and the where of
+	// the select is evaluated after the join anyway.
+	PushPredicateSelectivity = 0.9
+)
+
+// selectivity of a predicate whose selectivity can't be estimated.
+const defaultSelectivity = 1.0 / 3
+
+// estimateRows the estimated rows of a source, from the stats of its table
+// and the where pushed to it, false if its table has not been analyzed.
+func estimateRows(src *Source) (float64, bool) {
+	if src == nil || src.Tbl == nil {
+		return 0, false
+	}
+	stats := src.Tbl.Stats()
+	if stats == nil {
+		return 0, false
+	}
+	return float64(stats.RowCount) * whereSelectivity(stats, src.Stmt), true
+}
+
+// pushWhere should the where pushed to a joined source be evaluated by the
+// source, false if the stats of its table estimate it filters too few rows.
+func pushWhere(src *Source) bool {
+	w := src.Stmt.Source.Where
+	if w == nil || w.Expr == nil || w.Source != nil || src.Tbl == nil {
+		return true
+	}
+	stats := src.Tbl.Stats()
+	if stats == nil {
+		return true
+	}
+	return selectivity(stats, w.Expr) < PushPredicateSelectivity
+}
+
+// whereSelectivity the estimated fraction of rows passing the where of the
+// statement of source from.
+func whereSelectivity(stats *schema.TableStats, from *rel.SqlSource) float64 {
+	if from.Source == nil || from.Source.Where == nil || from.Source.Where.Expr == nil {
+		return 1
+	}
+	return selectivity(stats, from.Source.Where.Expr)
+}
+
+// selectivity the estimated fraction of rows for which node is true.
+func selectivity(stats *schema.TableStats, node expr.Node) float64 {
+	switch n := node.(type) {
+	case *expr.BinaryNode:
+		return binarySelectivity(stats, n)
+	case *expr.BooleanNode:
+		s := 1.0
+		if n.Operator.T == lex.TokenLogicOr || n.Operator.T == lex.TokenOr {
+			// none of the args true
+			for _, arg := range n.Args {
+				s *= 1 - selectivity(stats, arg)
+			}
+			s = 1 - s
+		} else {
+			for _, arg := range n.Args {
+				s *= selectivity(stats, arg)
+			}
+		}
+		if n.Negated() {
+			return 1 - s
+		}
+		return s
+	case *expr.UnaryNode:
+		if n.Operator.T == lex.TokenNegate {
+			return 1 - selectivity(stats, n.Arg)
+		}
+	case *expr.TriNode:
+		if n.Operator.T != lex.TokenBetween || len(n.Args) != 3 {
+			break
+		}
+		cs := columnStats(stats, n.Args[0])
+		lo, loOk := numberValue(n.Args[1])
+		hi, hiOk := numberValue(n.Args[2])
+		if cs == nil || !loOk || !hiOk {
+			break
+		}
+		below, ok1 := cs.LessSelectivity(lo)
+		upto, ok2 := cs.LessSelectivity(hi)
+		if !ok1 || !ok2 {
+			break
+		}
+		s := clampSelectivity(upto - below)
+		if n.Negated() {
+			return 1 - s
+		}
+		return s
+	}
+	return defaultSelectivity
+}
+
+func binarySelectivity(stats *schema.TableStats, n *expr.BinaryNode) float64 {
+	if len(n.Args) != 2 {
+		return defaultSelectivity
+	}
+	switch n.Operator.T {
+	case lex.TokenAnd, lex.TokenLogicAnd:
+		return selectivity(stats, n.Args[0]) * selectivity(stats, n.Args[1])
+	case lex.TokenOr, lex.TokenLogicOr:
+		s1, s2 := selectivity(stats, n.Args[0]), selectivity(stats, n.Args[1])
+		return s1 + s2 - s1*s2
+	}
+
+	op := n.Operator.T
+	colNode, valNode := n.Args[0], n.Args[1]
+	if _, ok := colNode.(*expr.IdentityNode); !ok {
+		// literal on the left, compare the other way around
+		colNode, valNode = valNode, colNode
+		switch op {
+		case lex.TokenLT:
+			op = lex.TokenGT
+		case lex.TokenLE:
+			op = lex.TokenGE
+		case lex.TokenGT:
+			op = lex.TokenLT
+		case lex.TokenGE:
+			op = lex.TokenLE
+		}
+	}
+	cs := columnStats(stats, colNode)
+	if cs == nil {
+		return defaultSelectivity
+	}
+
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return cs.EqualSelectivity()
+	case lex.TokenNE:
+		return clampSelectivity(1 - cs.NullFraction - cs.EqualSelectivity())
+	case lex.TokenIN:
+		arr, ok := valNode.(*expr.ArrayNode)
+		if !ok {
+			break
+		}
+		return clampSelectivity(float64(len(arr.Args)) * cs.EqualSelectivity())
+	case lex.TokenLT, lex.TokenLE, lex.TokenGT, lex.TokenGE:
+		x, ok := numberValue(valNode)
+		if !ok {
+			break
+		}
+		less, ok := cs.LessSelectivity(x)
+		if !ok {
+			break
+		}
+		// the histogram interpolates values as continuous, so < and <= are
+		// estimated the same
+		if op == lex.TokenGT || op == lex.TokenGE {
+			less = 1 - cs.NullFraction - less
+		}
+		return clampSelectivity(less)
+	}
+	return defaultSelectivity
+}
+
+// columnStats the stats of the column of identity node, nil if none.
+func columnStats(stats *schema.TableStats, node expr.Node) *schema.ColumnStats {
+	in, ok := node.(*expr.IdentityNode)
+	if !ok {
+		return nil
+	}
+	_, col, _ := in.LeftRight()
+	return stats.Column(col)
+}
+
+// numberValue the value of a number, or string of a number, literal.
+func numberValue(node expr.Node) (float64, bool) {
+	switch n := node.(type) {
+	case *expr.NumberNode:
+		if n.IsInt {
+			return float64(n.Int64), true
+		}
+		return n.Float64, n.IsFloat
+	case *expr.StringNode:
+		f, err := strconv.ParseFloat(n.Text, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func clampSelectivity(s float64) float64 {
+	switch {
+	case s < 0:
+		return 0
+	case s > 1:
+		return 1
+	}
+	return s
+}
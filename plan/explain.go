@@ -0,0 +1,179 @@
+package plan
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// ExplainTask a task of a plan dag as described by EXPLAIN, a row of the
+// EXPLAIN result, or with FORMAT = JSON a node of the tree of tasks.
+type ExplainTask struct {
+	Id       int            `json:"id"`
+	ParentId int            `json:"parent_id"`
+	Task     string         `json:"task"`
+	Parallel bool           `json:"parallel"`
+	Source   string         `json:"source,omitempty"`
+	Columns  []string       `json:"columns,omitempty"`
+	Detail   string         `json:"detail,omitempty"`
+	Children []*ExplainTask `json:"children,omitempty"`
+}
+
+// WalkExplain plan the statement to explain.
+func (m *PlannerDefault) WalkExplain(p *Explain) error {
+	pln, err := WalkStmt(p.Ctx, p.Stmt.Stmt, m.Planner)
+	if err != nil {
+		return err
+	}
+	p.Plan = pln
+	return nil
+}
+
+// Columns the result columns of EXPLAIN, a row per task, or with FORMAT
+// = JSON a single row of the tree of tasks.
+func (m *Explain) Columns() []string {
+	return ExplainColumns(m.Stmt)
+}
+
+// ExplainColumns the result columns of an EXPLAIN statement.  EXPLAIN
+// ANALYZE has the runtime stats of each task in place of its description.
+func ExplainColumns(stmt *rel.SqlDescribe) []string {
+	switch {
+	case stmt.Format == "json":
+		return []string{"plan"}
+	case stmt.Analyze:
+		return []string{"id", "parent_id", "task", "mode", "rows_in", "rows_out", "wall", "blocked", "peak_buffered"}
+	}
+	return []string{"id", "parent_id", "task", "mode", "source", "columns", "detail"}
+}
+
+// Tasks the tree of tasks of the explained plan, numbered depth first.
+func (m *Explain) Tasks() *ExplainTask {
+	id := 0
+	return explainTask(m.Plan, 0, &id)
+}
+
+// Rows the rows of the EXPLAIN result.
+func (m *Explain) Rows() ([][]driver.Value, error) {
+	if m.Stmt.Format == "json" {
+		by, err := json.Marshal(m.Tasks())
+		if err != nil {
+			return nil, err
+		}
+		return [][]driver.Value{{string(by)}}, nil
+	}
+	rows := make([][]driver.Value, 0)
+	var addRows func(t *ExplainTask)
+	addRows = func(t *ExplainTask) {
+		mode := "sequential"
+		if t.Parallel {
+			mode = "parallel"
+		}
+		rows = append(rows, []driver.Value{t.Id, t.ParentId, t.Task, mode, t.Source, strings.Join(t.Columns, ", "), t.Detail})
+		for _, c := range t.Children {
+			addRows(c)
+		}
+	}
+	addRows(m.Tasks())
+	return rows, nil
+}
+
+// explainTask describe task t, numbered id, and its children.
+func explainTask(t Task, parentId int, id *int) *ExplainTask {
+	*id++
+	et := &ExplainTask{Id: *id, ParentId: parentId, Parallel: t.IsParallel()}
+	name := fmt.Sprintf("%T", t)
+	et.Task = name[strings.LastIndex(name, ".")+1:]
+
+	var children []Task
+	switch p := t.(type) {
+	case *Select:
+		et.Detail = p.Stmt.String()
+	case *Compound:
+		for _, sel := range p.Selects {
+			children = append(children, sel)
+		}
+	case *Source:
+		et.Source = p.Stmt.SourceName()
+		if p.Stmt.Source != nil {
+			et.Columns = p.Stmt.Source.Columns.FieldNames()
+		}
+		switch {
+		case p.Cte != nil:
+			et.Detail = "common table " + p.Stmt.SourceName()
+		case len(p.Static) > 0:
+			et.Detail = "static"
+		default:
+			if _, ok := p.Conn.(SourcePlanner); ok && p.Stmt.Source != nil {
+				// the source planned its own statement, where and all
+				et.Detail = "source planned: " + p.Stmt.Source.String()
+			} else if est, ok := estimateRows(p); ok {
+				et.Detail = fmt.Sprintf("estimated rows %.0f", est)
+			}
+		}
+	case *Where:
+		if p.Stmt.Where != nil && p.Stmt.Where.Expr != nil {
+			et.Detail = p.Stmt.Where.Expr.String()
+		}
+	case *Having:
+		if p.Stmt.Having != nil {
+			et.Detail = p.Stmt.Having.String()
+		}
+	case *GroupBy:
+		et.Columns = p.Stmt.Columns.FieldNames()
+		gb := make([]string, len(p.Stmt.GroupBy))
+		for i, col := range p.Stmt.GroupBy {
+			gb[i] = col.String()
+		}
+		et.Detail = strings.Join(gb, ", ")
+		if p.Partial {
+			et.Detail += " partial"
+		}
+	case *Order:
+		ob := make([]string, len(p.Stmt.OrderBy))
+		for i, col := range p.Stmt.OrderBy {
+			ob[i] = col.String()
+		}
+		et.Detail = strings.Join(ob, ", ")
+		if p.TopN > 0 {
+			et.Detail += fmt.Sprintf(" top %d", p.TopN)
+		}
+	case *Projection:
+		if p.Proj != nil {
+			for _, col := range p.Proj.Columns {
+				et.Columns = append(et.Columns, col.As)
+			}
+		} else {
+			et.Columns = p.Stmt.Columns.AliasedFieldNames()
+		}
+		if p.Final {
+			et.Detail = "final"
+		}
+	case *JoinMerge:
+		children = []Task{p.Left, p.Right}
+		if p.RightFrom != nil && p.RightFrom.JoinExpr != nil {
+			et.Detail = p.RightFrom.JoinExpr.String()
+		}
+	case *NestedLoopJoin:
+		children = []Task{p.Left, p.Right}
+		if p.Cond != nil {
+			et.Detail = p.Cond.String()
+		} else {
+			et.Detail = "cross"
+		}
+	case *SemiJoin:
+		children = []Task{p.Sub}
+		et.Detail = p.Stmt.Where.String()
+	}
+
+	for _, c := range append(children, t.Children()...) {
+		if c == nil {
+			continue
+		}
+		et.Children = append(et.Children, explainTask(c, et.Id, id))
+	}
+	return et
+}
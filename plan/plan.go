@@ -12,6 +12,8 @@ import (
 	u "github.com/araddon/gou"
 	"github.com/golang/protobuf/proto"
 
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -27,6 +29,7 @@ var (
 	// Ensure our tasks implement Task Interface
 	_ Task = (*PreparedStatement)(nil)
 	_ Task = (*Select)(nil)
+	_ Task = (*Compound)(nil)
 	_ Task = (*Insert)(nil)
 	_ Task = (*Upsert)(nil)
 	_ Task = (*Update)(nil)
@@ -40,8 +43,13 @@ var (
 	_ Task = (*Having)(nil)
 	_ Task = (*GroupBy)(nil)
 	_ Task = (*Order)(nil)
+	_ Task = (*Window)(nil)
+	_ Task = (*Distinct)(nil)
 	_ Task = (*JoinMerge)(nil)
 	_ Task = (*JoinKey)(nil)
+	_ Task = (*NestedLoopJoin)(nil)
+	_ Task = (*SemiJoin)(nil)
+	_ Task = (*Explain)(nil)
 
 	// Force any plan that participates in a Select to implement Proto
 	//  which allows us to serialize and distribute to multiple nodes.
@@ -102,6 +110,7 @@ type (
 		WalkSourceSelect(p *Source) error
 		WalkProjectionSource(p *Source) error
 		WalkProjectionFinal(p *Select) error
+		WalkExplain(p *Explain) error
 
 		// Other Statements
 		WalkPreparedStatement(p *PreparedStatement) error
@@ -113,6 +122,12 @@ type (
 		WalkAlter(p *Alter) error
 	}
 
+	// CompoundPlanner is an optional interface for planners that plan
+	// compound selects (UNION, INTERSECT, EXCEPT), PlannerDefault does.
+	CompoundPlanner interface {
+		WalkCompound(p *Compound) error
+	}
+
 	// SourcePlanner Sources can often do their own planning for sub-select statements
 	// ie mysql can do its own (select, projection) mongo, es can as well
 	// - provide interface to allow passing down select planning to source
@@ -120,6 +135,25 @@ type (
 		// given our request statement, turn that into a plan.Task.
 		WalkSourceSelect(pl Planner, s *Source) (Task, error)
 	}
+
+	// SourceSorter is an optional interface of a source Conn that can return
+	// its rows already in ORDER BY order, so the planner does not add an
+	// Order task.
+	SourceSorter interface {
+		// Sort asks the conn to return rows ordered by these columns, only
+		// the first limit rows if limit > 0.  Returns false if it cannot.
+		Sort(orderBy rel.Columns, limit int) bool
+	}
+
+	// SourcePruner is an optional interface of a source Conn that can read
+	// only some of the columns of its rows, so columns the statement doesn't
+	// reference aren't decoded.  Rows keep all of their columns, in the same
+	// positions, the others are nil.
+	SourcePruner interface {
+		// PruneColumns asks the conn to read only these columns.  Returns
+		// false if it reads all of them.
+		PruneColumns(cols []string) bool
+	}
 )
 
 type (
@@ -137,11 +171,23 @@ type (
 	// Select plan
 	Select struct {
 		*PlanBase
-		Ctx      *Context
-		From     []*Source
-		Stmt     *rel.SqlSelect
-		ChildDag bool
-		pbplan   *PlanPb
+		Ctx        *Context
+		From       []*Source
+		Stmt       *rel.SqlSelect
+		SubSelects []*SubSelect   // sub-selects used as values in expressions of select
+		PostAgg    *rel.SqlSelect // post-aggregation statement if aggregates were split out of expressions
+		ChildDag   bool
+		pbplan     *PlanPb
+	}
+	// Compound plan for compound select, ie selects combined with
+	// UNION, INTERSECT, EXCEPT.  Each select is planned on its own, Result
+	// describes the combined result for Order, Limit.
+	Compound struct {
+		*PlanBase
+		Ctx     *Context
+		Stmt    *rel.SqlCompound
+		Selects []*Select
+		Result  *rel.SqlSelect
 	}
 	// Insert plan
 	Insert struct {
@@ -167,6 +213,14 @@ type (
 		Stmt   *rel.SqlDelete
 		Source schema.ConnDeletion
 	}
+	// Explain plan for EXPLAIN of a statement, Plan is the plan of the
+	// statement which is described rather than run.
+	Explain struct {
+		*PlanBase
+		Ctx  *Context
+		Stmt *rel.SqlDescribe
+		Plan Task
+	}
 	// Command for sql commands like SET.
 	Command struct {
 		*PlanBase
@@ -202,6 +256,12 @@ type (
 		Tbl        *schema.Table  // Table schema for this From
 		Static     []driver.Value // this is static data source
 		Cols       []string
+		Cte        *CommonTable // common table expression this source reads, if any
+
+		// pushed down to Conn when planned, re-applied by Reopen
+		sortBy    rel.Columns
+		sortLimit int
+		pruned    []string
 	}
 	// Into Select INTO table
 	Into struct {
@@ -218,6 +278,17 @@ type (
 	Order struct {
 		*PlanBase
 		Stmt *rel.SqlSelect
+		TopN int // ORDER BY with LIMIT keeps only first TopN rows, 0 for all
+	}
+	// Window evaluates window function columns, ie OVER (PARTITION BY ...)
+	Window struct {
+		*PlanBase
+		Stmt *rel.SqlSelect
+	}
+	// Distinct removes duplicate rows of the result, SELECT DISTINCT
+	Distinct struct {
+		*PlanBase
+		Stmt *rel.SqlSelect
 	}
 	// Where pre-aggregation filter
 	Where struct {
@@ -238,12 +309,47 @@ type (
 		LeftFrom  *rel.SqlSource
 		RightFrom *rel.SqlSource
 		ColIndex  map[string]int
+		BuildLeft bool // hash the rows of Left, streaming Right past them, else the reverse
 	}
 	// JoinKey plan
 	JoinKey struct {
 		*PlanBase
 		Source *Source
 	}
+	// NestedLoopJoin joins the rows of Left, a source or join of sources, to
+	// those of source Right on any condition, ie non-equi, cross and the
+	// later joins of 3 or more sources.
+	NestedLoopJoin struct {
+		*PlanBase
+		Left      Task
+		Right     Task
+		LeftFrom  *rel.SqlSource // source of Left, nil if Left is a join
+		RightFrom *rel.SqlSource
+		Cond      expr.Node   // join condition, nil for cross join
+		LeftKeys  []expr.Node // expressions of left rows = RightKeys, to hash rows on
+		RightKeys []expr.Node
+		ColIndex  map[string]int
+	}
+	// SemiJoin filters rows by the sub-select of the where clause, keeping
+	// those with a match (semi-join), or if negated without (anti-join).
+	//  - WHERE x [NOT] IN (SELECT ...)
+	//  - WHERE [NOT] EXISTS (SELECT ...)
+	SemiJoin struct {
+		*PlanBase
+		Stmt  *rel.SqlSelect // select whose where has the sub-select
+		Sub   *Select        // plan of the sub-select, rewritten to be uncorrelated
+		Outer []expr.Node    // expressions of rows compared to correlated columns of sub-select
+	}
+	// SubSelect is a sub-select used as a value in an expression of a select,
+	// ie (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id).  It is
+	// not a task, its node is evaluated by running the sub-select, once if
+	// uncorrelated else once per distinct values of the outer expressions.
+	SubSelect struct {
+		Node  *expr.SubSelectNode
+		Stmt  *rel.SqlSelect // sub-select, with correlated conditions removed
+		Inner []expr.Node    // expressions of sub-select equal to Outer
+		Outer []expr.Node    // expressions of rows of select the sub-select is correlated on
+	}
 
 	// DDL Tasks
 
@@ -275,6 +381,8 @@ func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error
 	switch st := stmt.(type) {
 	case *rel.SqlSelect:
 		p = &Select{Stmt: st, PlanBase: base, Ctx: ctx}
+	case *rel.SqlCompound:
+		p = NewCompound(ctx, st)
 	case *rel.SqlInsert:
 		p = &Insert{Stmt: st, PlanBase: base}
 	case *rel.SqlUpsert:
@@ -291,6 +399,10 @@ func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error
 		ctx.Stmt = sel
 		p = &Select{Stmt: sel, PlanBase: base, Ctx: ctx}
 	case *rel.SqlDescribe:
+		if st.Stmt != nil {
+			p = &Explain{Stmt: st, PlanBase: base, Ctx: ctx}
+			break
+		}
 		sel, err := RewriteDescribeAsSelect(st, ctx)
 		if err != nil {
 			return nil, err
@@ -313,6 +425,65 @@ func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error
 	return p, p.Walk(planner)
 }
 
+// Reopen prepares plan p, already executed, to be executed again, such as
+// for a prepared statement run with new bind parameter values, re-opening
+// the conns of its sources.  Returns ErrNotImplemented if p must be planned
+// again instead: mutations, whose conn is opened when planned, and selects
+// with sub-selects or common tables whose statements are rewritten when
+// planned or executed.
+func Reopen(p Task) error {
+	switch pt := p.(type) {
+	case *Select:
+		stmt := pt.Stmt
+		if len(pt.SubSelects) > 0 || len(stmt.Ctes) > 0 || (stmt.Where != nil && stmt.Where.Source != nil) {
+			return ErrNotImplemented
+		}
+		return reopenSources(pt, make(map[*Source]bool))
+	case *Compound:
+		for _, sel := range pt.Selects {
+			if err := Reopen(sel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrNotImplemented
+}
+
+// reopenSources reopen each Source task of the dag of t once.
+func reopenSources(t Task, seen map[*Source]bool) error {
+	switch pt := t.(type) {
+	case *Source:
+		if seen[pt] {
+			return nil
+		}
+		seen[pt] = true
+		if err := pt.Reopen(); err != nil {
+			return err
+		}
+	case *JoinMerge:
+		if err := reopenSources(pt.Left, seen); err != nil {
+			return err
+		}
+		if err := reopenSources(pt.Right, seen); err != nil {
+			return err
+		}
+	case *NestedLoopJoin:
+		if err := reopenSources(pt.Left, seen); err != nil {
+			return err
+		}
+		if err := reopenSources(pt.Right, seen); err != nil {
+			return err
+		}
+	}
+	for _, child := range t.Children() {
+		if err := reopenSources(child, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SelectPlanFromPbBytes Create a sql plan from pb.
 func SelectPlanFromPbBytes(pb []byte, loader SchemaLoader) (*Select, error) {
 	p := &PlanPb{}
@@ -340,6 +511,8 @@ func SelectTaskFromTaskPb(pb *PlanPb, ctx *Context, sel *rel.SqlSelect) (Task, e
 		return GroupByFromPB(pb), nil
 	case pb.Order != nil:
 		return OrderFromPB(pb), nil
+	case pb.Window != nil:
+		return WindowFromPB(pb), nil
 	case pb.Projection != nil:
 		return ProjectionFromPB(pb, sel), nil
 	case pb.JoinMerge != nil:
@@ -409,11 +582,36 @@ func (m *Upsert) Walk(p Planner) error            { return p.WalkUpsert(m) }
 func (m *Update) Walk(p Planner) error            { return p.WalkUpdate(m) }
 func (m *Delete) Walk(p Planner) error            { return p.WalkDelete(m) }
 func (m *Command) Walk(p Planner) error           { return p.WalkCommand(m) }
+func (m *Explain) Walk(p Planner) error           { return p.WalkExplain(m) }
 func (m *Source) Walk(p Planner) error            { return p.WalkSourceSelect(m) }
 func (m *Create) Walk(p Planner) error            { return p.WalkCreate(m) }
 func (m *Drop) Walk(p Planner) error              { return p.WalkDrop(m) }
 func (m *Alter) Walk(p Planner) error             { return p.WalkAlter(m) }
 
+// Walk a compound select, with a planner implementing CompoundPlanner.
+func (m *Compound) Walk(p Planner) error {
+	if cp, ok := p.(CompoundPlanner); ok {
+		return cp.WalkCompound(m)
+	}
+	return ErrNotImplemented
+}
+
+// NewCompound creates a new Compound select Task plan.
+func NewCompound(ctx *Context, stmt *rel.SqlCompound) *Compound {
+	result := rel.NewSqlSelect()
+	result.Columns = stmt.Columns()
+	result.OrderBy = stmt.OrderBy
+	result.Limit = stmt.Limit
+	result.Offset = stmt.Offset
+	return &Compound{
+		Stmt:     stmt,
+		Result:   result,
+		Ctx:      ctx,
+		PlanBase: NewPlanBase(false),
+		Selects:  make([]*Select, 0, len(stmt.Selects)),
+	}
+}
+
 // NewCreate creates a new Create Task plan.
 func NewCreate(ctx *Context, stmt *rel.SqlCreate) *Create {
 	return &Create{Stmt: stmt, PlanBase: NewPlanBase(false), Ctx: ctx}
@@ -669,6 +867,42 @@ func (m *Source) LoadConn() error {
 	m.Conn = source
 	return nil
 }
+
+// Reopen opens a new Conn for this source in place of the one closed by
+// the last execution of its plan, re-applying the sort and column pruning
+// pushed down to the conn when planned.  Conns that plan their own query,
+// sub-queries and common tables can't be reopened as their query is fixed
+// when planned.
+func (m *Source) Reopen() error {
+	if m.Cte != nil || m.Stmt == nil || m.Stmt.SubQuery != nil {
+		return ErrNotImplemented
+	}
+	if m.Conn == nil || m.DataSource == nil {
+		// static or literal source, nothing to open
+		return nil
+	}
+	if _, ok := m.Conn.(SourcePlanner); ok {
+		return ErrNotImplemented
+	}
+	conn, err := m.DataSource.Open(m.Stmt.SourceName())
+	if err != nil {
+		return err
+	}
+	m.Conn = conn
+	if len(m.sortBy) > 0 {
+		sorter, ok := conn.(SourceSorter)
+		if !ok || !sorter.Sort(m.sortBy, m.sortLimit) {
+			return fmt.Errorf("could not re-apply sort to %q", m.Stmt.SourceName())
+		}
+	}
+	if len(m.pruned) > 0 {
+		pruner, ok := conn.(SourcePruner)
+		if !ok || !pruner.PruneColumns(m.pruned) {
+			return fmt.Errorf("could not re-apply column pruning to %q", m.Stmt.SourceName())
+		}
+	}
+	return nil
+}
 func (m *Source) IsSchemaQuery() bool {
 	if m.Stmt != nil && len(m.Stmt.Schema) > 0 {
 		//u.Debugf("schema:%q name:%q", m.Stmt.Schema, m.Stmt.Name)
@@ -757,6 +991,14 @@ func (m *Source) load() error {
 	if m.ctx == nil {
 		return fmt.Errorf("missing context in Source")
 	}
+	if ct := m.ctx.CommonTable(fromName); ct != nil && m.Stmt.Schema == "" {
+		// common table expressions shadow tables of the same name
+		m.Cte = ct
+		m.Conn = ct
+		m.Tbl = ct.Tbl
+		ct.Refs++
+		return projectionForSourcePlan(m)
+	}
 	if m.ctx.Schema == nil {
 		u.Errorf("missing schema in *plan.Source load() from:%q", fromName)
 		return fmt.Errorf("Missing schema for %v", fromName)
@@ -864,23 +1106,126 @@ func NewJoinMerge(l, r Task, lf, rf *rel.SqlSource) *JoinMerge {
 	// Build an index of source to destination column indexing
 	for _, col := range lf.Source.Columns {
 		//u.Debugf("left col:  idx=%d  key=%q as=%q col=%v parentidx=%v", len(m.colIndex), col.Key(), col.As, col.String(), col.ParentIndex)
-		m.ColIndex[lf.Alias+"."+col.Key()] = col.ParentIndex
+		m.ColIndex[lf.Qualifier()+"."+col.Key()] = col.ParentIndex
 		//u.Debugf("left  colIndex:  %15q : idx:%d sidx:%d pidx:%d", m.leftStmt.Alias+"."+col.Key(), col.Index, col.SourceIndex, col.ParentIndex)
 	}
 	for _, col := range rf.Source.Columns {
 		//u.Debugf("right col:  idx=%d  key=%q as=%q col=%v", len(m.colIndex), col.Key(), col.As, col.String())
-		m.ColIndex[rf.Alias+"."+col.Key()] = col.ParentIndex
+		m.ColIndex[rf.Qualifier()+"."+col.Key()] = col.ParentIndex
 		//u.Debugf("right colIndex:  %15q : idx:%d sidx:%d pidx:%d", m.rightStmt.Alias+"."+col.Key(), col.Index, col.SourceIndex, col.ParentIndex)
 	}
 
 	return m
 }
 
+// NewNestedLoopJoin join of task l, of the sources joined before source rf,
+// and task r of rf on condition cond.  lf is the source of l if it is not a
+// join.  The = conditions of cond comparing the joined sources to rf are
+// the keys both sides are hashed on.
+//
+//   left source or join  ->
+//                          \
+//                            --  join on cond  -->
+//                          /
+//   right source         ->
+//
+func NewNestedLoopJoin(l, r Task, lf, rf *rel.SqlSource, joined []*rel.SqlSource, cond expr.Node) *NestedLoopJoin {
+
+	m := &NestedLoopJoin{
+		PlanBase:  NewPlanBase(false),
+		Left:      l,
+		Right:     r,
+		LeftFrom:  lf,
+		RightFrom: rf,
+		Cond:      cond,
+		ColIndex:  make(map[string]int),
+	}
+	m.SetParallel()
+
+	left := make(map[string]bool, len(joined))
+	for _, from := range append(joined, rf) {
+		alias := from.Qualifier()
+		if from != rf {
+			left[alias] = true
+		}
+		for _, col := range from.Source.Columns {
+			m.ColIndex[alias+"."+col.Key()] = col.ParentIndex
+		}
+	}
+	right := rf.Qualifier()
+
+	// side of a join key expression, "" if it uses sources of both or none
+	side := func(node expr.Node) string {
+		s := ""
+		for _, in := range expr.FindAllIdentities(node) {
+			alias, _, _ := in.LeftRight()
+			alias = strings.ToLower(alias)
+			switch {
+			case alias == right && (s == "" || s == "right"):
+				s = "right"
+			case left[alias] && (s == "" || s == "left"):
+				s = "left"
+			default:
+				return ""
+			}
+		}
+		return s
+	}
+	for _, node := range splitAnd(cond) {
+		bn, ok := node.(*expr.BinaryNode)
+		if !ok || (bn.Operator.T != lex.TokenEqual && bn.Operator.T != lex.TokenEqualEqual) {
+			continue
+		}
+		switch ls, rs := side(bn.Args[0]), side(bn.Args[1]); {
+		case ls == "left" && rs == "right":
+			m.LeftKeys = append(m.LeftKeys, bn.Args[0])
+			m.RightKeys = append(m.RightKeys, bn.Args[1])
+		case ls == "right" && rs == "left":
+			m.LeftKeys = append(m.LeftKeys, bn.Args[1])
+			m.RightKeys = append(m.RightKeys, bn.Args[0])
+		}
+	}
+	return m
+}
+
+// splitAnd split node into the conditions AND'd together.
+func splitAnd(node expr.Node) []expr.Node {
+	if bn, ok := node.(*expr.BinaryNode); ok {
+		switch bn.Operator.T {
+		case lex.TokenAnd, lex.TokenLogicAnd:
+			return append(splitAnd(bn.Args[0]), splitAnd(bn.Args[1])...)
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	return []expr.Node{node}
+}
+
+// andNodes AND conditions together, nil if none.
+func andNodes(nodes []expr.Node) expr.Node {
+	var node expr.Node
+	for _, n := range nodes {
+		if node == nil {
+			node = n
+			continue
+		}
+		node = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, node, n)
+	}
+	return node
+}
+
 // NewJoinKey creates JoinKey from Source.
 func NewJoinKey(s *Source) *JoinKey {
 	return &JoinKey{Source: s, PlanBase: NewPlanBase(false)}
 }
 
+// NewSemiJoin from SqlSelect statement and plan of the sub-select of its
+// where, with the outer expressions compared to its correlated columns.
+func NewSemiJoin(stmt *rel.SqlSelect, sub *Select, outer []expr.Node) *SemiJoin {
+	return &SemiJoin{Stmt: stmt, Sub: sub, Outer: outer, PlanBase: NewPlanBase(false)}
+}
+
 // NewWhere new Where Task from SqlSelect statement.
 func NewWhere(stmt *rel.SqlSelect) *Where {
 	return &Where{Stmt: stmt, PlanBase: NewPlanBase(false)}
@@ -903,7 +1248,27 @@ func NewGroupBy(stmt *rel.SqlSelect) *GroupBy {
 
 // NewOrder from SqlSelect statement.
 func NewOrder(stmt *rel.SqlSelect) *Order {
-	return &Order{Stmt: stmt, PlanBase: NewPlanBase(false)}
+	return &Order{Stmt: stmt, TopN: orderTopN(stmt), PlanBase: NewPlanBase(false)}
+}
+
+// orderTopN the number of ordered rows the result needs, LIMIT plus
+// OFFSET, or 0 if all rows are needed.  DISTINCT is evaluated after
+// ordering so needs all rows.
+func orderTopN(stmt *rel.SqlSelect) int {
+	if stmt.Limit <= 0 || stmt.Distinct {
+		return 0
+	}
+	return stmt.Limit + stmt.Offset
+}
+
+// NewDistinct from SqlSelect statement.
+func NewDistinct(stmt *rel.SqlSelect) *Distinct {
+	return &Distinct{Stmt: stmt, PlanBase: NewPlanBase(false)}
+}
+
+// NewWindow from SqlSelect statement.
+func NewWindow(stmt *rel.SqlSelect) *Window {
+	return &Window{Stmt: stmt, PlanBase: NewPlanBase(false)}
 }
 
 // Equal compares equality of two tasks.
@@ -1058,6 +1423,9 @@ func (m *Order) Equal(t Task) bool {
 	if !ok {
 		return false
 	}
+	if m.TopN != s.TopN {
+		return false
+	}
 
 	if !m.PlanBase.EqualBase(s.PlanBase) {
 		return false
@@ -1068,10 +1436,68 @@ func OrderFromPB(pb *PlanPb) *Order {
 	m := Order{
 		Stmt: rel.SqlSelectFromPb(pb.Order.Select),
 	}
+	m.TopN = orderTopN(m.Stmt)
 	m.PlanBase = NewPlanBase(pb.Parallel)
 	return &m
 }
 
+func (m *Window) ToPb() (*PlanPb, error) {
+	pbp, err := m.PlanBase.ToPb()
+	if err != nil {
+		return nil, err
+	}
+	pbp.Window = &WindowPb{Select: m.Stmt.ToPB()}
+	return pbp, nil
+}
+func (m *Window) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*Window)
+	if !ok {
+		return false
+	}
+
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	return true
+}
+func WindowFromPB(pb *PlanPb) *Window {
+	m := Window{
+		Stmt: rel.SqlSelectFromPb(pb.Window.Select),
+	}
+	m.PlanBase = NewPlanBase(pb.Parallel)
+	return &m
+}
+
+func (m *Distinct) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*Distinct)
+	if !ok {
+		return false
+	}
+
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	return true
+}
+
 func (m *JoinMerge) Equal(t Task) bool {
 	if m == nil && t == nil {
 		return true
@@ -1112,3 +1538,80 @@ func (m *JoinKey) Equal(t Task) bool {
 	}
 	return true
 }
+func (m *NestedLoopJoin) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*NestedLoopJoin)
+	if !ok {
+		return false
+	}
+
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	if !m.RightFrom.Equal(s.RightFrom) {
+		return false
+	}
+	return true
+}
+func (m *SemiJoin) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*SemiJoin)
+	if !ok {
+		return false
+	}
+
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	if !m.Stmt.Equal(s.Stmt) {
+		return false
+	}
+	return true
+}
+func (m *Compound) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*Compound)
+	if !ok {
+		return false
+	}
+
+	if !m.Stmt.Equal(s.Stmt) {
+		return false
+	}
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	if len(m.Selects) != len(s.Selects) {
+		return false
+	}
+	for i, sel := range m.Selects {
+		if !sel.Equal(s.Selects[i]) {
+			return false
+		}
+	}
+	return true
+}
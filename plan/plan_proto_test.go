@@ -23,6 +23,7 @@ var sqlStatements = []string{
 	"SELECT session_time FROM orders",
 	// Test order by
 	"SELECT name, order_id FROM orders ORDER BY name ASC;",
+	"SELECT name, order_id FROM orders ORDER BY name DESC NULLS FIRST, order_id;",
 	`
 		SELECT a.language, a.template, Count(*) AS count
 		FROM 
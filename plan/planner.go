@@ -8,7 +8,8 @@ import (
 
 var (
 	// Ensure our default planner meets Planner interface.
-	_ Planner = (*PlannerDefault)(nil)
+	_ Planner         = (*PlannerDefault)(nil)
+	_ CompoundPlanner = (*PlannerDefault)(nil)
 )
 
 // PlannerDefault is implementation of Planner that creates a dag of plan.Tasks
@@ -5,8 +5,11 @@ import (
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/vm"
 )
 
 func needsFinalProjection(s *rel.SqlSelect) bool {
@@ -20,6 +23,12 @@ func needsFinalProjection(s *rel.SqlSelect) bool {
 	if len(s.GroupBy) > 0 {
 		return true
 	}
+	if s.IsWindowQuery() {
+		return true
+	}
+	if len(s.SubSelects()) > 0 {
+		return true
+	}
 	return false
 }
 
@@ -29,6 +38,21 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 	// u.Debugf("VisitSelect ctx:%p  %+v", p.Ctx, p.Stmt)
 
 	needsFinalProject := true
+	// statement evaluated on the result rows, after aggregation
+	post := p.Stmt
+	// statement whose where filters the rows of the sources
+	where := p.Stmt
+	// source returns rows in order by order
+	sorted := false
+
+	optimizeWhere(p.Stmt)
+
+	if err := m.walkCommonTables(p); err != nil {
+		return err
+	}
+	if err := m.walkSubSelects(p); err != nil {
+		return err
+	}
 
 	if len(p.Stmt.From) == 0 {
 
@@ -54,50 +78,32 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 			goto finalProjection
 		}
 
-	} else {
-
-		var prevSource *Source
-		var prevTask Task
+		sorted = pushSort(p.Stmt, srcPlan)
 
-		for i, from := range p.Stmt.From {
-
-			// Need to rewrite the From statement to ensure all fields necessary to support
-			//  joins, wheres, etc exist but is standalone query
-			from.Rewrite(p.Stmt)
-			srcPlan, err := NewSource(m.Ctx, from, false)
-			if err != nil {
-				return nil
-			}
-			err = m.Planner.WalkSourceSelect(srcPlan)
-			if err != nil {
-				u.Errorf("Could not visitsubselect %v  %s", err, from)
-				return err
-			}
+	} else {
 
-			// now fold into previous task
-			if i != 0 {
-				from.Seekable = true
-				// fold this source into previous
-				curMergeTask := NewJoinMerge(prevTask, srcPlan, prevSource.Stmt, srcPlan.Stmt)
-				prevTask = curMergeTask
-			} else {
-				prevTask = srcPlan
-			}
-			prevSource = srcPlan
-			//u.Debugf("got task: %T", lastSource)
+		var err error
+		if where, err = m.walkJoin(p); err != nil {
+			return err
 		}
-		p.Add(prevTask)
-
 	}
 
 	if p.Stmt.Where != nil {
 		switch {
 		case p.Stmt.Where.Source != nil:
 			// SELECT id from article WHERE id in (select article_id from comments where comment_ct > 50);
-			u.Warnf("Found un-supported subquery: %#v", p.Stmt.Where)
-			return ErrNotImplemented
+			if p.Stmt.Where.Expr != nil {
+				p.Add(NewWhere(p.Stmt))
+			}
+			semiJoin, err := m.walkSemiJoin(p)
+			if err != nil {
+				return err
+			}
+			p.Add(semiJoin)
 		case p.Stmt.Where.Expr != nil:
-			p.Add(NewWhere(p.Stmt))
+			if where.Where != nil {
+				p.Add(NewWhere(where))
+			}
 		default:
 			u.Warnf("Found un-supported where type: %#v", p.Stmt.Where)
 			return fmt.Errorf("Unsupported Where Type")
@@ -106,16 +112,32 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 
 	if p.Stmt.IsAggQuery() {
 		//u.Debugf("Adding aggregate/group by? %#v", m.Planner)
-		p.Add(NewGroupBy(p.Stmt))
-		needsFinalProject = false
+		aggStmt, postAgg, err := splitAggregates(p.Stmt)
+		if err != nil {
+			return err
+		}
+		if aggStmt != nil {
+			// aggregates inside of expressions are calculated by group by,
+			// the expressions evaluated on its rows.
+			p.Add(NewGroupBy(aggStmt))
+			p.PostAgg = postAgg
+			post = postAgg
+		} else {
+			p.Add(NewGroupBy(p.Stmt))
+			needsFinalProject = false
+		}
+	}
+
+	if post.Having != nil {
+		p.Add(NewHaving(post))
 	}
 
-	if p.Stmt.Having != nil {
-		p.Add(NewHaving(p.Stmt))
+	if p.Stmt.IsWindowQuery() {
+		p.Add(NewWindow(post))
 	}
 
-	if len(p.Stmt.OrderBy) > 0 {
-		p.Add(NewOrder(p.Stmt))
+	if len(post.OrderBy) > 0 && !sorted {
+		p.Add(NewOrder(post))
 	}
 
 	if needsFinalProject {
@@ -125,6 +147,10 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 		}
 	}
 
+	if p.Stmt.Distinct {
+		p.Add(NewDistinct(p.Stmt))
+	}
+
 finalProjection:
 	if m.Ctx.Projection == nil {
 		proj, err := NewProjectionFinal(m.Ctx, p)
@@ -140,6 +166,351 @@ finalProjection:
 	return nil
 }
 
+// optimizeWhere simplify the where of stmt, evaluating its expressions of
+// only literals once, dropping it if it is always true.
+//
+//    SELECT name FROM users WHERE 1 = 1 AND age > toint("20")
+//
+func optimizeWhere(stmt *rel.SqlSelect) {
+	if stmt.Where == nil || stmt.Where.Expr == nil {
+		return
+	}
+	stmt.Where.Expr = vm.Optimize(stmt.Where.Expr)
+	if in, ok := stmt.Where.Expr.(*expr.IdentityNode); ok && in.IsBooleanIdentity() && in.Bool() && stmt.Where.Source == nil {
+		stmt.Where = nil
+	}
+}
+
+// walkCommonTables plan the common table expressions of select, in order so
+// each may reference the ones before it, registering them on the context
+// so sources of the select resolve them by name.
+func (m *PlannerDefault) walkCommonTables(p *Select) error {
+	if len(p.Stmt.Ctes) == 0 {
+		return nil
+	}
+	proj := m.Ctx.Projection
+	defer func() { m.Ctx.Projection = proj }()
+
+	for _, cte := range p.Stmt.Ctes {
+		m.Ctx.Projection = nil
+		sel := &Select{Stmt: cte.Select, PlanBase: NewPlanBase(false), Ctx: m.Ctx}
+		if err := m.Planner.WalkSelect(sel); err != nil {
+			return err
+		}
+		tbl, err := commonTableSchema(cte, m.Ctx.Projection)
+		if err != nil {
+			return err
+		}
+		m.Ctx.AddCommonTable(&CommonTable{Stmt: cte, Plan: sel, Tbl: tbl})
+	}
+	return nil
+}
+
+// walkJoin plan the join of the sources of select.  A join of 2 sources
+// on = conditions hashes both on the values compared, any other is a
+// nested loop join of each source to those before it.
+//
+// The conditions AND'd together in the where that use the columns of only one
+// source are pushed to it, to filter its rows before they are joined.
+// Returns the statement whose where has the rest of the conditions, to
+// evaluate on the joined rows, its where nil if none.
+//
+//    SELECT ... FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id
+//    WHERE o.price > 10 AND u.email != o.email
+//
+//    =>  orders:  SELECT ... FROM orders WHERE price > 10
+//        joined:  WHERE u.email != o.email
+//
+// If the tables of all of the sources have stats, from ANALYZE TABLE, an
+// inner join is always a nested loop join, its order and the sources
+// hashed chosen by their estimated rows, and the where pushed to a source
+// is evaluated after the join instead if it is estimated to filter few of
+// its rows.
+func (m *PlannerDefault) walkJoin(p *Select) (*rel.SqlSelect, error) {
+
+	sources := make(map[*rel.SqlSource]*Source, len(p.Stmt.From))
+	estimates := make(map[*rel.SqlSource]float64, len(p.Stmt.From))
+	inner := true
+	for _, from := range p.Stmt.From {
+		// Need to rewrite the From statement to ensure all fields necessary to support
+		//  joins, wheres, etc exist but is standalone query
+		from.Rewrite(p.Stmt)
+		srcPlan, err := NewSource(m.Ctx, from, false)
+		if err != nil {
+			return nil, err
+		}
+		if from.Source != nil && !pushWhere(srcPlan) {
+			// the where of the select is evaluated on the joined rows
+			from.Source.Where = nil
+		}
+		if est, ok := estimateRows(srcPlan); ok {
+			estimates[from] = est
+		}
+		if from.LeftOrRight != 0 {
+			inner = false
+		}
+		err = m.Planner.WalkSourceSelect(srcPlan)
+		if err != nil {
+			u.Errorf("Could not visitsubselect %v  %s", err, from)
+			return nil, err
+		}
+		sources[from] = srcPlan
+	}
+
+	estimated := inner && len(estimates) == len(p.Stmt.From)
+	if len(p.Stmt.From) == 2 && p.Stmt.From[1].IsEquiJoin() {
+		lf, rf := p.Stmt.From[0], p.Stmt.From[1]
+		rf.Seekable = true
+		jm := NewJoinMerge(sources[lf], sources[rf], lf, rf)
+		// the smaller side is hashed, the larger streamed past it
+		jm.BuildLeft = estimated && estimates[lf] < estimates[rf]
+		p.Add(jm)
+		return joinedWhere(p.Stmt), nil
+	}
+
+	order, conds := rel.JoinOrderEstimated(p.Stmt, func(from *rel.SqlSource) (float64, bool) {
+		est, ok := estimates[from]
+		return est, ok
+	})
+	var prevTask Task = sources[order[0]]
+	leftFrom := order[0]
+	for i := 1; i < len(order); i++ {
+		prevTask = NewNestedLoopJoin(prevTask, sources[order[i]], leftFrom, order[i], order[:i], conds[i])
+		leftFrom = nil
+	}
+	p.Add(prevTask)
+	return joinedWhere(p.Stmt), nil
+}
+
+// joinedWhere the statement whose where has the conditions of the where of
+// stmt not evaluated by its sources before they are joined.
+func joinedWhere(stmt *rel.SqlSelect) *rel.SqlSelect {
+	w := stmt.Where
+	if w == nil || w.Expr == nil || w.Source != nil {
+		return stmt
+	}
+	conds := make([]expr.Node, 0)
+	for _, cond := range splitAnd(w.Expr) {
+		evaluated := false
+		for _, from := range stmt.From {
+			pushed := from.Source != nil && from.Source.Where != nil
+			if pushed && from.CanEvaluate(stmt, cond) {
+				evaluated = true
+				break
+			}
+		}
+		if !evaluated {
+			conds = append(conds, cond)
+		}
+	}
+	joined := *stmt
+	joined.Where = nil
+	if len(conds) > 0 {
+		joined.Where = &rel.SqlWhere{Expr: andNodes(conds)}
+	}
+	return &joined
+}
+
+// walkSemiJoin plan the sub-select of the where of select, as a semi-join
+// filtering the rows of select.
+func (m *PlannerDefault) walkSemiJoin(p *Select) (*SemiJoin, error) {
+	w := p.Stmt.Where
+	switch w.Op {
+	case lex.TokenIN, lex.TokenExists:
+	default:
+		u.Warnf("Found un-supported subquery: %s", w)
+		return nil, ErrNotImplemented
+	}
+	if w.Op == lex.TokenIN && (w.Source.Star || len(w.Source.Columns) != 1) {
+		return nil, fmt.Errorf("sub-select of IN must have exactly one column: %s", w.Source)
+	}
+	sub, outer, err := rel.RewriteSubSelect(p.Stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	proj := m.Ctx.Projection
+	defer func() { m.Ctx.Projection = proj }()
+	m.Ctx.Projection = nil
+
+	subPlan := &Select{Stmt: sub, PlanBase: NewPlanBase(false), Ctx: m.Ctx}
+	if err := m.Planner.WalkSelect(subPlan); err != nil {
+		return nil, err
+	}
+	return NewSemiJoin(p.Stmt, subPlan, outer), nil
+}
+
+// walkSubSelects find the sub-selects used as values in expressions of
+// select, removing their correlated conditions so they can be run for the
+// values of the outer expressions of each row.
+func (m *PlannerDefault) walkSubSelects(p *Select) error {
+	for _, node := range p.Stmt.SubSelects() {
+		sub, ok := node.Stmt.(*rel.SqlSelect)
+		if !ok {
+			return fmt.Errorf("expected sub-select but got %T", node.Stmt)
+		}
+		if sub.Star || len(sub.Columns) != 1 {
+			return fmt.Errorf("sub-select used as value must have exactly one column: %s", sub)
+		}
+		stmt, inner, outer, err := rel.RewriteScalarSubSelect(p.Stmt, sub)
+		if err != nil {
+			return err
+		}
+		p.SubSelects = append(p.SubSelects, &SubSelect{Node: node, Stmt: stmt, Inner: inner, Outer: outer})
+	}
+	return nil
+}
+
+// commonTableSchema the table schema of the result of a common table, from the
+// projection of its select, named by the common table column names if any.
+func commonTableSchema(cte *rel.CommonTableExpr, proj *Projection) (*schema.Table, error) {
+	if proj == nil || proj.Proj == nil {
+		return nil, fmt.Errorf("no projection for common table %q", cte.Name)
+	}
+	cols := proj.Proj.Columns
+	if len(cte.Columns) > 0 && len(cte.Columns) != len(cols) {
+		return nil, fmt.Errorf("common table %q has %d column names but select has %d columns",
+			cte.Name, len(cte.Columns), len(cols))
+	}
+	tbl := schema.NewTable(cte.Name)
+	for i, col := range cols {
+		name := col.As
+		if len(cte.Columns) > 0 {
+			name = cte.Columns[i]
+		}
+		tbl.AddFieldType(name, col.Type)
+	}
+	tbl.SetColumnsFromFields()
+	return tbl, nil
+}
+
+// WalkCompound walk a compound select (UNION, INTERSECT, EXCEPT) planning
+// each select, then ordering of the combined result.
+func (m *PlannerDefault) WalkCompound(p *Compound) error {
+
+	// Each select sets its own projection on context, the combined
+	// result uses the projection of the first select.
+	var proj *Projection
+	for _, stmt := range p.Stmt.Selects {
+		m.Ctx.Projection = nil
+		sel := &Select{Stmt: stmt, PlanBase: NewPlanBase(false), Ctx: m.Ctx}
+		if err := m.Planner.WalkSelect(sel); err != nil {
+			return err
+		}
+		if proj == nil {
+			proj = m.Ctx.Projection
+		}
+		p.Selects = append(p.Selects, sel)
+	}
+	m.Ctx.Projection = proj
+
+	if len(p.Result.OrderBy) > 0 {
+		p.Add(NewOrder(p.Result))
+	}
+	return nil
+}
+
+// pushSort push the ORDER BY of a single source select down to a source
+// conn that implements SourceSorter, along with the LIMIT if no rows are
+// filtered out after the source.  Returns true if the conn sorts.
+//
+//    SELECT name FROM users ORDER BY user_id LIMIT 10
+//
+func pushSort(stmt *rel.SqlSelect, p *Source) bool {
+	if len(stmt.OrderBy) == 0 || stmt.IsAggQuery() || stmt.IsWindowQuery() || len(stmt.With) > 0 {
+		return false
+	}
+	sorter, ok := p.Conn.(SourceSorter)
+	if !ok {
+		return false
+	}
+	limit := orderTopN(stmt)
+	if stmt.Where != nil {
+		limit = 0
+	}
+	if !sorter.Sort(stmt.OrderBy, limit) {
+		return false
+	}
+	p.sortBy, p.sortLimit = stmt.OrderBy, limit
+	return true
+}
+
+// pruneColumns push the columns of a source the statement references down
+// to a source conn that implements SourcePruner.  Returns true if the conn
+// prunes.
+//
+//    SELECT name FROM users WHERE age > 20 ORDER BY user_id
+//
+func pruneColumns(p *Source) bool {
+	pruner, ok := p.Conn.(SourcePruner)
+	if !ok || p.Stmt.Source == nil {
+		return false
+	}
+	cols, ok := referencedColumns(p.Stmt.Source)
+	if !ok || !pruner.PruneColumns(cols) {
+		return false
+	}
+	p.pruned = cols
+	return true
+}
+
+// referencedColumns the names of the source columns referenced by the
+// columns, where, group by, having, order by and windows of stmt.  Returns
+// false if they can't all be known, ie SELECT * or sub-selects.
+func referencedColumns(stmt *rel.SqlSelect) ([]string, bool) {
+	if stmt.Star || len(stmt.With) > 0 || len(stmt.SubSelects()) > 0 {
+		return nil, false
+	}
+	if stmt.Where != nil && stmt.Where.Source != nil {
+		return nil, false
+	}
+	nodes := make([]expr.Node, 0)
+	addCols := func(cols rel.Columns) {
+		for _, col := range cols {
+			nodes = append(nodes, col.Expr, col.Guard)
+			if col.Over != nil {
+				nodes = append(nodes, col.Over.PartitionBy...)
+				for _, oc := range col.Over.OrderBy {
+					nodes = append(nodes, oc.Expr)
+				}
+			}
+		}
+	}
+	for _, col := range stmt.Columns {
+		if col.Star {
+			return nil, false
+		}
+		if col.Expr == nil && col.SourceField != "" {
+			nodes = append(nodes, expr.NewIdentityNodeVal(col.SourceField))
+		}
+	}
+	addCols(stmt.Columns)
+	addCols(stmt.GroupBy)
+	addCols(stmt.OrderBy)
+	if stmt.Where != nil {
+		nodes = append(nodes, stmt.Where.Expr)
+	}
+	nodes = append(nodes, stmt.Having)
+
+	cols := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		for _, in := range expr.FindAllIdentities(node) {
+			// not in.LeftRight(), it caches the split on the node
+			_, right, _ := expr.LeftRight(in.Text)
+			if right == "*" || seen[right] {
+				continue
+			}
+			seen[right] = true
+			cols = append(cols, right)
+		}
+	}
+	return cols, true
+}
+
 // WalkProjectionFinal walk the select plan to create final projection.
 func (m *PlannerDefault) WalkProjectionFinal(p *Select) error {
 	// Add a Final Projection to choose the columns for results
@@ -225,10 +596,14 @@ func (m *PlannerDefault) WalkSourceSelect(p *Source) error {
 			return fmt.Errorf("%q Didn't implement schema.ConnColumns: %T", p.Stmt.SourceName(), p.Conn)
 		}
 
+		pruneColumns(p)
+
 		if p.Stmt.Source != nil && p.Stmt.Source.Where != nil {
 			switch {
 			case p.Stmt.Source.Where.Expr != nil:
 				p.Add(NewWhere(p.Stmt.Source))
+			case p.Stmt.Source.Where.Source != nil:
+				// sub-select condition is a semi-join of the select
 			default:
 				u.Warnf("Found un-supported where type: %#v", p.Stmt.Source)
 				return fmt.Errorf("Unsupported Where clause:  %q", p.Stmt)
@@ -1,12 +1,18 @@
 package plan_test
 
 import (
+	"database/sql/driver"
+	"strings"
 	"testing"
 
 	u "github.com/araddon/gou"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
 )
 
 type plantest struct {
@@ -37,3 +43,279 @@ func TestPlans(t *testing.T) {
 
 	}
 }
+
+func TestPlanCommonTables(t *testing.T) {
+	ctx := td.TestContext(`WITH a AS (SELECT order_id, price FROM orders), b (id) AS (SELECT order_id FROM a)
+		SELECT x.order_id, y.id FROM a AS x INNER JOIN b AS y ON x.order_id = y.id`)
+	p := selectPlan(t, ctx)
+	assert.True(t, p != nil)
+
+	a := ctx.CommonTable("a")
+	assert.True(t, a != nil)
+	assert.Equal(t, []string{"order_id", "price"}, a.Columns())
+	// referenced by b and by the select so materialized once and shared
+	assert.Equal(t, 2, a.Refs)
+
+	b := ctx.CommonTable("B")
+	assert.True(t, b != nil)
+	assert.Equal(t, []string{"id"}, b.Columns())
+	assert.Equal(t, 1, b.Refs)
+	assert.True(t, ctx.CommonTable("orders") == nil)
+}
+
+func TestPlanJoins(t *testing.T) {
+	joinOf := func(p *plan.Select) plan.Task {
+		for _, task := range p.Children() {
+			switch task.(type) {
+			case *plan.JoinMerge, *plan.NestedLoopJoin:
+				return task
+			}
+		}
+		return nil
+	}
+
+	// 2 sources on = are hash joined
+	p := selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id"))
+	_, ok := joinOf(p).(*plan.JoinMerge)
+	assert.True(t, ok)
+
+	// any other condition is a nested loop join, hashing on its = conditions
+	p = selectPlan(t, td.TestContext("SELECT o.order_id FROM orders AS o INNER JOIN orders AS x ON o.user_id = x.user_id AND o.price < x.price"))
+	nl, ok := joinOf(p).(*plan.NestedLoopJoin)
+	assert.True(t, ok)
+	assert.Equal(t, "o.user_id", nl.LeftKeys[0].String())
+	assert.Equal(t, "x.user_id", nl.RightKeys[0].String())
+	assert.Equal(t, "o", nl.LeftFrom.Alias)
+
+	p = selectPlan(t, td.TestContext("SELECT u.email FROM users AS u CROSS JOIN orders AS o"))
+	nl, ok = joinOf(p).(*plan.NestedLoopJoin)
+	assert.True(t, ok)
+	assert.Equal(t, nil, nl.Cond)
+
+	// comma joins are ordered to join each source on a condition
+	p = selectPlan(t, td.TestContext("SELECT x.order_id FROM orders AS x, users AS u, orders AS o WHERE u.user_id = o.user_id AND o.item_id = x.item_id"))
+	nl, ok = joinOf(p).(*plan.NestedLoopJoin)
+	assert.True(t, ok)
+	assert.Equal(t, "u", nl.RightFrom.Alias)
+	assert.Equal(t, "u.user_id = o.user_id", nl.Cond.String())
+	left, ok := nl.Left.(*plan.NestedLoopJoin)
+	assert.True(t, ok)
+	assert.Equal(t, "o", left.RightFrom.Alias)
+	assert.Equal(t, "o.item_id = x.item_id", left.Cond.String())
+	assert.True(t, nl.LeftFrom == nil)
+}
+
+func TestPlanJoinWherePushdown(t *testing.T) {
+	whereOf := func(p *plan.Select) *plan.Where {
+		for _, task := range p.Children() {
+			if w, ok := task.(*plan.Where); ok {
+				return w
+			}
+		}
+		return nil
+	}
+
+	// conditions of one source are evaluated by it, the rest after the join
+	p := selectPlan(t, td.TestContext(`SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id
+		WHERE o.price < 30 AND tolower(u.email) LIKE "aaron%" AND u.user_id != o.item_id AND (o.price > 1 OR u.referral_count > 1)`))
+	users, orders := p.Stmt.From[0], p.Stmt.From[1]
+	assert.Equal(t, `tolower(email) LIKE "aaron%"`, users.Source.Where.Expr.String())
+	assert.Equal(t, "price < 30", orders.Source.Where.Expr.String())
+	w := whereOf(p)
+	assert.True(t, w != nil)
+	assert.Equal(t, "u.user_id != o.item_id AND (o.price > 1 OR u.referral_count > 1)", w.Stmt.Where.Expr.String())
+	// the select is unchanged
+	assert.Equal(t, 4, len(strings.Split(p.Stmt.Where.Expr.String(), " AND ")))
+
+	// all conditions pushed, none after the join
+	p = selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price < 30"))
+	assert.True(t, whereOf(p) == nil)
+
+	// the side of an outer join that is NULL for un-matched rows is filtered
+	// after the join, unqualified columns are ambiguous
+	p = selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id WHERE o.price < 30 AND u.email != \"x\" AND price > 1"))
+	users, orders = p.Stmt.From[0], p.Stmt.From[1]
+	assert.Equal(t, `email != "x"`, users.Source.Where.Expr.String())
+	assert.True(t, orders.Source.Where == nil)
+	assert.Equal(t, "o.price < 30 AND price > 1", whereOf(p).Stmt.Where.Expr.String())
+}
+
+func TestPlanJoinStats(t *testing.T) {
+	joinMergeOf := func(p *plan.Select) *plan.JoinMerge {
+		for _, task := range p.Children() {
+			if jm, ok := task.(*plan.JoinMerge); ok {
+				return jm
+			}
+		}
+		return nil
+	}
+	joinOf := func(p *plan.Select) *plan.NestedLoopJoin {
+		for _, task := range p.Children() {
+			if nl, ok := task.(*plan.NestedLoopJoin); ok {
+				return nl
+			}
+		}
+		return nil
+	}
+	// stats of n rows with an id column of values 0..n-1
+	setStats := func(ctx *plan.Context, name string, n int) {
+		tbl, err := ctx.Schema.Table(name)
+		assert.Equal(t, nil, err)
+		sb := schema.NewTableStatsBuilder([]string{"user_id", "price"})
+		for i := 0; i < n; i++ {
+			sb.Add([]driver.Value{int64(i), float64(i)})
+		}
+		tbl.SetStats(sb.Stats())
+	}
+	ctx := td.TestContext("SELECT 1")
+	defer func() {
+		for _, name := range []string{"users", "orders"} {
+			tbl, _ := ctx.Schema.Table(name)
+			tbl.SetStats(nil)
+		}
+	}()
+	setStats(ctx, "users", 10)
+	setStats(ctx, "orders", 1000)
+
+	// the smaller users is hashed, the larger orders streamed, by the
+	// join merge that spills past the memory limit
+	p := selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id"))
+	jm := joinMergeOf(p)
+	assert.True(t, jm != nil)
+	assert.Equal(t, "u", jm.LeftFrom.Alias)
+	assert.True(t, jm.BuildLeft)
+
+	// a selective where of orders leaves it the smaller side
+	p = selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price <= 5"))
+	jm = joinMergeOf(p)
+	assert.False(t, jm.BuildLeft)
+	assert.True(t, jm.RightFrom.Source.Where != nil)
+
+	// a where of orders filtering few rows is not pushed to it
+	p = selectPlan(t, td.TestContext("SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 5"))
+	jm = joinMergeOf(p)
+	assert.True(t, jm.BuildLeft)
+	assert.True(t, jm.RightFrom.Source.Where == nil)
+
+	// joins of more sources stream the largest first
+	p = selectPlan(t, td.TestContext(`SELECT u.email, o.order_id FROM users AS u
+		INNER JOIN orders AS o ON u.user_id = o.user_id INNER JOIN users AS x ON x.user_id = o.user_id`))
+	nl := joinOf(p)
+	assert.True(t, nl != nil)
+	left, ok := nl.Left.(*plan.NestedLoopJoin)
+	assert.True(t, ok)
+	assert.Equal(t, "o", left.LeftFrom.Alias)
+}
+
+func TestPlanAggregateExpressions(t *testing.T) {
+	groupByOf := func(p *plan.Select) *plan.GroupBy {
+		for _, task := range p.Children() {
+			if gb, ok := task.(*plan.GroupBy); ok {
+				return gb
+			}
+		}
+		return nil
+	}
+
+	// aggregates are calculated by group by, expressions of them after
+	p := selectPlan(t, td.TestContext(`SELECT user_id, sum(price) / count(*) AS a FROM orders
+		GROUP BY user_id HAVING count(*) > 1 ORDER BY max(price) DESC`))
+	gb := groupByOf(p)
+	assert.True(t, gb != nil)
+	cols := make([]string, 0)
+	for _, col := range gb.Stmt.Columns {
+		cols = append(cols, col.Expr.String()+" AS "+col.As)
+	}
+	assert.Equal(t, []string{"user_id AS _gb0", "sum(price) AS _agg0", "count(*) AS _agg1", "max(price) AS _agg2"}, cols)
+	assert.True(t, p.PostAgg != nil)
+	assert.Equal(t, "_gb0", p.PostAgg.Columns[0].Expr.String())
+	assert.Equal(t, "`_agg0` / `_agg1`", p.PostAgg.Columns[1].Expr.String())
+	assert.Equal(t, "`_agg1` > 1", p.PostAgg.Having.String())
+	assert.Equal(t, "_agg2", p.PostAgg.OrderBy[0].Expr.String())
+	// the statement itself is not changed
+	assert.Equal(t, "sum(price) / count(*)", p.Stmt.Columns[1].Expr.String())
+
+	// aggregates as select columns are calculated as they are
+	p = selectPlan(t, td.TestContext("SELECT user_id, count(*) AS ct FROM orders GROUP BY user_id HAVING ct > 1"))
+	assert.True(t, groupByOf(p).Stmt == p.Stmt)
+	assert.True(t, p.PostAgg == nil)
+}
+
+func TestPlanOrderTopN(t *testing.T) {
+	orderOf := func(p *plan.Select) *plan.Order {
+		for _, task := range p.Children() {
+			if o, ok := task.(*plan.Order); ok {
+				return o
+			}
+		}
+		return nil
+	}
+
+	// order by with limit keeps only limit + offset rows
+	p := selectPlan(t, td.TestContext("SELECT order_id FROM orders ORDER BY price DESC LIMIT 2 OFFSET 1"))
+	assert.Equal(t, 3, orderOf(p).TopN)
+	p = selectPlan(t, td.TestContext("SELECT order_id FROM orders ORDER BY price DESC"))
+	assert.Equal(t, 0, orderOf(p).TopN)
+	// distinct is after ordering so needs all rows
+	p = selectPlan(t, td.TestContext("SELECT DISTINCT user_id FROM orders ORDER BY user_id LIMIT 2"))
+	assert.Equal(t, 0, orderOf(p).TopN)
+
+	// a source conn that sorts takes the order by
+	db, err := memdb.NewMemDbData("plan_sorted", [][]driver.Value{{"b", 2}, {"a", 1}}, []string{"id", "ct"})
+	assert.Equal(t, nil, err)
+	err = schema.RegisterSourceAsSchema("memdb_plan_sorted", db)
+	assert.Equal(t, nil, err)
+	sch, _ := schema.DefaultRegistry().Schema("memdb_plan_sorted")
+	sortedCtx := func(sql string) *plan.Context {
+		ctx := td.TestContext(sql)
+		ctx.Schema = sch
+		return ctx
+	}
+	p = selectPlan(t, sortedCtx("SELECT id FROM plan_sorted ORDER BY id LIMIT 1"))
+	assert.True(t, orderOf(p) == nil)
+	p = selectPlan(t, sortedCtx("SELECT id FROM plan_sorted ORDER BY ct LIMIT 1"))
+	assert.Equal(t, 1, orderOf(p).TopN)
+}
+
+func TestPlanPruneColumns(t *testing.T) {
+	db, err := memdb.NewMemDbData("plan_pruned", [][]driver.Value{{"a", 1, "x", 10}},
+		[]string{"id", "ct", "name", "price"})
+	assert.Equal(t, nil, err)
+	err = schema.RegisterSourceAsSchema("memdb_plan_pruned", db)
+	assert.Equal(t, nil, err)
+	sch, _ := schema.DefaultRegistry().Schema("memdb_plan_pruned")
+	firstRow := func(sql string) []driver.Value {
+		ctx := td.TestContext(sql)
+		ctx.Schema = sch
+		p := selectPlan(t, ctx)
+		msg := p.From[0].Conn.(schema.ConnScanner).Next()
+		assert.NotEqual(t, nil, msg, sql)
+		return msg.(*datasource.SqlDriverMessageMap).Values()
+	}
+
+	// columns referenced by select, where and order by are read
+	vals := firstRow("SELECT name FROM plan_pruned WHERE ct > 0 ORDER BY id")
+	assert.Equal(t, []driver.Value{"a", 1, "x", nil}, vals)
+	vals = firstRow("SELECT ct, count(*) FROM plan_pruned GROUP BY ct")
+	assert.Equal(t, []driver.Value{nil, 1, nil, nil}, vals)
+	// all columns of *
+	vals = firstRow("SELECT * FROM plan_pruned")
+	assert.Equal(t, []driver.Value{"a", 1, "x", 10}, vals)
+}
+
+func TestPlanOptimizeWhere(t *testing.T) {
+	whereOf := func(p *plan.Select) *plan.Where {
+		for _, task := range p.Children() {
+			if w, ok := task.(*plan.Where); ok {
+				return w
+			}
+		}
+		return nil
+	}
+
+	// always true where is dropped
+	p := selectPlan(t, td.TestContext(`SELECT user_id FROM users WHERE 1 = 1`))
+	assert.True(t, whereOf(p) == nil)
+	p = selectPlan(t, td.TestContext(`SELECT user_id FROM users WHERE 50 < referral_count AND toint("2") > 1`))
+	assert.Equal(t, "referral_count > 50", whereOf(p).Stmt.Where.Expr.String())
+}
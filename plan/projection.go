@@ -9,6 +9,7 @@ import (
 
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/value"
 )
 
@@ -26,6 +27,10 @@ func NewProjectionFinal(ctx *Context, p *Select) (*Projection, error) {
 		PlanBase: NewPlanBase(false),
 		Final:    true,
 	}
+	if p.PostAgg != nil {
+		// columns are evaluated on the aggregated rows
+		s.Stmt = p.PostAgg
+	}
 	var err error
 	if len(p.Stmt.From) == 0 {
 		err = s.loadLiteralProjection(ctx)
@@ -96,7 +101,13 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 	for _, from := range m.Stmt.From {
 
 		fromName := strings.ToLower(from.SourceName())
-		tbl, err := ctx.Schema.Table(fromName)
+		var tbl *schema.Table
+		var err error
+		if ct := ctx.CommonTable(fromName); ct != nil && from.Schema == "" {
+			tbl = ct.Tbl
+		} else {
+			tbl, err = ctx.Schema.Table(fromName)
+		}
 		if err != nil {
 			u.Errorf("could not get table: %v", err)
 			return err
@@ -116,9 +127,9 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 				} else {
 					if schemaCol, ok := tbl.FieldMap[col.SourceField]; ok {
 						if isFinal {
-							if col.InFinalProjection() {
+							if inFinalProjection(m.Stmt, col) {
 								//u.Debugf("in plan final %s", col.As)
-								m.Proj.AddColumnShort(col.As, schemaCol.ValueType())
+								m.Proj.AddColumnShort(finalName(m.Stmt, col), schemaCol.ValueType())
 							}
 						} else {
 							//u.Debugf("not final %s", col.As)
@@ -128,8 +139,8 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 					} else {
 						//u.Infof("schema col not found: final?%v col: %#v InFinal?%v", isFinal, col, col.InFinalProjection())
 						if isFinal {
-							if col.InFinalProjection() {
-								m.Proj.AddColumnShort(col.As, value.StringType)
+							if inFinalProjection(m.Stmt, col) {
+								m.Proj.AddColumnShort(finalName(m.Stmt, col), value.StringType)
 							} else {
 								u.Warnf("not adding to projection? %s", col)
 							}
@@ -145,6 +156,21 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 	return nil
 }
 
+// inFinalProjection is column of a source of stmt in its final projection, not
+// one the source adds for evaluating the where or sub-selects after the join.
+func inFinalProjection(stmt *rel.SqlSelect, col *rel.Column) bool {
+	return col.InFinalProjection() && col.ParentIndex < len(stmt.Columns)
+}
+
+// finalName the name of column col of a source of stmt in the final
+// projection, that of the select column it is projected as.
+func finalName(stmt *rel.SqlSelect, col *rel.Column) string {
+	if col.ParentIndex >= 0 && col.ParentIndex < len(stmt.Columns) {
+		return stmt.Columns[col.ParentIndex].As
+	}
+	return col.As
+}
+
 func projectionForSourcePlan(plan *Source) error {
 
 	plan.Proj = rel.NewProjection()
@@ -206,11 +232,11 @@ func projectionForSourcePlan(plan *Source) error {
 					} else {
 						plan.Proj.AddColumnShort(col.As, value.NumberType)
 					}
-				case *expr.FuncNode, *expr.BinaryNode:
-					// Probably not string?
-					plan.Proj.AddColumnShort(col.As, value.StringType)
 				default:
-					u.Warnf("schema col not found:  SourceField=%q   vals=%#v", col.SourceField, col)
+					// FuncNode, BinaryNode, CaseNode, SubSelectNode etc, not
+					// known until evaluated but every column needs its place
+					// in the projected row.
+					plan.Proj.AddColumnShort(col.As, value.StringType)
 				}
 
 			} else {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 
 	u "github.com/araddon/gou"
 
@@ -19,6 +20,17 @@ var (
 		"offset", "include", "all", "any", "some"}
 )
 
+func init() {
+	// sub-selects used as values in expressions are sql selects
+	expr.SubSelectParser = func(sql string, fr expr.FuncResolver) (expr.SubSelect, error) {
+		sel, err := ParseSqlSelectResolver(sql, fr)
+		if err != nil {
+			return nil, err
+		}
+		return sel, nil
+	}
+}
+
 // ParseError type
 type ParseError struct {
 	error
@@ -104,7 +116,16 @@ func (m *Sqlbridge) parse() (SqlStatement, error) {
 	case lex.TokenPrepare:
 		return m.parsePrepare()
 	case lex.TokenSelect:
-		return m.parseSqlSelect()
+		sel, err := m.parseSqlSelect()
+		if err != nil {
+			return nil, err
+		}
+		if isSetOperator(m.Cur().T) {
+			return m.parseSqlCompound(sel)
+		}
+		return sel, nil
+	case lex.TokenWith:
+		return m.parseSqlWith()
 	case lex.TokenInsert, lex.TokenReplace:
 		return m.parseSqlInsert()
 	case lex.TokenUpdate:
@@ -121,6 +142,8 @@ func (m *Sqlbridge) parse() (SqlStatement, error) {
 		return m.parseCommand()
 	case lex.TokenRollback, lex.TokenCommit:
 		return m.parseTransaction()
+	case lex.TokenAnalyze:
+		return m.parseAnalyze()
 	case lex.TokenCreate:
 		return m.parseCreate()
 	case lex.TokenDrop:
@@ -200,6 +223,10 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 		// valid end
 		return req, nil
 	}
+	// SELECT 1 UNION SELECT 2;
+	if isSetOperator(m.Cur().T) {
+		return req, nil
+	}
 
 	// INTO
 	discardComments(m)
@@ -207,10 +234,13 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 		return nil, err
 	}
 
-	// FROM
+	// FROM, optional for literal last select of compound
+	//   SELECT 1 AS a UNION SELECT 2 ORDER BY a
 	discardComments(m)
-	if err := m.parseSources(req); err != nil {
-		return nil, err
+	if m.Cur().T != lex.TokenOrderBy {
+		if err := m.parseSources(req); err != nil {
+			return nil, err
+		}
 	}
 
 	// WHERE
@@ -263,7 +293,8 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 		return nil, err
 	}
 
-	if m.Cur().T == lex.TokenEOF || m.Cur().T == lex.TokenEOS || m.Cur().T == lex.TokenRightParenthesis {
+	if m.Cur().T == lex.TokenEOF || m.Cur().T == lex.TokenEOS || m.Cur().T == lex.TokenRightParenthesis ||
+		isSetOperator(m.Cur().T) {
 
 		if err := req.Finalize(); err != nil {
 			return nil, err
@@ -277,6 +308,156 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 	return nil, fmt.Errorf("Did not complete parsing input: %v", m.LexTokenPager.Cur().V)
 }
 
+// WITH common table expressions followed by the select that references them.
+//
+//    WITH a AS (SELECT ...), b (x, y) AS (SELECT ... FROM a) SELECT ... FROM b
+func (m *Sqlbridge) parseSqlWith() (SqlStatement, error) {
+
+	m.Next() // Consume WITH
+
+	ctes := make([]*CommonTableExpr, 0)
+	for {
+		if m.Cur().T != lex.TokenIdentity {
+			return nil, m.ErrMsg("expected name of common table expression")
+		}
+		cte := &CommonTableExpr{Name: m.Next().V}
+		for _, prev := range ctes {
+			if strings.EqualFold(prev.Name, cte.Name) {
+				return nil, m.ErrMsg("duplicate common table expression name " + cte.Name)
+			}
+		}
+
+		// Optional column names
+		if m.Cur().T == lex.TokenLeftParenthesis {
+			m.Next()
+			for m.Cur().T == lex.TokenIdentity {
+				cte.Columns = append(cte.Columns, m.Next().V)
+				if m.Cur().T == lex.TokenComma {
+					m.Next()
+				}
+			}
+			if m.Cur().T != lex.TokenRightParenthesis {
+				return nil, m.ErrMsg("expected right paren ) after column names")
+			}
+			m.Next()
+		}
+
+		if m.Cur().T != lex.TokenAs {
+			return nil, m.ErrMsg("expected AS for common table expression")
+		}
+		m.Next()
+		if m.Cur().T != lex.TokenLeftParenthesis {
+			return nil, m.ErrMsg("expected left paren ( for common table expression")
+		}
+		m.Next()
+		if m.Cur().T != lex.TokenSelect {
+			return nil, m.ErrMsg("expected SELECT for common table expression")
+		}
+		sel, err := m.parseSqlSelect()
+		if err != nil {
+			return nil, err
+		}
+		if m.Cur().T != lex.TokenRightParenthesis {
+			return nil, m.ErrMsg("common table expression must be a single select")
+		}
+		m.Next() // discard right paren
+		sel.Raw = sel.String()
+		if len(cte.Columns) > 0 && len(cte.Columns) != len(sel.Columns) && !hasStar(sel.Columns) {
+			return nil, fmt.Errorf("common table expression %s has %d column names but select has %d columns",
+				cte.Name, len(cte.Columns), len(sel.Columns))
+		}
+		cte.Select = sel
+		ctes = append(ctes, cte)
+
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next()
+	}
+
+	if m.Cur().T != lex.TokenSelect {
+		return nil, m.ErrMsg("expected SELECT after common table expressions")
+	}
+	sel, err := m.parseSqlSelect()
+	if err != nil {
+		return nil, err
+	}
+	sel.Ctes = ctes
+	if isSetOperator(m.Cur().T) {
+		return m.parseSqlCompound(sel)
+	}
+	return sel, nil
+}
+
+// A select followed by UNION, INTERSECT, EXCEPT so parse the rest of the
+// selects in this compound select.
+func (m *Sqlbridge) parseSqlCompound(first *SqlSelect) (*SqlCompound, error) {
+
+	req := NewSqlCompound()
+	req.Raw = m.l.RawInput()
+	req.Selects = append(req.Selects, first)
+
+	for isSetOperator(m.Cur().T) {
+
+		prev := req.Selects[len(req.Selects)-1]
+		if len(prev.OrderBy) > 0 || prev.Limit > 0 || prev.Offset > 0 {
+			return nil, m.ErrMsg("ORDER BY, LIMIT only allowed after last select of compound select")
+		}
+
+		op := &SqlSetOp{Op: m.Cur().T}
+		m.Next() // Consume UNION, INTERSECT, EXCEPT
+
+		switch m.Cur().T {
+		case lex.TokenAll:
+			if op.Op != lex.TokenUnion {
+				return nil, m.ErrMsg("ALL is only supported for UNION")
+			}
+			op.All = true
+			m.Next()
+		case lex.TokenDistinct:
+			m.Next()
+		}
+
+		if m.Cur().T != lex.TokenSelect {
+			return nil, m.ErrMsg("Expected SELECT after " + strings.ToUpper(op.Op.String()))
+		}
+		sel, err := m.parseSqlSelect()
+		if err != nil {
+			return nil, err
+		}
+		if !hasStar(first.Columns) && !hasStar(sel.Columns) && len(sel.Columns) != len(first.Columns) {
+			return nil, fmt.Errorf("Each SELECT of compound select must have same number of columns: %d vs %d",
+				len(first.Columns), len(sel.Columns))
+		}
+		req.Ops = append(req.Ops, op)
+		req.Selects = append(req.Selects, sel)
+	}
+
+	// ORDER BY, LIMIT, OFFSET parsed on the last select apply to the
+	// combined result not the last select.
+	last := req.Selects[len(req.Selects)-1]
+	req.OrderBy, req.Limit, req.Offset = last.OrderBy, last.Limit, last.Offset
+	last.OrderBy, last.Limit, last.Offset = nil, 0, 0
+
+	return req, nil
+}
+
+func hasStar(cols Columns) bool {
+	for _, col := range cols {
+		if col.Star {
+			return true
+		}
+	}
+	return false
+}
+func isSetOperator(t lex.TokenType) bool {
+	switch t {
+	case lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
+		return true
+	}
+	return false
+}
+
 // First keyword was INSERT, REPLACE
 func (m *Sqlbridge) parseSqlInsert() (*SqlInsert, error) {
 
@@ -509,38 +690,63 @@ func (m *Sqlbridge) parseDescribe() (SqlStatement, error) {
 	req.Tok = m.Cur()
 	m.Next() // Consume Describe
 
-	//u.Debugf("token:  %v", m.Cur())
-	switch nextWord := strings.ToLower(m.Cur().V); nextWord {
-	case "select":
-		// TODO:  make the lexer handle this
-		sqlText := strings.Replace(m.l.RawInput(), req.Tok.V, "", 1)
-		sqlSel, err := ParseSql(sqlText)
-		if err != nil {
-			return nil, err
+	// A statement to explain is parsed on its own from the raw text after
+	// the keyword and options
+	//   EXPLAIN [ANALYZE] [EXTENDED] [FORMAT = {TRADITIONAL|JSON}] SELECT ...
+	sqlText := strings.TrimSpace(strings.Replace(m.l.RawInput(), req.Tok.V, "", 1))
+	if strings.EqualFold(firstWord(sqlText), "analyze") {
+		req.Analyze = true
+		sqlText = strings.TrimSpace(sqlText[len("analyze"):])
+	}
+	if strings.EqualFold(firstWord(sqlText), "extended") {
+		sqlText = strings.TrimSpace(sqlText[len("extended"):])
+	}
+	if strings.EqualFold(firstWord(sqlText), "format") {
+		sqlText = strings.TrimSpace(sqlText[len("format"):])
+		if !strings.HasPrefix(sqlText, "=") {
+			return nil, m.ErrMsg("expected FORMAT = {TRADITIONAL|JSON}")
+		}
+		sqlText = strings.TrimSpace(sqlText[1:])
+		req.Format = strings.ToLower(firstWord(sqlText))
+		switch req.Format {
+		case "traditional", "json":
+		default:
+			return nil, m.ErrMsg("expected FORMAT = {TRADITIONAL|JSON}")
 		}
-		req.Stmt = sqlSel
-		return req, nil
-	case "extended":
-		sqlText := strings.Replace(m.l.RawInput(), req.Tok.V, "", 1)
-		sqlText = strings.Replace(sqlText, m.Cur().V, "", 1)
-		sqlSel, err := ParseSql(sqlText)
+		sqlText = strings.TrimSpace(sqlText[len(req.Format):])
+	}
+
+	switch strings.ToLower(firstWord(sqlText)) {
+	case "select", "with":
+		stmt, err := ParseSql(sqlText)
 		if err != nil {
 			return nil, err
 		}
-		req.Stmt = sqlSel
+		req.Stmt = stmt
 		return req, nil
-	default:
-		if lex.TokenIdentity == m.Cur().T {
-			req.Identity = m.Cur().V
-		} else {
-			return nil, m.ErrMsg("expected idenity")
-		}
-
+	}
+	if req.Format != "" || req.Analyze {
+		return nil, m.ErrMsg("expected SELECT to explain")
+	}
+	if lex.TokenIdentity == m.Cur().T {
+		req.Identity = m.Cur().V
+	} else {
+		return nil, m.ErrMsg("expected idenity")
 	}
 
 	return req, nil
 }
 
+// firstWord the leading word of text, letters, digits and underscores.
+func firstWord(text string) string {
+	for i, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return text[:i]
+		}
+	}
+	return text
+}
+
 // First keyword was SHOW
 func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 
@@ -873,6 +1079,34 @@ func (m *Sqlbridge) parseTransaction() (*SqlCommand, error) {
 	return req, nil
 }
 
+// First keyword was ANALYZE, the tables to collect statistics of are
+// the columns of the command.
+//
+//    ANALYZE TABLE tbl_name [, tbl_name] ...
+func (m *Sqlbridge) parseAnalyze() (*SqlCommand, error) {
+
+	req := &SqlCommand{Columns: make(CommandColumns, 0)}
+	req.kw = m.Next().T // analyze
+	if m.Cur().T == lex.TokenTable || strings.EqualFold(m.Cur().V, "table") {
+		m.Next()
+	}
+	for {
+		if m.Cur().T != lex.TokenIdentity {
+			return nil, m.ErrMsg("expected table name for ANALYZE TABLE")
+		}
+		req.Columns = append(req.Columns, &CommandColumn{Name: m.Next().V})
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next()
+	}
+	switch m.Cur().T {
+	case lex.TokenEOF, lex.TokenEOS:
+		return req, nil
+	}
+	return nil, m.ErrMsg("expected end of ANALYZE TABLE")
+}
+
 func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement) error {
 
 	var col *Column
@@ -953,6 +1187,32 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 				return err
 			}
 			col.Expr = exprNode
+		case lex.TokenLeftParenthesis:
+			// (SELECT ...) sub-select or parenthesized expression column,
+			// un-aliased is named by its expression
+			col = &Column{}
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return err
+			}
+			col.Expr = exprNode
+			col.As = exprNode.String()
+		case lex.TokenCase:
+			// CASE WHEN ... END expression column, un-aliased is named "case"
+			col = &Column{As: "case"}
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return err
+			}
+			col.Expr = exprNode
+			col.SourceField = expr.FindFirstIdentity(col.Expr)
+			if _, r, ok := expr.LeftRight(col.SourceField); ok {
+				col.SourceField = r
+			}
+		}
+		if col != nil && !col.Agg && col.Over == nil && col.Expr != nil && len(expr.FindAllAggregates(col.Expr)) > 0 {
+			// aggregates inside of an expression, ie  sum(price) / count(*)
+			col.Agg = true
 		}
 		//u.Debugf("after colstart?:   %v  ", m.Cur())
 		comment += readComment(m)
@@ -970,7 +1230,8 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 				continue
 			}
 			return m.ErrMsg("expected identity")
-		case lex.TokenFrom, lex.TokenInto, lex.TokenLimit, lex.TokenEOS, lex.TokenEOF:
+		case lex.TokenFrom, lex.TokenInto, lex.TokenLimit, lex.TokenEOS, lex.TokenEOF,
+			lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept, lex.TokenOrderBy:
 			// This indicates we have come to the End of the columns
 			col.Comment = comment
 			stmt.AddColumn(*col)
@@ -985,6 +1246,21 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 			col.Guard = exprNode
 			// Hm, we need to backup here?  Parse Node went to deep?
 			continue
+		case lex.TokenOver:
+			// Window function column
+			fn, ok := col.Expr.(*expr.FuncNode)
+			if !ok {
+				return m.ErrMsg("expected window function before OVER")
+			}
+			m.Next()
+			over, err := parseWindowSpec(m, fr)
+			if err != nil {
+				return err
+			}
+			col.Over = over
+			col.Agg = false
+			col.As = fn.Name
+			continue
 		case lex.TokenRightParenthesis:
 			// loop on my friend
 		case lex.TokenComma:
@@ -1051,6 +1327,12 @@ func (m *Sqlbridge) parseUpdateList() (map[string]*ValueColumn, error) {
 		case lex.TokenInteger:
 			iv, _ := strconv.ParseInt(m.Cur().V, 10, 64)
 			cols[lastColName] = &ValueColumn{Value: value.NewIntValue(iv)}
+		case lex.TokenParam:
+			pn, err := m.parseParam()
+			if err != nil {
+				return nil, err
+			}
+			cols[lastColName] = &ValueColumn{Expr: pn}
 		case lex.TokenComma, lex.TokenEqual:
 			// don't need to do anything
 		case lex.TokenIdentity:
@@ -1075,6 +1357,16 @@ func (m *Sqlbridge) parseUpdateList() (map[string]*ValueColumn, error) {
 	}
 }
 
+// parseParam the ? or $N bind parameter at the current token, ? numbered
+// in the order parsed.
+func (m *Sqlbridge) parseParam() (*expr.ParamNode, error) {
+	pos := 0
+	if m.Cur().V == "?" {
+		pos = m.l.NextParam()
+	}
+	return expr.NewParamNode(m.Cur(), pos)
+}
+
 func (m *Sqlbridge) parseValueList() ([][]*ValueColumn, error) {
 
 	if m.Cur().T != lex.TokenLeftParenthesis {
@@ -1139,6 +1431,12 @@ func (m *Sqlbridge) parseValueList() ([][]*ValueColumn, error) {
 			}
 			row = append(row, &ValueColumn{Value: arrayVal})
 			u.Infof("what is token?  %v peek:%v", m.Cur(), m.Peek())
+		case lex.TokenParam:
+			pn, err := m.parseParam()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, &ValueColumn{Expr: pn})
 		case lex.TokenComma:
 			// don't need to do anything
 		case lex.TokenUdfExpr:
@@ -1189,13 +1487,22 @@ func (m *Sqlbridge) parseSources(req *SqlSelect) error {
 			if m.Cur().T == lex.TokenRightParenthesis {
 				m.Next()
 			}
-		case lex.TokenLeft, lex.TokenRight, lex.TokenInner, lex.TokenOuter, lex.TokenJoin:
+		case lex.TokenLeft, lex.TokenRight, lex.TokenFull, lex.TokenInner, lex.TokenOuter, lex.TokenCross, lex.TokenJoin:
 			// JOIN
 			if err := m.parseSourceJoin(src); err != nil {
 				return err
 			}
+		case lex.TokenComma:
+			// SELECT [columns] FROM users AS u, orders AS o
+			//   is a CROSS JOIN
+			m.Next()
+			src.JoinType = lex.TokenCross
+			if err := m.parseSourceJoinTable(src); err != nil {
+				return err
+			}
 		case lex.TokenEOF, lex.TokenEOS, lex.TokenWhere, lex.TokenGroupBy, lex.TokenLimit,
-			lex.TokenOffset, lex.TokenWith, lex.TokenAlias, lex.TokenOrderBy:
+			lex.TokenOffset, lex.TokenWith, lex.TokenAlias, lex.TokenOrderBy,
+			lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
 			return nil
 		default:
 			return m.ErrMsg("unexpected token")
@@ -1264,14 +1571,14 @@ func (m *Sqlbridge) parseSourceTable(req *SqlSelect) error {
 func (m *Sqlbridge) parseSourceJoin(src *SqlSource) error {
 
 	switch m.Cur().T {
-	case lex.TokenLeft, lex.TokenRight:
+	case lex.TokenLeft, lex.TokenRight, lex.TokenFull:
 		src.LeftOrRight = m.Cur().T
 		m.Next()
 	}
 
-	// Optional Inner/Outer
+	// Optional Inner/Outer/Cross
 	switch m.Cur().T {
-	case lex.TokenInner, lex.TokenOuter:
+	case lex.TokenInner, lex.TokenOuter, lex.TokenCross:
 		src.JoinType = m.Cur().T
 		m.Next()
 	}
@@ -1281,6 +1588,10 @@ func (m *Sqlbridge) parseSourceJoin(src *SqlSource) error {
 	} else {
 		return m.ErrMsg("Requires join")
 	}
+	return m.parseSourceJoinTable(src)
+}
+
+func (m *Sqlbridge) parseSourceJoinTable(src *SqlSource) error {
 
 	switch m.Cur().T {
 	case lex.TokenLeftParenthesis:
@@ -1316,17 +1627,174 @@ func (m *Sqlbridge) parseInto(req *SqlSelect) error {
 	return nil
 }
 
-func (m *Sqlbridge) parseWhereSubSelect(req *SqlSelect) error {
+// parseWhereSubSelect looks ahead through the where clause for a sub-select
+// and if found parses it into where along with the conditions AND'd with it.
+//
+//    WHERE x > 1 AND user_id [NOT] IN (SELECT user_id FROM orders) AND y = 2
+//    WHERE [NOT] EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id)
+//
+// Other sub-selects, such as  price > (SELECT avg(price) FROM items)  are
+// scalar values of the where expression.
+func (m *Sqlbridge) parseWhereSubSelect(where *SqlWhere) (bool, error) {
+
+	toks := make([]lex.Token, 0)
+	conds := []int{0} // start of each condition AND'd together
+	depth, sub, subEnd := 0, -1, -1
+	between, hasOr := false, false
+
+scan:
+	for {
+		tok := m.Cur()
+		switch tok.T {
+		case lex.TokenEOF, lex.TokenEOS, lex.TokenError:
+			break scan
+		case lex.TokenGroupBy, lex.TokenOrderBy, lex.TokenHaving, lex.TokenLimit,
+			lex.TokenOffset, lex.TokenWith, lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
+			if depth == 0 {
+				break scan
+			}
+		case lex.TokenLeftParenthesis:
+			if m.Peek().T == lex.TokenSelect && len(toks) > 0 && (sub < 0 || subEnd >= 0) {
+				switch toks[len(toks)-1].T {
+				case lex.TokenIN, lex.TokenExists:
+					if depth > 0 {
+						return false, m.ErrMsg("sub-select must be a condition of WHERE, AND'd with any others")
+					}
+					if sub >= 0 {
+						return false, m.ErrMsg("only one sub-select condition is supported in WHERE")
+					}
+					sub = len(toks)
+				}
+			}
+			depth++
+		case lex.TokenRightParenthesis:
+			if depth == 0 {
+				// end of the select this where belongs to
+				break scan
+			}
+			depth--
+			if depth == 0 && sub >= 0 && subEnd < 0 {
+				subEnd = len(toks)
+			}
+		case lex.TokenBetween:
+			between = depth == 0
+		case lex.TokenLogicAnd, lex.TokenAnd:
+			if depth == 0 {
+				if between {
+					between = false
+				} else {
+					conds = append(conds, len(toks)+1)
+				}
+			}
+		case lex.TokenLogicOr, lex.TokenOr:
+			if depth == 0 {
+				hasOr = true
+			}
+		}
+		toks = append(toks, tok)
+		m.Next()
+	}
+	for range toks {
+		m.Backup()
+	}
+	if sub < 0 {
+		return false, nil
+	}
+	if subEnd < 0 {
+		return false, m.ErrMsg("expected right paren ) after sub-select")
+	}
+	if hasOr {
+		return false, m.ErrMsg("sub-select condition of WHERE may only be AND'd with others, not OR'd")
+	}
 
-	if m.Cur().T != lex.TokenSelect {
-		return nil
+	// the condition holding the sub-select
+	condStart, condEnd := 0, len(toks)
+	for i, start := range conds {
+		if start > sub {
+			condEnd = start - 1
+			break
+		}
+		condStart = conds[i]
+	}
+	if subEnd+1 != condEnd {
+		return false, fmt.Errorf("unexpected token after sub-select: %v", toks[subEnd+1])
+	}
+
+	op := sub - 1
+	where.Op = toks[op].T
+	left := toks[condStart:op]
+	if len(left) > 0 && left[len(left)-1].T == lex.TokenNegate {
+		where.Negate = true
+		left = left[:len(left)-1]
+	} else if where.Op == lex.TokenExists && len(left) == 1 && left[0].T == lex.TokenNegate {
+		where.Negate = true
+		left = nil
+	}
+	switch {
+	case where.Op == lex.TokenExists && len(left) > 0:
+		return false, fmt.Errorf("unexpected token before EXISTS: %v", left[0])
+	case where.Op != lex.TokenExists && len(left) == 0:
+		return false, fmt.Errorf("expected expression before %s (SELECT ...)", where.Op)
+	}
+
+	// The other conditions AND'd with the sub-select, parsed in the order
+	// written so ? bind parameters are numbered in order.
+	if condStart > 0 {
+		n, err := m.parseTokens(toks[:condStart-1])
+		if err != nil {
+			return false, err
+		}
+		where.Expr = n
+	}
+	if len(left) > 0 {
+		n, err := m.parseTokens(left)
+		if err != nil {
+			return false, err
+		}
+		where.Left = n
+	}
+
+	for i := 0; i <= sub; i++ {
+		m.Next()
 	}
-	stmt, err := m.parseSqlSelect()
+	sel, err := m.parseSqlSelect()
 	if err != nil {
-		return err
+		return false, err
 	}
-	req = stmt
-	return nil
+	if m.Cur().T != lex.TokenRightParenthesis {
+		return false, m.ErrMsg("sub-select must be a single select")
+	}
+	sel.Raw = sel.String()
+	where.Source = sel
+
+	if condEnd < len(toks) {
+		n, err := m.parseTokens(toks[condEnd+1:])
+		if err != nil {
+			return false, err
+		}
+		if where.Expr == nil {
+			where.Expr = n
+		} else {
+			where.Expr = expr.NewBinaryNode(toks[condStart-1], where.Expr, n)
+		}
+	}
+	for i := subEnd; i < len(toks); i++ {
+		m.Next()
+	}
+	return true, nil
+}
+
+// parseTokens parse an expression from the given already lexed tokens.
+func (m *Sqlbridge) parseTokens(toks []lex.Token) (expr.Node, error) {
+	pager := newTokenSlicePager(m.l, toks)
+	n, err := expr.ParseExprWithFuncs(pager, m.funcs)
+	if err != nil {
+		return nil, err
+	}
+	if !pager.IsEnd() {
+		return nil, pager.ErrMsg("unexpected token")
+	}
+	return n, nil
 }
 
 func (m *Sqlbridge) parseWhereSelect(req *SqlSelect) error {
@@ -1338,12 +1806,6 @@ func (m *Sqlbridge) parseWhereSelect(req *SqlSelect) error {
 	defer func() {
 		if r := recover(); r != nil {
 			u.Errorf("where error? %v \n %v\n%s", r, m.Cur(), m.Lexer().RawInput())
-			if m.Cur().T == lex.TokenSelect {
-				// TODO this is deeply flawed, need to fix/use tokenpager
-				//    with rewind ability
-				err = m.parseWhereSubSelect(req)
-				return
-			}
 			err = fmt.Errorf("panic err: %v", r)
 		}
 	}()
@@ -1369,38 +1831,20 @@ func (m *Sqlbridge) parseWhere() (*SqlWhere, error) {
 
 	where := SqlWhere{}
 
-	// We are going to Peek forward at the next 3 tokens used
-	// to determine which type of where clause
-	m.Next() // x
-	t2 := m.Cur().T
-	m.Next()
-	t3 := m.Cur().T
-	m.Next()
-	t4 := m.Cur().T
-	m.Backup()
-	m.Backup()
-	m.Backup()
-
 	// Check for Types of Where
-	//                                 t1            T2      T3     T4
-	//    SELECT x FROM user   WHERE user_id         IN      (      SELECT user_id from orders where ...)
-	//    SELECT * FROM t1     WHERE column1         =       (      SELECT column1 FROM t2);
-	//    select a FROM movies WHERE director        IN      (     "Quentin","copola","Bay","another")
-	//    select b FROM movies WHERE director        =       "bob";
-	//    select b FROM movies WHERE create          BETWEEN "2015" AND "2010";
-	//    select b from movies WHERE director        LIKE    "%bob"
+	//    SELECT x FROM user   WHERE user_id IN (SELECT user_id from orders where ...)
+	//    SELECT * FROM t1     WHERE NOT EXISTS (SELECT 1 FROM t2 WHERE t2.id = t1.id)
+	//    SELECT * FROM t1     WHERE column1 = (SELECT column1 FROM t2);
+	//    select a FROM movies WHERE director IN ("Quentin","copola","Bay","another")
+	//    select b FROM movies WHERE director = "bob";
+	//    select b FROM movies WHERE create BETWEEN "2015" AND "2010";
+	//    select b from movies WHERE director LIKE "%bob"
 	// TODO:
-	//    SELECT * FROM t3     WHERE ROW(5*t2.s1,77) =       (      SELECT 50,11*s1 FROM t4)
-	switch {
-	case (t2 == lex.TokenIN || t2 == lex.TokenEqual) && t3 == lex.TokenLeftParenthesis && t4 == lex.TokenSelect:
-		//u.Infof("in parseWhere: %v", m.Cur())
-		m.Next() // T1  ?? this might be udf?
-		m.Next() // t2  (IN | =)
-		m.Next() // t3 = (
-		//m.Next() // t4 = SELECT
-		where.Op = t2
-		where.Source = &SqlSelect{}
-		return &where, m.parseWhereSubSelect(where.Source)
+	//    SELECT * FROM t3     WHERE ROW(5*t2.s1,77) = (SELECT 50,11*s1 FROM t4)
+	if found, err := m.parseWhereSubSelect(&where); err != nil {
+		return nil, err
+	} else if found {
+		return &where, nil
 	}
 	exprNode, err := expr.ParseExprWithFuncs(m, m.funcs)
 	if err != nil {
@@ -1419,10 +1863,36 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 
 	var col *Column
 
+	// the grouping sets of each group by element, a plain column is the
+	// single set of itself
+	var elems [][][]int
+	hasSets := false
+	addCol := func() {
+		if col != nil {
+			req.GroupBy = append(req.GroupBy, col)
+			elems = append(elems, [][]int{{len(req.GroupBy) - 1}})
+		}
+		col = nil
+	}
+	done := func() error {
+		addCol()
+		if hasSets {
+			req.GroupingSets = crossGroupingSets(elems)
+		}
+		return nil
+	}
+
 	for {
 
 		//u.Debugf("Group By? %v", m.Cur())
 		switch m.Cur().T {
+		case lex.TokenRollup, lex.TokenCube, lex.TokenGroupingSets:
+			sets, err := m.parseGroupingSets(req)
+			if err != nil {
+				return err
+			}
+			hasSets = true
+			elems = append(elems, sets)
 		case lex.TokenUdfExpr:
 			// we have a udf/functional expression column
 			//u.Infof("udf: %v", m.Cur().V)
@@ -1484,11 +1954,13 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 			}
 			return m.ErrMsg("expected identity")
 		case lex.TokenFrom, lex.TokenOrderBy, lex.TokenInto, lex.TokenLimit, lex.TokenHaving,
-			lex.TokenWith, lex.TokenEOS, lex.TokenEOF:
+			lex.TokenWith, lex.TokenEOS, lex.TokenEOF, lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
 
 			// This indicates we have come to the End of the columns
-			req.GroupBy = append(req.GroupBy, col)
-			return nil
+			return done()
+		case lex.TokenRightParenthesis:
+			// End of a sub-select, ie common table expression
+			return done()
 		case lex.TokenIf:
 			// If guard
 			m.Next()
@@ -1500,10 +1972,8 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 		case lex.TokenCommentSingleLine:
 			m.Next()
 			col.Comment = m.Cur().V
-		case lex.TokenRightParenthesis:
-			// loop on my friend
 		case lex.TokenComma:
-			req.GroupBy = append(req.GroupBy, col)
+			addCol()
 		default:
 			return m.ErrMsg("expected column of group by ")
 		}
@@ -1511,6 +1981,142 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 	}
 }
 
+// parseGroupingSets parse a ROLLUP, CUBE or GROUPING SETS group by element
+// into the sets of group by column indexes it groups on.
+//
+//    ROLLUP (a, b)              =>  (a, b), (a), ()
+//    CUBE (a, b)                =>  (a, b), (a), (b), ()
+//    GROUPING SETS ((a, b), ()) =>  (a, b), ()
+//
+func (m *Sqlbridge) parseGroupingSets(req *SqlSelect) ([][]int, error) {
+
+	op := m.Cur().T
+	m.Next()
+	if m.Cur().T != lex.TokenLeftParenthesis {
+		return nil, m.ErrMsg("expected ( after " + op.String())
+	}
+	m.Next()
+
+	var cols []int
+	var sets [][]int
+	for m.Cur().T != lex.TokenRightParenthesis {
+		if op == lex.TokenGroupingSets && m.Cur().T == lex.TokenLeftParenthesis {
+			m.Next()
+			set := []int{}
+			for m.Cur().T != lex.TokenRightParenthesis {
+				idx, err := m.parseGroupingColumn(req)
+				if err != nil {
+					return nil, err
+				}
+				set = append(set, idx)
+				if m.Cur().T == lex.TokenComma {
+					m.Next()
+				} else if m.Cur().T != lex.TokenRightParenthesis {
+					return nil, m.ErrMsg("expected , or ) in grouping list")
+				}
+			}
+			m.Next()
+			sets = append(sets, set)
+		} else {
+			idx, err := m.parseGroupingColumn(req)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, idx)
+			if op == lex.TokenGroupingSets {
+				sets = append(sets, []int{idx})
+			}
+		}
+		switch m.Cur().T {
+		case lex.TokenComma:
+			m.Next()
+		case lex.TokenRightParenthesis:
+		default:
+			return nil, m.ErrMsg("expected , or ) in grouping list")
+		}
+	}
+	m.Next()
+
+	switch op {
+	case lex.TokenRollup:
+		for n := len(cols); n >= 0; n-- {
+			sets = append(sets, append([]int{}, cols[:n]...))
+		}
+	case lex.TokenCube:
+		if len(cols) > 12 {
+			return nil, m.ErrMsg("too many columns for CUBE")
+		}
+		for mask := 1<<uint(len(cols)) - 1; mask >= 0; mask-- {
+			set := []int{}
+			for i, idx := range cols {
+				if mask&(1<<uint(len(cols)-1-i)) != 0 {
+					set = append(set, idx)
+				}
+			}
+			sets = append(sets, set)
+		}
+	}
+	return sets, nil
+}
+
+// parseGroupingColumn parse a column of a grouping list, adding it to the
+// group by columns if not already there, and return its index.
+func (m *Sqlbridge) parseGroupingColumn(req *SqlSelect) (int, error) {
+	switch m.Cur().T {
+	case lex.TokenUdfExpr, lex.TokenIdentity, lex.TokenValue:
+	default:
+		return 0, m.ErrMsg("expected column in grouping list")
+	}
+	col := NewColumnFromToken(m.Cur())
+	exprNode, err := expr.ParseExprWithFuncs(m, m.funcs)
+	if err != nil {
+		return 0, err
+	}
+	col.Expr = exprNode
+	if n, ok := exprNode.(*expr.FuncNode); ok {
+		col.As = expr.FindIdentityName(0, n, "")
+		if col.As == "" {
+			col.As = n.Name
+		}
+	}
+	for i, gb := range req.GroupBy {
+		if gb.Expr != nil && gb.Expr.Equal(exprNode) {
+			return i, nil
+		}
+	}
+	req.GroupBy = append(req.GroupBy, col)
+	return len(req.GroupBy) - 1, nil
+}
+
+// crossGroupingSets the grouping sets of a group by, each combination of
+// one set from each element.
+//
+//    GROUP BY a, ROLLUP (b, c)  =>  (a, b, c), (a, b), (a)
+//
+func crossGroupingSets(elems [][][]int) [][]int {
+	sets := [][]int{{}}
+	for _, elemSets := range elems {
+		next := make([][]int, 0, len(sets)*len(elemSets))
+		for _, set := range sets {
+			for _, elemSet := range elemSets {
+				nset := append([]int{}, set...)
+			idxLoop:
+				for _, idx := range elemSet {
+					for _, have := range nset {
+						if have == idx {
+							continue idxLoop
+						}
+					}
+					nset = append(nset, idx)
+				}
+				next = append(next, nset)
+			}
+		}
+		sets = next
+	}
+	return sets
+}
+
 func (m *Sqlbridge) parseHaving(req *SqlSelect) (err error) {
 
 	if m.Cur().T != lex.TokenHaving {
@@ -1584,8 +2190,13 @@ func (m *Sqlbridge) parseOrderBy(req *SqlSelect) (err error) {
 		switch m.Cur().T {
 		case lex.TokenAsc, lex.TokenDesc:
 			col.Order = strings.ToUpper(m.Cur().V)
+		case lex.TokenNullsFirst:
+			col.Nulls = "FIRST"
+		case lex.TokenNullsLast:
+			col.Nulls = "LAST"
 
-		case lex.TokenInto, lex.TokenLimit, lex.TokenEOS, lex.TokenEOF:
+		case lex.TokenInto, lex.TokenLimit, lex.TokenOffset, lex.TokenWith, lex.TokenEOS, lex.TokenEOF,
+			lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
 			// This indicates we have come to the End of the columns
 			req.OrderBy = append(req.OrderBy, col)
 			return nil
@@ -1603,6 +2214,146 @@ func (m *Sqlbridge) parseOrderBy(req *SqlSelect) (err error) {
 	}
 }
 
+// parseWindowSpec the parenthesized window specification following OVER
+//
+//     OVER ( [PARTITION BY <expr>, ...] [ORDER BY <expr> [ASC|DESC] [NULLS FIRST|LAST], ...] [(ROWS|RANGE) <frame>] )
+func parseWindowSpec(m expr.TokenPager, fr expr.FuncResolver) (*WindowSpec, error) {
+
+	if m.Cur().T != lex.TokenLeftParenthesis {
+		return nil, m.ErrMsg("expected ( after OVER")
+	}
+	m.Next()
+
+	ws := &WindowSpec{}
+	if m.Cur().T == lex.TokenPartitionBy {
+		m.Next()
+		for {
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return nil, err
+			}
+			ws.PartitionBy = append(ws.PartitionBy, exprNode)
+			if m.Cur().T != lex.TokenComma {
+				break
+			}
+			m.Next()
+		}
+	}
+	if m.Cur().T == lex.TokenOrderBy {
+		m.Next()
+		for {
+			col := NewColumnFromToken(m.Cur())
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return nil, err
+			}
+			col.Expr = exprNode
+			switch m.Cur().T {
+			case lex.TokenAsc, lex.TokenDesc:
+				col.Order = strings.ToUpper(m.Cur().V)
+				m.Next()
+			}
+			switch m.Cur().T {
+			case lex.TokenNullsFirst:
+				col.Nulls = "FIRST"
+				m.Next()
+			case lex.TokenNullsLast:
+				col.Nulls = "LAST"
+				m.Next()
+			}
+			ws.OrderBy = append(ws.OrderBy, col)
+			if m.Cur().T != lex.TokenComma {
+				break
+			}
+			m.Next()
+		}
+	}
+	switch m.Cur().T {
+	case lex.TokenRows, lex.TokenRange:
+		frame, err := parseWindowFrame(m)
+		if err != nil {
+			return nil, err
+		}
+		if frame.Unit == lex.TokenRange && len(ws.OrderBy) != 1 &&
+			(frame.Start.Offset > 0 || (frame.End != nil && frame.End.Offset > 0)) {
+			return nil, m.ErrMsg("RANGE with offset requires exactly one ORDER BY column")
+		}
+		ws.Frame = frame
+	}
+	if m.Cur().T != lex.TokenRightParenthesis {
+		return nil, m.ErrMsg("expected ) to end window specification")
+	}
+	m.Next()
+	return ws, nil
+}
+
+// parseWindowFrame (ROWS | RANGE) ( <bound> | BETWEEN <bound> AND <bound> )
+func parseWindowFrame(m expr.TokenPager) (*WindowFrame, error) {
+
+	frame := &WindowFrame{Unit: m.Cur().T}
+	m.Next()
+
+	var err error
+	if m.Cur().T != lex.TokenBetween {
+		if frame.Start, err = parseWindowFrameBound(m); err != nil {
+			return nil, err
+		}
+		if frame.Start.Type == lex.TokenFollowing {
+			return nil, m.ErrMsg("frame start cannot be FOLLOWING without BETWEEN")
+		}
+		return frame, nil
+	}
+	m.Next()
+	if frame.Start, err = parseWindowFrameBound(m); err != nil {
+		return nil, err
+	}
+	if m.Cur().T != lex.TokenLogicAnd {
+		return nil, m.ErrMsg("expected AND in frame BETWEEN")
+	}
+	m.Next()
+	if frame.End, err = parseWindowFrameBound(m); err != nil {
+		return nil, err
+	}
+	if frame.Start.Unbounded && frame.Start.Type == lex.TokenFollowing {
+		return nil, m.ErrMsg("frame start cannot be UNBOUNDED FOLLOWING")
+	}
+	if frame.End.Unbounded && frame.End.Type == lex.TokenPreceding {
+		return nil, m.ErrMsg("frame end cannot be UNBOUNDED PRECEDING")
+	}
+	return frame, nil
+}
+
+// parseWindowFrameBound UNBOUNDED (PRECEDING | FOLLOWING) | CURRENT ROW | <integer> (PRECEDING | FOLLOWING)
+func parseWindowFrameBound(m expr.TokenPager) (*WindowFrameBound, error) {
+
+	bound := &WindowFrameBound{}
+	switch m.Cur().T {
+	case lex.TokenCurrentRow:
+		bound.Type = lex.TokenCurrentRow
+		m.Next()
+		return bound, nil
+	case lex.TokenUnbounded:
+		bound.Unbounded = true
+	case lex.TokenInteger:
+		iv, err := strconv.ParseInt(m.Cur().V, 10, 64)
+		if err != nil || iv < 0 {
+			return nil, m.ErrMsg("expected non-negative integer frame offset")
+		}
+		bound.Offset = iv
+	default:
+		return nil, m.ErrMsg("expected frame bound")
+	}
+	m.Next()
+	switch m.Cur().T {
+	case lex.TokenPreceding, lex.TokenFollowing:
+		bound.Type = m.Cur().T
+	default:
+		return nil, m.ErrMsg("expected PRECEDING or FOLLOWING")
+	}
+	m.Next()
+	return bound, nil
+}
+
 func (m *Sqlbridge) parseWhereDelete(req *SqlDelete) error {
 	if m.Cur().T != lex.TokenWhere {
 		return nil
@@ -2312,6 +3063,45 @@ func ParseKeyValue(pg expr.TokenPager, jh u.JsonHelper) error {
 	}
 }
 
+// tokenSlicePager is a TokenPager over part of the already lexed tokens of
+// a statement, such as the conditions around a sub-select.
+type tokenSlicePager struct {
+	tokens []lex.Token
+	cursor int
+	l      *lex.Lexer
+}
+
+func newTokenSlicePager(l *lex.Lexer, toks []lex.Token) *tokenSlicePager {
+	tokens := make([]lex.Token, len(toks), len(toks)+1)
+	copy(tokens, toks)
+	tokens = append(tokens, lex.Token{T: lex.TokenEOF})
+	return &tokenSlicePager{tokens: tokens, l: l}
+}
+
+func (m *tokenSlicePager) Cur() lex.Token { return m.tokens[m.cursor] }
+func (m *tokenSlicePager) Next() lex.Token {
+	tok := m.tokens[m.cursor]
+	if m.cursor < len(m.tokens)-1 {
+		m.cursor++
+	}
+	return tok
+}
+func (m *tokenSlicePager) Peek() lex.Token {
+	if m.cursor < len(m.tokens)-1 {
+		return m.tokens[m.cursor+1]
+	}
+	return m.tokens[m.cursor]
+}
+func (m *tokenSlicePager) Backup() {
+	if m.cursor > 0 {
+		m.cursor--
+	}
+}
+func (m *tokenSlicePager) IsEnd() bool             { return m.Cur().T == lex.TokenEOF }
+func (m *tokenSlicePager) ClauseEnd() bool         { return m.IsEnd() }
+func (m *tokenSlicePager) Lexer() *lex.Lexer       { return m.l }
+func (m *tokenSlicePager) ErrMsg(msg string) error { return m.l.ErrMsg(m.Cur(), msg) }
+
 // TokenPager is responsible for determining end of
 // current tree (column, etc)
 type SqlTokenPager struct {
@@ -2335,7 +3125,8 @@ func (m *SqlTokenPager) ClauseEnd() bool {
 	tok := m.Cur()
 	switch tok.T {
 	case lex.TokenEOF, lex.TokenEOS, lex.TokenFrom, lex.TokenHaving, lex.TokenComma,
-		lex.TokenIf, lex.TokenAs, lex.TokenLimit, lex.TokenSelect:
+		lex.TokenIf, lex.TokenAs, lex.TokenLimit, lex.TokenSelect,
+		lex.TokenUnion, lex.TokenIntersect, lex.TokenExcept:
 		return true
 	}
 	return false
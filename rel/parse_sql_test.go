@@ -9,6 +9,7 @@ import (
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
 )
 
 func init() {
@@ -159,6 +160,7 @@ func TestSqlParseOnly(t *testing.T) {
 	parseSqlError(t, "SELECT hash(join(, \", \")) AS id, `x`, `y`, `z` FROM nothing;")
 
 	parseSqlTest(t, "SELECT COUNT(*) AS count FROM providers WHERE (`providers._id` != NULL)")
+	parseSqlTest(t, "SELECT count(DISTINCT user_id) AS ct, sum(DISTINCT(price)) AS s FROM orders")
 
 	parseSqlTest(t, "select title from article WITH distributed=true, node_ct=10")
 	parseSqlTest(t, "SELECT `appearances`.`G_ph` AS `field` FROM `appearances` ORDER BY `appearances`.`G_ph` ASC LIMIT 500 OFFSET 0")
@@ -358,6 +360,16 @@ func TestSqlParseAstCheck(t *testing.T) {
 	assert.True(t, sel.OrderBy[0].Order == "ASC", "%v", sel.OrderBy[0].String())
 	assert.True(t, sel.OrderBy[1].Order == "DESC", "%v", sel.OrderBy[1].String())
 
+	sql = "select name from users ORDER BY name NULLS FIRST, toint(age) DESC NULLS LAST WITH collation = \"nocase\""
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	sel = req.(*rel.SqlSelect)
+	assert.Equal(t, 2, len(sel.OrderBy))
+	assert.True(t, sel.OrderBy[0].Asc() && sel.OrderBy[0].NullsFirst(), "%v", sel.OrderBy[0].String())
+	assert.True(t, !sel.OrderBy[1].Asc() && !sel.OrderBy[1].NullsFirst(), "%v", sel.OrderBy[1].String())
+	assert.Equal(t, "nocase", sel.With.String("collation"))
+	parseSqlTest(t, sql)
+
 	sql = "select name from `github_public` limit 0, 100;"
 	req, err = rel.ParseSql(sql)
 	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
@@ -473,6 +485,54 @@ func TestSqlAggregateTypeSelect(t *testing.T) {
 	assert.True(t, sel.IsAggQuery(), "wanted IsAggQuery()==true but got false")
 }
 
+func TestSqlAggregateExpressionSelect(t *testing.T) {
+	t.Parallel()
+	sel, err := rel.ParseSqlSelect(`select round(sum(price) / count(*), 2) AS a from orders`)
+	assert.Equal(t, nil, err)
+	assert.True(t, sel.Columns[0].Agg)
+	assert.True(t, sel.IsAggQuery(), "wanted IsAggQuery()==true but got false")
+
+	sel, err = rel.ParseSqlSelect(`select round(price, 2) AS p from orders`)
+	assert.Equal(t, nil, err)
+	assert.False(t, sel.IsAggQuery())
+}
+
+func TestSqlGroupingSets(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT a, b, sum(x) FROM t GROUP BY ROLLUP (a, b)`)
+	parseSqlTest(t, `SELECT a, b, grouping(a, b) FROM t GROUP BY CUBE(a, toint(b)) HAVING sum(x) > 1`)
+	parseSqlTest(t, `SELECT a, b FROM t GROUP BY GROUPING SETS ((a, b), (b), ()) ORDER BY a`)
+	parseSqlTest(t, `SELECT a FROM (SELECT a, count(*) FROM t GROUP BY ROLLUP (a)) AS x`)
+	parseSqlError(t, `SELECT a FROM t GROUP BY ROLLUP (a, b`)
+	parseSqlError(t, `SELECT a FROM t GROUP BY ROLLUP a`)
+	parseSqlError(t, `SELECT a FROM t GROUP BY GROUPING SETS ((a, b)`)
+
+	sel, err := rel.ParseSqlSelect(`SELECT a, b, c FROM t GROUP BY ROLLUP (a, b, c)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(sel.GroupBy))
+	assert.Equal(t, [][]int{{0, 1, 2}, {0, 1}, {0}, {}}, sel.GroupingSets)
+
+	sel, err = rel.ParseSqlSelect(`SELECT a, b, c FROM t GROUP BY a, CUBE(b, c)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(sel.GroupBy))
+	assert.Equal(t, [][]int{{0, 1, 2}, {0, 1}, {0, 2}, {0}}, sel.GroupingSets)
+
+	sel, err = rel.ParseSqlSelect(`SELECT a, b FROM t GROUP BY grouping sets ((a, b), a, ())`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(sel.GroupBy))
+	assert.Equal(t, [][]int{{0, 1}, {0}, {}}, sel.GroupingSets)
+	assert.Equal(t, "SELECT a, b FROM t GROUP BY GROUPING SETS ((a, b), (a), ())", sel.String())
+	sel2, err := rel.ParseSqlSelect(sel.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, sel.String(), sel2.String())
+	assert.Equal(t, sel.GroupingSets, sel2.GroupingSets)
+
+	// plain group by has no grouping sets
+	sel, err = rel.ParseSqlSelect(`SELECT a, b FROM t GROUP BY a, b`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(sel.GroupingSets))
+}
+
 func TestSqlParseFromTypes(t *testing.T) {
 	t.Parallel()
 	sql := `select gh.repository.name, gh.id, gp.date 
@@ -522,6 +582,42 @@ func TestSqlParseFromTypes(t *testing.T) {
 	u.Info(sel.String())
 }
 
+func TestSqlParseOuterJoins(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id`)
+	parseSqlTest(t, `SELECT u.email, o.order_id FROM users AS u LEFT OUTER JOIN orders AS o ON u.user_id = o.user_id ORDER BY u.email`)
+	parseSqlTest(t, `SELECT u.email, o.order_id FROM users AS u RIGHT JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 10`)
+	parseSqlTest(t, `SELECT u.email, o.order_id FROM users AS u FULL OUTER JOIN orders AS o ON u.user_id = o.user_id`)
+	parseSqlTest(t, `SELECT u.email, o.order_id FROM users AS u CROSS JOIN orders AS o`)
+	parseSqlTest(t, `SELECT u.email FROM users AS u, orders AS o, items AS i WHERE u.user_id = o.user_id AND o.item_id = i.item_id`)
+	parseSqlTest(t, `SELECT u.email FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id LEFT JOIN orders AS x ON o.price < x.price`)
+
+	tests := []struct {
+		sql         string
+		leftOrRight lex.TokenType
+		joinType    lex.TokenType
+	}{
+		{"SELECT u.email FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id", 0, lex.TokenInner},
+		{"SELECT u.email FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id", lex.TokenLeft, 0},
+		{"SELECT u.email FROM users AS u RIGHT OUTER JOIN orders AS o ON u.user_id = o.user_id", lex.TokenRight, lex.TokenOuter},
+		{"SELECT u.email FROM users AS u FULL JOIN orders AS o ON u.user_id = o.user_id", lex.TokenFull, 0},
+		{"SELECT u.email FROM users AS u CROSS JOIN orders AS o", 0, lex.TokenCross},
+		{"SELECT u.email FROM users AS u, orders AS o WHERE u.user_id = o.user_id", 0, lex.TokenCross},
+	}
+	for _, tt := range tests {
+		sel, err := rel.ParseSqlSelect(tt.sql)
+		assert.Equal(t, nil, err, tt.sql)
+		assert.Equal(t, 2, len(sel.From), tt.sql)
+		assert.Equal(t, tt.leftOrRight, sel.From[1].LeftOrRight, tt.sql)
+		assert.Equal(t, tt.joinType, sel.From[1].JoinType, tt.sql)
+		// round trips through String()
+		sel2, err := rel.ParseSqlSelect(sel.String())
+		assert.Equal(t, nil, err, sel.String())
+		assert.Equal(t, tt.leftOrRight, sel2.From[1].LeftOrRight, sel.String())
+		assert.Equal(t, tt.joinType, sel2.From[1].JoinType, sel.String())
+	}
+}
+
 func TestSqlShowAst(t *testing.T) {
 	t.Parallel()
 	/*
@@ -547,6 +643,48 @@ func TestSqlShowAst(t *testing.T) {
 	assert.True(t, show.Like.String() == "Field LIKE \"%\"", "has Like? %q", show.Like.String())
 }
 
+func TestSqlExplain(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `EXPLAIN SELECT a FROM t WHERE x > 1`)
+	parseSqlTest(t, `explain format = json SELECT a FROM t GROUP BY a`)
+	parseSqlTest(t, `EXPLAIN SELECT a FROM t UNION SELECT b FROM u`)
+	parseSqlTest(t, `DESCRIBE users`)
+	parseSqlError(t, `EXPLAIN FORMAT = xml SELECT a FROM t`)
+	parseSqlError(t, `EXPLAIN FORMAT json SELECT a FROM t`)
+	parseSqlError(t, `EXPLAIN FORMAT = JSON users`)
+	parseSqlTest(t, `EXPLAIN ANALYZE SELECT a FROM t WHERE x > 1`)
+	parseSqlError(t, `EXPLAIN ANALYZE users`)
+
+	req, err := rel.ParseSql(`EXPLAIN FORMAT=JSON SELECT a FROM t WHERE x > 1`)
+	assert.Equal(t, nil, err)
+	desc, ok := req.(*rel.SqlDescribe)
+	assert.True(t, ok, "is SqlDescribe: %T", req)
+	assert.Equal(t, "json", desc.Format)
+	_, ok = desc.Stmt.(*rel.SqlSelect)
+	assert.True(t, ok, "is SqlSelect: %T", desc.Stmt)
+	assert.Equal(t, "EXPLAIN FORMAT = JSON SELECT a FROM t WHERE x > 1", desc.String())
+
+	req, err = rel.ParseSql(`EXPLAIN SELECT a FROM t UNION SELECT b FROM u`)
+	assert.Equal(t, nil, err)
+	desc = req.(*rel.SqlDescribe)
+	assert.Equal(t, "", desc.Format)
+	_, ok = desc.Stmt.(*rel.SqlCompound)
+	assert.True(t, ok, "is SqlCompound: %T", desc.Stmt)
+
+	req, err = rel.ParseSql(`explain analyze format = json SELECT a FROM t`)
+	assert.Equal(t, nil, err)
+	desc = req.(*rel.SqlDescribe)
+	assert.True(t, desc.Analyze)
+	assert.Equal(t, "json", desc.Format)
+	assert.Equal(t, "EXPLAIN ANALYZE FORMAT = JSON SELECT a FROM t", desc.String())
+
+	req, err = rel.ParseSql(`DESCRIBE users`)
+	assert.Equal(t, nil, err)
+	desc = req.(*rel.SqlDescribe)
+	assert.Equal(t, "users", desc.Identity)
+	assert.True(t, desc.Stmt == nil)
+}
+
 func TestSqlCommands(t *testing.T) {
 	t.Parallel()
 	// Administrative commands
@@ -573,6 +711,16 @@ func TestSqlCommands(t *testing.T) {
 	assert.True(t, ok, "is SqlCommand: %T", req)
 	assert.True(t, cmd.Keyword() == lex.TokenUse, "has USE kw: %#v", cmd)
 	assert.True(t, cmd.Identity == "myschema", "has myschema: %#v", cmd.Identity)
+
+	sql = `ANALYZE TABLE orders, users;`
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	cmd, ok = req.(*rel.SqlCommand)
+	assert.True(t, ok, "is SqlCommand: %T", req)
+	assert.True(t, cmd.Keyword() == lex.TokenAnalyze, "has ANALYZE kw: %#v", cmd)
+	assert.True(t, len(cmd.Columns) == 2 && cmd.Columns[1].Name == "users", "has tables: %#v", cmd.Columns)
+	parseSqlError(t, `ANALYZE TABLE`)
+	parseSqlError(t, `ANALYZE TABLE orders users`)
 }
 
 func TestSqlAlias(t *testing.T) {
@@ -591,6 +739,177 @@ func TestSqlAlias(t *testing.T) {
 	assert.True(t, sel.Alias == "user_query", "has alias: %v", sel.Alias)
 }
 
+func TestSqlCompound(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT user_id FROM users UNION SELECT user_id FROM orders`)
+	parseSqlTest(t, `SELECT a, b FROM t1 WHERE x = 1 UNION ALL SELECT a, b FROM t2 ORDER BY a ASC LIMIT 10`)
+	parseSqlTest(t, `SELECT a FROM t1 INTERSECT SELECT a FROM t2 EXCEPT SELECT a FROM t3;`)
+	parseSqlTest(t, `SELECT 1 AS a UNION DISTINCT SELECT 2 ORDER BY a`)
+	parseSqlError(t, `SELECT a FROM t1 ORDER BY a UNION SELECT a FROM t2`)
+	parseSqlError(t, `SELECT a, b FROM t1 UNION SELECT a FROM t2`)
+	parseSqlError(t, `SELECT a FROM t1 INTERSECT ALL SELECT a FROM t2`)
+	parseSqlError(t, `SELECT a FROM t1 UNION FROM t2`)
+
+	sql := `SELECT a FROM t1 UNION ALL SELECT a FROM t2 EXCEPT SELECT a FROM t3 ORDER BY a LIMIT 5 OFFSET 2`
+	req, err := rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	cs, ok := req.(*rel.SqlCompound)
+	assert.True(t, ok, "is SqlCompound: %T", req)
+	assert.Equal(t, 3, len(cs.Selects))
+	assert.Equal(t, 2, len(cs.Ops))
+	assert.Equal(t, lex.TokenUnion, cs.Ops[0].Op)
+	assert.True(t, cs.Ops[0].All)
+	assert.Equal(t, lex.TokenExcept, cs.Ops[1].Op)
+	assert.False(t, cs.IsAll())
+	// order, limit belong to the compound not the last select
+	assert.Equal(t, 1, len(cs.OrderBy))
+	assert.Equal(t, 5, cs.Limit)
+	assert.Equal(t, 2, cs.Offset)
+	last := cs.Selects[2]
+	assert.True(t, len(last.OrderBy) == 0 && last.Limit == 0 && last.Offset == 0)
+	assert.Equal(t, "SELECT a FROM t1 UNION ALL SELECT a FROM t2 EXCEPT SELECT a FROM t3 ORDER BY a LIMIT 5 OFFSET 2", cs.String())
+	cs2, err := rel.ParseSql(cs.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cs.String(), cs2.String())
+}
+
+func TestSqlWindow(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT user_id, row_number() OVER (PARTITION BY user_id ORDER BY created DESC) AS rn FROM orders`)
+	parseSqlTest(t, `SELECT rank() OVER (ORDER BY score DESC), dense_rank() OVER (ORDER BY score DESC) FROM scores`)
+	parseSqlTest(t, `SELECT lag(amount, 2, 0) OVER (PARTITION BY user_id ORDER BY created) AS prev FROM orders`)
+	parseSqlTest(t, `SELECT sum(amount) OVER (PARTITION BY user_id ORDER BY created ROWS BETWEEN 2 PRECEDING AND CURRENT ROW) AS running FROM orders`)
+	parseSqlTest(t, `SELECT avg(amount) OVER (ORDER BY created RANGE BETWEEN 10 PRECEDING AND UNBOUNDED FOLLOWING) FROM orders`)
+	parseSqlTest(t, `SELECT first_value(amount) OVER (ORDER BY created ROWS UNBOUNDED PRECEDING) FROM orders`)
+	parseSqlTest(t, `SELECT count(*) OVER () AS total FROM orders`)
+	parseSqlError(t, `SELECT user_id OVER (ORDER BY created) FROM orders`)
+	parseSqlError(t, `SELECT row_number() OVER ORDER BY created FROM orders`)
+	parseSqlError(t, `SELECT row_number() OVER (ORDER BY created FROM orders`)
+	parseSqlError(t, `SELECT sum(x) OVER (ORDER BY a ROWS 2 FOLLOWING) FROM orders`)
+	parseSqlError(t, `SELECT sum(x) OVER (ORDER BY a ROWS BETWEEN CURRENT ROW AND UNBOUNDED PRECEDING) FROM orders`)
+	parseSqlError(t, `SELECT sum(x) OVER (ORDER BY a, b RANGE 2 PRECEDING) FROM orders`)
+
+	sql := `SELECT user_id, sum(amount) OVER (PARTITION BY user_id ORDER BY created ASC ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) AS moving FROM orders`
+	sel, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.True(t, sel.IsWindowQuery())
+	assert.False(t, sel.IsAggQuery())
+	col := sel.Columns[1]
+	assert.Equal(t, "moving", col.As)
+	assert.Equal(t, 1, len(col.Over.PartitionBy))
+	assert.Equal(t, 1, len(col.Over.OrderBy))
+	assert.Equal(t, lex.TokenRows, col.Over.Frame.Unit)
+	assert.Equal(t, lex.TokenPreceding, col.Over.Frame.Start.Type)
+	assert.Equal(t, int64(1), col.Over.Frame.Start.Offset)
+	assert.Equal(t, lex.TokenFollowing, col.Over.Frame.End.Type)
+	assert.Equal(t, sql, sel.String())
+	sel2, err := rel.ParseSqlSelect(sel.String())
+	assert.Equal(t, nil, err)
+	assert.True(t, sel.Equal(sel2))
+}
+
+func TestSqlWith(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `WITH big AS (SELECT user_id, price FROM orders WHERE price > 30) SELECT user_id FROM big`)
+	parseSqlTest(t, `WITH a AS (SELECT user_id FROM orders), b (uid) AS (SELECT user_id FROM a) SELECT uid FROM b`)
+	parseSqlTest(t, `WITH a AS (SELECT user_id, count(*) AS ct FROM orders GROUP BY user_id)
+		SELECT u.email, a.ct FROM users AS u INNER JOIN a ON u.user_id = a.user_id`)
+	parseSqlTest(t, `WITH a AS (SELECT name FROM t WHERE x = "(") SELECT name FROM a`)
+	parseSqlError(t, `WITH a (SELECT user_id FROM orders) SELECT user_id FROM a`)
+	parseSqlError(t, `WITH a AS SELECT user_id FROM orders SELECT user_id FROM a`)
+	parseSqlError(t, `WITH a AS (SELECT user_id FROM orders)`)
+	parseSqlError(t, `WITH a AS (SELECT x FROM t), a AS (SELECT y FROM t) SELECT x FROM a`)
+	parseSqlError(t, `WITH a (x, y) AS (SELECT x FROM t) SELECT x FROM a`)
+	parseSqlError(t, `WITH a AS (SELECT x FROM t UNION SELECT y FROM t) SELECT x FROM a`)
+
+	sql := `WITH a AS (SELECT user_id FROM orders WHERE price > 10), b (uid) AS (SELECT user_id FROM a) SELECT uid FROM b`
+	sel, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(sel.Ctes))
+	assert.Equal(t, "a", sel.Ctes[0].Name)
+	assert.Equal(t, []string{"user_id"}, sel.Ctes[0].ColumnNames())
+	assert.Equal(t, []string{"uid"}, sel.Ctes[1].ColumnNames())
+	assert.Equal(t, "b", sel.From[0].Name)
+	assert.Equal(t, sql, sel.String())
+
+	stmt, err := rel.ParseSql(`WITH a AS (SELECT x FROM t) SELECT x FROM a UNION SELECT y FROM a`)
+	assert.Equal(t, nil, err)
+	cs, ok := stmt.(*rel.SqlCompound)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(cs.Selects[0].Ctes))
+	cs2, err := rel.ParseSql(cs.String())
+	assert.Equal(t, nil, err)
+	assert.True(t, cs.Equal(cs2))
+}
+
+func TestSqlSubSelectWhere(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT email FROM users WHERE user_id IN (SELECT user_id FROM orders)`)
+	parseSqlTest(t, `SELECT email FROM users WHERE user_id NOT IN (SELECT user_id FROM orders) ORDER BY email`)
+	parseSqlTest(t, `SELECT email FROM users AS u WHERE EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id)`)
+	parseSqlTest(t, `SELECT email FROM users AS u WHERE NOT EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id) LIMIT 10`)
+	parseSqlTest(t, `SELECT email FROM users WHERE x > 1 AND user_id IN (SELECT user_id FROM orders WHERE price > 30) AND y BETWEEN 1 AND 5`)
+	parseSqlError(t, `SELECT email FROM users WHERE x > 1 OR user_id IN (SELECT user_id FROM orders)`)
+	parseSqlError(t, `SELECT email FROM users WHERE a IN (SELECT a FROM t) AND b IN (SELECT b FROM t)`)
+	parseSqlError(t, `SELECT email FROM users WHERE user_id IN (SELECT user_id FROM orders`)
+
+	sql := `SELECT email FROM users WHERE x > 1 AND user_id NOT IN (SELECT user_id FROM orders) AND y < 2`
+	sel, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, lex.TokenIN, sel.Where.Op)
+	assert.True(t, sel.Where.Negate)
+	assert.Equal(t, "user_id", sel.Where.Left.String())
+	assert.Equal(t, "SELECT user_id FROM orders", sel.Where.Source.String())
+	assert.Equal(t, "x > 1 AND y < 2", sel.Where.Expr.String())
+}
+
+func TestSqlSubSelectValue(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT email, (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) AS ct FROM users AS u`)
+	parseSqlTest(t, `SELECT order_id FROM orders WHERE price > (SELECT avg(price) FROM orders)`)
+	parseSqlTest(t, `SELECT email FROM users AS u WHERE (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) > 1`)
+	parseSqlTest(t, `SELECT (SELECT max(price) FROM orders) - 1 AS x FROM users`)
+	parseSqlError(t, `SELECT email, (SELECT count(*) FROM orders AS ct FROM users`)
+
+	sql := `SELECT email, (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) AS ct FROM users AS u WHERE price > (SELECT avg(price) FROM orders)`
+	sel, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ct", sel.Columns[1].As)
+	subs := sel.SubSelects()
+	assert.Equal(t, 2, len(subs))
+	assert.Equal(t, "SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id", subs[0].Stmt.String())
+	assert.Equal(t, "SELECT avg(price) FROM orders", subs[1].Stmt.String())
+}
+
+func TestSqlParams(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT email FROM users WHERE user_id = ? AND age > $2`)
+
+	bind := func(sql string, args ...interface{}) string {
+		stmt, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err, sql)
+		vals := make([]value.Value, len(args))
+		for i, arg := range args {
+			vals[i] = value.NewValue(arg)
+		}
+		assert.Equal(t, nil, rel.BindParams(rel.Params(stmt), vals), sql)
+		return stmt.String()
+	}
+	// ? are numbered in the order written, including those of sub-selects
+	assert.Equal(t, `SELECT a FROM t WHERE user_id IN (SELECT user_id FROM orders WHERE price > 2) AND x = 1 AND y < 3`,
+		bind(`SELECT a FROM t WHERE x = ? AND user_id IN (SELECT user_id FROM orders WHERE price > ?) AND y < ?`, 1, 2, 3))
+	assert.Equal(t, `SELECT a FROM t WHERE b > 1 AND c < (SELECT max(c) FROM t2 WHERE d = 2) AND e = 3`,
+		bind(`SELECT a FROM t WHERE b > ? AND c < (SELECT max(c) FROM t2 WHERE d = ?) AND e = ?`, 1, 2, 3))
+	assert.Equal(t, `UPDATE users SET name = "bob" WHERE id = 7`,
+		bind(`UPDATE users SET name = ? WHERE id = ?`, "bob", 7))
+
+	stmt, err := rel.ParseSql(`INSERT INTO users (id, name, email) VALUES (?, ?, $1)`)
+	assert.Equal(t, nil, err)
+	params := rel.Params(stmt)
+	assert.Equal(t, 2, expr.NumParams(params))
+	assert.NotEqual(t, nil, rel.BindParams(params, []value.Value{value.NewIntValue(1)}))
+}
+
 func TestSqlUpsert(t *testing.T) {
 	t.Parallel()
 	// This is obviously not exactly sql standard
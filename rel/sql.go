@@ -9,6 +9,7 @@ import (
 	"hash/fnv"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 
 	u "github.com/araddon/gou"
@@ -22,6 +23,7 @@ import (
 var (
 	// Ensure SqlSelect and cousins etc are SqlStatements
 	_ SqlStatement = (*SqlSelect)(nil)
+	_ SqlStatement = (*SqlCompound)(nil)
 	_ SqlStatement = (*SqlInsert)(nil)
 	_ SqlStatement = (*SqlUpsert)(nil)
 	_ SqlStatement = (*SqlUpdate)(nil)
@@ -96,17 +98,41 @@ type (
 		OrderBy   Columns
 		Limit     int
 		Offset    int
-		Alias     string       // Non-Standard sql, alias/name of sql another way of expression Prepared Statement
-		With      u.JsonHelper // Non-Standard SQL for properties/config info, similar to Cassandra with, purse json
-		proj      *Projection  // Projected fields
-		isAgg     bool         // is this an aggregate query?  has group-by, or aggregate selector expressions (count, cardinality etc)
-		finalized bool         // have we already finalized, ie formalized left/right aliases
-		schemaqry bool         // is this a schema qry?  ie select @@max_packet etc
+		Alias     string             // Non-Standard sql, alias/name of sql another way of expression Prepared Statement
+		With      u.JsonHelper       // Non-Standard SQL for properties/config info, similar to Cassandra with, purse json
+		Ctes      []*CommonTableExpr // Common table expressions, WITH name AS (SELECT ...)
+		proj      *Projection        // Projected fields
+		isAgg     bool               // is this an aggregate query?  has group-by, or aggregate selector expressions (count, cardinality etc)
+		finalized bool               // have we already finalized, ie formalized left/right aliases
+		schemaqry bool               // is this a schema qry?  ie select @@max_packet etc
+
+		// GroupingSets of GROUP BY ROLLUP, CUBE or GROUPING SETS, each the
+		// indexes of the GroupBy columns the set groups on.  nil for a
+		// plain group by of all the GroupBy columns.
+		GroupingSets [][]int
 
 		// Memoized sql, we assume this is an immuteable struct so if this is populated use it
 		pb            *SqlStatementPb
 		fingerprintid int64
 	}
+	// SqlCompound is a compound select, two or more selects combined with
+	// set operators, evaluated left to right.
+	//  - SELECT a FROM x UNION ALL SELECT a FROM y ORDER BY a LIMIT 10
+	//  - SELECT a FROM x INTERSECT SELECT a FROM y EXCEPT SELECT a FROM z
+	SqlCompound struct {
+		Raw     string       // full original raw statement
+		Selects []*SqlSelect // the selects in order
+		Ops     []*SqlSetOp  // Ops[i] combines result so far with Selects[i+1]
+		OrderBy Columns      // Order by of combined result
+		Limit   int          // Limit of combined result
+		Offset  int          // Offset of combined result
+	}
+	// SqlSetOp is the set operator (UNION, INTERSECT, EXCEPT) joining
+	// two selects in a compound select.
+	SqlSetOp struct {
+		Op  lex.TokenType // TokenUnion, TokenIntersect, TokenExcept
+		All bool          // UNION ALL, keep duplicates
+	}
 	// SqlSource is a table name, sub-query, or join as used in
 	// SELECT <columns> FROM <SQLSOURCE>
 	//  - SELECT .. FROM table_name
@@ -124,8 +150,8 @@ type (
 		Alias       string             // From name aliased
 		Schema      string             //  FROM `schema`.`table`
 		Op          lex.TokenType      // In, =, ON
-		LeftOrRight lex.TokenType      // Left, Right
-		JoinType    lex.TokenType      // INNER, OUTER
+		LeftOrRight lex.TokenType      // Left, Right, Full
+		JoinType    lex.TokenType      // INNER, OUTER, CROSS
 		JoinExpr    expr.Node          // Join expression       x.y = q.y
 		SubQuery    *SqlSelect         // optional, Join/SubSelect statement
 
@@ -139,12 +165,15 @@ type (
 	// - WHERE x = y
 	// - WHERE x = y AND z = q
 	// - WHERE tolower(x) IN (select name from q)
+	// - WHERE NOT EXISTS (select 1 from q WHERE q.id = x.id) AND x.y > 5
 	SqlWhere struct {
 		// Either Op + Source exists
-		Op     lex.TokenType // (In|=|ON)  for Select Clauses operators
+		Op     lex.TokenType // (In|Exists)  for Select Clauses operators
+		Negate bool          // NOT IN (SELECT ...), NOT EXISTS (SELECT ...)
+		Left   expr.Node     // x  of  x IN (SELECT ...), nil for EXISTS
 		Source *SqlSelect    // IN (SELECT a,b,c from z)
 
-		// OR expr but not both
+		// OR expr, or the conditions AND'd with Op + Source
 		Expr expr.Node // x = y AND q > 5
 	}
 	// SqlInsert SQL Insert Statement
@@ -195,6 +224,8 @@ type (
 		Identity string    // Describe
 		Tok      lex.Token // Explain, Describe, Desc
 		Stmt     SqlStatement
+		Format   string // EXPLAIN FORMAT = json|traditional of statement, lower cased
+		Analyze  bool   // EXPLAIN ANALYZE, run the statement and describe its runtime stats
 	}
 	// SqlInto   INTO statement   (select a,b,c from y INTO z)
 	SqlInto struct {
@@ -239,24 +270,59 @@ type (
 	// Column represents the Column as expressed in a [SELECT]
 	// expression
 	Column struct {
-		sourceQuoteByte byte      // quote mark?   [ or ` etc
-		asQuoteByte     byte      // quote mark   [ or `
-		originalAs      string    // original as string
-		left            string    // users.col_name   = "users"
-		right           string    // users.first_name = "first_name"
-		isLiteral       bool      // is this a literal column?
-		ParentIndex     int       // slice idx position in parent query cols
-		Index           int       // slice idx position in original query cols
-		SourceIndex     int       // slice idx position in source []driver.Value
-		SourceField     string    // field name of underlying field
-		SourceOriginal  string    // field name of underlying field without the "left.right" parse
-		As              string    // As field, auto-populate the Field Name if exists
-		Comment         string    // optional in-line comments
-		Order           string    // (ASC | DESC)
-		Star            bool      // *
-		Agg             bool      // aggregate function column?   count(*), avg(x) etc
-		Expr            expr.Node // Expression, optional, often Identity.Node
-		Guard           expr.Node // column If guard, non-standard sql column guard
+		sourceQuoteByte byte        // quote mark?   [ or ` etc
+		asQuoteByte     byte        // quote mark   [ or `
+		originalAs      string      // original as string
+		left            string      // users.col_name   = "users"
+		right           string      // users.first_name = "first_name"
+		isLiteral       bool        // is this a literal column?
+		ParentIndex     int         // slice idx position in parent query cols
+		Index           int         // slice idx position in original query cols
+		SourceIndex     int         // slice idx position in source []driver.Value
+		SourceField     string      // field name of underlying field
+		SourceOriginal  string      // field name of underlying field without the "left.right" parse
+		As              string      // As field, auto-populate the Field Name if exists
+		Comment         string      // optional in-line comments
+		Order           string      // (ASC | DESC)
+		Nulls           string      // (FIRST | LAST) order of nulls, ORDER BY x NULLS FIRST
+		Star            bool        // *
+		Agg             bool        // aggregate function column?   count(*), avg(x) etc
+		Expr            expr.Node   // Expression, optional, often Identity.Node
+		Guard           expr.Node   // column If guard, non-standard sql column guard
+		Over            *WindowSpec // window specification of a window function column
+	}
+	// WindowSpec the OVER clause of a window function column
+	//
+	//     rank() OVER (PARTITION BY category ORDER BY price DESC)
+	//     sum(x) OVER (ORDER BY ts ROWS BETWEEN 2 PRECEDING AND CURRENT ROW)
+	WindowSpec struct {
+		PartitionBy []expr.Node  // PARTITION BY expressions
+		OrderBy     Columns      // ORDER BY columns, sorting rows within partition
+		Frame       *WindowFrame // optional frame, nil uses the sql default frame
+	}
+	// WindowFrame the ROWS | RANGE frame of rows, relative to current row, that
+	// an aggregate window function is evaluated over.
+	WindowFrame struct {
+		Unit  lex.TokenType     // TokenRows | TokenRange
+		Start *WindowFrameBound // start of frame
+		End   *WindowFrameBound // end of frame
+	}
+	// WindowFrameBound start or end of a window frame
+	WindowFrameBound struct {
+		Type      lex.TokenType // TokenPreceding | TokenFollowing | TokenCurrentRow
+		Unbounded bool          // UNBOUNDED PRECEDING|FOLLOWING
+		Offset    int64         // <offset> PRECEDING|FOLLOWING
+	}
+	// CommonTableExpr a named select preceding a select statement that
+	// may be referenced like a table by the statement (and by later
+	// common table expressions).
+	//
+	//     WITH big_orders AS (SELECT user_id, price FROM orders WHERE price > 100)
+	//     SELECT user_id, count(*) FROM big_orders GROUP BY user_id
+	CommonTableExpr struct {
+		Name    string     // name the select is referenced by
+		Columns []string   // optional column names, else names of select columns
+		Select  *SqlSelect // the select statement
 	}
 	// ValueColumn List of Value columns in INSERT into TABLE (colnames) VALUES (valuecolumns)
 	ValueColumn struct {
@@ -333,6 +399,9 @@ func NewSqlSelect() *SqlSelect {
 	req.Columns = make(Columns, 0)
 	return req
 }
+func NewSqlCompound() *SqlCompound {
+	return &SqlCompound{Selects: make([]*SqlSelect, 0), Ops: make([]*SqlSetOp, 0)}
+}
 func NewSqlInsert() *SqlInsert {
 	req := &SqlInsert{}
 	req.Columns = make(Columns, 0)
@@ -656,6 +725,10 @@ func (m *Column) WriteDialect(w expr.DialectWriter) {
 			exprStr = w.String()[start:]
 		}
 	}
+	if m.Over != nil {
+		io.WriteString(w, " OVER ")
+		m.Over.WriteDialect(w)
+	}
 
 	if m.asQuoteByte != 0 && m.originalAs != "" {
 		io.WriteString(w, " AS ")
@@ -674,6 +747,10 @@ func (m *Column) WriteDialect(w expr.DialectWriter) {
 		io.WriteString(w, " ")
 		io.WriteString(w, m.Order)
 	}
+	if m.Nulls != "" {
+		io.WriteString(w, " NULLS ")
+		io.WriteString(w, m.Nulls)
+	}
 }
 
 // Is this a select count(*) column
@@ -739,12 +816,31 @@ func (m *Column) IsLiteralOrFunc() bool {
 		return false
 	case *expr.StringNode, *expr.NumberNode, *expr.ValueNode:
 		return true
+	case *expr.SubSelectNode:
+		// (SELECT count(*) FROM orders)
+		return true
 	}
-	return false
+	// aggregate expression, count(*) + 1
+	return m.Agg
 }
 
+// Asc is order by direction of this column ascending, the default if
+// neither ASC nor DESC is given.
 func (m *Column) Asc() bool {
-	return strings.ToLower(m.Order) == "asc"
+	return strings.ToLower(m.Order) != "desc"
+}
+
+// NullsFirst do nulls sort before non-null values of this order by column.
+// Nulls are lowest value unless NULLS FIRST | LAST is given, so first
+// for ascending and last for descending.
+func (m *Column) NullsFirst() bool {
+	switch strings.ToLower(m.Nulls) {
+	case "first":
+		return true
+	case "last":
+		return false
+	}
+	return m.Asc()
 }
 func (m *Column) Equal(c *Column) bool {
 	if m == nil && c == nil {
@@ -792,6 +888,9 @@ func (m *Column) Equal(c *Column) bool {
 	if m.Order != c.Order {
 		return false
 	}
+	if m.Nulls != c.Nulls {
+		return false
+	}
 	if m.Star != c.Star {
 		return false
 	}
@@ -805,6 +904,9 @@ func (m *Column) Equal(c *Column) bool {
 			return false
 		}
 	}
+	if !m.Over.Equal(c.Over) {
+		return false
+	}
 	return true
 }
 
@@ -838,9 +940,11 @@ func (m *Column) Copy() *Column {
 		As:              m.right,
 		Comment:         m.Comment,
 		Order:           m.Order,
+		Nulls:           m.Nulls,
 		Star:            m.Star,
 		Expr:            m.Expr,
 		Guard:           m.Guard,
+		Over:            m.Over,
 	}
 }
 func (m *Column) ToPB() *ColumnPb {
@@ -869,6 +973,9 @@ func (m *Column) ToPB() *ColumnPb {
 	if len(m.Order) > 0 {
 		n.Order = &m.Order
 	}
+	if len(m.Nulls) > 0 {
+		n.Nulls = &m.Nulls
+	}
 	if m.Star {
 		n.Star = &m.Star
 	}
@@ -878,6 +985,9 @@ func (m *Column) ToPB() *ColumnPb {
 	if m.Guard != nil {
 		n.Guard = m.Guard.NodePb()
 	}
+	if m.Over != nil {
+		n.Over = m.Over.ToPB()
+	}
 	return &n
 }
 func columnFromPb(c *ColumnPb) *Column {
@@ -893,9 +1003,11 @@ func columnFromPb(c *ColumnPb) *Column {
 		SourceField:     c.GetSourceField(),
 		As:              c.GetAs(),
 		Order:           c.GetOrder(),
+		Nulls:           c.GetNulls(),
 		Star:            c.GetStar(),
 		Expr:            expr.NodeFromNodePb(c.GetExpr()),
 		Guard:           expr.NodeFromNodePb(c.GetGuard()),
+		Over:            windowSpecFromPb(c.GetOver()),
 	}
 }
 
@@ -908,6 +1020,206 @@ func (m *Column) LeftRight() (string, string, bool) {
 	return m.left, m.right, m.left != ""
 }
 
+func (m *WindowSpec) String() string {
+	w := NewSqlDialect()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *WindowSpec) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "(")
+	for i, n := range m.PartitionBy {
+		if i == 0 {
+			io.WriteString(w, "PARTITION BY ")
+		} else {
+			io.WriteString(w, ", ")
+		}
+		n.WriteDialect(w)
+	}
+	if len(m.OrderBy) > 0 {
+		if len(m.PartitionBy) > 0 {
+			io.WriteString(w, " ")
+		}
+		io.WriteString(w, "ORDER BY ")
+		m.OrderBy.WriteDialect(w)
+	}
+	if m.Frame != nil {
+		if len(m.PartitionBy) > 0 || len(m.OrderBy) > 0 {
+			io.WriteString(w, " ")
+		}
+		m.Frame.WriteDialect(w)
+	}
+	io.WriteString(w, ")")
+}
+func (m *WindowSpec) Equal(s *WindowSpec) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if len(m.PartitionBy) != len(s.PartitionBy) {
+		return false
+	}
+	for i, n := range m.PartitionBy {
+		if !n.Equal(s.PartitionBy[i]) {
+			return false
+		}
+	}
+	if !m.OrderBy.Equal(s.OrderBy) {
+		return false
+	}
+	return m.Frame.Equal(s.Frame)
+}
+func (m *WindowSpec) ToPB() *WindowSpecPb {
+	n := &WindowSpecPb{}
+	n.PartitionBy = expr.NodesPbFromNodes(m.PartitionBy)
+	if len(m.OrderBy) > 0 {
+		n.OrderBy = ColumnsToPb(m.OrderBy)
+	}
+	if m.Frame != nil {
+		n.Frame = &WindowFramePb{Unit: int32(m.Frame.Unit)}
+		n.Frame.Start = m.Frame.Start.ToPB()
+		n.Frame.End = m.Frame.End.ToPB()
+	}
+	return n
+}
+func windowSpecFromPb(pb *WindowSpecPb) *WindowSpec {
+	if pb == nil {
+		return nil
+	}
+	s := &WindowSpec{}
+	if len(pb.PartitionBy) > 0 {
+		s.PartitionBy = expr.NodesFromNodesPbPtr(pb.PartitionBy)
+	}
+	if len(pb.OrderBy) > 0 {
+		s.OrderBy = ColumnsFromPb(pb.OrderBy)
+	}
+	if pb.Frame != nil {
+		s.Frame = &WindowFrame{
+			Unit:  lex.TokenType(pb.Frame.GetUnit()),
+			Start: windowFrameBoundFromPb(pb.Frame.GetStart()),
+			End:   windowFrameBoundFromPb(pb.Frame.GetEnd()),
+		}
+	}
+	return s
+}
+func (m *WindowFrame) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, strings.ToUpper(m.Unit.String()))
+	if m.End == nil {
+		io.WriteString(w, " ")
+		m.Start.WriteDialect(w)
+		return
+	}
+	io.WriteString(w, " BETWEEN ")
+	m.Start.WriteDialect(w)
+	io.WriteString(w, " AND ")
+	m.End.WriteDialect(w)
+}
+func (m *WindowFrame) Equal(s *WindowFrame) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if m.Unit != s.Unit {
+		return false
+	}
+	return m.Start.Equal(s.Start) && m.End.Equal(s.End)
+}
+func (m *WindowFrameBound) WriteDialect(w expr.DialectWriter) {
+	switch {
+	case m.Type == lex.TokenCurrentRow:
+		io.WriteString(w, "CURRENT ROW")
+		return
+	case m.Unbounded:
+		io.WriteString(w, "UNBOUNDED ")
+	default:
+		io.WriteString(w, strconv.FormatInt(m.Offset, 10))
+		io.WriteString(w, " ")
+	}
+	io.WriteString(w, strings.ToUpper(m.Type.String()))
+}
+func (m *WindowFrameBound) Equal(s *WindowFrameBound) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	return m.Type == s.Type && m.Unbounded == s.Unbounded && m.Offset == s.Offset
+}
+func (m *WindowFrameBound) ToPB() *WindowFrameBoundPb {
+	if m == nil {
+		return nil
+	}
+	return &WindowFrameBoundPb{Type: int32(m.Type), Unbounded: m.Unbounded, Offset: m.Offset}
+}
+func windowFrameBoundFromPb(pb *WindowFrameBoundPb) *WindowFrameBound {
+	if pb == nil {
+		return nil
+	}
+	return &WindowFrameBound{Type: lex.TokenType(pb.GetType()), Unbounded: pb.GetUnbounded(), Offset: pb.GetOffset()}
+}
+
+func (m *CommonTableExpr) String() string {
+	w := NewSqlDialect()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *CommonTableExpr) WriteDialect(w expr.DialectWriter) {
+	w.WriteIdentity(m.Name)
+	if len(m.Columns) > 0 {
+		io.WriteString(w, " (")
+		for i, col := range m.Columns {
+			if i > 0 {
+				io.WriteString(w, ", ")
+			}
+			w.WriteIdentity(col)
+		}
+		io.WriteString(w, ")")
+	}
+	io.WriteString(w, " AS (")
+	m.Select.WriteDialect(w)
+	io.WriteString(w, ")")
+}
+
+// ColumnNames the names of the columns of this common table, either the
+// explicit column names or the names of the select columns.
+func (m *CommonTableExpr) ColumnNames() []string {
+	if len(m.Columns) > 0 {
+		return m.Columns
+	}
+	names := make([]string, len(m.Select.Columns))
+	for i, col := range m.Select.Columns {
+		names[i] = col.As
+	}
+	return names
+}
+func (m *CommonTableExpr) Equal(s *CommonTableExpr) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if m.Name != s.Name || len(m.Columns) != len(s.Columns) {
+		return false
+	}
+	for i, col := range m.Columns {
+		if col != s.Columns[i] {
+			return false
+		}
+	}
+	return m.Select.Equal(s.Select)
+}
+func (m *CommonTableExpr) ToPB() *CommonTableExprPb {
+	return &CommonTableExprPb{Name: m.Name, Columns: m.Columns, Select: m.Select.ToPB()}
+}
+func commonTableExprFromPb(pb *CommonTableExprPb) *CommonTableExpr {
+	return &CommonTableExpr{Name: pb.GetName(), Columns: pb.GetColumns(), Select: SqlSelectFromPb(pb.GetSelect())}
+}
+
 func (m *PreparedStatement) Keyword() lex.TokenType { return lex.TokenPrepare }
 func (m *PreparedStatement) String() string {
 	w := expr.NewDefaultWriter()
@@ -997,6 +1309,16 @@ func sqlSelectToPbDepth(m *SqlSelect, depth int) *SqlSelectPb {
 	if m.Into != nil {
 		s.Into = &m.Into.Table
 	}
+	for _, cte := range m.Ctes {
+		s.Ctes = append(s.Ctes, cte.ToPB())
+	}
+	for _, set := range m.GroupingSets {
+		spb := &GroupingSetPb{Cols: make([]int32, len(set))}
+		for i, idx := range set {
+			spb.Cols[i] = int32(idx)
+		}
+		s.GroupingSets = append(s.GroupingSets, spb)
+	}
 	return &s
 }
 func (m *SqlSelect) Equal(ss SqlStatement) bool {
@@ -1077,6 +1399,19 @@ func (m *SqlSelect) Equal(ss SqlStatement) bool {
 			return false
 		}
 	}
+	if len(m.GroupingSets) != len(s.GroupingSets) {
+		return false
+	}
+	for i, set := range m.GroupingSets {
+		if len(set) != len(s.GroupingSets[i]) {
+			return false
+		}
+		for j, idx := range set {
+			if idx != s.GroupingSets[i][j] {
+				return false
+			}
+		}
+	}
 	if len(m.OrderBy) != len(s.OrderBy) {
 		return false
 	}
@@ -1085,6 +1420,14 @@ func (m *SqlSelect) Equal(ss SqlStatement) bool {
 			return false
 		}
 	}
+	if len(m.Ctes) != len(s.Ctes) {
+		return false
+	}
+	for i, cte := range m.Ctes {
+		if !cte.Equal(s.Ctes[i]) {
+			return false
+		}
+	}
 	if !m.proj.Equal(s.proj) {
 		return false
 	}
@@ -1136,6 +1479,16 @@ func SqlSelectFromPb(pb *SqlSelectPb) *SqlSelect {
 		ss.With = make(u.JsonHelper)
 		json.Unmarshal(pb.With, &ss.With)
 	}
+	for _, cpb := range pb.Ctes {
+		ss.Ctes = append(ss.Ctes, commonTableExprFromPb(cpb))
+	}
+	for _, spb := range pb.GroupingSets {
+		set := make([]int, len(spb.Cols))
+		for i, idx := range spb.Cols {
+			set[i] = int(idx)
+		}
+		ss.GroupingSets = append(ss.GroupingSets, set)
+	}
 	return &ss
 }
 func (m *SqlSelect) IsAggQuery() bool {
@@ -1144,6 +1497,146 @@ func (m *SqlSelect) IsAggQuery() bool {
 	}
 	return false
 }
+
+// IsWindowQuery does this select have window function columns, ie OVER (...)
+func (m *SqlSelect) IsWindowQuery() bool {
+	for _, col := range m.Columns {
+		if col.Over != nil {
+			return true
+		}
+	}
+	return false
+}
+// SubSelects the sub-selects used as values in expressions of this select,
+// ie  price > (SELECT avg(price) FROM items), not including the IN or EXISTS
+// sub-select of where.
+func (m *SqlSelect) SubSelects() []*expr.SubSelectNode {
+	nodes := make([]*expr.SubSelectNode, 0)
+	for _, col := range m.Columns {
+		nodes = append(nodes, expr.FindAllSubSelects(col.Expr)...)
+		nodes = append(nodes, expr.FindAllSubSelects(col.Guard)...)
+	}
+	if m.Where != nil {
+		nodes = append(nodes, expr.FindAllSubSelects(m.Where.Left)...)
+		nodes = append(nodes, expr.FindAllSubSelects(m.Where.Expr)...)
+	}
+	for _, col := range m.GroupBy {
+		nodes = append(nodes, expr.FindAllSubSelects(col.Expr)...)
+	}
+	nodes = append(nodes, expr.FindAllSubSelects(m.Having)...)
+	for _, col := range m.OrderBy {
+		nodes = append(nodes, expr.FindAllSubSelects(col.Expr)...)
+	}
+	return nodes
+}
+// Params the ? and $N bind parameters of statement stmt, including those of
+// its sub-selects, common tables and joined sources.
+func Params(stmt SqlStatement) []*expr.ParamNode {
+	params := make([]*expr.ParamNode, 0)
+	addRows := func(rows [][]*ValueColumn) {
+		for _, row := range rows {
+			for _, vc := range row {
+				params = append(params, expr.FindAllParams(vc.Expr)...)
+			}
+		}
+	}
+	addValues := func(vals map[string]*ValueColumn) {
+		for _, vc := range vals {
+			params = append(params, expr.FindAllParams(vc.Expr)...)
+		}
+	}
+	switch st := stmt.(type) {
+	case *SqlSelect:
+		params = st.params(params)
+	case *SqlCompound:
+		for _, sel := range st.Selects {
+			params = sel.params(params)
+		}
+	case *SqlInsert:
+		addRows(st.Rows)
+		if st.Select != nil {
+			params = st.Select.params(params)
+		}
+	case *SqlUpsert:
+		addRows(st.Rows)
+		addValues(st.Values)
+		params = st.Where.params(params)
+	case *SqlUpdate:
+		addValues(st.Values)
+		params = st.Where.params(params)
+	case *SqlDelete:
+		params = st.Where.params(params)
+	}
+	return params
+}
+
+// BindParams binds args to the bind parameters params of a statement, the
+// arg at the index of each param.  Errors if the number of args doesn't
+// match the params.
+func BindParams(params []*expr.ParamNode, args []value.Value) error {
+	if n := expr.NumParams(params); n != len(args) {
+		return fmt.Errorf("expected %d bind parameters but got %d", n, len(args))
+	}
+	for _, p := range params {
+		p.Value = args[p.Index]
+	}
+	return nil
+}
+
+func (m *SqlSelect) params(params []*expr.ParamNode) []*expr.ParamNode {
+	add := func(nodes ...expr.Node) {
+		for _, node := range nodes {
+			params = append(params, expr.FindAllParams(node)...)
+			for _, ss := range expr.FindAllSubSelects(node) {
+				if sel, ok := ss.Stmt.(*SqlSelect); ok {
+					params = sel.params(params)
+				}
+			}
+		}
+	}
+	addCols := func(cols Columns) {
+		for _, col := range cols {
+			add(col.Expr, col.Guard)
+			if col.Over != nil {
+				add(col.Over.PartitionBy...)
+				for _, oc := range col.Over.OrderBy {
+					add(oc.Expr)
+				}
+			}
+		}
+	}
+	for _, cte := range m.Ctes {
+		params = cte.Select.params(params)
+	}
+	addCols(m.Columns)
+	for _, from := range m.From {
+		add(from.JoinExpr)
+		if from.SubQuery != nil {
+			params = from.SubQuery.params(params)
+		}
+	}
+	if m.Where != nil {
+		add(m.Where.Left, m.Where.Expr)
+		if m.Where.Source != nil {
+			params = m.Where.Source.params(params)
+		}
+	}
+	addCols(m.GroupBy)
+	add(m.Having)
+	addCols(m.OrderBy)
+	return params
+}
+func (m *SqlWhere) params(params []*expr.ParamNode) []*expr.ParamNode {
+	if m == nil {
+		return params
+	}
+	params = append(params, expr.FindAllParams(m.Expr)...)
+	params = append(params, expr.FindAllParams(m.Left)...)
+	if m.Source != nil {
+		params = m.Source.params(params)
+	}
+	return params
+}
 func (m *SqlSelect) String() string {
 	w := NewSqlDialect()
 	m.writeDialectDepth(0, w)
@@ -1151,6 +1644,17 @@ func (m *SqlSelect) String() string {
 }
 func (m *SqlSelect) writeDialectDepth(depth int, w expr.DialectWriter) {
 
+	for i, cte := range m.Ctes {
+		if i == 0 {
+			io.WriteString(w, "WITH ")
+		} else {
+			io.WriteString(w, ", ")
+		}
+		cte.WriteDialect(w)
+		if i == len(m.Ctes)-1 {
+			io.WriteString(w, " ")
+		}
+	}
 	io.WriteString(w, "SELECT ")
 	if m.Distinct {
 		io.WriteString(w, "DISTINCT ")
@@ -1181,7 +1685,23 @@ func (m *SqlSelect) writeDialectDepth(depth int, w expr.DialectWriter) {
 		io.WriteString(w, " WHERE ")
 		m.Where.writeDialectDepth(depth, w)
 	}
-	if len(m.GroupBy) > 0 {
+	if len(m.GroupingSets) > 0 {
+		io.WriteString(w, " GROUP BY GROUPING SETS (")
+		for i, set := range m.GroupingSets {
+			if i > 0 {
+				io.WriteString(w, ", ")
+			}
+			io.WriteString(w, "(")
+			for j, idx := range set {
+				if j > 0 {
+					io.WriteString(w, ", ")
+				}
+				m.GroupBy[idx].Expr.WriteDialect(w)
+			}
+			io.WriteString(w, ")")
+		}
+		io.WriteString(w, ")")
+	} else if len(m.GroupBy) > 0 {
 		io.WriteString(w, " GROUP BY ")
 		m.GroupBy.WriteDialect(w)
 	}
@@ -1214,6 +1734,96 @@ func (m *SqlSelect) WriteDialect(w expr.DialectWriter) {
 	m.writeDialectDepth(0, w)
 }
 
+func (m *SqlCompound) Keyword() lex.TokenType { return lex.TokenSelect }
+
+// Columns of the combined result, which are named by first select.
+func (m *SqlCompound) Columns() Columns {
+	if len(m.Selects) == 0 {
+		return nil
+	}
+	return m.Selects[0].Columns
+}
+
+// IsAll is this compound only UNION ALL set operations, ie
+// results may be streamed without de-duplication.
+func (m *SqlCompound) IsAll() bool {
+	for _, op := range m.Ops {
+		if op.Op != lex.TokenUnion || !op.All {
+			return false
+		}
+	}
+	return true
+}
+func (m *SqlCompound) String() string {
+	w := NewSqlDialect()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlCompound) WriteDialect(w expr.DialectWriter) {
+	for i, sel := range m.Selects {
+		if i > 0 {
+			m.Ops[i-1].WriteDialect(w)
+		}
+		sel.WriteDialect(w)
+	}
+	if len(m.OrderBy) > 0 {
+		io.WriteString(w, " ORDER BY ")
+		m.OrderBy.WriteDialect(w)
+	}
+	if m.Limit > 0 {
+		io.WriteString(w, fmt.Sprintf(" LIMIT %d", m.Limit))
+	}
+	if m.Offset > 0 {
+		io.WriteString(w, fmt.Sprintf(" OFFSET %d", m.Offset))
+	}
+}
+func (m *SqlCompound) Equal(ss SqlStatement) bool {
+	s, ok := ss.(*SqlCompound)
+	if !ok {
+		return false
+	}
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if len(m.Selects) != len(s.Selects) || len(m.Ops) != len(s.Ops) {
+		return false
+	}
+	for i, sel := range m.Selects {
+		if !sel.Equal(s.Selects[i]) {
+			return false
+		}
+	}
+	for i, op := range m.Ops {
+		if *op != *s.Ops[i] {
+			return false
+		}
+	}
+	if !m.OrderBy.Equal(s.OrderBy) {
+		return false
+	}
+	if m.Limit != s.Limit || m.Offset != s.Offset {
+		return false
+	}
+	return true
+}
+
+func (m *SqlSetOp) String() string {
+	w := NewSqlDialect()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlSetOp) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, " ")
+	io.WriteString(w, strings.ToUpper(m.Op.String()))
+	if m.All {
+		io.WriteString(w, " ALL")
+	}
+	io.WriteString(w, " ")
+}
+
 // Finalize this Query plan by preparing sub-sources
 //  ie we need to rewrite some things into sub-statements
 //  - we need to share the join expression across sources
@@ -1326,6 +1936,15 @@ func (m *SqlSource) SourceName() string {
 	}
 	return right
 }
+
+// Qualifier the lower cased alias, or name if not aliased, qualifying the
+// columns of this source, ie "u" of u.email.
+func (m *SqlSource) Qualifier() string {
+	if m.Alias != "" {
+		return strings.ToLower(m.Alias)
+	}
+	return strings.ToLower(m.Name)
+}
 func (m *SqlSource) String() string {
 	w := expr.NewDefaultWriter()
 	m.WriteDialect(w)
@@ -1355,8 +1974,12 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 
 	//   Jointype                Op
 	//  INNER JOIN orders AS o 	ON
+	if int(m.LeftOrRight) != 0 {
+		io.WriteString(w, strings.ToTitle(m.LeftOrRight.String())) // left/right/full
+		io.WriteString(w, " ")
+	}
 	if int(m.JoinType) != 0 {
-		io.WriteString(w, strings.ToTitle(m.JoinType.String())) // inner/outer
+		io.WriteString(w, strings.ToTitle(m.JoinType.String())) // inner/outer/cross
 		io.WriteString(w, " ")
 	}
 	io.WriteString(w, "JOIN ")
@@ -1380,6 +2003,10 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 		w.WriteIdentity(m.Alias)
 	}
 
+	if int(m.Op) == 0 {
+		// CROSS JOIN has no ON
+		return
+	}
 	io.WriteString(w, " ")
 	io.WriteString(w, strings.ToTitle(m.Op.String()))
 
@@ -1699,12 +2326,23 @@ func (m *SqlWhere) writeDialectDepth(depth int, w expr.DialectWriter) {
 		return
 	}
 	// Op = subselect or in etc
-	//  SELECT ... WHERE IN (SELECT ...)
+	//  SELECT ... WHERE x IN (SELECT ...) AND y > 5
 	if int(m.Op) != 0 && m.Source != nil {
-		io.WriteString(w, m.Op.String())
+		if m.Left != nil {
+			m.Left.WriteDialect(w)
+			io.WriteString(w, " ")
+		}
+		if m.Negate {
+			io.WriteString(w, "NOT ")
+		}
+		io.WriteString(w, strings.ToUpper(m.Op.String()))
 		io.WriteString(w, " (")
 		m.Source.writeDialectDepth(depth+1, w)
 		io.WriteString(w, ")")
+		if m.Expr != nil {
+			io.WriteString(w, " AND ")
+			m.Expr.WriteDialect(w)
+		}
 		return
 	}
 	u.Errorf("unrecognized SqlWhere statement? %#v", m)
@@ -1728,6 +2366,15 @@ func (m *SqlWhere) Equal(s *SqlWhere) bool {
 	if m.Op != s.Op {
 		return false
 	}
+	if m.Negate != s.Negate {
+		return false
+	}
+	if (m.Left != nil && s.Left == nil) || (m.Left == nil && s.Left != nil) {
+		return false
+	}
+	if m.Left != nil && !m.Left.Equal(s.Left) {
+		return false
+	}
 	if !m.Source.Equal(s.Source) {
 		return false
 	}
@@ -1743,6 +2390,10 @@ func (m *SqlWhere) Equal(s *SqlWhere) bool {
 func SqlWhereToPb(m *SqlWhere) *SqlWherePb {
 	s := SqlWherePb{}
 	s.Op = int32(m.Op)
+	s.Negate = m.Negate
+	if m.Left != nil {
+		s.Left = m.Left.NodePb()
+	}
 	if m.Source != nil {
 		s.Source = SqlSelectToPb(m.Source)
 	}
@@ -1753,7 +2404,11 @@ func SqlWhereToPb(m *SqlWhere) *SqlWherePb {
 }
 func SqlWhereFromPb(pb *SqlWherePb) *SqlWhere {
 	w := SqlWhere{
-		Op: lex.TokenType(pb.GetOp()),
+		Op:     lex.TokenType(pb.GetOp()),
+		Negate: pb.GetNegate(),
+	}
+	if pb.Left != nil {
+		w.Left = expr.NodeFromNodePb(pb.GetLeft())
 	}
 	if pb.Source != nil {
 		w.Source = SqlSelectFromPb(pb.Source)
@@ -1879,7 +2534,12 @@ func (m *SqlUpdate) WriteDialect(w expr.DialectWriter) {
 		}
 		firstCol = false
 		w.WriteIdentity(key)
-		w.WriteValue(val.Value)
+		io.WriteString(w, " = ")
+		if val.Expr != nil {
+			val.Expr.WriteDialect(w)
+		} else {
+			w.WriteValue(val.Value)
+		}
 	}
 	if m.Where != nil {
 		io.WriteString(w, " WHERE ")
@@ -1914,9 +2574,29 @@ func (m *SqlDelete) WriteDialect(w expr.DialectWriter) {}
 
 func (m *SqlDelete) SqlSelect() *SqlSelect { return sqlSelectFromWhere(m.Table, m.Where) }
 
-func (m *SqlDescribe) Keyword() lex.TokenType            { return lex.TokenDescribe }
-func (m *SqlDescribe) String() string                    { return fmt.Sprintf("%s ", m.Keyword()) }
-func (m *SqlDescribe) WriteDialect(w expr.DialectWriter) {}
+func (m *SqlDescribe) Keyword() lex.TokenType { return lex.TokenDescribe }
+func (m *SqlDescribe) String() string {
+	w := NewSqlDialect()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlDescribe) WriteDialect(w expr.DialectWriter) {
+	if m.Stmt == nil {
+		io.WriteString(w, "DESCRIBE ")
+		w.WriteIdentity(m.Identity)
+		return
+	}
+	io.WriteString(w, "EXPLAIN ")
+	if m.Analyze {
+		io.WriteString(w, "ANALYZE ")
+	}
+	if m.Format != "" {
+		io.WriteString(w, "FORMAT = ")
+		io.WriteString(w, strings.ToUpper(m.Format))
+		io.WriteString(w, " ")
+	}
+	m.Stmt.WriteDialect(w)
+}
 
 func (m *SqlShow) Keyword() lex.TokenType            { return lex.TokenShow }
 func (m *SqlShow) String() string                    { return fmt.Sprintf("%s ", m.Keyword()) }
@@ -18,6 +18,11 @@
 		KvInt
 		ColumnPb
 		CommandColumnPb
+		WindowSpecPb
+		WindowFramePb
+		WindowFrameBoundPb
+		CommonTableExprPb
+		GroupingSetPb
 */
 package rel
 
@@ -74,26 +79,28 @@ func (m *SqlStatementPb) GetProjection() *ProjectionPb {
 }
 
 type SqlSelectPb struct {
-	Db               string         `protobuf:"bytes,1,req,name=db" json:"db"`
-	Raw              string         `protobuf:"bytes,2,req,name=raw" json:"raw"`
-	Star             bool           `protobuf:"varint,3,req,name=star" json:"star"`
-	Distinct         bool           `protobuf:"varint,4,req,name=distinct" json:"distinct"`
-	Columns          []*ColumnPb    `protobuf:"bytes,5,rep,name=columns" json:"columns,omitempty"`
-	From             []*SqlSourcePb `protobuf:"bytes,6,rep,name=from" json:"from,omitempty"`
-	Into             *string        `protobuf:"bytes,7,opt,name=into" json:"into,omitempty"`
-	Where            *SqlWherePb    `protobuf:"bytes,8,opt,name=where" json:"where,omitempty"`
-	Having           *expr.NodePb   `protobuf:"bytes,9,opt,name=having" json:"having,omitempty"`
-	GroupBy          []*ColumnPb    `protobuf:"bytes,11,rep,name=groupBy" json:"groupBy,omitempty"`
-	OrderBy          []*ColumnPb    `protobuf:"bytes,10,rep,name=orderBy" json:"orderBy,omitempty"`
-	Limit            int32          `protobuf:"varint,12,opt,name=limit" json:"limit"`
-	Offset           int32          `protobuf:"varint,13,opt,name=offset" json:"offset"`
-	Alias            *string        `protobuf:"bytes,14,opt,name=alias" json:"alias,omitempty"`
-	Projection       *ProjectionPb  `protobuf:"bytes,15,opt,name=projection" json:"projection,omitempty"`
-	IsAgg            bool           `protobuf:"varint,16,req,name=isAgg" json:"isAgg"`
-	Finalized        bool           `protobuf:"varint,17,req,name=finalized" json:"finalized"`
-	Schemaqry        bool           `protobuf:"varint,18,req,name=schemaqry" json:"schemaqry"`
-	With             []byte         `protobuf:"bytes,19,opt,name=with" json:"with,omitempty"`
-	XXX_unrecognized []byte         `json:"-"`
+	Db               string               `protobuf:"bytes,1,req,name=db" json:"db"`
+	Raw              string               `protobuf:"bytes,2,req,name=raw" json:"raw"`
+	Star             bool                 `protobuf:"varint,3,req,name=star" json:"star"`
+	Distinct         bool                 `protobuf:"varint,4,req,name=distinct" json:"distinct"`
+	Columns          []*ColumnPb          `protobuf:"bytes,5,rep,name=columns" json:"columns,omitempty"`
+	From             []*SqlSourcePb       `protobuf:"bytes,6,rep,name=from" json:"from,omitempty"`
+	Into             *string              `protobuf:"bytes,7,opt,name=into" json:"into,omitempty"`
+	Where            *SqlWherePb          `protobuf:"bytes,8,opt,name=where" json:"where,omitempty"`
+	Having           *expr.NodePb         `protobuf:"bytes,9,opt,name=having" json:"having,omitempty"`
+	GroupBy          []*ColumnPb          `protobuf:"bytes,11,rep,name=groupBy" json:"groupBy,omitempty"`
+	OrderBy          []*ColumnPb          `protobuf:"bytes,10,rep,name=orderBy" json:"orderBy,omitempty"`
+	Limit            int32                `protobuf:"varint,12,opt,name=limit" json:"limit"`
+	Offset           int32                `protobuf:"varint,13,opt,name=offset" json:"offset"`
+	Alias            *string              `protobuf:"bytes,14,opt,name=alias" json:"alias,omitempty"`
+	Projection       *ProjectionPb        `protobuf:"bytes,15,opt,name=projection" json:"projection,omitempty"`
+	IsAgg            bool                 `protobuf:"varint,16,req,name=isAgg" json:"isAgg"`
+	Finalized        bool                 `protobuf:"varint,17,req,name=finalized" json:"finalized"`
+	Schemaqry        bool                 `protobuf:"varint,18,req,name=schemaqry" json:"schemaqry"`
+	With             []byte               `protobuf:"bytes,19,opt,name=with" json:"with,omitempty"`
+	Ctes             []*CommonTableExprPb `protobuf:"bytes,20,rep,name=ctes" json:"ctes,omitempty"`
+	GroupingSets     []*GroupingSetPb     `protobuf:"bytes,21,rep,name=groupingSets" json:"groupingSets,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
 }
 
 func (m *SqlSelectPb) Reset()                    { *m = SqlSelectPb{} }
@@ -234,6 +241,20 @@ func (m *SqlSelectPb) GetWith() []byte {
 	return nil
 }
 
+func (m *SqlSelectPb) GetCtes() []*CommonTableExprPb {
+	if m != nil {
+		return m.Ctes
+	}
+	return nil
+}
+
+func (m *SqlSelectPb) GetGroupingSets() []*GroupingSetPb {
+	if m != nil {
+		return m.GroupingSets
+	}
+	return nil
+}
+
 type SqlSourcePb struct {
 	Final            bool           `protobuf:"varint,1,opt,name=final" json:"final"`
 	AliasInner       *string        `protobuf:"bytes,2,opt,name=aliasInner" json:"aliasInner,omitempty"`
@@ -367,6 +388,8 @@ type SqlWherePb struct {
 	Op               int32        `protobuf:"varint,1,req,name=op" json:"op"`
 	Source           *SqlSelectPb `protobuf:"bytes,2,opt,name=source" json:"source,omitempty"`
 	Expr             *expr.NodePb `protobuf:"bytes,3,opt,name=Expr,json=expr" json:"Expr,omitempty"`
+	Negate           bool         `protobuf:"varint,4,opt,name=negate" json:"negate"`
+	Left             *expr.NodePb `protobuf:"bytes,5,opt,name=left" json:"left,omitempty"`
 	XXX_unrecognized []byte       `json:"-"`
 }
 
@@ -396,6 +419,20 @@ func (m *SqlWherePb) GetExpr() *expr.NodePb {
 	return nil
 }
 
+func (m *SqlWherePb) GetNegate() bool {
+	if m != nil {
+		return m.Negate
+	}
+	return false
+}
+
+func (m *SqlWherePb) GetLeft() *expr.NodePb {
+	if m != nil {
+		return m.Left
+	}
+	return nil
+}
+
 type ProjectionPb struct {
 	Distinct         bool              `protobuf:"varint,1,req,name=distinct" json:"distinct"`
 	Final            bool              `protobuf:"varint,2,req,name=final" json:"final"`
@@ -528,23 +565,25 @@ func (m *KvInt) GetV() int32 {
 }
 
 type ColumnPb struct {
-	SourceQuote      []byte       `protobuf:"bytes,1,opt,name=sourceQuote" json:"sourceQuote,omitempty"`
-	AsQuoteByte      []byte       `protobuf:"bytes,2,opt,name=asQuoteByte" json:"asQuoteByte,omitempty"`
-	OriginalAs       *string      `protobuf:"bytes,3,opt,name=originalAs" json:"originalAs,omitempty"`
-	Left             *string      `protobuf:"bytes,4,opt,name=left" json:"left,omitempty"`
-	Right            *string      `protobuf:"bytes,5,opt,name=right" json:"right,omitempty"`
-	ParentIndex      int32        `protobuf:"varint,6,opt,name=parentIndex" json:"parentIndex"`
-	Index            int32        `protobuf:"varint,7,opt,name=index" json:"index"`
-	SourceIndex      int32        `protobuf:"varint,8,opt,name=sourceIndex" json:"sourceIndex"`
-	SourceField      *string      `protobuf:"bytes,9,opt,name=sourceField" json:"sourceField,omitempty"`
-	As               string       `protobuf:"bytes,11,opt,name=as" json:"as"`
-	Comment          *string      `protobuf:"bytes,12,opt,name=comment" json:"comment,omitempty"`
-	Order            *string      `protobuf:"bytes,13,opt,name=order" json:"order,omitempty"`
-	Star             *bool        `protobuf:"varint,14,opt,name=star" json:"star,omitempty"`
-	Agg              bool         `protobuf:"varint,15,opt,name=agg" json:"agg"`
-	Expr             *expr.NodePb `protobuf:"bytes,16,opt,name=Expr,json=expr" json:"Expr,omitempty"`
-	Guard            *expr.NodePb `protobuf:"bytes,17,opt,name=Guard,json=guard" json:"Guard,omitempty"`
-	XXX_unrecognized []byte       `json:"-"`
+	SourceQuote      []byte        `protobuf:"bytes,1,opt,name=sourceQuote" json:"sourceQuote,omitempty"`
+	AsQuoteByte      []byte        `protobuf:"bytes,2,opt,name=asQuoteByte" json:"asQuoteByte,omitempty"`
+	OriginalAs       *string       `protobuf:"bytes,3,opt,name=originalAs" json:"originalAs,omitempty"`
+	Left             *string       `protobuf:"bytes,4,opt,name=left" json:"left,omitempty"`
+	Right            *string       `protobuf:"bytes,5,opt,name=right" json:"right,omitempty"`
+	ParentIndex      int32         `protobuf:"varint,6,opt,name=parentIndex" json:"parentIndex"`
+	Index            int32         `protobuf:"varint,7,opt,name=index" json:"index"`
+	SourceIndex      int32         `protobuf:"varint,8,opt,name=sourceIndex" json:"sourceIndex"`
+	SourceField      *string       `protobuf:"bytes,9,opt,name=sourceField" json:"sourceField,omitempty"`
+	As               string        `protobuf:"bytes,11,opt,name=as" json:"as"`
+	Comment          *string       `protobuf:"bytes,12,opt,name=comment" json:"comment,omitempty"`
+	Order            *string       `protobuf:"bytes,13,opt,name=order" json:"order,omitempty"`
+	Star             *bool         `protobuf:"varint,14,opt,name=star" json:"star,omitempty"`
+	Agg              bool          `protobuf:"varint,15,opt,name=agg" json:"agg"`
+	Expr             *expr.NodePb  `protobuf:"bytes,16,opt,name=Expr,json=expr" json:"Expr,omitempty"`
+	Guard            *expr.NodePb  `protobuf:"bytes,17,opt,name=Guard,json=guard" json:"Guard,omitempty"`
+	Over             *WindowSpecPb `protobuf:"bytes,18,opt,name=over" json:"over,omitempty"`
+	Nulls            *string       `protobuf:"bytes,19,opt,name=nulls" json:"nulls,omitempty"`
+	XXX_unrecognized []byte        `json:"-"`
 }
 
 func (m *ColumnPb) Reset()                    { *m = ColumnPb{} }
@@ -664,6 +703,20 @@ func (m *ColumnPb) GetGuard() *expr.NodePb {
 	return nil
 }
 
+func (m *ColumnPb) GetOver() *WindowSpecPb {
+	if m != nil {
+		return m.Over
+	}
+	return nil
+}
+
+func (m *ColumnPb) GetNulls() string {
+	if m != nil && m.Nulls != nil {
+		return *m.Nulls
+	}
+	return ""
+}
+
 type CommandColumnPb struct {
 	Expr             *expr.NodePb `protobuf:"bytes,1,opt,name=Expr,json=expr" json:"Expr,omitempty"`
 	Name             string       `protobuf:"bytes,2,req,name=name" json:"name"`
@@ -689,6 +742,159 @@ func (m *CommandColumnPb) GetName() string {
 	return ""
 }
 
+// Window specification of a window function column, OVER (...)
+type WindowSpecPb struct {
+	PartitionBy      []*expr.NodePb `protobuf:"bytes,1,rep,name=partitionBy" json:"partitionBy,omitempty"`
+	OrderBy          []*ColumnPb    `protobuf:"bytes,2,rep,name=orderBy" json:"orderBy,omitempty"`
+	Frame            *WindowFramePb `protobuf:"bytes,3,opt,name=frame" json:"frame,omitempty"`
+	XXX_unrecognized []byte         `json:"-"`
+}
+
+func (m *WindowSpecPb) Reset()                    { *m = WindowSpecPb{} }
+func (m *WindowSpecPb) String() string            { return proto.CompactTextString(m) }
+func (*WindowSpecPb) ProtoMessage()               {}
+func (*WindowSpecPb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{9} }
+
+func (m *WindowSpecPb) GetPartitionBy() []*expr.NodePb {
+	if m != nil {
+		return m.PartitionBy
+	}
+	return nil
+}
+
+func (m *WindowSpecPb) GetOrderBy() []*ColumnPb {
+	if m != nil {
+		return m.OrderBy
+	}
+	return nil
+}
+
+func (m *WindowSpecPb) GetFrame() *WindowFramePb {
+	if m != nil {
+		return m.Frame
+	}
+	return nil
+}
+
+type WindowFramePb struct {
+	Unit             int32               `protobuf:"varint,1,req,name=unit" json:"unit"`
+	Start            *WindowFrameBoundPb `protobuf:"bytes,2,opt,name=start" json:"start,omitempty"`
+	End              *WindowFrameBoundPb `protobuf:"bytes,3,opt,name=end" json:"end,omitempty"`
+	XXX_unrecognized []byte              `json:"-"`
+}
+
+func (m *WindowFramePb) Reset()                    { *m = WindowFramePb{} }
+func (m *WindowFramePb) String() string            { return proto.CompactTextString(m) }
+func (*WindowFramePb) ProtoMessage()               {}
+func (*WindowFramePb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{10} }
+
+func (m *WindowFramePb) GetUnit() int32 {
+	if m != nil {
+		return m.Unit
+	}
+	return 0
+}
+
+func (m *WindowFramePb) GetStart() *WindowFrameBoundPb {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *WindowFramePb) GetEnd() *WindowFrameBoundPb {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+type WindowFrameBoundPb struct {
+	Type             int32  `protobuf:"varint,1,req,name=type" json:"type"`
+	Unbounded        bool   `protobuf:"varint,2,opt,name=unbounded" json:"unbounded"`
+	Offset           int64  `protobuf:"varint,3,opt,name=offset" json:"offset"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *WindowFrameBoundPb) Reset()                    { *m = WindowFrameBoundPb{} }
+func (m *WindowFrameBoundPb) String() string            { return proto.CompactTextString(m) }
+func (*WindowFrameBoundPb) ProtoMessage()               {}
+func (*WindowFrameBoundPb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{11} }
+
+func (m *WindowFrameBoundPb) GetType() int32 {
+	if m != nil {
+		return m.Type
+	}
+	return 0
+}
+
+func (m *WindowFrameBoundPb) GetUnbounded() bool {
+	if m != nil {
+		return m.Unbounded
+	}
+	return false
+}
+
+func (m *WindowFrameBoundPb) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+// Common table expression, WITH name AS (SELECT ...)
+type CommonTableExprPb struct {
+	Name             string       `protobuf:"bytes,1,opt,name=name" json:"name"`
+	Columns          []string     `protobuf:"bytes,2,rep,name=columns" json:"columns,omitempty"`
+	Select           *SqlSelectPb `protobuf:"bytes,3,opt,name=select" json:"select,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *CommonTableExprPb) Reset()                    { *m = CommonTableExprPb{} }
+func (m *CommonTableExprPb) String() string            { return proto.CompactTextString(m) }
+func (*CommonTableExprPb) ProtoMessage()               {}
+func (*CommonTableExprPb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{12} }
+
+func (m *CommonTableExprPb) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CommonTableExprPb) GetColumns() []string {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *CommonTableExprPb) GetSelect() *SqlSelectPb {
+	if m != nil {
+		return m.Select
+	}
+	return nil
+}
+
+// Grouping set of GROUP BY ROLLUP, CUBE or GROUPING SETS, the indexes of the
+// group by columns it groups on
+type GroupingSetPb struct {
+	Cols             []int32 `protobuf:"varint,1,rep,name=cols" json:"cols,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *GroupingSetPb) Reset()                    { *m = GroupingSetPb{} }
+func (m *GroupingSetPb) String() string            { return proto.CompactTextString(m) }
+func (*GroupingSetPb) ProtoMessage()               {}
+func (*GroupingSetPb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{13} }
+
+func (m *GroupingSetPb) GetCols() []int32 {
+	if m != nil {
+		return m.Cols
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*SqlStatementPb)(nil), "rel.SqlStatementPb")
 	proto.RegisterType((*SqlSelectPb)(nil), "rel.SqlSelectPb")
@@ -699,6 +905,11 @@ func init() {
 	proto.RegisterType((*KvInt)(nil), "rel.KvInt")
 	proto.RegisterType((*ColumnPb)(nil), "rel.ColumnPb")
 	proto.RegisterType((*CommandColumnPb)(nil), "rel.CommandColumnPb")
+	proto.RegisterType((*WindowSpecPb)(nil), "rel.WindowSpecPb")
+	proto.RegisterType((*WindowFramePb)(nil), "rel.WindowFramePb")
+	proto.RegisterType((*WindowFrameBoundPb)(nil), "rel.WindowFrameBoundPb")
+	proto.RegisterType((*CommonTableExprPb)(nil), "rel.CommonTableExprPb")
+	proto.RegisterType((*GroupingSetPb)(nil), "rel.GroupingSetPb")
 }
 func (m *SqlStatementPb) Marshal() (data []byte, err error) {
 	size := m.Size()
@@ -924,6 +1135,34 @@ func (m *SqlSelectPb) MarshalTo(data []byte) (int, error) {
 		i = encodeVarintSql(data, i, uint64(len(m.With)))
 		i += copy(data[i:], m.With)
 	}
+	if len(m.Ctes) > 0 {
+		for _, msg := range m.Ctes {
+			data[i] = 0xa2
+			i++
+			data[i] = 0x1
+			i++
+			i = encodeVarintSql(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.GroupingSets) > 0 {
+		for _, msg := range m.GroupingSets {
+			data[i] = 0xaa
+			i++
+			data[i] = 0x1
+			i++
+			i = encodeVarintSql(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1098,6 +1337,24 @@ func (m *SqlWherePb) MarshalTo(data []byte) (int, error) {
 		}
 		i += n11
 	}
+	data[i] = 0x20
+	i++
+	if m.Negate {
+		data[i] = 1
+	} else {
+		data[i] = 0
+	}
+	i++
+	if m.Left != nil {
+		data[i] = 0x2a
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Left.Size()))
+		n21, err := m.Left.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n21
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1379,6 +1636,26 @@ func (m *ColumnPb) MarshalTo(data []byte) (int, error) {
 		}
 		i += n14
 	}
+	if m.Over != nil {
+		data[i] = 0x92
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Over.Size()))
+		n16, err := m.Over.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n16
+	}
+	if m.Nulls != nil {
+		data[i] = 0x9a
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(len(*m.Nulls)))
+		i += copy(data[i:], *m.Nulls)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1420,64 +1697,276 @@ func (m *CommandColumnPb) MarshalTo(data []byte) (int, error) {
 	return i, nil
 }
 
-func encodeFixed64Sql(data []byte, offset int, v uint64) int {
-	data[offset] = uint8(v)
-	data[offset+1] = uint8(v >> 8)
-	data[offset+2] = uint8(v >> 16)
-	data[offset+3] = uint8(v >> 24)
-	data[offset+4] = uint8(v >> 32)
-	data[offset+5] = uint8(v >> 40)
-	data[offset+6] = uint8(v >> 48)
-	data[offset+7] = uint8(v >> 56)
-	return offset + 8
-}
-func encodeFixed32Sql(data []byte, offset int, v uint32) int {
-	data[offset] = uint8(v)
-	data[offset+1] = uint8(v >> 8)
-	data[offset+2] = uint8(v >> 16)
-	data[offset+3] = uint8(v >> 24)
-	return offset + 4
-}
-func encodeVarintSql(data []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		data[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *WindowSpecPb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
 	}
-	data[offset] = uint8(v)
-	return offset + 1
+	return data[:n], nil
 }
-func (m *SqlStatementPb) Size() (n int) {
+
+func (m *WindowSpecPb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Select != nil {
-		l = m.Select.Size()
-		n += 1 + l + sovSql(uint64(l))
+	if len(m.PartitionBy) > 0 {
+		for _, msg := range m.PartitionBy {
+			data[i] = 0xa
+			i++
+			i = encodeVarintSql(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	if m.Source != nil {
-		l = m.Source.Size()
-		n += 1 + l + sovSql(uint64(l))
+	if len(m.OrderBy) > 0 {
+		for _, msg := range m.OrderBy {
+			data[i] = 0x12
+			i++
+			i = encodeVarintSql(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	if m.Projection != nil {
-		l = m.Projection.Size()
-		n += 1 + l + sovSql(uint64(l))
+	if m.Frame != nil {
+		data[i] = 0x1a
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Frame.Size()))
+		n17, err := m.Frame.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n17
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(data[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *SqlSelectPb) Size() (n int) {
+func (m *WindowFramePb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *WindowFramePb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Db)
-	n += 1 + l + sovSql(uint64(l))
-	l = len(m.Raw)
-	n += 1 + l + sovSql(uint64(l))
-	n += 2
-	n += 2
-	if len(m.Columns) > 0 {
+	data[i] = 0x8
+	i++
+	i = encodeVarintSql(data, i, uint64(m.Unit))
+	if m.Start != nil {
+		data[i] = 0x12
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Start.Size()))
+		n18, err := m.Start.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n18
+	}
+	if m.End != nil {
+		data[i] = 0x1a
+		i++
+		i = encodeVarintSql(data, i, uint64(m.End.Size()))
+		n19, err := m.End.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n19
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *WindowFrameBoundPb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *WindowFrameBoundPb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	data[i] = 0x8
+	i++
+	i = encodeVarintSql(data, i, uint64(m.Type))
+	data[i] = 0x10
+	i++
+	if m.Unbounded {
+		data[i] = 1
+	} else {
+		data[i] = 0
+	}
+	i++
+	data[i] = 0x18
+	i++
+	i = encodeVarintSql(data, i, uint64(m.Offset))
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *CommonTableExprPb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *CommonTableExprPb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	data[i] = 0xa
+	i++
+	i = encodeVarintSql(data, i, uint64(len(m.Name)))
+	i += copy(data[i:], m.Name)
+	if len(m.Columns) > 0 {
+		for _, s := range m.Columns {
+			data[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				data[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			data[i] = uint8(l)
+			i++
+			i += copy(data[i:], s)
+		}
+	}
+	if m.Select != nil {
+		data[i] = 0x1a
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Select.Size()))
+		n20, err := m.Select.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n20
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *GroupingSetPb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *GroupingSetPb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cols) > 0 {
+		for _, num := range m.Cols {
+			data[i] = 0x8
+			i++
+			i = encodeVarintSql(data, i, uint64(num))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func encodeFixed64Sql(data []byte, offset int, v uint64) int {
+	data[offset] = uint8(v)
+	data[offset+1] = uint8(v >> 8)
+	data[offset+2] = uint8(v >> 16)
+	data[offset+3] = uint8(v >> 24)
+	data[offset+4] = uint8(v >> 32)
+	data[offset+5] = uint8(v >> 40)
+	data[offset+6] = uint8(v >> 48)
+	data[offset+7] = uint8(v >> 56)
+	return offset + 8
+}
+func encodeFixed32Sql(data []byte, offset int, v uint32) int {
+	data[offset] = uint8(v)
+	data[offset+1] = uint8(v >> 8)
+	data[offset+2] = uint8(v >> 16)
+	data[offset+3] = uint8(v >> 24)
+	return offset + 4
+}
+func encodeVarintSql(data []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		data[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	data[offset] = uint8(v)
+	return offset + 1
+}
+func (m *SqlStatementPb) Size() (n int) {
+	var l int
+	_ = l
+	if m.Select != nil {
+		l = m.Select.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.Source != nil {
+		l = m.Source.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.Projection != nil {
+		l = m.Projection.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SqlSelectPb) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Db)
+	n += 1 + l + sovSql(uint64(l))
+	l = len(m.Raw)
+	n += 1 + l + sovSql(uint64(l))
+	n += 2
+	n += 2
+	if len(m.Columns) > 0 {
 		for _, e := range m.Columns {
 			l = e.Size()
 			n += 1 + l + sovSql(uint64(l))
@@ -1530,6 +2019,18 @@ func (m *SqlSelectPb) Size() (n int) {
 		l = len(m.With)
 		n += 2 + l + sovSql(uint64(l))
 	}
+	if len(m.Ctes) > 0 {
+		for _, e := range m.Ctes {
+			l = e.Size()
+			n += 2 + l + sovSql(uint64(l))
+		}
+	}
+	if len(m.GroupingSets) > 0 {
+		for _, e := range m.GroupingSets {
+			l = e.Size()
+			n += 2 + l + sovSql(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1602,6 +2103,11 @@ func (m *SqlWherePb) Size() (n int) {
 		l = m.Expr.Size()
 		n += 1 + l + sovSql(uint64(l))
 	}
+	n += 2
+	if m.Left != nil {
+		l = m.Left.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1720,6 +2226,14 @@ func (m *ColumnPb) Size() (n int) {
 		l = m.Guard.Size()
 		n += 2 + l + sovSql(uint64(l))
 	}
+	if m.Over != nil {
+		l = m.Over.Size()
+		n += 2 + l + sovSql(uint64(l))
+	}
+	if m.Nulls != nil {
+		l = len(*m.Nulls)
+		n += 2 + l + sovSql(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1741,52 +2255,142 @@ func (m *CommandColumnPb) Size() (n int) {
 	return n
 }
 
-func sovSql(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *WindowSpecPb) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.PartitionBy) > 0 {
+		for _, e := range m.PartitionBy {
+			l = e.Size()
+			n += 1 + l + sovSql(uint64(l))
+		}
+	}
+	if len(m.OrderBy) > 0 {
+		for _, e := range m.OrderBy {
+			l = e.Size()
+			n += 1 + l + sovSql(uint64(l))
 		}
 	}
+	if m.Frame != nil {
+		l = m.Frame.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
 	return n
 }
-func sozSql(x uint64) (n int) {
-	return sovSql(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+func (m *WindowFramePb) Size() (n int) {
+	var l int
+	_ = l
+	n += 1 + sovSql(uint64(m.Unit))
+	if m.Start != nil {
+		l = m.Start.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.End != nil {
+		l = m.End.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
 }
-func (m *SqlStatementPb) Unmarshal(data []byte) error {
-	l := len(data)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowSql
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := data[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: SqlStatementPb: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SqlStatementPb: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Select", wireType)
-			}
+
+func (m *WindowFrameBoundPb) Size() (n int) {
+	var l int
+	_ = l
+	n += 1 + sovSql(uint64(m.Type))
+	n += 2
+	n += 1 + sovSql(uint64(m.Offset))
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *CommonTableExprPb) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	n += 1 + l + sovSql(uint64(l))
+	if len(m.Columns) > 0 {
+		for _, s := range m.Columns {
+			l = len(s)
+			n += 1 + l + sovSql(uint64(l))
+		}
+	}
+	if m.Select != nil {
+		l = m.Select.Size()
+		n += 1 + l + sovSql(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GroupingSetPb) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Cols) > 0 {
+		for _, e := range m.Cols {
+			n += 1 + sovSql(uint64(e))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovSql(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozSql(x uint64) (n int) {
+	return sovSql(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *SqlStatementPb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SqlStatementPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SqlStatementPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Select", wireType)
+			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
@@ -2451,6 +3055,68 @@ func (m *SqlSelectPb) Unmarshal(data []byte) error {
 				m.With = []byte{}
 			}
 			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ctes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Ctes = append(m.Ctes, &CommonTableExprPb{})
+			if err := m.Ctes[len(m.Ctes)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 21:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GroupingSets", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GroupingSets = append(m.GroupingSets, &GroupingSetPb{})
+			if err := m.GroupingSets[len(m.GroupingSets)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -3080,6 +3746,59 @@ func (m *SqlWherePb) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Negate", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Negate = bool(v != 0)
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Left", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Left == nil {
+				m.Left = &expr.NodePb{}
+			}
+			if err := m.Left.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -4076,6 +4795,69 @@ func (m *ColumnPb) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Over", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Over == nil {
+				m.Over = &WindowSpecPb{}
+			}
+			if err := m.Over.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 19:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nulls", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			s := string(data[iNdEx:postIndex])
+			m.Nulls = &s
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -4216,6 +4998,649 @@ func (m *CommandColumnPb) Unmarshal(data []byte) error {
 	}
 	return nil
 }
+func (m *WindowSpecPb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WindowSpecPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WindowSpecPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartitionBy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PartitionBy = append(m.PartitionBy, &expr.NodePb{})
+			if err := m.PartitionBy[len(m.PartitionBy)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OrderBy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OrderBy = append(m.OrderBy, &ColumnPb{})
+			if err := m.OrderBy[len(m.OrderBy)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Frame", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Frame == nil {
+				m.Frame = &WindowFramePb{}
+			}
+			if err := m.Frame.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WindowFramePb) Unmarshal(data []byte) error {
+	var hasFields [1]uint64
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WindowFramePb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WindowFramePb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unit", wireType)
+			}
+			m.Unit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Unit |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			hasFields[0] |= uint64(0x00000001)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Start == nil {
+				m.Start = &WindowFrameBoundPb{}
+			}
+			if err := m.Start.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.End == nil {
+				m.End = &WindowFrameBoundPb{}
+			}
+			if err := m.End.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+	if hasFields[0]&uint64(0x00000001) == 0 {
+		return new(github_com_golang_protobuf_proto.RequiredNotSetError)
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WindowFrameBoundPb) Unmarshal(data []byte) error {
+	var hasFields [1]uint64
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WindowFrameBoundPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WindowFrameBoundPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Type |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			hasFields[0] |= uint64(0x00000001)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unbounded", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Unbounded = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Offset |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+	if hasFields[0]&uint64(0x00000001) == 0 {
+		return new(github_com_golang_protobuf_proto.RequiredNotSetError)
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CommonTableExprPb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CommonTableExprPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CommonTableExprPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(data[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Columns", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Columns = append(m.Columns, string(data[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Select", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Select == nil {
+				m.Select = &SqlSelectPb{}
+			}
+			if err := m.Select.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GroupingSetPb) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GroupingSetPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GroupingSetPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			readVarint := func() (int32, error) {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowSql
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := data[iNdEx]
+					iNdEx++
+					v |= (int32(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				return v, nil
+			}
+			switch wireType {
+			case 0:
+				v, err := readVarint()
+				if err != nil {
+					return err
+				}
+				m.Cols = append(m.Cols, v)
+			case 2:
+				// packed
+				packedLen, err := readVarint()
+				if err != nil {
+					return err
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthSql
+				}
+				postIndex := iNdEx + int(packedLen)
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					v, err := readVarint()
+					if err != nil {
+						return err
+					}
+					m.Cols = append(m.Cols, v)
+				}
+			default:
+				return fmt.Errorf("proto: wrong wireType = %d for field Cols", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipSql(data []byte) (n int, err error) {
 	l := len(data)
 	iNdEx := 0
@@ -4322,72 +5747,81 @@ var (
 )
 
 var fileDescriptorSql = []byte{
-	// 1067 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
-	0x10, 0x0e, 0x25, 0x51, 0x96, 0x56, 0x8e, 0xed, 0x6c, 0x82, 0x62, 0x61, 0x14, 0xae, 0x41, 0x14,
-	0x81, 0xd1, 0x34, 0x52, 0x91, 0x1e, 0x7a, 0x8e, 0x83, 0xb6, 0x30, 0x0a, 0xa4, 0x8e, 0x5c, 0xa0,
-	0x67, 0x4a, 0x5c, 0x51, 0x8c, 0x29, 0xae, 0xbc, 0x24, 0xe5, 0x38, 0x4f, 0xd2, 0x4b, 0x81, 0x5e,
-	0x7b, 0xeb, 0x33, 0xf4, 0xe4, 0x63, 0x9f, 0xa0, 0xe8, 0x0f, 0xfa, 0x1e, 0x9d, 0x99, 0x25, 0x97,
-	0x6b, 0x47, 0x72, 0x72, 0x10, 0xc0, 0xfd, 0xe6, 0xdb, 0xdd, 0x99, 0xd9, 0x6f, 0x66, 0xc4, 0xfa,
-	0xf9, 0x45, 0x3a, 0x5c, 0x6a, 0x55, 0x28, 0xde, 0xd6, 0x32, 0xdd, 0x7f, 0x12, 0x27, 0xc5, 0xbc,
-	0x9c, 0x0c, 0xa7, 0x6a, 0x31, 0x0a, 0x75, 0x18, 0x45, 0x2a, 0x1b, 0x5d, 0xa4, 0x13, 0x9d, 0x44,
-	0xb1, 0x1c, 0xc9, 0x37, 0x4b, 0x3d, 0xca, 0x54, 0x24, 0xcd, 0x8e, 0xfd, 0xa7, 0x0e, 0x39, 0x56,
-	0xb1, 0x1a, 0x11, 0x3c, 0x29, 0x67, 0xb4, 0xa2, 0x05, 0x7d, 0x19, 0x7a, 0xf0, 0xab, 0xc7, 0x76,
-	0xce, 0x2e, 0xd2, 0xb3, 0x22, 0x2c, 0xe4, 0x42, 0x66, 0xc5, 0xe9, 0x84, 0x0f, 0x59, 0x37, 0x97,
-	0xa9, 0x9c, 0x16, 0xc2, 0x3b, 0xf4, 0x8e, 0x06, 0xcf, 0xf6, 0x86, 0xe0, 0xc4, 0x10, 0x49, 0x84,
-	0x9e, 0x4e, 0x8e, 0x3b, 0xd7, 0x7f, 0x7e, 0xe2, 0x8d, 0x2b, 0x16, 0xf1, 0x55, 0xa9, 0xa7, 0x52,
-	0xb4, 0x6e, 0xf1, 0x09, 0x75, 0xf8, 0xb4, 0xe6, 0x5f, 0x31, 0x06, 0x77, 0xbf, 0x86, 0xad, 0x89,
-	0xca, 0x44, 0x87, 0xf6, 0x3c, 0xa0, 0x3d, 0xa7, 0x16, 0xb6, 0x9b, 0x1c, 0x6a, 0xf0, 0x9b, 0xcf,
-	0x06, 0x8e, 0x1b, 0xfc, 0x11, 0x6b, 0x45, 0x13, 0x70, 0xb2, 0x75, 0xd4, 0x27, 0xf6, 0xbd, 0x31,
-	0xac, 0xf9, 0x47, 0xac, 0xad, 0xc3, 0x4b, 0xf0, 0xa5, 0x81, 0x11, 0xe0, 0x82, 0x75, 0xf2, 0x22,
-	0xd4, 0xa2, 0x0d, 0x86, 0x5e, 0x65, 0x20, 0x84, 0x1f, 0xb2, 0x5e, 0x94, 0xe4, 0x45, 0x92, 0x41,
-	0xc8, 0x1d, 0xc7, 0x6a, 0x51, 0xfe, 0x94, 0x6d, 0x4d, 0x55, 0x5a, 0x2e, 0xb2, 0x5c, 0xf8, 0x87,
-	0x6d, 0xf0, 0xf7, 0x3e, 0xf9, 0xfb, 0x82, 0x30, 0xeb, 0x6b, 0xcd, 0xe1, 0x9f, 0xb1, 0xce, 0x4c,
-	0xab, 0x85, 0xe8, 0x12, 0x77, 0x53, 0x3e, 0x88, 0x83, 0x6e, 0x25, 0x59, 0xa1, 0xc4, 0x16, 0xe4,
-	0xa1, 0x5f, 0x5b, 0x10, 0xe1, 0x4f, 0x98, 0x7f, 0x39, 0x97, 0x5a, 0x8a, 0x1e, 0xa5, 0x68, 0xb7,
-	0x3e, 0xe6, 0x47, 0x04, 0xed, 0x29, 0x86, 0x03, 0x57, 0x76, 0xe7, 0xe1, 0x2a, 0xc9, 0x62, 0xd1,
-	0x27, 0xf6, 0xf6, 0x10, 0x85, 0x31, 0x7c, 0x09, 0xc2, 0x68, 0x1e, 0xc0, 0x30, 0x30, 0x1a, 0xa5,
-	0x23, 0xa9, 0x8f, 0xaf, 0x04, 0xbb, 0x23, 0x9a, 0x8a, 0x83, 0xf4, 0x58, 0xab, 0x72, 0x09, 0xf4,
-	0xc1, 0x1d, 0xf4, 0x8a, 0xc3, 0xf7, 0x99, 0x9f, 0x26, 0x8b, 0xa4, 0x10, 0xdb, 0xe0, 0x88, 0x5f,
-	0xa5, 0xd2, 0x40, 0xfc, 0x63, 0xd6, 0x55, 0xb3, 0x59, 0x2e, 0x0b, 0x71, 0xdf, 0x31, 0x56, 0x18,
-	0xee, 0x0c, 0xd3, 0x24, 0xcc, 0xc5, 0x8e, 0x93, 0x0b, 0x03, 0xdd, 0x12, 0xcd, 0xee, 0x07, 0x8b,
-	0x06, 0x0f, 0x4d, 0xf2, 0xe7, 0x71, 0x2c, 0xf6, 0x9c, 0x97, 0x35, 0x10, 0x0f, 0x58, 0x7f, 0x96,
-	0x64, 0x70, 0xc1, 0x5b, 0x19, 0x89, 0x07, 0x8e, 0xbd, 0x81, 0x91, 0x93, 0x4f, 0xe7, 0x72, 0x11,
-	0x5e, 0xe8, 0x2b, 0xc1, 0x5d, 0x8e, 0x85, 0xf1, 0x0d, 0x2f, 0xa1, 0xea, 0xc4, 0x43, 0x70, 0x6b,
-	0xbb, 0x7e, 0x43, 0x44, 0x82, 0xdf, 0x3b, 0x46, 0xb2, 0xd5, 0xcb, 0xa3, 0x37, 0x74, 0x34, 0x95,
-	0x96, 0xf5, 0x86, 0x20, 0xfe, 0x29, 0x63, 0x14, 0xeb, 0x49, 0x96, 0x49, 0x4d, 0xb5, 0x54, 0xe7,
-	0xc0, 0xc1, 0x5d, 0x29, 0xb6, 0x3f, 0x40, 0x8a, 0x9f, 0xb3, 0x1e, 0x7c, 0x9e, 0x64, 0x91, 0x7c,
-	0x03, 0xda, 0x46, 0x3e, 0x23, 0xfe, 0x77, 0xab, 0x93, 0xac, 0xa8, 0x75, 0x5e, 0x33, 0xf8, 0x17,
-	0xac, 0xff, 0x5a, 0x25, 0x19, 0xaa, 0xa6, 0x56, 0xfa, 0x3a, 0x21, 0x35, 0x24, 0xa7, 0xf8, 0xbb,
-	0xef, 0x69, 0x16, 0xa6, 0xf8, 0xab, 0xea, 0x6c, 0xd4, 0xde, 0x54, 0x67, 0x16, 0x2e, 0x8c, 0xd6,
-	0x6b, 0x03, 0x21, 0x8d, 0x2a, 0xfa, 0x8e, 0xa9, 0x52, 0x05, 0x74, 0x00, 0xb5, 0x04, 0x11, 0xb7,
-	0xac, 0x96, 0x60, 0xcd, 0x1f, 0xb3, 0x41, 0x2a, 0x67, 0xc5, 0xf7, 0x7a, 0x9c, 0xc4, 0xf3, 0x02,
-	0x44, 0xdb, 0x98, 0x5d, 0x03, 0xd6, 0x3d, 0x06, 0xf2, 0xc3, 0xd5, 0x52, 0x82, 0x58, 0x1b, 0x92,
-	0x45, 0x21, 0x3a, 0xfa, 0xfe, 0x1a, 0x32, 0x40, 0x8a, 0x5d, 0x9f, 0x0e, 0xcb, 0xe1, 0xcf, 0x58,
-	0x2f, 0x2f, 0x27, 0xaf, 0x4a, 0x09, 0x5a, 0xd9, 0xb9, 0x33, 0x1f, 0x96, 0x87, 0x5e, 0xe4, 0x52,
-	0x9e, 0x87, 0x93, 0x54, 0x92, 0xae, 0x6d, 0xf7, 0xa9, 0xd1, 0xe0, 0x2d, 0x63, 0x4d, 0xd9, 0x57,
-	0x31, 0x7b, 0xb7, 0x62, 0xde, 0xdc, 0x84, 0xd7, 0xbf, 0xc3, 0x63, 0xd6, 0xa1, 0xa8, 0xda, 0x1b,
-	0xa3, 0xea, 0x20, 0x14, 0xfc, 0xec, 0xb1, 0x6d, 0xb7, 0xc2, 0x6e, 0x34, 0x4b, 0x6f, 0x6d, 0xb3,
-	0xb4, 0x1a, 0x6f, 0xb9, 0x15, 0x67, 0x34, 0xbe, 0x4f, 0x72, 0x7c, 0x09, 0xef, 0x6a, 0xe4, 0xdb,
-	0x1f, 0xdb, 0x35, 0xff, 0xb2, 0x51, 0xb6, 0x51, 0xea, 0x43, 0x8a, 0x61, 0x2c, 0xf3, 0x32, 0x2d,
-	0x36, 0xe8, 0x3b, 0xf8, 0x0f, 0xe6, 0xd7, 0x4d, 0xc6, 0xba, 0x1a, 0xf3, 0xea, 0xfb, 0x6b, 0x99,
-	0xb9, 0xd3, 0xc1, 0xc8, 0x0c, 0x5a, 0x13, 0x9c, 0x79, 0xaa, 0x72, 0x1a, 0x10, 0xb6, 0x35, 0x19,
-	0x0c, 0x7a, 0x71, 0xd7, 0xdc, 0x58, 0xcd, 0xab, 0xb5, 0x45, 0x57, 0x51, 0xec, 0xa4, 0xf1, 0x9d,
-	0xfb, 0xcd, 0xa4, 0x81, 0xb7, 0x03, 0x21, 0x77, 0xdd, 0x89, 0x05, 0x2a, 0x86, 0x16, 0xb3, 0x0a,
-	0xd3, 0x52, 0x92, 0x10, 0xb7, 0x9c, 0xdb, 0x1b, 0x38, 0x18, 0x31, 0x9f, 0x4a, 0x96, 0x73, 0xe6,
-	0x9d, 0xdf, 0x98, 0x79, 0xde, 0x39, 0x62, 0x2b, 0x0a, 0xa9, 0xde, 0xe8, 0xad, 0x82, 0x5f, 0x3a,
-	0xac, 0x67, 0x53, 0x02, 0x15, 0x61, 0xde, 0xfd, 0x55, 0xa9, 0x0a, 0x49, 0x89, 0xa9, 0xfb, 0x94,
-	0x6b, 0x40, 0x5e, 0x98, 0xd3, 0xe7, 0xf1, 0x55, 0x61, 0xa4, 0x64, 0x79, 0x8e, 0x01, 0x5b, 0x95,
-	0xd2, 0x49, 0x8c, 0x29, 0x7d, 0x9e, 0x93, 0x86, 0x6c, 0xab, 0x6a, 0x70, 0xcc, 0x03, 0x96, 0x1b,
-	0xa5, 0xcc, 0x8e, 0x36, 0x44, 0xf0, 0x89, 0x34, 0xd5, 0xa6, 0xef, 0x76, 0x7a, 0x82, 0xd0, 0x87,
-	0x65, 0xa8, 0xe1, 0xaf, 0x88, 0x69, 0x5a, 0x5d, 0x67, 0x50, 0xb8, 0x06, 0x6a, 0xec, 0xc4, 0xd8,
-	0x72, 0xe7, 0x0c, 0x41, 0x4d, 0xbc, 0xe6, 0x8c, 0x9e, 0x7b, 0x86, 0x63, 0x68, 0x78, 0xdf, 0x24,
-	0x32, 0x8d, 0x9c, 0x0e, 0x63, 0xf3, 0x42, 0x86, 0xea, 0xdd, 0x06, 0x4e, 0x03, 0xc2, 0x77, 0x3b,
-	0x40, 0xc1, 0x2e, 0xf0, 0x5f, 0x13, 0xcd, 0xba, 0x7e, 0xa3, 0x4d, 0x02, 0xd1, 0x43, 0x9a, 0xa1,
-	0xd4, 0x3a, 0x6c, 0x94, 0x04, 0x59, 0x8d, 0xec, 0xbc, 0xa3, 0x11, 0xe8, 0x90, 0x21, 0x8c, 0x2b,
-	0xb7, 0x15, 0x20, 0x60, 0x2b, 0x76, 0xef, 0xee, 0x8a, 0xe5, 0x47, 0xcc, 0xff, 0xb6, 0x0c, 0x35,
-	0x0e, 0xb4, 0x4d, 0x44, 0x3f, 0x46, 0x42, 0x70, 0xc6, 0x76, 0x5f, 0x80, 0xab, 0x61, 0x16, 0x39,
-	0x42, 0x31, 0x97, 0x78, 0xef, 0xb9, 0x64, 0x63, 0x1d, 0x1d, 0x3f, 0xba, 0xfe, 0xfb, 0xc0, 0xbb,
-	0xfe, 0xe7, 0xc0, 0xfb, 0x03, 0x7e, 0x7f, 0xc1, 0xef, 0xa7, 0x7f, 0x0f, 0xee, 0xfd, 0x1f, 0x00,
-	0x00, 0xff, 0xff, 0xa8, 0x40, 0xb5, 0x23, 0xd3, 0x0a, 0x00, 0x00,
+	// 1206 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0xce, 0xf2, 0x47, 0x96, 0x56, 0xb2, 0x9d, 0x6c, 0x82, 0x74, 0x61, 0x14, 0xaa, 0x40, 0x14,
+	0x81, 0x10, 0x37, 0x52, 0x91, 0x14, 0xe8, 0x39, 0x0a, 0x9a, 0x22, 0x28, 0x90, 0x3a, 0x72, 0x81,
+	0x9c, 0x29, 0x71, 0x45, 0x31, 0x21, 0xb9, 0xf2, 0x72, 0x29, 0x47, 0x79, 0x89, 0x5e, 0x7b, 0xe9,
+	0xa9, 0xa7, 0xde, 0xfa, 0x0c, 0x3d, 0xf9, 0x52, 0xa0, 0x4f, 0x50, 0xb4, 0x2e, 0xfa, 0x1e, 0xc5,
+	0x2c, 0x29, 0x72, 0xe4, 0x48, 0x8e, 0x6f, 0xe4, 0x37, 0xdf, 0x2e, 0xe7, 0xe7, 0x9b, 0x19, 0xd2,
+	0x56, 0x76, 0x16, 0x0f, 0x16, 0x4a, 0x6a, 0xc9, 0x6c, 0x25, 0xe2, 0xa3, 0xe3, 0x30, 0xd2, 0xf3,
+	0x7c, 0x32, 0x98, 0xca, 0x64, 0xe8, 0x2b, 0x3f, 0x08, 0x64, 0x3a, 0x3c, 0x8b, 0x27, 0x2a, 0x0a,
+	0x42, 0x31, 0x14, 0xef, 0x16, 0x6a, 0x98, 0xca, 0x40, 0x14, 0x27, 0x8e, 0x1e, 0x21, 0x72, 0x28,
+	0x43, 0x39, 0x34, 0xf0, 0x24, 0x9f, 0x99, 0x37, 0xf3, 0x62, 0x9e, 0x0a, 0xba, 0xf7, 0x2b, 0xa1,
+	0x07, 0xa7, 0x67, 0xf1, 0xa9, 0xf6, 0xb5, 0x48, 0x44, 0xaa, 0x4f, 0x26, 0x6c, 0x40, 0x1b, 0x99,
+	0x88, 0xc5, 0x54, 0x73, 0xd2, 0x23, 0xfd, 0xf6, 0xe3, 0xdb, 0x03, 0x25, 0xe2, 0x01, 0x90, 0x0c,
+	0x7a, 0x32, 0x19, 0x39, 0x17, 0x7f, 0x7d, 0x46, 0xc6, 0x25, 0xcb, 0xf0, 0x65, 0xae, 0xa6, 0x82,
+	0x5b, 0x57, 0xf8, 0x06, 0x45, 0x7c, 0xf3, 0xce, 0xbe, 0xa6, 0x74, 0xa1, 0xe4, 0x1b, 0x31, 0xd5,
+	0x91, 0x4c, 0xb9, 0x63, 0xce, 0xdc, 0x31, 0x67, 0x4e, 0x2a, 0xb8, 0x3a, 0x84, 0xa8, 0xde, 0x6f,
+	0x2e, 0x6d, 0x23, 0x37, 0xd8, 0x3d, 0x6a, 0x05, 0x13, 0x4e, 0x7a, 0x56, 0xbf, 0x65, 0xd8, 0xb7,
+	0xc6, 0x56, 0x30, 0x61, 0xf7, 0xa9, 0xad, 0xfc, 0x73, 0x6e, 0x21, 0x18, 0x00, 0xc6, 0xa9, 0x93,
+	0x69, 0x5f, 0x71, 0xbb, 0x67, 0xf5, 0x9b, 0xa5, 0xc1, 0x20, 0xac, 0x47, 0x9b, 0x41, 0x94, 0xe9,
+	0x28, 0x9d, 0x6a, 0xee, 0x20, 0x6b, 0x85, 0xb2, 0x47, 0x74, 0x6f, 0x2a, 0xe3, 0x3c, 0x49, 0x33,
+	0xee, 0xf6, 0xec, 0x7e, 0xfb, 0xf1, 0xbe, 0xf1, 0xf7, 0x99, 0xc1, 0x2a, 0x5f, 0xd7, 0x1c, 0xf6,
+	0x90, 0x3a, 0x33, 0x25, 0x13, 0xde, 0xe8, 0xd9, 0xd7, 0xe4, 0xc3, 0x70, 0xc0, 0xad, 0x28, 0xd5,
+	0x92, 0xef, 0xf5, 0x48, 0xe9, 0x2f, 0x19, 0x1b, 0x84, 0x1d, 0x53, 0xf7, 0x7c, 0x2e, 0x94, 0xe0,
+	0x4d, 0x93, 0xa2, 0xc3, 0xf5, 0x35, 0xaf, 0x01, 0xac, 0x6e, 0x29, 0x38, 0xec, 0x21, 0x6d, 0xcc,
+	0xfd, 0x65, 0x94, 0x86, 0xbc, 0x65, 0xd8, 0x9d, 0x01, 0x08, 0x63, 0xf0, 0x52, 0x06, 0xa8, 0x00,
+	0x05, 0x03, 0xa2, 0x91, 0x2a, 0x10, 0x6a, 0xb4, 0xe2, 0xf4, 0x9a, 0x68, 0x4a, 0x0e, 0xd0, 0x43,
+	0x25, 0xf3, 0xc5, 0x68, 0xc5, 0xdb, 0xd7, 0xd0, 0x4b, 0x0e, 0x3b, 0xa2, 0x6e, 0x1c, 0x25, 0x91,
+	0xe6, 0x9d, 0x1e, 0xe9, 0xbb, 0x65, 0x2a, 0x0b, 0x88, 0x7d, 0x4a, 0x1b, 0x72, 0x36, 0xcb, 0x84,
+	0xe6, 0xfb, 0xc8, 0x58, 0x62, 0x70, 0xd2, 0x8f, 0x23, 0x3f, 0xe3, 0x07, 0x28, 0x17, 0x05, 0x74,
+	0x45, 0x34, 0x87, 0x37, 0x16, 0x0d, 0x5c, 0x1a, 0x65, 0x4f, 0xc3, 0x90, 0xdf, 0x46, 0x95, 0x2d,
+	0x20, 0xe6, 0xd1, 0xd6, 0x2c, 0x4a, 0xfd, 0x38, 0x7a, 0x2f, 0x02, 0x7e, 0x07, 0xd9, 0x6b, 0x18,
+	0x38, 0xd9, 0x74, 0x2e, 0x12, 0xff, 0x4c, 0xad, 0x38, 0xc3, 0x9c, 0x0a, 0x86, 0x1a, 0x9e, 0x47,
+	0x7a, 0xce, 0xef, 0xf6, 0x48, 0xbf, 0xb3, 0xae, 0x21, 0x20, 0xde, 0xef, 0x0e, 0x6d, 0xa3, 0xca,
+	0x83, 0x37, 0xe6, 0x6a, 0xd3, 0x5a, 0x95, 0x37, 0x06, 0x62, 0x9f, 0x53, 0x6a, 0x62, 0x7d, 0x91,
+	0xa6, 0x42, 0x71, 0x0b, 0xe5, 0x00, 0xe1, 0x58, 0x8a, 0xf6, 0x0d, 0xa4, 0xf8, 0x05, 0x6d, 0x4e,
+	0x65, 0xfc, 0x22, 0x0d, 0xc4, 0x3b, 0xee, 0x18, 0x3e, 0x35, 0xfc, 0xef, 0x96, 0x2f, 0x52, 0xbd,
+	0xd6, 0xf9, 0x9a, 0xc1, 0xbe, 0xa4, 0xad, 0x37, 0x32, 0x4a, 0x41, 0x35, 0x6b, 0xa5, 0x6f, 0x13,
+	0x52, 0x4d, 0x42, 0xcd, 0xdf, 0xf8, 0xc8, 0xb0, 0x28, 0x9a, 0xbf, 0xec, 0xce, 0x5a, 0xed, 0x75,
+	0x77, 0xa6, 0x7e, 0x52, 0x68, 0x7d, 0x6d, 0x30, 0x48, 0xad, 0x8a, 0x16, 0x32, 0x15, 0x10, 0x4c,
+	0x00, 0xb9, 0xe0, 0xb4, 0x67, 0x55, 0x5a, 0xb2, 0xe4, 0x82, 0x3d, 0xa0, 0xed, 0x58, 0xcc, 0xf4,
+	0xf7, 0x6a, 0x1c, 0x85, 0x73, 0xcd, 0xdb, 0xc8, 0x8c, 0x0d, 0xd0, 0xf7, 0x10, 0xc8, 0x0f, 0xab,
+	0x85, 0xe0, 0x1d, 0x44, 0xaa, 0x50, 0x36, 0x28, 0x18, 0xdf, 0xbc, 0x5b, 0x28, 0xa3, 0xd8, 0xed,
+	0xe9, 0xa8, 0x38, 0xec, 0x31, 0x6d, 0x66, 0xf9, 0xe4, 0x55, 0x2e, 0xd4, 0x8a, 0x1f, 0x5c, 0x9b,
+	0x8f, 0x8a, 0x07, 0x5e, 0x64, 0x42, 0xbc, 0xf5, 0x27, 0xb1, 0xe0, 0x87, 0x48, 0x15, 0x15, 0xea,
+	0xbd, 0xa7, 0xb4, 0x6e, 0xfb, 0x32, 0x66, 0x72, 0x25, 0xe6, 0xdd, 0x43, 0x78, 0x7b, 0x1d, 0x1e,
+	0x50, 0xc7, 0x44, 0x65, 0xef, 0x8c, 0xca, 0x01, 0xc8, 0xfb, 0x99, 0xd0, 0x0e, 0xee, 0xb0, 0x8d,
+	0x61, 0x49, 0xb6, 0x0e, 0xcb, 0x4a, 0xe3, 0x16, 0xee, 0x38, 0x03, 0xb1, 0x23, 0x23, 0xc7, 0x97,
+	0x7e, 0x22, 0x0a, 0xf9, 0xb6, 0xc6, 0xd5, 0x3b, 0x7b, 0x52, 0x2b, 0xbb, 0x50, 0xea, 0x5d, 0x13,
+	0xc3, 0x58, 0x64, 0x79, 0xac, 0x77, 0xe8, 0xdb, 0xfb, 0x8f, 0xd0, 0x83, 0x4d, 0xc6, 0xb6, 0x1e,
+	0x23, 0xeb, 0xef, 0xaf, 0x65, 0x86, 0xb7, 0x83, 0x41, 0x60, 0x34, 0x4d, 0x65, 0x7c, 0x22, 0x33,
+	0x6e, 0xa3, 0xd4, 0x96, 0x18, 0x3b, 0x36, 0xd6, 0x3c, 0x59, 0xef, 0xab, 0xad, 0x4d, 0x57, 0x52,
+	0xaa, 0x4d, 0xe3, 0xa2, 0xef, 0x1b, 0x04, 0x6a, 0xe7, 0x67, 0xbc, 0x81, 0x37, 0x96, 0x9f, 0xc1,
+	0x88, 0x59, 0xfa, 0x71, 0x2e, 0x8c, 0x10, 0xf7, 0xd0, 0xd7, 0x6b, 0xd8, 0x1b, 0x52, 0xd7, 0xb4,
+	0x2c, 0x63, 0x94, 0xbc, 0xdd, 0xd8, 0x79, 0xe4, 0x2d, 0x60, 0x4b, 0x6e, 0xa1, 0x83, 0x64, 0xe9,
+	0xfd, 0xe1, 0xd0, 0x66, 0x95, 0x92, 0x07, 0xb4, 0x5d, 0xd4, 0xfd, 0x55, 0x2e, 0xb5, 0xe0, 0x04,
+	0xcd, 0x29, 0x6c, 0x00, 0x9e, 0x9f, 0x99, 0xc7, 0xd1, 0x4a, 0x17, 0x52, 0xaa, 0x78, 0xc8, 0x00,
+	0xa3, 0x4a, 0xaa, 0x28, 0x84, 0x94, 0x3e, 0xcd, 0x8c, 0x86, 0xaa, 0x51, 0x55, 0xe3, 0x90, 0x07,
+	0x68, 0x37, 0xee, 0x20, 0xbb, 0x41, 0xa0, 0x44, 0xca, 0xf4, 0xa6, 0x8b, 0x4c, 0x05, 0x04, 0x3e,
+	0x2c, 0x7c, 0x25, 0x52, 0x5d, 0x0c, 0xad, 0x06, 0x5a, 0x14, 0xd8, 0x60, 0x06, 0xbb, 0x61, 0xec,
+	0xe1, 0x3d, 0x63, 0xa0, 0x3a, 0xde, 0xe2, 0x8e, 0x26, 0xbe, 0x03, 0x19, 0x6a, 0xde, 0xf3, 0x48,
+	0xc4, 0x01, 0x9a, 0x30, 0x64, 0x8c, 0x0d, 0x65, 0xdd, 0xda, 0x3d, 0xb2, 0x51, 0xb7, 0x2e, 0x08,
+	0x36, 0x81, 0xbf, 0x26, 0xde, 0xa9, 0x4c, 0x64, 0xbc, 0x06, 0xc1, 0x43, 0xb3, 0x43, 0xf9, 0x3e,
+	0xb2, 0x16, 0x50, 0xa5, 0x91, 0x83, 0x0f, 0x34, 0x72, 0x9f, 0xda, 0x7e, 0x18, 0x6e, 0x8c, 0x02,
+	0x00, 0xaa, 0x8e, 0xbd, 0x7d, 0x7d, 0xc7, 0xb2, 0x3e, 0x75, 0xbf, 0xcd, 0x7d, 0x05, 0x0b, 0x6d,
+	0x17, 0xd1, 0x0d, 0x81, 0xc0, 0x8e, 0xa9, 0x23, 0x97, 0x42, 0x71, 0x86, 0xb6, 0xe9, 0xeb, 0x28,
+	0x0d, 0xe4, 0xf9, 0xe9, 0x42, 0x4c, 0xeb, 0x6b, 0x81, 0xe4, 0x9d, 0xd2, 0xc3, 0x67, 0x32, 0x49,
+	0xfc, 0x34, 0x40, 0xaa, 0x2a, 0x3c, 0x22, 0x1f, 0xf1, 0x68, 0x67, 0xd3, 0x79, 0xbf, 0x10, 0xda,
+	0xc1, 0x5f, 0x64, 0x5f, 0x99, 0xe2, 0xeb, 0x08, 0x86, 0xcd, 0x68, 0xc5, 0xc9, 0xce, 0x15, 0x84,
+	0x69, 0xf8, 0x87, 0xc6, 0xba, 0xc1, 0x0f, 0xcd, 0x80, 0xba, 0x33, 0x05, 0x0e, 0x15, 0xc3, 0x8f,
+	0xa1, 0xc0, 0x9f, 0x03, 0x5e, 0xe7, 0xc9, 0xd0, 0xbc, 0x1f, 0x09, 0xdd, 0xdf, 0x30, 0x43, 0x44,
+	0x79, 0x1a, 0xe9, 0x8d, 0x29, 0x6c, 0x10, 0xf6, 0x84, 0xba, 0x50, 0x45, 0x5d, 0x8e, 0xe1, 0x4f,
+	0xae, 0xde, 0x3d, 0x92, 0x79, 0x1a, 0xd4, 0x1f, 0x30, 0x5c, 0x36, 0xa4, 0xb6, 0x48, 0x03, 0x6e,
+	0xdf, 0xe4, 0x08, 0x30, 0xbd, 0x05, 0x65, 0x1f, 0x12, 0xc0, 0x2b, 0x0d, 0x23, 0x64, 0xc3, 0x2b,
+	0x40, 0x60, 0xc2, 0xe4, 0xe9, 0x04, 0x68, 0x22, 0xe0, 0x16, 0x52, 0x56, 0x0d, 0xa3, 0x7f, 0x33,
+	0xf0, 0xc3, 0xde, 0xfc, 0x37, 0x1b, 0xdd, 0xbb, 0xf8, 0xa7, 0x4b, 0x2e, 0x2e, 0xbb, 0xe4, 0xcf,
+	0xcb, 0x2e, 0xf9, 0xfb, 0xb2, 0x4b, 0x7e, 0xfa, 0xb7, 0x7b, 0xeb, 0xff, 0x01, 0x00, 0x1a, 0xd4,
+	0xac, 0xcc, 0xaa, 0x0c, 0x00, 0x00,
 }
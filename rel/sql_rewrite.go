@@ -1,6 +1,7 @@
 package rel
 
 import (
+	"fmt"
 	"strings"
 
 	u "github.com/araddon/gou"
@@ -17,6 +18,7 @@ func RewriteSelect(m *SqlSelect) {
 	rewriteIntoProjection(m, m.GroupBy)
 	if m.Where != nil {
 		colsToAdd := expr.FindAllIdentityField(m.Where.Expr)
+		colsToAdd = append(colsToAdd, subSelectIdentities(m).Strings()...)
 		addIntoProjection(m, colsToAdd)
 	}
 	rewriteIntoProjection(m, m.OrderBy)
@@ -40,7 +42,10 @@ func RewriteSqlSource(m *SqlSource, parentStmt *SqlSelect) *SqlSelect {
 	if !parentStmt.Star {
 		for idx, col := range parentStmt.Columns {
 			left, _, hasLeft := col.LeftRight()
-			if !hasLeft {
+			if col.Expr != nil && len(expr.FindAllSubSelects(col.Expr)) > 0 {
+				// sub-selects are evaluated after sources are joined
+				continue
+			} else if !hasLeft {
 				// Was not left/right qualified, so use as is?  or is this an error?
 				//  what is official sql grammar on this?
 				newCol := col.Copy()
@@ -85,25 +90,99 @@ func RewriteSqlSource(m *SqlSource, parentStmt *SqlSelect) *SqlSelect {
 		}
 	}
 
-	if parentStmt.Where != nil {
-		node, cols := rewriteWhere(parentStmt, m, parentStmt.Where.Expr, make(Columns, 0))
-		if node != nil {
-			sql2.Where = &SqlWhere{Expr: node}
+	cols := make(Columns, 0)
+	if parentStmt.Where != nil && parentStmt.Where.Expr != nil {
+		if !m.outerJoined(parentStmt) {
+			var node expr.Node
+			node, cols = rewriteWhere(parentStmt, m, parentStmt.Where.Expr, cols)
+			if node != nil {
+				sql2.Where = &SqlWhere{Expr: node}
+			}
 		}
-		if len(cols) > 0 {
-			parentIdx := len(parentStmt.Columns)
-			for _, col := range cols {
-				col.Index = len(sql2.Columns)
-				col.ParentIndex = parentIdx
-				parentIdx++
-				sql2.Columns = append(sql2.Columns, col)
+		// the where is evaluated again after the join, so needs all
+		// of the columns of this source it uses
+		for _, in := range expr.FindAllIdentities(parentStmt.Where.Expr) {
+			if left, right, _ := in.LeftRight(); left == m.alias && !cols.hasSourceField(right) {
+				cols = append(cols, NewColumn(right))
 			}
 		}
 	}
+	// columns of this source the sub-selects need, they are
+	// evaluated after sources are joined
+	for _, in := range subSelectIdentities(parentStmt) {
+		left, right, hasLeft := in.LeftRight()
+		if left == m.alias || (!hasLeft && len(parentStmt.From) == 1) {
+			cols = append(cols, NewColumn(right))
+		}
+	}
+	parentIdx := nextParentIndex(parentStmt)
+	for _, col := range sql2.Columns {
+		if col.ParentIndex < 0 {
+			// join columns, joins of more than 2 sources or not
+			// on equal keys evaluate the join on the joined row
+			col.ParentIndex = parentIdx
+			parentIdx++
+		}
+	}
+	if len(cols) > 0 {
+		for _, col := range cols {
+			col.Index = len(sql2.Columns)
+			col.ParentIndex = parentIdx
+			parentIdx++
+			sql2.Columns = append(sql2.Columns, col)
+		}
+	}
 	m.Source = sql2
 	m.cols = sql2.UnAliasedColumns()
 	return sql2
 }
+
+// outerJoined is this source the side of an outer join of stmt whose columns
+// are NULL for un-matched rows of the other side, ie the right of a LEFT JOIN.
+func (m *SqlSource) outerJoined(stmt *SqlSelect) bool {
+	for i, from := range stmt.From {
+		if from != m {
+			continue
+		}
+		if i > 0 && (from.LeftOrRight == lex.TokenLeft || from.LeftOrRight == lex.TokenFull) {
+			return true
+		}
+		for _, next := range stmt.From[i+1:] {
+			if next.LeftOrRight == lex.TokenRight || next.LeftOrRight == lex.TokenFull {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasSourceField is there a column of source field name.
+func (m Columns) hasSourceField(name string) bool {
+	for _, col := range m {
+		if col.SourceField == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nextParentIndex the next free index into the columns of parentStmt for
+// columns sources add, that the parent needs but doesn't project.
+func nextParentIndex(parentStmt *SqlSelect) int {
+	idx := len(parentStmt.Columns)
+	for _, from := range parentStmt.From {
+		if from.Source == nil || from.Source == parentStmt {
+			continue
+		}
+		for _, col := range from.Source.Columns {
+			if col.ParentIndex >= idx {
+				idx = col.ParentIndex + 1
+			}
+		}
+	}
+	return idx
+}
+
 func rewriteIntoProjection(sel *SqlSelect, m Columns) {
 	if len(m) == 0 {
 		return
@@ -114,7 +193,7 @@ func rewriteIntoProjection(sel *SqlSelect, m Columns) {
 		switch n := c.Expr.(type) {
 		case *expr.IdentityNode:
 			colsToAdd = append(colsToAdd, c.SourceField)
-		case *expr.FuncNode:
+		case *expr.FuncNode, *expr.CaseNode:
 
 			idents := expr.FindAllIdentities(n)
 			for _, in := range idents {
@@ -132,6 +211,17 @@ func rewriteIntoProjection(sel *SqlSelect, m Columns) {
 		default:
 			u.Warnf("unhandled column? %T  %s", n, n)
 		}
+		if c.Over != nil {
+			// window partition, order by columns are needed from source as well
+			for _, pn := range c.Over.PartitionBy {
+				colsToAdd = append(colsToAdd, expr.FindAllIdentityField(pn)...)
+			}
+			for _, oc := range c.Over.OrderBy {
+				if oc.Expr != nil {
+					colsToAdd = append(colsToAdd, expr.FindAllIdentityField(oc.Expr)...)
+				}
+			}
+		}
 	}
 	addIntoProjection(sel, colsToAdd)
 }
@@ -158,65 +248,103 @@ func addIntoProjection(sel *SqlSelect, newCols []string) {
 		}
 	}
 }
+// rewriteWhere the conditions AND'd together in where node of stmt that
+// source from can evaluate, as an expression of the columns of its
+// stand-alone statement, nil if none.
 func rewriteWhere(stmt *SqlSelect, from *SqlSource, node expr.Node, cols Columns) (expr.Node, Columns) {
-	//u.Debugf("rewrite where %s", node)
-	switch nt := node.(type) {
-	case *expr.IdentityNode:
-		if left, right, hasLeft := nt.LeftRight(); hasLeft {
-			//u.Debugf("rewriteWhere  from.Name:%v l:%v  r:%v", from.alias, left, right)
-			if left == from.alias {
-				in := expr.IdentityNode{Text: right}
+	conds := make([]expr.Node, 0)
+	for _, cond := range splitAnd(node) {
+		if !from.CanEvaluate(stmt, cond) {
+			continue
+		}
+		for _, in := range expr.FindAllIdentities(cond) {
+			if _, right, _ := in.LeftRight(); !cols.hasSourceField(right) {
 				cols = append(cols, NewColumn(right))
-				//u.Warnf("nice, found it! in = %v  cols:%d", in, len(cols))
-				return &in, cols
-			} else {
-				//u.Warnf("what to do? source:%v    %v", from.alias, nt.String())
 			}
-		} else {
-			//u.Debugf("returning original: %s", nt)
-			return node, cols
 		}
-	case *expr.NumberNode, *expr.NullNode, *expr.StringNode:
-		return nt, cols
-	case *expr.BinaryNode:
-		//u.Infof("binaryNode  T:%v", nt.Operator.T.String())
-		switch nt.Operator.T {
-		case lex.TokenAnd, lex.TokenLogicAnd, lex.TokenLogicOr:
-			var n1, n2 expr.Node
-			n1, cols = rewriteWhere(stmt, from, nt.Args[0], cols)
-			n2, cols = rewriteWhere(stmt, from, nt.Args[1], cols)
+		conds = append(conds, from.SourceExpr(cond))
+	}
+	return andNodes(conds), cols
+}
 
-			if n1 != nil && n2 != nil {
-				return &expr.BinaryNode{Operator: nt.Operator, Args: []expr.Node{n1, n2}}, cols
-			} else if n1 != nil {
-				return n1, cols
-			} else if n2 != nil {
-				return n2, cols
-			} else {
-				//u.Warnf("n1=%#v  n2=%#v    %#v", n1, n2, nt)
-			}
-		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenGT, lex.TokenGE, lex.TokenLE, lex.TokenNE:
-			var n1, n2 expr.Node
-			n1, cols = rewriteWhere(stmt, from, nt.Args[0], cols)
-			n2, cols = rewriteWhere(stmt, from, nt.Args[1], cols)
-			//u.Debugf("n1=%#v  n2=%#v    %#v", n1, n2, nt)
-			if n1 != nil && n2 != nil {
-				return &expr.BinaryNode{Operator: nt.Operator, Args: []expr.Node{n1, n2}}, cols
-				// } else if n1 != nil {
-				// 	return n1
-				// } else if n2 != nil {
-				// 	return n2
-			} else {
-				//u.Warnf("n1=%#v  n2=%#v    %#v", n1, n2, nt)
-			}
+// CanEvaluate can condition node of the where of stmt be evaluated by source
+// m before it is joined, filtering the rows of m.  It must only use columns
+// of m, qualified by its alias unless m is the only source of stmt, have no
+// sub-selects, and m must not be the side of an outer join whose columns are
+// NULL for un-matched rows.
+func (m *SqlSource) CanEvaluate(stmt *SqlSelect, node expr.Node) bool {
+	if node == nil || len(expr.FindAllSubSelects(node)) > 0 || m.outerJoined(stmt) {
+		return false
+	}
+	ids := expr.FindAllIdentities(node)
+	if len(ids) == 0 {
+		// constant conditions are evaluated after the join
+		return false
+	}
+	for _, in := range ids {
+		left, _, hasLeft := in.LeftRight()
+		switch {
+		case hasLeft && strings.ToLower(left) == m.Qualifier():
+		case !hasLeft && len(stmt.From) == 1:
 		default:
-			//u.Warnf("un-implemented op: %#v", nt)
+			return false
 		}
-	default:
-		u.Warnf("%T node types are not suppored yet for where rewrite", node)
 	}
-	//u.Warnf("nil?? %T  %s  %#v", node, node, node)
-	return nil, cols
+	return true
+}
+
+// SourceExpr a copy of node, an expression of the columns of source m, with
+// its identities qualified by the alias of m un-qualified, as an expression of
+// the columns of the stand-alone statement of m.
+func (m *SqlSource) SourceExpr(node expr.Node) expr.Node {
+	alias := m.Qualifier()
+	copyArgs := func(args []expr.Node) []expr.Node {
+		nargs := make([]expr.Node, len(args))
+		for i, arg := range args {
+			nargs[i] = m.SourceExpr(arg)
+		}
+		return nargs
+	}
+	switch nt := node.(type) {
+	case nil:
+		return nil
+	case *expr.IdentityNode:
+		if left, right, hasLeft := nt.LeftRight(); hasLeft && strings.ToLower(left) == alias {
+			return expr.NewIdentityNodeVal(right)
+		}
+	case *expr.BinaryNode:
+		nn := *nt
+		nn.Args = copyArgs(nt.Args)
+		return &nn
+	case *expr.BooleanNode:
+		nn := *nt
+		nn.Args = copyArgs(nt.Args)
+		return &nn
+	case *expr.TriNode:
+		nn := *nt
+		nn.Args = copyArgs(nt.Args)
+		return &nn
+	case *expr.ArrayNode:
+		nn := *nt
+		nn.Args = copyArgs(nt.Args)
+		return &nn
+	case *expr.FuncNode:
+		nn := *nt
+		nn.Args = copyArgs(nt.Args)
+		return &nn
+	case *expr.UnaryNode:
+		nn := *nt
+		nn.Arg = m.SourceExpr(nt.Arg)
+		return &nn
+	case *expr.CaseNode:
+		nn := *nt
+		nn.Arg = m.SourceExpr(nt.Arg)
+		nn.Whens = copyArgs(nt.Whens)
+		nn.Thens = copyArgs(nt.Thens)
+		nn.Else = m.SourceExpr(nt.Else)
+		return &nn
+	}
+	return node
 }
 
 func joinNodesForFrom(stmt *SqlSelect, from *SqlSource, node expr.Node, depth int) expr.Node {
@@ -323,53 +451,26 @@ func columnsFromJoin(from *SqlSource, node expr.Node, cols Columns) Columns {
 		return cols
 	}
 	//u.Debugf("columnsFromJoin()  T:%T  node=%q", node, node.String())
-	switch nt := node.(type) {
-	case *expr.IdentityNode:
-		if left, right, ok := nt.LeftRight(); ok {
-			//u.Debugf("from.Name:%v AS %v   Joinnode l:%v  r:%v    %#v", from.Name, from.alias, left, right, nt)
-			//u.Warnf("check cols against join expr arg: %#v", nt)
-			if left == from.alias {
-				found := false
-				for _, col := range cols {
-					colLeft, colRight, _ := col.LeftRight()
-					//u.Debugf("left='%s'  colLeft='%s' right='%s'  %#v", left, colLeft, colRight,  col)
-					//u.Debugf("col:  From %s AS '%s'   '%s'.'%s'  JoinExpr: '%v'.'%v' col:%#v", from.Name, from.alias, colLeft, colRight, left, right, col)
-					if left == colLeft || colRight == right {
-						found = true
-						//u.Infof("columnsFromJoin from.Name:%v l:%v  r:%v", from.alias, left, right)
-					} else {
-						//u.Warnf("not? from.Name:%v l:%v  r:%v   col: P:%p %#v", from.alias, left, right, col, col)
-					}
-				}
-				if !found {
-					//u.Debugf("columnsFromJoin from.Name:%v l:%v  r:%v", from.alias, left, right)
-					newCol := &Column{As: right, SourceField: right, Expr: &expr.IdentityNode{Text: right}}
-					newCol.Index = len(cols)
-					newCol.ParentIndex = -1 // if -1, we don't need in parent index
-					cols = append(cols, newCol)
-					//u.Warnf("added col %s idx:%d pidx:%v", right, newCol.Index, newCol.Index)
-				}
-			}
+	for _, in := range expr.FindAllIdentities(node) {
+		left, right, ok := in.LeftRight()
+		if !ok || left != from.alias {
+			continue
 		}
-	case *expr.FuncNode:
-		//u.Warnf("columnsFromJoin func node: %s", nt.String())
-		for _, arg := range nt.Args {
-			cols = columnsFromJoin(from, arg, cols)
+		found := false
+		for _, col := range cols {
+			colLeft, colRight, _ := col.LeftRight()
+			if left == colLeft || colRight == right {
+				found = true
+				break
+			}
 		}
-	case *expr.BinaryNode:
-		switch nt.Operator.T {
-		case lex.TokenAnd, lex.TokenLogicAnd, lex.TokenLogicOr:
-			cols = columnsFromJoin(from, nt.Args[0], cols)
-			cols = columnsFromJoin(from, nt.Args[1], cols)
-		case lex.TokenEqual, lex.TokenEqualEqual:
-			cols = columnsFromJoin(from, nt.Args[0], cols)
-			cols = columnsFromJoin(from, nt.Args[1], cols)
-		default:
-			u.Warnf("un-implemented op: %v", nt.Operator)
+		if !found {
+			//u.Debugf("columnsFromJoin from.Name:%v l:%v  r:%v", from.alias, left, right)
+			newCol := &Column{As: right, SourceField: right, Expr: &expr.IdentityNode{Text: right}}
+			newCol.Index = len(cols)
+			newCol.ParentIndex = -1 // if -1, not projected, RewriteSqlSource gives it an index
+			cols = append(cols, newCol)
 		}
-	default:
-		u.LogTracef(u.INFO, "whoops")
-		u.Warnf("%T node types are not suppored yet for join rewrite %s", node, from.String())
 	}
 	return cols
 }
@@ -425,3 +526,393 @@ func rewriteNode(from *SqlSource, node expr.Node) expr.Node {
 	}
 	return nil
 }
+
+// RewriteSubSelect rewrite the sub-select of the where of stmt so it can be run
+// once, independent of the rows of stmt, for a semi-join.  Returns the new
+// sub-select and for a correlated one the expressions of stmt rows to compare
+// to its correlated columns.
+//
+// Conditions of a correlated sub-select comparing one of its expressions to
+// one of stmt (qualified by the alias of a source of stmt) are removed from
+// its where, and its side added to the columns after the IN column.
+//
+//    SELECT * FROM users AS u
+//    WHERE EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id AND o.price > 10)
+//
+//    =>  SELECT o.user_id AS corr_0 FROM orders AS o WHERE o.price > 10
+//        u.user_id
+func RewriteSubSelect(stmt *SqlSelect) (*SqlSelect, []expr.Node, error) {
+	w := stmt.Where
+	if w == nil || w.Source == nil {
+		return nil, nil, fmt.Errorf("no sub-select in where")
+	}
+	sub := w.Source
+	conds, innerNodes, outerNodes, err := decorrelate(stmt, sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(outerNodes) == 0 {
+		return sub, nil, nil
+	}
+	if sub.IsAggQuery() || len(sub.GroupBy) > 0 || sub.Having != nil || sub.Limit > 0 {
+		return nil, nil, fmt.Errorf("correlated sub-select with GROUP BY, aggregates or LIMIT is not supported: %s", sub)
+	}
+
+	rw := sub.Copy()
+	rw.Columns = make(Columns, 0, len(innerNodes)+1)
+	if w.Op != lex.TokenExists && len(sub.Columns) > 0 {
+		rw.Columns = append(rw.Columns, sub.Columns[0])
+	}
+	for i, n := range innerNodes {
+		as := fmt.Sprintf("corr_%d", i)
+		rw.Columns = append(rw.Columns, &Column{As: as, originalAs: as, Expr: n})
+	}
+	rw.Star = false
+	rw.OrderBy = nil
+	rw.Where = uncorrelatedWhere(sub.Where, conds)
+	sel, err := ParseSqlSelect(rw.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return sel, outerNodes, nil
+}
+
+// RewriteScalarSubSelect rewrite sub, a sub-select used as a value in an
+// expression of stmt, removing its correlated conditions.  Returns the new
+// sub-select and for a correlated one its expressions, and the expressions
+// of stmt rows they equal.  Evaluating it for a row adds the conditions back
+// comparing to the values of the row.
+//
+//    SELECT u.email, (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id AND o.price > 10)
+//    FROM users AS u
+//
+//    =>  SELECT count(*) FROM orders AS o WHERE o.price > 10
+//        o.user_id
+//        u.user_id
+func RewriteScalarSubSelect(stmt, sub *SqlSelect) (*SqlSelect, []expr.Node, []expr.Node, error) {
+	conds, innerNodes, outerNodes, err := decorrelate(stmt, sub)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(outerNodes) == 0 {
+		return sub, nil, nil, nil
+	}
+	rw := sub.Copy()
+	rw.Where = uncorrelatedWhere(sub.Where, conds)
+	sel, err := ParseSqlSelect(rw.String())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return sel, innerNodes, outerNodes, nil
+}
+
+// decorrelate split the where of sub, a sub-select of stmt, into its
+// uncorrelated conditions and those comparing one of its expressions to
+// one of stmt (inner = outer).  Errors on any other reference to stmt.
+func decorrelate(stmt, sub *SqlSelect) (conds, innerNodes, outerNodes []expr.Node, err error) {
+	outer, inner := sourceAliases(stmt.From), sourceAliases(sub.From)
+
+	conds = make([]expr.Node, 0)
+	if sub.Where != nil {
+		if sub.Where.Left != nil && hasOuterIdentity(sub.Where.Left, outer, inner) {
+			return nil, nil, nil, fmt.Errorf("correlated sub-select only supports = comparison to outer columns: %s", sub.Where.Left)
+		}
+		for _, cond := range splitAnd(sub.Where.Expr) {
+			if bn, ok := cond.(*expr.BinaryNode); ok && (bn.Operator.T == lex.TokenEqual || bn.Operator.T == lex.TokenEqualEqual) {
+				lo := hasOuterIdentity(bn.Args[0], outer, inner)
+				ro := hasOuterIdentity(bn.Args[1], outer, inner)
+				if lo && !ro {
+					outerNodes = append(outerNodes, bn.Args[0])
+					innerNodes = append(innerNodes, bn.Args[1])
+					continue
+				} else if ro && !lo {
+					outerNodes = append(outerNodes, bn.Args[1])
+					innerNodes = append(innerNodes, bn.Args[0])
+					continue
+				}
+			}
+			if hasOuterIdentity(cond, outer, inner) {
+				return nil, nil, nil, fmt.Errorf("correlated sub-select only supports = comparison to outer columns: %s", cond)
+			}
+			conds = append(conds, cond)
+		}
+	}
+	for _, col := range sub.Columns {
+		if col.Expr != nil && hasOuterIdentity(col.Expr, outer, inner) {
+			return nil, nil, nil, fmt.Errorf("sub-select column may not refer to outer select: %s", col)
+		}
+	}
+	return conds, innerNodes, outerNodes, nil
+}
+
+// uncorrelatedWhere the where of a sub-select with only its uncorrelated
+// conditions, keeping its own IN or EXISTS sub-select.
+func uncorrelatedWhere(w *SqlWhere, conds []expr.Node) *SqlWhere {
+	if len(conds) == 0 && w.Source == nil {
+		return nil
+	}
+	rw := &SqlWhere{Expr: andNodes(conds)}
+	if w.Source != nil {
+		rw.Op = w.Op
+		rw.Negate = w.Negate
+		rw.Left = w.Left
+		rw.Source = w.Source
+	}
+	return rw
+}
+
+// subSelectIdentities the identities of the outer select the sub-selects
+// use, ie the left side of IN and those the sub-select of the where, or
+// sub-selects used as values, are correlated on.
+func subSelectIdentities(stmt *SqlSelect) expr.IdentityNodes {
+	var ids expr.IdentityNodes
+	outer := sourceAliases(stmt.From)
+	if w := stmt.Where; w != nil && w.Source != nil {
+		ids = append(ids, expr.FindAllIdentities(w.Left)...)
+		ids = append(ids, outerIdentities(w.Source, outer)...)
+	}
+	for _, node := range stmt.SubSelects() {
+		if sub, ok := node.Stmt.(*SqlSelect); ok {
+			ids = append(ids, outerIdentities(sub, outer)...)
+		}
+	}
+	return ids
+}
+
+// outerIdentities the identities of the where of sub-select sub qualified
+// by one of the outer source aliases.
+func outerIdentities(sub *SqlSelect, outer map[string]bool) expr.IdentityNodes {
+	if sub.Where == nil {
+		return nil
+	}
+	var ids expr.IdentityNodes
+	inner := sourceAliases(sub.From)
+	for _, in := range expr.FindAllIdentities(sub.Where.Expr) {
+		if hasOuterIdentity(in, outer, inner) {
+			ids = append(ids, in)
+		}
+	}
+	return ids
+}
+
+// lower cased aliases, or names if not aliased, of sources
+func sourceAliases(from []*SqlSource) map[string]bool {
+	aliases := make(map[string]bool, len(from))
+	for _, src := range from {
+		aliases[src.Qualifier()] = true
+	}
+	return aliases
+}
+
+// nodeAliases the lower cased source aliases of the identities of node, ok
+// is false if any of them is not qualified by one.
+func nodeAliases(node expr.Node) (aliases map[string]bool, ok bool) {
+	aliases = make(map[string]bool)
+	for _, in := range expr.FindAllIdentities(node) {
+		left, _, hasLeft := in.LeftRight()
+		if !hasLeft {
+			return aliases, false
+		}
+		aliases[strings.ToLower(left)] = true
+	}
+	return aliases, true
+}
+
+// IsEquiJoin is the ON of source m only = conditions, each comparing an
+// expression of m to one of a source before it.  Rows of both sides can
+// then be hashed by the values of their expressions to join.
+func (m *SqlSource) IsEquiJoin() bool {
+	if m.JoinExpr == nil {
+		return false
+	}
+	alias := m.Qualifier()
+	for _, cond := range splitAnd(m.JoinExpr) {
+		bn, ok := cond.(*expr.BinaryNode)
+		if !ok || (bn.Operator.T != lex.TokenEqual && bn.Operator.T != lex.TokenEqualEqual) {
+			return false
+		}
+		la, lok := nodeAliases(bn.Args[0])
+		ra, rok := nodeAliases(bn.Args[1])
+		if !lok || !rok || len(la) != 1 || len(ra) != 1 || la[alias] == ra[alias] {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinOrder the order to join the sources of stmt, and for each the
+// condition joining it to the sources before it, nil for a cross join.
+//
+// With only inner and cross joins the ON and WHERE conditions all filter
+// the joined rows, so each condition comparing sources joins the source at
+// which all of its sources have been joined.  The next source joined is the
+// first with a condition on those before it, so cross joins are only used
+// if there is no such source.
+//
+//    SELECT ... FROM users AS u, items AS i, orders AS o
+//    WHERE u.user_id = o.user_id AND o.item_id = i.item_id
+//
+//    =>  users AS u
+//        orders AS o   u.user_id = o.user_id
+//        items AS i    o.item_id = i.item_id
+//
+// With outer joins the sources are joined in the order written, on their
+// ON conditions.
+func JoinOrder(stmt *SqlSelect) ([]*SqlSource, []expr.Node) {
+	return JoinOrderEstimated(stmt, nil)
+}
+
+// JoinOrderEstimated the order to join the sources of stmt as JoinOrder,
+// using rows, the estimated rows of each source, false if not known.  If
+// all of the sources of an inner join are estimated the largest is joined
+// first, as it is streamed through those joined to it, and the next source
+// joined is the smallest with a condition on those before it.
+func JoinOrderEstimated(stmt *SqlSelect, rows func(from *SqlSource) (float64, bool)) ([]*SqlSource, []expr.Node) {
+	order := make([]*SqlSource, 0, len(stmt.From))
+	conds := make([]expr.Node, 0, len(stmt.From))
+	for _, from := range stmt.From {
+		if from.LeftOrRight != 0 {
+			for _, from := range stmt.From {
+				order = append(order, from)
+				conds = append(conds, from.JoinExpr)
+			}
+			conds[0] = nil
+			return order, conds
+		}
+	}
+
+	type joinCond struct {
+		node    expr.Node
+		aliases map[string]bool
+		used    bool
+	}
+	pending := make([]*joinCond, 0)
+	for _, from := range stmt.From {
+		for _, node := range splitAnd(from.JoinExpr) {
+			aliases, ok := nodeAliases(node)
+			if !ok {
+				// not known which sources, evaluated once all joined
+				aliases = sourceAliases(stmt.From)
+			}
+			pending = append(pending, &joinCond{node: node, aliases: aliases})
+		}
+	}
+	if stmt.Where != nil && stmt.Where.Expr != nil {
+		for _, node := range splitAnd(stmt.Where.Expr) {
+			aliases, ok := nodeAliases(node)
+			if ok && len(aliases) > 1 && len(expr.FindAllSubSelects(node)) == 0 {
+				pending = append(pending, &joinCond{node: node, aliases: aliases})
+			}
+		}
+	}
+
+	// placeable are all of the sources of cond joined once src is
+	placeable := func(cond *joinCond, src string, joined map[string]bool) bool {
+		if cond.used {
+			return false
+		}
+		for alias := range cond.aliases {
+			if alias != src && !joined[alias] {
+				return false
+			}
+		}
+		return true
+	}
+	var estimates map[*SqlSource]float64
+	if rows != nil {
+		estimates = make(map[*SqlSource]float64, len(stmt.From))
+		for _, from := range stmt.From {
+			est, ok := rows(from)
+			if !ok {
+				estimates = nil
+				break
+			}
+			estimates[from] = est
+		}
+	}
+	first := 0
+	for i, from := range stmt.From {
+		if estimates != nil && estimates[from] > estimates[stmt.From[first]] {
+			first = i
+		}
+	}
+
+	joined := map[string]bool{stmt.From[first].Qualifier(): true}
+	order = append(order, stmt.From[first])
+	conds = append(conds, nil)
+	remaining := append([]*SqlSource{}, stmt.From[:first]...)
+	remaining = append(remaining, stmt.From[first+1:]...)
+	for len(remaining) > 0 {
+		next := -1
+		for i, from := range remaining {
+			if next >= 0 && (estimates == nil || estimates[from] >= estimates[remaining[next]]) {
+				continue
+			}
+			alias := from.Qualifier()
+			for _, cond := range pending {
+				if len(cond.aliases) > 1 && cond.aliases[alias] && placeable(cond, alias, joined) {
+					next = i
+					break
+				}
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+		from := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+
+		alias := from.Qualifier()
+		nodes := make([]expr.Node, 0)
+		for _, cond := range pending {
+			if placeable(cond, alias, joined) {
+				cond.used = true
+				nodes = append(nodes, cond.node)
+			}
+		}
+		joined[alias] = true
+		order = append(order, from)
+		conds = append(conds, andNodes(nodes))
+	}
+	return order, conds
+}
+
+// hasOuterIdentity does node have an identity qualified by an outer source
+// alias not shadowed by an inner one.
+func hasOuterIdentity(node expr.Node, outer, inner map[string]bool) bool {
+	for _, in := range expr.FindAllIdentities(node) {
+		left, _, hasLeft := in.LeftRight()
+		left = strings.ToLower(left)
+		if hasLeft && outer[left] && !inner[left] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAnd split node into the conditions AND'd together.
+func splitAnd(node expr.Node) []expr.Node {
+	if bn, ok := node.(*expr.BinaryNode); ok {
+		switch bn.Operator.T {
+		case lex.TokenAnd, lex.TokenLogicAnd:
+			return append(splitAnd(bn.Args[0]), splitAnd(bn.Args[1])...)
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	return []expr.Node{node}
+}
+
+// andNodes AND conditions together, nil if none.
+func andNodes(nodes []expr.Node) expr.Node {
+	var node expr.Node
+	for _, n := range nodes {
+		if node == nil {
+			node = n
+			continue
+		}
+		node = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, node, n)
+	}
+	return node
+}
@@ -110,6 +110,7 @@ type (
 		tblID          uint64                 // internal tableid, hash of table name + schema?
 		cols           []string               // array of column names
 		lastRefreshed  time.Time              // Last time we refreshed this schema
+		stats          *TableStats            // optional statistics collected by ANALYZE TABLE
 		rows           [][]driver.Value
 	}
 
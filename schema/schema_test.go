@@ -3,6 +3,7 @@ package schema_test
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"os"
 	"sort"
 	"testing"
@@ -230,6 +231,52 @@ func TestTable(t *testing.T) {
 	assert.NotEqual(t, nil, tbl.Body())
 	assert.Equal(t, uint64(0), tbl.Id())
 }
+func TestTableStats(t *testing.T) {
+	tbl := schema.NewTable("stats")
+	assert.True(t, tbl.Stats() == nil)
+
+	sb := schema.NewTableStatsBuilder([]string{"id", "Name", "score"})
+	for i := 0; i < 100; i++ {
+		var score driver.Value
+		if i%4 != 0 {
+			score = float64(i)
+		}
+		sb.Add([]driver.Value{int64(i), fmt.Sprintf("name%d", i%10), score})
+	}
+	version := schema.StatsVersion()
+	tbl.SetStats(sb.Stats())
+	assert.NotEqual(t, version, schema.StatsVersion())
+
+	stats := tbl.Stats()
+	assert.Equal(t, int64(100), stats.RowCount)
+	id := stats.Column("id")
+	assert.Equal(t, int64(100), id.Distinct)
+	assert.Equal(t, int64(0), id.Min)
+	assert.Equal(t, int64(99), id.Max)
+	assert.Equal(t, schema.StatsHistogramBuckets+1, len(id.Histogram))
+	assert.Equal(t, 0.01, id.EqualSelectivity())
+	less, ok := id.LessSelectivity(50)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.5, less, 0.02)
+	less, _ = id.LessSelectivity(-1)
+	assert.Equal(t, 0.0, less)
+
+	name := stats.Column("NAME")
+	assert.Equal(t, int64(10), name.Distinct)
+	assert.Equal(t, "name0", name.Min)
+	assert.Equal(t, "name9", name.Max)
+	assert.True(t, name.Histogram == nil)
+	_, ok = name.LessSelectivity(5)
+	assert.True(t, !ok)
+
+	score := stats.Column("score")
+	assert.Equal(t, 0.25, score.NullFraction)
+	assert.Equal(t, int64(75), score.Distinct)
+	less, _ = score.LessSelectivity(1000)
+	assert.Equal(t, 0.75, less)
+	assert.True(t, stats.Column("age") == nil)
+}
+
 func TestFields(t *testing.T) {
 	f := schema.NewFieldBase("Field", value.StringType, 64, "string")
 	assert.NotEqual(t, nil, f)
@@ -0,0 +1,259 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// StatsHistogramBuckets the number of equi-depth buckets of the histograms
+	// of numeric columns collected by ANALYZE TABLE.
+	StatsHistogramBuckets = 16
+
+	// guards the stats of all tables, replaced as a whole by ANALYZE TABLE
+	statsMu sync.RWMutex
+	// changed each time the stats of a table are set
+	statsVersion uint64
+)
+
+type (
+	// TableStats optional statistics of the rows of a table, collected by
+	// ANALYZE TABLE scanning its source, used by the planner to estimate the
+	// rows of plans.
+	TableStats struct {
+		RowCount int64                   // rows in table
+		Columns  map[string]*ColumnStats // stats per lower cased column name
+		Analyzed time.Time               // when collected
+	}
+	// ColumnStats statistics of the values of a column.
+	ColumnStats struct {
+		Distinct     int64        // distinct non-null values
+		NullFraction float64      // fraction of rows that are null
+		Min          driver.Value // smallest non-null value
+		Max          driver.Value // largest non-null value
+		// Histogram the bounds of equi-depth buckets of numeric columns, each
+		// bucket having the same number of rows, nil for other columns.
+		Histogram []float64
+	}
+	// TableStatsBuilder collects the TableStats of the rows of a table.
+	TableStatsBuilder struct {
+		cols  []string
+		rowCt int64
+		stats []*columnStatsBuilder
+	}
+	columnStatsBuilder struct {
+		distinct map[uint64]struct{}
+		nullCt   int64
+		min, max driver.Value
+		nums     []float64
+		numeric  bool
+	}
+)
+
+// Stats the statistics of this table, nil if it has not been analyzed.
+func (m *Table) Stats() *TableStats {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+	return m.stats
+}
+
+// SetStats set the statistics of this table.
+func (m *Table) SetStats(stats *TableStats) {
+	statsMu.Lock()
+	m.stats = stats
+	statsVersion++
+	statsMu.Unlock()
+}
+
+// StatsVersion the version of the stats of all tables, changed each time
+// the stats of a table are set.  Plans made from the stats of an older
+// version should be planned again.
+func StatsVersion() uint64 {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+	return statsVersion
+}
+
+// Column the stats of column name, nil if none.
+func (m *TableStats) Column(name string) *ColumnStats {
+	if m == nil {
+		return nil
+	}
+	return m.Columns[strings.ToLower(name)]
+}
+
+// EqualSelectivity estimated fraction of rows equal to a value.
+func (m *ColumnStats) EqualSelectivity() float64 {
+	if m.Distinct <= 0 {
+		return 0
+	}
+	return (1 - m.NullFraction) / float64(m.Distinct)
+}
+
+// LessSelectivity estimated fraction of rows less than x, interpolating
+// within the histogram bucket of x, or between min and max if there is no
+// histogram.  Returns false if the column isn't numeric.
+func (m *ColumnStats) LessSelectivity(x float64) (float64, bool) {
+	bounds := m.Histogram
+	if len(bounds) < 2 {
+		min, minOk := statsFloat(m.Min)
+		max, maxOk := statsFloat(m.Max)
+		if !minOk || !maxOk {
+			return 0, false
+		}
+		bounds = []float64{min, max}
+	}
+	notNull := 1 - m.NullFraction
+	switch {
+	case x <= bounds[0]:
+		return 0, true
+	case x > bounds[len(bounds)-1]:
+		return notNull, true
+	}
+	buckets := float64(len(bounds) - 1)
+	for i := 1; i < len(bounds); i++ {
+		if x > bounds[i] {
+			continue
+		}
+		frac := 1.0
+		if width := bounds[i] - bounds[i-1]; width > 0 {
+			frac = (x - bounds[i-1]) / width
+		}
+		return notNull * (float64(i-1) + frac) / buckets, true
+	}
+	return notNull, true
+}
+
+// NewTableStatsBuilder collects stats of rows with values of columns cols.
+func NewTableStatsBuilder(cols []string) *TableStatsBuilder {
+	m := &TableStatsBuilder{cols: cols, stats: make([]*columnStatsBuilder, len(cols))}
+	for i := range cols {
+		m.stats[i] = &columnStatsBuilder{distinct: make(map[uint64]struct{}), numeric: true}
+	}
+	return m
+}
+
+// Add a row, its values in the order of the columns.  Empty strings, the
+// missing values of csv and similar sources, are counted as null.
+func (m *TableStatsBuilder) Add(vals []driver.Value) {
+	m.rowCt++
+	for i, cs := range m.stats {
+		if i >= len(vals) || vals[i] == nil || vals[i] == "" {
+			cs.nullCt++
+			continue
+		}
+		cs.add(vals[i])
+	}
+}
+
+// Stats the collected stats.
+func (m *TableStatsBuilder) Stats() *TableStats {
+	ts := &TableStats{
+		RowCount: m.rowCt,
+		Columns:  make(map[string]*ColumnStats, len(m.cols)),
+		Analyzed: time.Now(),
+	}
+	for i, col := range m.cols {
+		cs := m.stats[i]
+		stats := &ColumnStats{Distinct: int64(len(cs.distinct)), Min: cs.min, Max: cs.max}
+		if m.rowCt > 0 {
+			stats.NullFraction = float64(cs.nullCt) / float64(m.rowCt)
+		}
+		if cs.numeric && len(cs.nums) > 0 {
+			stats.Histogram = equiDepthBounds(cs.nums, StatsHistogramBuckets)
+		}
+		ts.Columns[strings.ToLower(col)] = stats
+	}
+	return ts
+}
+
+func (m *columnStatsBuilder) add(v driver.Value) {
+	h := fnv.New64a()
+	switch vt := v.(type) {
+	case string:
+		h.Write([]byte(vt))
+	case []byte:
+		h.Write(vt)
+	default:
+		fmt.Fprintf(h, "%v", vt)
+	}
+	m.distinct[h.Sum64()] = struct{}{}
+
+	if m.min == nil || statsLess(v, m.min) {
+		m.min = v
+	}
+	if m.max == nil || statsLess(m.max, v) {
+		m.max = v
+	}
+	if !m.numeric {
+		return
+	}
+	if f, ok := statsFloat(v); ok {
+		m.nums = append(m.nums, f)
+	} else {
+		m.numeric = false
+		m.nums = nil
+	}
+}
+
+// equiDepthBounds the bounds of buckets of sorted nums with the same number
+// of values each.
+func equiDepthBounds(nums []float64, buckets int) []float64 {
+	sort.Float64s(nums)
+	if buckets > len(nums) {
+		buckets = len(nums)
+	}
+	if buckets < 1 {
+		buckets = 1
+	}
+	bounds := make([]float64, buckets+1)
+	for i := 0; i < buckets; i++ {
+		bounds[i] = nums[i*len(nums)/buckets]
+	}
+	bounds[buckets] = nums[len(nums)-1]
+	return bounds
+}
+
+// statsLess is a less than b, comparing numbers, times and otherwise their
+// string values.
+func statsLess(a, b driver.Value) bool {
+	af, aOk := statsFloat(a)
+	bf, bOk := statsFloat(b)
+	if aOk && bOk {
+		return af < bf
+	}
+	at, aOk := a.(time.Time)
+	bt, bOk := b.(time.Time)
+	if aOk && bOk {
+		return at.Before(bt)
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// statsFloat the float value of a number, or string of a number.
+func statsFloat(v driver.Value) (float64, bool) {
+	switch vt := v.(type) {
+	case int:
+		return float64(vt), true
+	case int32:
+		return float64(vt), true
+	case int64:
+		return float64(vt), true
+	case uint64:
+		return float64(vt), true
+	case float32:
+		return float64(vt), true
+	case float64:
+		return vt, true
+	case string:
+		f, err := strconv.ParseFloat(vt, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -94,6 +94,10 @@ func RunTestSuite(t TestingT) {
 	TestSelect(t, "SELECT email FROM users WHERE interests != NULL)",
 		[][]driver.Value{{"aaron@email.com"}, {"bob@email.com"}},
 	)
+	// - literal only expressions of where evaluated once
+	TestSelect(t, `SELECT email FROM users WHERE 1 = 1 AND 50 < referral_count AND referral_count > toint("5") + 2`,
+		[][]driver.Value{{"aaron@email.com"}},
+	)
 	TestSelect(t, "SELECT email FROM users WHERE (`users`.`email` like \"%aaron%\");",
 		[][]driver.Value{{"aaron@email.com"}},
 	)
@@ -123,7 +127,74 @@ func RunTestSuite(t TestingT) {
 
 	// Distinct keyword
 	TestSelect(t, "SELECT COUNT(DISTINCT(`users.email`)) AS cd FROM users",
-		[][]driver.Value{{int64(0)}},
+		[][]driver.Value{{int64(3)}},
+	)
+	TestSelect(t, "SELECT COUNT(DISTINCT(`users.user_id`)) AS cd FROM users",
+		[][]driver.Value{{int64(3)}},
+	)
+	TestSelect(t, "SELECT count(DISTINCT user_id) AS cd, count(user_id) AS ct FROM orders",
+		[][]driver.Value{{int64(2), int64(3)}},
+	)
+	TestSelect(t, "SELECT item_id, count(DISTINCT user_id) AS cd FROM orders GROUP BY item_id ORDER BY item_id ASC",
+		[][]driver.Value{{"1", int64(2)}, {"2", int64(1)}},
+	)
+	TestSelect(t, "SELECT DISTINCT user_id FROM orders ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}, {"abcabcabc"}},
+	)
+	TestSelect(t, "SELECT DISTINCT item_id, price FROM orders ORDER BY item_id ASC",
+		[][]driver.Value{{"1", "22.50"}, {"2", "37.50"}},
+	)
+	TestSelect(t, "SELECT DISTINCT user_id FROM orders ORDER BY user_id ASC LIMIT 1",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}},
+	)
+
+	// Aggregate funcs
+	TestSelect(t, "SELECT min(price) AS mn, max(price) AS mx, max(toint(item_id)) AS mxi FROM orders",
+		[][]driver.Value{{"22.50", "37.50", int64(2)}},
+	)
+	TestSelect(t, "SELECT stddev(price) AS sd, variance(price) AS v, median(price) AS md FROM orders",
+		[][]driver.Value{{float64(7.0710678118654755), float64(50), float64(22.5)}},
+	)
+	TestSelect(t, "SELECT user_id, group_concat(item_id, \"|\") AS items, count(DISTINCT item_id) AS cd, avg(price) AS a FROM orders GROUP BY user_id ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", "1|2", int64(2), float64(30)}, {"abcabcabc", "1", int64(1), float64(22.5)}},
+	)
+
+	// Aggregates inside of expressions, having and order by
+	TestSelect(t, "SELECT sum(price) / count(*) AS avg_order FROM orders",
+		[][]driver.Value{{float64(27.5)}},
+	)
+	TestSelect(t, "SELECT user_id, round(avg(price) / 7, 2) AS a, count(*) * 10 AS c FROM orders GROUP BY user_id ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", float64(4.29), int64(20)}, {"abcabcabc", float64(3.21), int64(10)}},
+	)
+	TestSelect(t, "SELECT user_id FROM orders GROUP BY user_id HAVING count(*) > 1",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}},
+	)
+	TestSelect(t, `SELECT CASE WHEN count(*) > 1 THEN "many" ELSE "one" END AS k, user_id FROM orders GROUP BY user_id ORDER BY user_id ASC`,
+		[][]driver.Value{{"many", "9Ip1aKbeZe2njCDM"}, {"one", "abcabcabc"}},
+	)
+	TestSelect(t, "SELECT item_id, count(*) AS ct FROM orders GROUP BY item_id ORDER BY sum(price) ASC",
+		[][]driver.Value{{"2", int64(1)}, {"1", int64(2)}},
+	)
+
+	// Subtotals and grand totals of grouping sets
+	TestSelect(t, "SELECT user_id, item_id, sum(price) AS s, grouping(user_id, item_id) AS g FROM orders GROUP BY ROLLUP (user_id, item_id) ORDER BY user_id ASC, item_id ASC",
+		[][]driver.Value{
+			{nil, nil, float64(82.5), int64(3)},
+			{"9Ip1aKbeZe2njCDM", nil, float64(60), int64(1)},
+			{"9Ip1aKbeZe2njCDM", "1", float64(22.5), int64(0)},
+			{"9Ip1aKbeZe2njCDM", "2", float64(37.5), int64(0)},
+			{"abcabcabc", nil, float64(22.5), int64(1)},
+			{"abcabcabc", "1", float64(22.5), int64(0)},
+		},
+	)
+	TestSelect(t, "SELECT item_id, count(*) AS ct FROM orders GROUP BY CUBE (user_id, item_id) HAVING grouping(user_id) = 1 ORDER BY item_id ASC",
+		[][]driver.Value{{nil, int64(3)}, {"1", int64(2)}, {"2", int64(1)}},
+	)
+	TestSelect(t, "SELECT user_id, count(*) AS ct FROM orders GROUP BY GROUPING SETS ((user_id), ()) HAVING count(*) > 1 ORDER BY ct DESC",
+		[][]driver.Value{{nil, int64(3)}, {"9Ip1aKbeZe2njCDM", int64(2)}},
+	)
+	TestSelect(t, "SELECT user_id, count(*) AS ct FROM orders WHERE price > 100 GROUP BY ROLLUP (user_id)",
+		[][]driver.Value{{nil, int64(0)}},
 	)
 
 	TestSelect(t, "SELECT email FROM users ORDER BY email DESC",
@@ -132,17 +203,225 @@ func RunTestSuite(t TestingT) {
 	TestSelect(t, "SELECT email FROM users ORDER BY email ASC",
 		[][]driver.Value{{"aaron@email.com"}, {"bob@email.com"}, {"not_an_email_2"}},
 	)
+	// default order is ascending
+	TestSelect(t, "SELECT email FROM users ORDER BY email",
+		[][]driver.Value{{"aaron@email.com"}, {"bob@email.com"}, {"not_an_email_2"}},
+	)
+	TestSelect(t, "SELECT order_id FROM orders ORDER BY user_id DESC, toint(item_id) ASC",
+		[][]driver.Value{{"3"}, {"1"}, {"2"}},
+	)
 
 	// This is an error because we have schema on this table, and this column
 	// doesn't exist.
 	TestSelectErr(t, "SELECT email, non_existent_field FROM users ORDER BY email ASC", nil)
 
-	/*
-		// TODO: #56 DISTINCT inside count()
-		testutil.TestSelect(t, "SELECT COUNT(DISTINCT(`users.user_id`)) AS cd FROM users",
-			[][]driver.Value{{int64(3)}},
-		)
+	// Compound selects, ORDER BY applies to combined result
+	TestSelect(t, "SELECT user_id FROM users UNION SELECT user_id FROM orders ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}, {"abcabcabc"}, {"hT2impsOPUREcVPc"}, {"hT2impsabc345c"}},
+	)
+	TestSelect(t, "SELECT user_id FROM users UNION ALL SELECT user_id FROM orders",
+		[][]driver.Value{{"hT2impsabc345c"}, {"9Ip1aKbeZe2njCDM"}, {"hT2impsOPUREcVPc"},
+			{"9Ip1aKbeZe2njCDM"}, {"abcabcabc"}, {"9Ip1aKbeZe2njCDM"}},
+	)
+	TestSelect(t, "SELECT user_id FROM users INTERSECT SELECT user_id FROM orders",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}},
+	)
+	TestSqlSelect(t, "mockcsv", "SELECT user_id FROM users INTERSECT SELECT user_id FROM orders",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM"}},
+	)
+	TestSelect(t, "SELECT user_id FROM users EXCEPT SELECT user_id FROM orders ORDER BY user_id ASC",
+		[][]driver.Value{{"hT2impsOPUREcVPc"}, {"hT2impsabc345c"}},
+	)
+	TestSelect(t, "SELECT 1 AS a UNION SELECT 2 UNION SELECT 1 ORDER BY a DESC",
+		[][]driver.Value{{int64(2)}, {int64(1)}},
+	)
+	// rows are equal by typed value, an int and float number but not a string
+	TestSelect(t, `SELECT 1 AS a UNION SELECT tonumber("1") UNION SELECT "1"`,
+		[][]driver.Value{{int64(1)}, {"1"}},
+	)
+	TestSelect(t, `SELECT toint(item_id) AS i FROM orders EXCEPT SELECT tonumber(item_id) FROM orders`,
+		[][]driver.Value{},
+	)
+	TestSelect(t, `SELECT item_id AS i FROM orders INTERSECT SELECT toint(item_id) FROM orders`,
+		[][]driver.Value{},
+	)
+	TestSelect(t, "SELECT user_id FROM users UNION ALL SELECT user_id FROM orders LIMIT 10 OFFSET 4",
+		[][]driver.Value{{"abcabcabc"}, {"9Ip1aKbeZe2njCDM"}},
+	)
+
+	// Case expressions
+	TestSelect(t, `SELECT email, CASE WHEN email LIKE "*email.com" THEN "valid" ELSE "invalid" END AS status FROM users ORDER BY email ASC`,
+		[][]driver.Value{{"aaron@email.com", "valid"}, {"bob@email.com", "valid"}, {"not_an_email_2", "invalid"}},
+	)
+	TestSelect(t, `SELECT email FROM users WHERE CASE email WHEN "bob@email.com" THEN 1 ELSE 0 END = 1`,
+		[][]driver.Value{{"bob@email.com"}},
+	)
+
+	// Window functions
+	TestSelect(t, "SELECT order_id, row_number() OVER (PARTITION BY user_id ORDER BY price DESC) AS rn FROM orders ORDER BY order_id ASC",
+		[][]driver.Value{{"1", int64(2)}, {"2", int64(1)}, {"3", int64(1)}},
+	)
+	TestSelect(t, "SELECT order_id, rank() OVER (ORDER BY price), dense_rank() OVER (ORDER BY price) FROM orders ORDER BY order_id ASC",
+		[][]driver.Value{{"1", int64(1), int64(1)}, {"2", int64(3), int64(2)}, {"3", int64(1), int64(1)}},
+	)
+	TestSelect(t, "SELECT order_id, sum(price) OVER (ORDER BY order_id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS running FROM orders ORDER BY order_id ASC",
+		[][]driver.Value{{"1", float64(22.5)}, {"2", float64(60)}, {"3", float64(82.5)}},
+	)
+	TestSelect(t, "SELECT order_id, avg(price) OVER (PARTITION BY user_id) AS user_avg FROM orders ORDER BY order_id ASC",
+		[][]driver.Value{{"1", float64(30)}, {"2", float64(30)}, {"3", float64(22.5)}},
+	)
+	TestSelect(t, "SELECT order_id, lag(order_id) OVER (ORDER BY order_id) AS prev, first_value(order_id) OVER (PARTITION BY user_id ORDER BY order_id) AS first FROM orders ORDER BY order_id ASC",
+		[][]driver.Value{{"1", nil, "1"}, {"2", "1", "1"}, {"3", "2", "3"}},
+	)
+	TestSelect(t, "SELECT order_id, row_number() OVER (ORDER BY price DESC, order_id) AS rn FROM orders ORDER BY rn ASC",
+		[][]driver.Value{{"2", int64(1)}, {"1", int64(2)}, {"3", int64(3)}},
+	)
+	// window over the aggregated rows of a group by
+	TestSelect(t, "SELECT user_id, count(*) AS c, row_number() OVER (ORDER BY user_id) AS rn FROM orders GROUP BY user_id ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", int64(2), int64(1)}, {"abcabcabc", int64(1), int64(2)}},
+	)
+	TestSelect(t, "SELECT user_id, rank() OVER (ORDER BY count(*) ASC) AS r, sum(count(*)) OVER () AS total FROM orders GROUP BY user_id ORDER BY r ASC",
+		[][]driver.Value{{"abcabcabc", int64(1), float64(3)}, {"9Ip1aKbeZe2njCDM", int64(2), float64(3)}},
+	)
 
+	// Common table expressions
+	TestSelect(t, "WITH big AS (SELECT order_id, user_id, price FROM orders WHERE price > 30) SELECT order_id FROM big",
+		[][]driver.Value{{"2"}},
+	)
+	TestSelect(t, "WITH big AS (SELECT * FROM orders WHERE price > 30) SELECT order_id, price FROM big",
+		[][]driver.Value{{"2", "37.50"}},
+	)
+	TestSelect(t, "WITH a AS (SELECT order_id, price FROM orders WHERE price > 20), b (id) AS (SELECT order_id FROM a WHERE price < 30) SELECT id FROM b ORDER BY id ASC",
+		[][]driver.Value{{"1"}, {"3"}},
+	)
+	TestSelect(t, "WITH ct AS (SELECT user_id, count(*) AS n FROM orders GROUP BY user_id) SELECT user_id, n FROM ct ORDER BY user_id ASC",
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", int64(2)}, {"abcabcabc", int64(1)}},
+	)
+	TestSelect(t, "WITH big AS (SELECT user_id, price FROM orders WHERE price > 30) SELECT u.email, o.price FROM users AS u INNER JOIN big AS o ON u.user_id = o.user_id",
+		[][]driver.Value{{"aaron@email.com", "37.50"}},
+	)
+	// referenced twice, materialized once
+	TestSelect(t, "WITH a AS (SELECT order_id FROM orders WHERE price < 30) SELECT order_id FROM a UNION ALL SELECT order_id FROM a ORDER BY order_id ASC",
+		[][]driver.Value{{"1"}, {"1"}, {"3"}, {"3"}},
+	)
+	TestSelect(t, "WITH a AS (SELECT order_id, price FROM orders WHERE price > 30) SELECT x.order_id, y.price FROM a AS x INNER JOIN a AS y ON x.order_id = y.order_id",
+		[][]driver.Value{{"2", "37.50"}},
+	)
+
+	// Outer joins, un-matched rows NULL padded
+	TestSelect(t, "SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id ORDER BY o.order_id ASC",
+		[][]driver.Value{{"aaron@email.com", "1"}, {"aaron@email.com", "2"}},
+	)
+	TestSelect(t, "SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id ORDER BY u.email ASC",
+		[][]driver.Value{{"aaron@email.com", "1"}, {"aaron@email.com", "2"}, {"bob@email.com", nil}, {"not_an_email_2", nil}},
+	)
+	TestSelect(t, "SELECT u.email, o.order_id FROM users AS u RIGHT OUTER JOIN orders AS o ON u.user_id = o.user_id ORDER BY o.order_id ASC",
+		[][]driver.Value{{"aaron@email.com", "1"}, {"aaron@email.com", "2"}, {nil, "3"}},
+	)
+	TestSelect(t, "SELECT o.order_id, u.email FROM orders AS o FULL OUTER JOIN users AS u ON o.user_id = u.user_id ORDER BY u.email ASC",
+		[][]driver.Value{{"3", nil}, {"1", "aaron@email.com"}, {"2", "aaron@email.com"}, {nil, "bob@email.com"}, {nil, "not_an_email_2"}},
+	)
+	// where of the NULL padded side is evaluated after the join
+	TestSelect(t, "SELECT u.email FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id WHERE NOT EXISTS o.order_id ORDER BY u.email ASC",
+		[][]driver.Value{{"bob@email.com"}, {"not_an_email_2"}},
+	)
+	// conditions of one source filter its rows before the join, the rest the joined rows
+	TestSelect(t, `SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE toint(o.item_id) < 2 AND u.email != "x" AND (o.order_id = "2" OR u.email = "aaron@email.com")`,
+		[][]driver.Value{{"aaron@email.com", "1"}},
+	)
+	TestSelect(t, `SELECT u.email, o.order_id FROM users AS u LEFT JOIN orders AS o ON u.user_id = o.user_id WHERE toint(o.item_id) < 2 OR u.email = "bob@email.com" ORDER BY u.email ASC`,
+		[][]driver.Value{{"aaron@email.com", "1"}, {"bob@email.com", nil}},
+	)
+
+	// Nested loop joins, on any condition, cross and of 3 or more sources
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o INNER JOIN orders AS x ON toint(o.item_id) < toint(x.item_id) ORDER BY o.order_id ASC",
+		[][]driver.Value{{"1", "2"}, {"3", "2"}},
+	)
+	TestSelect(t, "SELECT count(*) AS ct FROM orders AS o INNER JOIN orders AS x ON toint(o.item_id) BETWEEN toint(x.item_id) - 2 AND toint(x.item_id) + 1",
+		[][]driver.Value{{int64(7)}},
+	)
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o INNER JOIN orders AS x ON o.user_id = x.user_id AND o.order_id != x.order_id ORDER BY o.order_id ASC",
+		[][]driver.Value{{"1", "2"}, {"2", "1"}},
+	)
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o RIGHT JOIN orders AS x ON toint(o.item_id) > toint(x.item_id) ORDER BY x.order_id ASC",
+		[][]driver.Value{{"2", "1"}, {nil, "2"}, {"2", "3"}},
+	)
+	// csv columns compared by order as numbers or times, not strings
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o INNER JOIN orders AS x ON o.item_id < x.item_id ORDER BY o.order_id ASC",
+		[][]driver.Value{{"1", "2"}, {"3", "2"}},
+	)
+	TestSelect(t, "SELECT count(*) AS ct FROM orders AS o INNER JOIN users AS u ON o.order_id < u.referral_count",
+		[][]driver.Value{{int64(9)}},
+	)
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o INNER JOIN orders AS x ON o.order_id BETWEEN x.item_id AND x.item_count ORDER BY x.order_id ASC, o.order_id ASC",
+		[][]driver.Value{{"2", "1"}, {"3", "1"}, {"3", "2"}, {"2", "3"}, {"3", "3"}},
+	)
+	TestSelect(t, "SELECT o.order_id, x.order_id FROM orders AS o LEFT JOIN orders AS x ON o.order_date < x.order_date ORDER BY o.order_id ASC, x.order_id ASC",
+		[][]driver.Value{{"1", "2"}, {"1", "3"}, {"2", nil}, {"3", nil}},
+	)
+	TestSelect(t, `SELECT u.email, o.order_id FROM users AS u INNER JOIN orders AS o ON u.reg_date BETWEEN "2010-01-01" AND o.order_date ORDER BY o.order_id ASC`,
+		[][]driver.Value{{"aaron@email.com", "1"}, {"aaron@email.com", "2"}, {"aaron@email.com", "3"}},
+	)
+	TestSelect(t, "SELECT count(*) AS ct FROM users AS u CROSS JOIN orders AS o",
+		[][]driver.Value{{int64(9)}},
+	)
+	TestSelect(t, "SELECT u.email, o.order_id FROM users AS u, orders AS o WHERE u.user_id = o.user_id ORDER BY o.order_id ASC",
+		[][]driver.Value{{"aaron@email.com", "1"}, {"aaron@email.com", "2"}},
+	)
+	TestSelect(t, "SELECT u.email, o.order_id, x.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id INNER JOIN orders AS x ON o.item_id = x.item_id ORDER BY x.order_id ASC",
+		[][]driver.Value{{"aaron@email.com", "1", "1"}, {"aaron@email.com", "2", "2"}, {"aaron@email.com", "1", "3"}},
+	)
+	TestSelect(t, "SELECT u.email, o.order_id, x.order_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id LEFT JOIN orders AS x ON toint(o.item_id) < toint(x.item_id) ORDER BY o.order_id ASC",
+		[][]driver.Value{{"aaron@email.com", "1", "2"}, {"aaron@email.com", "2", nil}},
+	)
+	// joined in an order that avoids the cross join of x and o
+	TestSelect(t, "SELECT x.order_id, u.email FROM orders AS x, users AS u, orders AS o WHERE u.user_id = o.user_id AND o.item_id = x.item_id ORDER BY x.order_id ASC",
+		[][]driver.Value{{"1", "aaron@email.com"}, {"2", "aaron@email.com"}, {"3", "aaron@email.com"}},
+	)
+
+	// Sub-selects in where
+	TestSelect(t, "SELECT email FROM users WHERE user_id IN (SELECT user_id FROM orders) ORDER BY email ASC",
+		[][]driver.Value{{"aaron@email.com"}},
+	)
+	TestSelect(t, "SELECT email FROM users WHERE user_id NOT IN (SELECT user_id FROM orders) ORDER BY email ASC",
+		[][]driver.Value{{"bob@email.com"}, {"not_an_email_2"}},
+	)
+	TestSelect(t, "SELECT email FROM users WHERE user_id IN (SELECT user_id FROM orders WHERE price > 30) AND email LIKE \"*email.com\"",
+		[][]driver.Value{{"aaron@email.com"}},
+	)
+	TestSelect(t, "SELECT email FROM users AS u WHERE EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id) ORDER BY email ASC",
+		[][]driver.Value{{"aaron@email.com"}},
+	)
+	TestSelect(t, "SELECT email FROM users AS u WHERE NOT EXISTS (SELECT order_id FROM orders AS o WHERE o.user_id = u.user_id AND o.price > 30) ORDER BY email ASC",
+		[][]driver.Value{{"bob@email.com"}, {"not_an_email_2"}},
+	)
+	TestSelect(t, "SELECT o.order_id FROM orders AS o WHERE o.item_id IN (SELECT x.item_id FROM orders AS x WHERE x.user_id = o.user_id AND x.price > 30) ORDER BY order_id ASC",
+		[][]driver.Value{{"2"}},
+	)
+	TestSelect(t, "SELECT order_id FROM orders AS o WHERE o.user_id IN (SELECT u.user_id FROM users AS u WHERE u.email = \"aaron@email.com\") ORDER BY order_id ASC",
+		[][]driver.Value{{"1"}, {"2"}},
+	)
+	// only equality correlation can be decorrelated
+	TestSelectErr(t, "SELECT email FROM users AS u WHERE EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id != u.user_id)", nil)
+
+	// Sub-selects used as values
+	TestSelect(t, "SELECT email, (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) AS ct FROM users AS u ORDER BY email ASC",
+		[][]driver.Value{{"aaron@email.com", int64(2)}, {"bob@email.com", int64(0)}, {"not_an_email_2", int64(0)}},
+	)
+	TestSelect(t, "SELECT order_id FROM orders WHERE price > (SELECT avg(price) FROM orders) ORDER BY order_id ASC",
+		[][]driver.Value{{"2"}},
+	)
+	TestSelect(t, "SELECT u.email FROM users AS u WHERE (SELECT count(*) FROM orders AS o WHERE o.user_id = u.user_id) > 1",
+		[][]driver.Value{{"aaron@email.com"}},
+	)
+	TestSelect(t, "SELECT (SELECT count(*) FROM orders) AS n",
+		[][]driver.Value{{int64(3)}},
+	)
+	// sub-select used as value must have one column
+	TestSelectErr(t, "SELECT email, (SELECT order_id, price FROM orders) AS oid FROM users", nil)
+	TestSelectErr(t, "SELECT order_id, (SELECT email FROM users) AS e FROM orders", nil)
+
+	/*
 		// TODO: #56 this doesn't work because ordering is non-deterministic coming out of group by currently
 		//  which technically don't think there is any sql expectation of ordering, but there is for this test harness
 		testutil.TestSelect(t, "select `users`.`user_id` AS userids FROM users GROUP BY `users`.`user_id`;",
@@ -202,7 +481,7 @@ func RunSimpleSuite(t TestingT) {
 
 	// Distinct keyword
 	TestSelect(t, "SELECT COUNT(DISTINCT(`users`.`email`)) AS cd FROM users",
-		[][]driver.Value{{int64(0)}},
+		[][]driver.Value{{int64(3)}},
 	)
 
 	// Function in select projected columns that needs to be late evaluated.
@@ -211,6 +490,17 @@ func RunSimpleSuite(t TestingT) {
 		[][]driver.Value{{"aaron"}},
 	)
 
+	// Case expressions
+	TestSelect(t, `SELECT email, CASE WHEN email LIKE "%email.com" THEN "valid" ELSE "invalid" END AS status FROM users WHERE email = "bob@email.com"`,
+		[][]driver.Value{{"bob@email.com", "valid"}},
+	)
+	TestSelect(t, `SELECT email FROM users WHERE CASE email WHEN "bob@email.com" THEN 1 ELSE 0 END = 1`,
+		[][]driver.Value{{"bob@email.com"}},
+	)
+	TestSelect(t, `SELECT CASE user_id WHEN "hT2impsOPUREcVPc" THEN email ELSE lower(user_id) END AS e FROM users WHERE email = "bob@email.com"`,
+		[][]driver.Value{{"bob@email.com"}},
+	)
+
 	return
 	TestSelect(t, "SELECT email FROM users ORDER BY email DESC",
 		[][]driver.Value{{"not_an_email_2"}, {"bob@email.com"}, {"aaron@email.com"}},
@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Optimize returns a simplified copy of node, its expressions of only
+// literals evaluated once by the vm instead of for each row, see
+// expr.Optimize.
+//
+//    created > "now-1d" AND 1 = 1    =>  created > "now-1d"
+//    price > toint("5") + 2          =>  price > 7
+//
+func Optimize(node expr.Node) expr.Node {
+	return expr.Optimize(node, evalLiteral)
+}
+
+// OptimizeFilter returns a copy of FilterQL statement with its filter and
+// where optimized.
+func OptimizeFilter(stmt *rel.FilterStatement) *rel.FilterStatement {
+	fs := *stmt
+	fs.Filter = Optimize(stmt.Filter)
+	fs.Where = Optimize(stmt.Where)
+	return &fs
+}
+
+// evalLiteral evaluate an expression of literals without a context,
+// not ok for functions that need one.
+func evalLiteral(node expr.Node) (val value.Value, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			val, ok = nil, false
+		}
+	}()
+	return Eval(nil, node)
+}
@@ -0,0 +1,63 @@
+package vm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/vm"
+)
+
+func TestOptimize(t *testing.T) {
+	ctx := datasource.NewContextSimpleNative(map[string]interface{}{
+		"x": 10, "name": "bob", "created": time.Now().Add(-time.Hour),
+	})
+	tests := []struct {
+		exp string
+		out string
+	}{
+		{`x > toint("5") + 2`, `x > 7`},
+		{`x > 5 AND 1 = 1`, `x > 5`},
+		{`1 = 2 AND x > 5`, `false`},
+		{`x > 5 OR 2 > 1`, `true`},
+		{`5 < x`, `x > 5`},
+		{`NOT NOT (x > 5)`, `(x > 5)`},
+		{`NOT (1 = 1)`, `false`},
+		{`name IN ("a", tolower("BOB"))`, `name IN ("a", "bob")`},
+		{`AND ( x > 5, AND ( name == "bob", true ), 3 > 2 )`, `AND ( x > 5, name == "bob" )`},
+		{`OR ( x > 50, false )`, `x > 50`},
+		{`NOT AND ( true, 1 = 1 )`, `false`},
+		{`CASE WHEN 1 = 1 THEN "a" ELSE "b" END`, `"a"`},
+		// functions of the context, or now, aren't folded
+		{`x > toint(todate("now-1d"))`, `x > toint(todate("now-1d"))`},
+		{`created > "now-1d" AND true`, `created > "now-1d"`},
+		{`count(5) > 1`, `count(5) > 1`},
+	}
+	for _, tc := range tests {
+		n, err := expr.ParseExpression(tc.exp)
+		assert.Equal(t, nil, err, tc.exp)
+		before := n.String()
+		on := vm.Optimize(n)
+		assert.Equal(t, tc.out, on.String(), tc.exp)
+		// original is not modified
+		assert.Equal(t, before, n.String(), tc.exp)
+
+		v1, ok1 := vm.Eval(ctx, n)
+		v2, ok2 := vm.Eval(ctx, on)
+		assert.Equal(t, ok1, ok2, tc.exp)
+		if ok1 && ok2 {
+			assert.Equal(t, v1.Value(), v2.Value(), tc.exp)
+		}
+	}
+
+	fs := rel.MustParseFilter(`FILTER AND ( x > 5, OR ( name == "bob", false ), 1 = 1 )`)
+	ofs := vm.OptimizeFilter(fs)
+	assert.Equal(t, `AND ( x > 5, name == "bob" )`, ofs.Filter.String())
+	matched, ok := vm.Matches(ctx, ofs)
+	assert.True(t, ok)
+	assert.True(t, matched)
+}
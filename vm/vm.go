@@ -106,8 +106,14 @@ func resolveIncludesDepth(ctx expr.Includer, arg expr.Node, depth int) error {
 				return err
 			}
 		}
+	case *expr.CaseNode:
+		for _, narg := range n.ChildrenArgs() {
+			if err := resolveIncludesDepth(ctx, narg, depth+1); err != nil {
+				return err
+			}
+		}
 	case *expr.NumberNode, *expr.IdentityNode, *expr.StringNode, nil,
-		*expr.ValueNode, *expr.NullNode:
+		*expr.ValueNode, *expr.NullNode, *expr.SubSelectNode, *expr.ParamNode:
 		return nil
 	case *expr.IncludeNode:
 		return resolveInclude(ctx, n, depth+1)
@@ -142,6 +148,12 @@ func evalDepth(ctx expr.EvalContext, arg expr.Node, depth int) (value.Value, boo
 		return walkUnary(ctx, argVal, depth)
 	case *expr.TriNode:
 		return walkTernary(ctx, argVal, depth)
+	case *expr.CaseNode:
+		return walkCase(ctx, argVal, depth)
+	case *expr.SubSelectNode:
+		return walkSubSelect(ctx, argVal)
+	case *expr.ParamNode:
+		return walkParam(argVal)
 	case *expr.ArrayNode:
 		return walkArray(ctx, argVal, depth)
 	case *expr.FuncNode:
@@ -914,6 +926,67 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 	return nil, false
 }
 
+// walkCase CASE evaluator, the THEN of first matching WHEN else the ELSE
+//
+//     CASE WHEN a > 5 THEN "big" ELSE "small" END
+//     CASE a WHEN 1 THEN "one" WHEN 2 THEN "two" END
+//
+func walkCase(ctx expr.EvalContext, node *expr.CaseNode, depth int) (value.Value, bool) {
+
+	var arg value.Value
+	if node.Arg != nil {
+		av, ok := evalDepth(ctx, node.Arg, depth+1)
+		if ok && av != nil && !av.Nil() {
+			arg = av
+		}
+	}
+	for i, when := range node.Whens {
+		matched := false
+		if node.Arg == nil {
+			matched, _ = evalBool(ctx, when, depth+1)
+		} else if arg != nil {
+			wv, ok := evalDepth(ctx, when, depth+1)
+			if ok && wv != nil && !wv.Nil() {
+				matched, _ = value.Equal(arg, wv)
+			}
+		}
+		if matched {
+			return evalDepth(ctx, node.Thens[i], depth+1)
+		}
+	}
+	if node.Else != nil {
+		return evalDepth(ctx, node.Else, depth+1)
+	}
+	// no match and no ELSE is NULL
+	return value.NewNilValue(), true
+}
+
+// walkSubSelect sub-select evaluator, the value the executor evaluates it to
+// for this row.
+//
+//     (SELECT avg(price) FROM items)
+//
+func walkSubSelect(ctx expr.EvalContext, node *expr.SubSelectNode) (value.Value, bool) {
+	if node.Eval == nil {
+		u.Warnf("sub-select has no evaluator: %s", node)
+		return nil, false
+	}
+	return node.Eval(ctx)
+}
+
+// walkParam bind parameter evaluator, the value bound to it, not ok if
+// none was.
+//
+//     user_id = ?
+//
+func walkParam(node *expr.ParamNode) (value.Value, bool) {
+	if node.Value == nil {
+		u.Warnf("bind parameter %s has no value", node.Text)
+		return nil, false
+	}
+	return node.Value, true
+}
+
 // walkArray Array evaluator:  evaluate multiple values into an array
 //
 //     (b,c,d)
@@ -137,6 +137,15 @@ var (
 		vmt(`created BETWEEN "12/18/2015" AND "12/18/2050"`, true, noError),
 		vmt(`created BETWEEN "now-50w" AND "12/18/2050"`, true, noError),
 
+		// Case
+		vmt(`CASE WHEN int5 > 3 THEN "big" ELSE "small" END`, "big", noError),
+		vmt(`CASE WHEN int5 > 10 THEN "big" ELSE "small" END`, "small", noError),
+		vmt(`CASE WHEN int5 > 10 THEN "big" WHEN int5 > 3 THEN "medium" END`, "medium", noError),
+		vmt(`CASE int5 WHEN 1 THEN "one" WHEN 5 THEN "five" END`, "five", noError),
+		vmt(`CASE user_id WHEN "xyz" THEN 1 WHEN "abc" THEN 2 ELSE 3 END`, int64(2), noError),
+		vmt(`CASE WHEN int5 > 3 AND bvalt THEN int5 * 2 END + 1`, int64(11), noError),
+		vmt(`toint(CASE WHEN bvalf THEN "1" ELSE str5 END)`, int64(5), noError),
+
 		// In:  Multi Arg Tests
 		vmtall(`10 IN ("a","b",10, 4.5)`, true, parseOk, evalError),
 		vmtall(`10 IN ("a","b",20, 4.5)`, false, parseOk, evalError),
@@ -344,6 +353,31 @@ func TestRunExpr(t *testing.T) {
 	}
 }
 
+func TestRunParams(t *testing.T) {
+	n, err := expr.ParseExpression(`int5 > ? AND user_id = $2`)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	params := expr.FindAllParams(n)
+	if len(params) != 2 {
+		t.Fatalf("expected 2 params but got %v", params)
+	}
+	if _, ok := vm.Eval(msgContext, params[0]); ok {
+		t.Errorf("%s - un-bound param should not evaluate", params[0])
+	}
+	for _, tc := range []struct {
+		arg  int64
+		want bool
+	}{{3, true}, {10, false}} {
+		params[0].Value = value.NewIntValue(tc.arg)
+		params[1].Value = value.NewStringValue("abc")
+		val, ok := vm.Eval(msgContext, n)
+		if !ok || val.Value() != tc.want {
+			t.Errorf("%s - expected %v but got %v", n, tc.want, val)
+		}
+	}
+}
+
 type vmTest struct {
 	qlText  string
 	parseok bool